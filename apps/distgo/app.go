@@ -25,6 +25,7 @@ import (
 	"github.com/palantir/godel/apps/distgo/cmd/products"
 	"github.com/palantir/godel/apps/distgo/cmd/publish"
 	"github.com/palantir/godel/apps/distgo/cmd/run"
+	"github.com/palantir/godel/apps/distgo/cmd/sbom"
 )
 
 func App() *cli.App {
@@ -38,6 +39,7 @@ func App() *cli.App {
 		run.Command(),
 		dist.Command(),
 		publish.Command(),
+		sbom.Command(),
 	}
 	return app
 }
@@ -15,28 +15,65 @@
 package artifacts
 
 import (
+	"path"
 	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	"github.com/palantir/godel/apps/distgo/cmd"
 	"github.com/palantir/godel/apps/distgo/cmd/build"
 	"github.com/palantir/godel/apps/distgo/cmd/dist"
 	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/manifest"
 	"github.com/palantir/godel/apps/distgo/pkg/osarch"
 )
 
 // DistArtifacts returns a map from product name to OrderedStringMap, where the values of the OrderedStringMap contains
-// the mapping from the DistType to the path for the artifact for that type.
+// the mapping from the DistType to the path for the artifact for that type. If a distribution is split by OS/Arch or
+// creates multiple artifacts (such as an RPM for each linux OS/Arch), the map contains an entry for each of its
+// artifacts whose key is of the form "{{DistType}}/{{OSArch}}". If signing is configured for a product, the map also
+// contains an entry for the signature of each artifact whose key is the key of the artifact followed by the extension
+// of the signature (for example, "sls.asc") and entries for the SHA256SUMS file of the product and its signatures whose
+// keys are "sha256sums" and "sha256sums" followed by the extension. If a distribution writes SBOMs, the map contains an
+// entry for each SBOM whose key is of the form "{{DistType}}.{{Format}}" (for example, "sls.spdx").
 func DistArtifacts(buildSpecsWithDeps []params.ProductBuildSpecWithDeps, absPath bool) (map[string]OrderedStringMap, error) {
-	return artifacts(buildSpecsWithDeps, func(spec params.ProductBuildSpec) buildSpecWithPaths {
+	return artifacts(buildSpecsWithDeps, func(spec params.ProductBuildSpec) (buildSpecWithPaths, error) {
 		distTypeToPathMap := newOrderedStringMap()
+		signatureExts := dist.SignatureExtensions(spec)
+		putWithSignatures := func(k, v string) {
+			distTypeToPathMap.Put(k, v)
+			for _, currExt := range signatureExts {
+				distTypeToPathMap.Put(k+currExt, v+currExt)
+			}
+		}
 
 		for _, currDistCfg := range spec.Dist {
-			distTypeToPathMap.Put(string(currDistCfg.Info.Type()), dist.ArtifactPath(spec, currDistCfg))
+			distArtifacts, err := dist.Artifacts(spec, currDistCfg)
+			if err != nil {
+				return buildSpecWithPaths{}, err
+			}
+			if !currDistCfg.SplitByOSArch && len(distArtifacts) == 1 {
+				putWithSignatures(string(currDistCfg.Info.Type()), distArtifacts[0].Path)
+			} else {
+				for _, currArtifact := range distArtifacts {
+					putWithSignatures(path.Join(string(currDistCfg.Info.Type()), currArtifact.OSArchs[0].String()), currArtifact.Path)
+				}
+			}
+			for i, currSBOMPath := range dist.SBOMPaths(spec, currDistCfg) {
+				distTypeToPathMap.Put(string(currDistCfg.Info.Type())+"."+string(dist.SBOMFormats(currDistCfg)[i]), currSBOMPath)
+			}
 		}
-		return buildSpecWithPaths{spec: &spec, paths: distTypeToPathMap}
+		if checksumsPath := dist.ChecksumsPath(spec); checksumsPath != "" {
+			putWithSignatures(checksumsArtifactKey, checksumsPath)
+		}
+		return buildSpecWithPaths{spec: &spec, paths: distTypeToPathMap}, nil
 	}, absPath)
 }
 
+// checksumsArtifactKey is the key of the SHA256SUMS file of a signed product in the map returned by DistArtifacts.
+const checksumsArtifactKey = "sha256sums"
+
 type BuildArtifactsParams struct {
 	AbsPath       bool
 	RequiresBuild bool
@@ -44,18 +81,26 @@ type BuildArtifactsParams struct {
 }
 
 // BuildArtifacts returns a map from product name to OrderedStringMap, where the values of the OrderedStringMap contains
-// the mapping from the OSArch to the path for the artifact for that OSArch.
+// the mapping from the OSArch to the path for the artifact for that OSArch. If the build mode of a product creates
+// additional files for an OSArch (such as C header files), the map also contains an entry for each of these files whose
+// key is of the form "{{OSArch}}/{{file name}}" (see BuildArtifactOSArch).
 func BuildArtifacts(buildSpecsWithDeps []params.ProductBuildSpecWithDeps, buildParams BuildArtifactsParams) (map[string]OrderedStringMap, error) {
-	artifacts, err := artifacts(buildSpecsWithDeps, func(spec params.ProductBuildSpec) buildSpecWithPaths {
+	artifacts, err := artifacts(buildSpecsWithDeps, func(spec params.ProductBuildSpec) (buildSpecWithPaths, error) {
 		osArchToPathMap := newOrderedStringMap()
-		buildPaths := build.ArtifactPaths(spec)
+		buildPaths, err := build.OutputPaths(spec)
+		if err != nil {
+			return buildSpecWithPaths{}, err
+		}
 
 		for _, osArch := range spec.Build.OSArchs {
 			if v, ok := buildPaths[osArch]; ok && buildParams.OSArchs.Matches(osArch) {
-				osArchToPathMap.Put(osArch.String(), v)
+				osArchToPathMap.Put(osArch.String(), v[0])
+				for _, currPath := range v[1:] {
+					osArchToPathMap.Put(path.Join(osArch.String(), path.Base(currPath)), currPath)
+				}
 			}
 		}
-		return buildSpecWithPaths{spec: &spec, paths: osArchToPathMap}
+		return buildSpecWithPaths{spec: &spec, paths: osArchToPathMap}, nil
 	}, buildParams.AbsPath)
 
 	// if error occurred or requiresBuild is not true, return
@@ -73,13 +118,13 @@ func BuildArtifacts(buildSpecsWithDeps []params.ProductBuildSpecWithDeps, buildP
 			copy(origKeys, src)
 
 			// remove any OSArch values that do not need to be built
-			for _, osArchStr := range origKeys {
-				osArch, err := osarch.New(osArchStr)
+			for _, key := range origKeys {
+				osArch, _, err := BuildArtifactOSArch(key)
 				if err != nil {
 					return nil, err
 				}
 				if !requiresBuildInfo.RequiresBuild(product, osArch) {
-					artifacts[product].Remove(osArchStr)
+					artifacts[product].Remove(key)
 				}
 			}
 			// if product no longer has any OSArch values after filtering, remove it from the map
@@ -92,10 +137,63 @@ func BuildArtifacts(buildSpecsWithDeps []params.ProductBuildSpecWithDeps, buildP
 	return artifacts, err
 }
 
+// BuildArtifactOSArch returns the OSArch for the provided key of a map returned by BuildArtifacts. Returns true if the
+// key identifies the primary artifact for the OSArch and false if it identifies an additional file created by the build.
+func BuildArtifactOSArch(key string) (osarch.OSArch, bool, error) {
+	parts := strings.SplitN(key, "/", 2)
+	osArch, err := osarch.New(parts[0])
+	if err != nil {
+		return osarch.OSArch{}, false, err
+	}
+	return osArch, len(parts) == 1, nil
+}
+
+// Manifests returns the build manifest entries for the provided artifacts, which must be a map returned by
+// BuildArtifacts or DistArtifacts that contains paths relative to the project directory. The returned slice contains a
+// manifest for each product that has artifacts (in the order of the provided specs) that only contains the entries for
+// the provided artifacts. Returns an error if any of the artifacts is not recorded in the manifest of its product.
+func Manifests(buildSpecsWithDeps []params.ProductBuildSpecWithDeps, artifacts map[string]OrderedStringMap) ([]manifest.Manifest, error) {
+	manifests := []manifest.Manifest{}
+	for _, currSpecWithDeps := range buildSpecsWithDeps {
+		spec := currSpecWithDeps.Spec
+		paths, ok := artifacts[spec.ProductName]
+		if !ok {
+			continue
+		}
+
+		manifestPath := build.ManifestPath(spec)
+		productManifest, err := manifest.Read(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		entries := make(map[string]manifest.Artifact)
+		for _, curr := range productManifest.Artifacts {
+			entries[curr.Path] = curr
+		}
+
+		filtered := manifest.Manifest{
+			Product: spec.ProductName,
+			Version: spec.ProductVersion,
+		}
+		for _, k := range paths.Keys() {
+			entry, ok := entries[paths.Get(k)]
+			if !ok {
+				return nil, errors.Errorf("artifact %s of %s is not recorded in build manifest %s", paths.Get(k), spec.ProductName, manifestPath)
+			}
+			filtered.Artifacts = append(filtered.Artifacts, entry)
+		}
+		manifests = append(manifests, filtered)
+	}
+	return manifests, nil
+}
+
 func artifacts(buildSpecsWithDeps []params.ProductBuildSpecWithDeps, f artifactPathsFunc, absPath bool) (map[string]OrderedStringMap, error) {
 	artifacts := make(map[string]OrderedStringMap)
 	for _, currBuildSpecWithDeps := range buildSpecsWithDeps {
-		specWithPaths := f(currBuildSpecWithDeps.Spec)
+		specWithPaths, err := f(currBuildSpecWithDeps.Spec)
+		if err != nil {
+			return nil, err
+		}
 		for _, k := range specWithPaths.paths.Keys() {
 			if !absPath {
 				absPathValue, err := filepath.Rel(specWithPaths.spec.ProjectDir, specWithPaths.paths.Get(k))
@@ -117,7 +215,7 @@ type buildSpecWithPaths struct {
 	spec  *params.ProductBuildSpec
 }
 
-type artifactPathsFunc func(spec params.ProductBuildSpec) buildSpecWithPaths
+type artifactPathsFunc func(spec params.ProductBuildSpec) (buildSpecWithPaths, error)
 
 // OrderedStringMap represents an ordered map with strings as the keys and values.
 type OrderedStringMap interface {
@@ -15,7 +15,9 @@
 package artifacts_test
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"testing"
@@ -199,11 +201,8 @@ func TestBuildArtifactsRequiresBuild(t *testing.T) {
 				}, ioutil.Discard)
 				require.NoError(t, err)
 
-				// sleep to ensure that modification time will differ
-				time.Sleep(time.Second)
-
 				// update source file
-				err = ioutil.WriteFile(path.Join(projectDir, "main.go"), []byte("package main; func main(){}"), 0644)
+				err = ioutil.WriteFile(path.Join(projectDir, "main.go"), []byte("package main; func main(){ println() }"), 0644)
 				require.NoError(t, err)
 			},
 			want: map[string][]string{
@@ -214,6 +213,29 @@ func TestBuildArtifactsRequiresBuild(t *testing.T) {
 				},
 			},
 		},
+		// returns empty if input source file has a newer modification time but its content is unchanged
+		{
+			specs: func(projectDir string) params.ProductBuildSpecWithDeps {
+				return createSpec(projectDir, "foo", "0.1.0", []osarch.OSArch{
+					{OS: "darwin", Arch: "amd64"},
+					{OS: "darwin", Arch: "386"},
+					{OS: "linux", Arch: "amd64"},
+				}, &params.SLSDistInfo{})
+			},
+			beforeAction: func(projectDir string, specs []params.ProductBuildSpec) {
+				// build products
+				err := build.Run(specs, nil, build.Context{
+					Parallel: false,
+				}, ioutil.Discard)
+				require.NoError(t, err)
+
+				// update modification time of source file
+				later := time.Now().Add(time.Hour)
+				err = os.Chtimes(path.Join(projectDir, "main.go"), later, later)
+				require.NoError(t, err)
+			},
+			want: map[string][]string{},
+		},
 		// if OS/Archs are specified, results are filtered base on that
 		{
 			specs: func(projectDir string) params.ProductBuildSpecWithDeps {
@@ -286,6 +308,50 @@ func TestDistArtifacts(t *testing.T) {
 				"bar": {"bar-unspecified.sls.tgz"},
 			},
 		},
+		{
+			specs: func(projectDir string) []params.ProductBuildSpecWithDeps {
+				spec := createSpec(projectDir, "foo", "0.1.0", []osarch.OSArch{
+					{OS: "darwin", Arch: "amd64"},
+					{OS: "windows", Arch: "amd64"},
+				}, &params.BinDistInfo{})
+				spec.Spec.Dist[0].ArchiveFormat = params.ZipArchiveFormat
+				spec.Spec.Dist[0].SplitByOSArch = true
+				return []params.ProductBuildSpecWithDeps{spec}
+			},
+			want: map[string][]string{
+				"foo": {"foo-0.1.0-darwin-amd64.zip", "foo-0.1.0-windows-amd64.zip"},
+			},
+		},
+		{
+			specs: func(projectDir string) []params.ProductBuildSpecWithDeps {
+				spec := createSpec(projectDir, "foo", "0.1.0", nil, &params.SLSDistInfo{})
+				spec.Spec.Signing = &params.Signing{
+					OpenPGP:  &params.OpenPGPSigning{Keyring: "keyring.gpg"},
+					Minisign: &params.MinisignSigning{KeyEnvVar: "MINISIGN_KEY"},
+				}
+				return []params.ProductBuildSpecWithDeps{spec}
+			},
+			want: map[string][]string{
+				"foo": {
+					"foo-0.1.0.sls.tgz",
+					"foo-0.1.0.sls.tgz.asc",
+					"foo-0.1.0.sls.tgz.minisig",
+					"foo-0.1.0-SHA256SUMS",
+					"foo-0.1.0-SHA256SUMS.asc",
+					"foo-0.1.0-SHA256SUMS.minisig",
+				},
+			},
+		},
+		{
+			specs: func(projectDir string) []params.ProductBuildSpecWithDeps {
+				spec := createSpec(projectDir, "foo", "0.1.0", nil, &params.SLSDistInfo{})
+				spec.Spec.Dist[0].SBOM = &params.SBOM{}
+				return []params.ProductBuildSpecWithDeps{spec}
+			},
+			want: map[string][]string{
+				"foo": {"foo-0.1.0.sls.tgz", "foo-0.1.0.spdx.json", "foo-0.1.0.cdx.json"},
+			},
+		},
 	} {
 		currProjectDir, err := ioutil.TempDir(tmpDir, "")
 		require.NoError(t, err)
@@ -313,6 +379,49 @@ func toAbs(input map[string][]string, baseDir string) map[string][]string {
 	return absWant
 }
 
+func TestManifests(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(path.Join(tmpDir, "main.go"), []byte("package main; func main(){}"), 0644)
+	require.NoError(t, err)
+
+	builtSpec := createSpec(tmpDir, "foo", "0.1.0", []osarch.OSArch{osarch.Current()}, &params.SLSDistInfo{})
+	builtSpec.Spec.Build.Ldflags = []string{"-s"}
+	err = build.Run([]params.ProductBuildSpec{builtSpec.Spec}, nil, build.Context{}, ioutil.Discard)
+	require.NoError(t, err)
+
+	buildArtifacts, err := artifacts.BuildArtifacts([]params.ProductBuildSpecWithDeps{builtSpec}, artifacts.BuildArtifactsParams{})
+	require.NoError(t, err)
+	got, err := artifacts.Manifests([]params.ProductBuildSpecWithDeps{builtSpec}, buildArtifacts)
+	require.NoError(t, err)
+
+	artifactPaths, err := build.ArtifactPaths(builtSpec.Spec)
+	require.NoError(t, err)
+	artifactPath := artifactPaths[osarch.Current()]
+	info, err := os.Stat(artifactPath)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(got))
+	require.Equal(t, 1, len(got[0].Artifacts))
+	assert.Equal(t, "foo", got[0].Product)
+	assert.Equal(t, "0.1.0", got[0].Version)
+	assert.Equal(t, path.Join("build", osarch.Current().String(), "foo"), got[0].Artifacts[0].Path)
+	assert.Equal(t, "build", got[0].Artifacts[0].Type)
+	assert.Equal(t, []string{osarch.Current().String()}, got[0].Artifacts[0].OSArchs)
+	assert.Equal(t, info.Size(), got[0].Artifacts[0].Size)
+	assert.Regexp(t, "^[0-9a-f]{64}$", got[0].Artifacts[0].SHA256)
+	assert.Regexp(t, "^go", got[0].Artifacts[0].GoVersion)
+	assert.Equal(t, "-s", got[0].Artifacts[0].Ldflags)
+
+	// artifacts that have not been built are not in the manifest
+	unbuiltSpec := createSpec(tmpDir, "bar", "0.1.0", []osarch.OSArch{osarch.Current()}, &params.SLSDistInfo{})
+	buildArtifacts, err = artifacts.BuildArtifacts([]params.ProductBuildSpecWithDeps{unbuiltSpec}, artifacts.BuildArtifactsParams{})
+	require.NoError(t, err)
+	_, err = artifacts.Manifests([]params.ProductBuildSpecWithDeps{unbuiltSpec}, buildArtifacts)
+	assert.EqualError(t, err, fmt.Sprintf("artifact %s of bar is not recorded in build manifest %s", path.Join("build", osarch.Current().String(), "bar"), build.ManifestPath(unbuiltSpec.Spec)))
+}
+
 func createSpec(projectDir, productName, productVersion string, osArchs []osarch.OSArch, distInfo params.DistInfo) params.ProductBuildSpecWithDeps {
 	return params.ProductBuildSpecWithDeps{
 		Spec: params.ProductBuildSpec{
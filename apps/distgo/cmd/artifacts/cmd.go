@@ -15,10 +15,13 @@
 package artifacts
 
 import (
+	"encoding/json"
+
 	"github.com/nmiyake/pkg/dirs"
 	"github.com/palantir/pkg/cli"
 	"github.com/palantir/pkg/cli/cfgcli"
 	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
 
 	"github.com/palantir/godel/apps/distgo/cmd"
 	"github.com/palantir/godel/apps/distgo/cmd/build"
@@ -29,6 +32,8 @@ import (
 const (
 	absPathFlagName       = "absolute"
 	requiresBuildFlagName = "requires-build"
+	verboseFlagName       = "verbose"
+	manifestFlagName      = "manifest"
 )
 
 var (
@@ -40,6 +45,14 @@ var (
 		Name:  requiresBuildFlagName,
 		Usage: "If true, only prints the artifacts that require building (omits artifacts that are already built and are up-to-date)",
 	}
+	manifestFlag = flag.BoolFlag{
+		Name:  manifestFlagName,
+		Usage: "Print the build manifest entries (including checksums) for the artifacts as JSON rather than their paths",
+	}
+	verboseFlag = flag.BoolFlag{
+		Name:  verboseFlagName,
+		Usage: "Print the effective build configuration for the OS/Arch of each artifact after its path",
+	}
 )
 
 func Command() cli.Command {
@@ -60,6 +73,7 @@ func artifactsCommand(name, usage string, action artifactsAction) cli.Command {
 		Flags: []flag.Flag{
 			cmd.ProductsParam,
 			absPathFlag,
+			manifestFlag,
 		},
 		Action: func(ctx cli.Context) error {
 			cfg, err := config.Load(cfgcli.ConfigPath, cfgcli.ConfigJSON)
@@ -76,15 +90,46 @@ func artifactsCommand(name, usage string, action artifactsAction) cli.Command {
 				return err
 			}
 
+			if ctx.Bool(manifestFlagName) {
+				// manifest entries are identified by their path relative to the project directory
+				artifacts, err := action(ctx, specs, false)
+				if err != nil {
+					return err
+				}
+				manifests, err := Manifests(specs, artifacts)
+				if err != nil {
+					return err
+				}
+				bytes, err := json.MarshalIndent(manifests, "", "  ")
+				if err != nil {
+					return errors.Wrapf(err, "failed to marshal manifests")
+				}
+				ctx.Println(string(bytes))
+				return nil
+			}
+
 			artifacts, err := action(ctx, specs, ctx.Bool(absPathFlagName))
 			if err != nil {
 				return err
 			}
 
+			verbose := ctx.Has(verboseFlagName) && ctx.Bool(verboseFlagName)
 			for _, spec := range specs {
 				if v, ok := artifacts[spec.Spec.ProductName]; ok {
 					for _, k := range v.Keys() {
 						ctx.Println(v.Get(k))
+						if !verbose {
+							continue
+						}
+						osArch, primary, err := BuildArtifactOSArch(k)
+						if err != nil {
+							return err
+						} else if !primary {
+							continue
+						}
+						for _, line := range build.EffectiveConfig(spec.Spec, osArch) {
+							ctx.Println("  " + line)
+						}
 					}
 				}
 			}
@@ -98,7 +143,7 @@ func artifactsCommand(name, usage string, action artifactsAction) cli.Command {
 // command that are only relevant for the "build" action.
 func buildArtifactsCommand(name, usage string) cli.Command {
 	buildCmd := artifactsCommand(name, usage, buildArtifactsAction)
-	buildCmd.Flags = append(buildCmd.Flags, cmd.OSArchFlag, requiresBuildFlag)
+	buildCmd.Flags = append(buildCmd.Flags, cmd.OSArchFlag, requiresBuildFlag, verboseFlag)
 	return buildCmd
 }
 
@@ -16,7 +16,9 @@ package build
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"go/build"
 	"io"
 	"os"
 	"os/exec"
@@ -24,6 +26,7 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -34,6 +37,8 @@ import (
 	"github.com/palantir/godel/apps/distgo/params"
 	"github.com/palantir/godel/apps/distgo/pkg/osarch"
 	"github.com/palantir/godel/apps/distgo/pkg/script"
+	"github.com/palantir/godel/apps/distgo/templating"
+	"github.com/palantir/godel/pkg/gomod"
 )
 
 type buildUnit struct {
@@ -43,8 +48,20 @@ type buildUnit struct {
 
 type Context struct {
 	Parallel bool
-	Install  bool
-	Pkgdir   bool
+	// Workers is the number of units that are built concurrently when Parallel is true. If it is not positive, the
+	// number of logical processors is used.
+	Workers int
+	Install bool
+	Pkgdir  bool
+	// VerifyReproducible specifies that each unit should be built twice in reproducible mode in separate temporary
+	// directories to verify that the builds produce identical output rather than being built to the output directory.
+	VerifyReproducible bool
+	// SizeReport specifies that the size of the executable of each unit and its change from the previous build of the
+	// unit should be printed.
+	SizeReport bool
+	// SizeReportPackages specifies that the size report (which is printed if this value is true even if SizeReport is
+	// false) should include the total size of the symbols of the largest packages in each executable.
+	SizeReportPackages bool
 }
 
 func Products(products []string, osArchs cmd.OSArchFilter, buildCtx Context, cfg params.Project, wd string, stdout io.Writer) error {
@@ -57,16 +74,34 @@ func Products(products []string, osArchs cmd.OSArchFilter, buildCtx Context, cfg
 	}, cfg, products, wd, stdout)
 }
 
-// Run builds all of the executables specified by buildSpecs using the mode specified in ctx. If ctx.Parallel is true,
-// then the products will be built in parallel with N workers, where N is the number of logical processors reported by
-// Go. When builds occur in parallel, each (Product, OSArch) pair is treated as an individual unit of work. Thus, it is
-// possible that different products may be built in parallel. If any build process returns an error, the first error
-// returned is propagated back (and any builds that have not started will not be started). If ctx.PkgDir is true, a
-// custom per-OS/Arch "pkg" directory is used and the "install" command is run before build for each unit, which can
-// speed up compilations on repeated runs by writing compiled packages to disk for reuse.
+// WatchProducts builds the specified products like Products and then rebuilds the products whose source files change
+// (see Watch) until the provided context is done.
+func WatchProducts(runCtx context.Context, products []string, osArchs cmd.OSArchFilter, buildCtx Context, cfg params.Project, wd string, stdout io.Writer) error {
+	return RunBuildFunc(func(buildSpec []params.ProductBuildSpecWithDeps, stdout io.Writer) error {
+		specs := make([]params.ProductBuildSpec, len(buildSpec))
+		for i, curr := range buildSpec {
+			specs[i] = curr.Spec
+		}
+		return Watch(runCtx, specs, osArchs, buildCtx, DefaultWatchInterval, stdout)
+	}, cfg, products, wd, stdout)
+}
+
+// Run builds the executables specified by buildSpecs for the OS/Archs that match osArchs using the mode specified in
+// ctx. Each (Product, OSArch) pair is a unit of work that is built by executeBuild. If ctx.Parallel is true, the units
+// are built in parallel (see runParallel); otherwise, they are built serially and the first error encountered is
+// returned.
 func Run(buildSpecs []params.ProductBuildSpec, osArchs cmd.OSArchFilter, ctx Context, stdout io.Writer) error {
+	return run(context.Background(), buildSpecs, osArchs, ctx, stdout)
+}
+
+// run builds the provided specs like Run. The build processes are killed if the provided context is done.
+func run(runCtx context.Context, buildSpecs []params.ProductBuildSpec, osArchs cmd.OSArchFilter, ctx Context, stdout io.Writer) error {
 	var units []buildUnit
 	for _, currSpec := range distinct(buildSpecs) {
+		if err := validateOSArchs(currSpec); err != nil {
+			return err
+		}
+
 		// execute pre-build script
 		distEnvVars := cmd.ScriptEnvVariables(currSpec, "")
 		if err := script.WriteAndExecute(currSpec, currSpec.Build.Script, stdout, os.Stderr, distEnvVars); err != nil {
@@ -83,123 +118,312 @@ func Run(buildSpecs []params.ProductBuildSpec, osArchs cmd.OSArchFilter, ctx Con
 		}
 	}
 
+	if ctx.VerifyReproducible {
+		return verifyReproducible(runCtx, units, ctx, stdout)
+	}
+
 	if len(units) == 1 || !ctx.Parallel {
 		// process serially
 		for _, currUnit := range units {
-			if err := executeBuild(stdout, currUnit.buildSpec, ctx, currUnit.osArch); err != nil {
+			if err := executeBuild(runCtx, stdout, currUnit.buildSpec, ctx, currUnit.osArch); err != nil {
 				return err
 			}
 		}
-	} else {
-		done := make(chan struct{})
-		defer close(done)
+		return nil
+	}
+	return runParallel(runCtx, units, ctx, stdout)
+}
 
-		// send all jobs
-		nUnits := len(units)
-		buildUnitsJobs := make(chan buildUnit, nUnits)
-		for _, currUnit := range units {
-			buildUnitsJobs <- currUnit
+// validateOSArchs returns an error if any of the OSArchs of the provided spec or any of the keys of its OSArch
+// overrides is not a target supported by the installed Go toolchain. Configuration is validated when it is loaded, but
+// specs that are created directly are only validated when they are built.
+func validateOSArchs(buildSpec params.ProductBuildSpec) error {
+	for _, osArch := range buildSpec.Build.OSArchs {
+		if err := osarch.Validate(osArch); err != nil {
+			return errors.Wrapf(err, "invalid value in os-archs of %s", buildSpec.ProductName)
+		}
+	}
+	for osArch := range buildSpec.Build.OSArchOverrides {
+		if err := osarch.Validate(osArch); err != nil {
+			return errors.Wrapf(err, "invalid key in os-arch-overrides of %s", buildSpec.ProductName)
 		}
-		close(buildUnitsJobs)
+	}
+	return nil
+}
+
+// runParallel builds the provided units using a pool of ctx.Workers workers (or the number of logical processors if
+// ctx.Workers is not positive). The first unit that fails (or the provided context being done) cancels the context
+// shared by all of the units, which kills the build processes of the units that are in progress and causes units that
+// have not started to be skipped. If any unit fails, a *ParallelBuildError that summarizes the outcome of every unit is
+// returned.
+func runParallel(parentCtx context.Context, units []buildUnit, ctx Context, stdout io.Writer) error {
+	runCtx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	// send all jobs
+	nUnits := len(units)
+	jobs := make(chan int, nUnits)
+	for i := range units {
+		jobs <- i
+	}
+	close(jobs)
 
-		// create workers
-		nWorkers := runtime.NumCPU()
-		if nUnits < nWorkers {
-			nWorkers = nUnits
+	// create workers
+	nWorkers := ctx.Workers
+	if nWorkers <= 0 {
+		nWorkers = runtime.NumCPU()
+	}
+	if nUnits < nWorkers {
+		nWorkers = nUnits
+	}
+
+	results := make([]UnitResult, nUnits)
+	var wg sync.WaitGroup
+	wg.Add(nWorkers)
+	for i := 0; i < nWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = buildParallelUnit(runCtx, cancel, stdout, units[idx], ctx)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Status == UnitFailed {
+			return &ParallelBuildError{Results: results}
 		}
-		var cs []<-chan error
-		for i := 0; i < nWorkers; i++ {
-			cs = append(cs, worker(stdout, buildUnitsJobs, ctx))
+	}
+	return nil
+}
+
+func buildParallelUnit(runCtx context.Context, cancel context.CancelFunc, stdout io.Writer, unit buildUnit, ctx Context) UnitResult {
+	result := UnitResult{
+		Product: unit.buildSpec.ProductName,
+		OSArch:  unit.osArch,
+		Status:  UnitSucceeded,
+	}
+	if runCtx.Err() != nil {
+		result.Status = UnitCancelled
+		return result
+	}
+	if err := executeBuild(runCtx, stdout, unit.buildSpec, ctx, unit.osArch); err != nil {
+		result.Err = err
+		if runCtx.Err() != nil {
+			// build was interrupted because another unit failed
+			result.Status = UnitCancelled
+		} else {
+			result.Status = UnitFailed
+			cancel()
 		}
+	}
+	return result
+}
 
-		for err := range merge(done, cs...) {
-			if err != nil {
-				return err
-			}
+// UnitStatus is the outcome of building a single (Product, OSArch) unit.
+type UnitStatus string
+
+const (
+	UnitSucceeded UnitStatus = "succeeded"
+	UnitFailed    UnitStatus = "failed"
+	UnitCancelled UnitStatus = "cancelled"
+)
+
+// UnitResult records the outcome of building a single (Product, OSArch) unit.
+type UnitResult struct {
+	Product string
+	OSArch  osarch.OSArch
+	Status  UnitStatus
+	// Err is the error returned by the build of the unit. Only set if Status is UnitFailed or if a unit in progress was
+	// cancelled.
+	Err error
+}
+
+// ParallelBuildError is returned by Run when one or more units fail to build in parallel. It contains the results for
+// all of the units, in the order in which the units were scheduled.
+type ParallelBuildError struct {
+	Results []UnitResult
+}
+
+func (e *ParallelBuildError) Error() string {
+	nFailed := 0
+	for _, result := range e.Results {
+		if result.Status == UnitFailed {
+			nFailed++
 		}
 	}
 
-	return nil
+	lines := []string{fmt.Sprintf("failed to build %d of %d (product, OS/Arch) units:", nFailed, len(e.Results))}
+	for _, result := range e.Results {
+		line := fmt.Sprintf("  %s %s: %s", result.Product, result.OSArch.String(), result.Status)
+		if result.Status == UnitFailed {
+			line += ": " + result.Err.Error()
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
 }
 
+const (
+	// DefaultOutputPath is the template for the path of the directory relative to the build output directory to which
+	// the outputs for an OS/Arch are written if the spec does not specify one.
+	DefaultOutputPath = "{{.VersionInfo.Version}}/{{.OSArch}}"
+	// DefaultArtifactName is the template for the name of the outputs if the spec does not specify one.
+	DefaultArtifactName = "{{.ProductName}}"
+)
+
 // ArtifactPaths returns a map that contains the paths to the executables created by the provided spec. The keys in the
 // map are the OS/architecture of the executable, and the value is the output path for the executable for that
-// OS/architecture.
-func ArtifactPaths(buildSpec params.ProductBuildSpec) map[osarch.OSArch]string {
+// OS/architecture. If the output directory of the spec is an absolute path, the executables are written to that
+// directory rather than to a directory relative to the project directory. If the spec uses a build mode that does not
+// produce an executable, the value is the path to the library or plugin that is created.
+func ArtifactPaths(buildSpec params.ProductBuildSpec) (map[osarch.OSArch]string, error) {
+	outputPaths, err := OutputPaths(buildSpec)
+	if err != nil {
+		return nil, err
+	}
 	paths := make(map[osarch.OSArch]string)
-	for _, osArch := range buildSpec.Build.OSArchs {
-		paths[osArch] = path.Join(buildSpec.ProjectDir, buildSpec.Build.OutputDir, buildSpec.VersionInfo.Version, osArch.String(), ExecutableName(buildSpec.ProductName, osArch.OS))
+	for osArch, currOutputPaths := range outputPaths {
+		paths[osArch] = currOutputPaths[0]
 	}
-	return paths
+	return paths, nil
 }
 
-// merge handles "fanning in" the result of multiple output channels into a single output channel. If a signal is
-// received on the "done" channel, output processing will stop.
-func merge(done <-chan struct{}, cs ...<-chan error) <-chan error {
-	var wg sync.WaitGroup
-	out := make(chan error)
-
-	output := func(c <-chan error) {
-		defer wg.Done()
-		for err := range c {
-			select {
-			case out <- err:
-			case <-done:
-				return
-			}
-		}
+// OutputPaths returns a map that contains the paths to all of the files created by the provided spec for each
+// OS/architecture. The first path for each OS/architecture is the path returned by ArtifactPaths and any remaining
+// paths are the additional files created by the build mode of the spec (such as C header files). The paths are
+// determined by rendering the OutputPath and ArtifactName templates of the spec (or DefaultOutputPath and
+// DefaultArtifactName if they are blank) for each OS/architecture. Returns an error if the templates render to the same
+// path for different OS/architectures.
+func OutputPaths(buildSpec params.ProductBuildSpec) (map[osarch.OSArch][]string, error) {
+	outputPathTmpl := buildSpec.Build.OutputPath
+	if outputPathTmpl == "" {
+		outputPathTmpl = DefaultOutputPath
 	}
-
-	wg.Add(len(cs))
-	for _, c := range cs {
-		go output(c)
+	artifactNameTmpl := buildSpec.Build.ArtifactName
+	if artifactNameTmpl == "" {
+		artifactNameTmpl = DefaultArtifactName
 	}
 
-	go func() {
-		wg.Wait()
-		close(out)
-	}()
-	return out
+	paths := make(map[osarch.OSArch][]string)
+	pathOSArchs := make(map[string]osarch.OSArch)
+	for _, osArch := range buildSpec.Build.OSArchs {
+		templateCfg := templating.PathConfig{
+			Config: templating.ConvertBuildSpec(buildSpec),
+			OSArch: templating.OSArch(osArch),
+		}
+		outputPath, err := templating.RenderPath("output-path", outputPathTmpl, templateCfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine output path of %s for %s", buildSpec.ProductName, osArch.String())
+		}
+		artifactName, err := templating.RenderPath("artifact-name", artifactNameTmpl, templateCfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine artifact name of %s for %s", buildSpec.ProductName, osArch.String())
+		}
+		for _, currName := range OutputNames(artifactName, buildSpec.Build.BuildMode, osArch.OS) {
+			currPath := path.Join(outputDir(buildSpec), outputPath, currName)
+			if prevOSArch, ok := pathOSArchs[currPath]; ok && prevOSArch != osArch {
+				return nil, errors.Errorf("output path of %s is %s for both %s and %s: the output path or artifact name must be different for every OS/Arch", buildSpec.ProductName, currPath, prevOSArch.String(), osArch.String())
+			}
+			pathOSArchs[currPath] = osArch
+			paths[osArch] = append(paths[osArch], currPath)
+		}
+	}
+	return paths, nil
 }
 
-func worker(stdout io.Writer, in <-chan buildUnit, ctx Context) <-chan error {
-	out := make(chan error)
-	go func() {
-		for unit := range in {
-			out <- executeBuild(stdout, unit.buildSpec, ctx, unit.osArch)
-		}
-		close(out)
-	}()
-	return out
+// outputDir returns the path to the build output directory of the provided spec.
+func outputDir(buildSpec params.ProductBuildSpec) string {
+	if path.IsAbs(buildSpec.Build.OutputDir) {
+		return buildSpec.Build.OutputDir
+	}
+	return path.Join(buildSpec.ProjectDir, buildSpec.Build.OutputDir)
 }
 
-func executeBuild(stdout io.Writer, buildSpec params.ProductBuildSpec, ctx Context, osArch osarch.OSArch) error {
+// executeBuild builds the outputs of the provided spec for the provided OS/Arch. If the build cache already contains
+// the outputs for the current inputs of the unit (see cacheKey), they are restored from the cache rather than being
+// built again; otherwise, the outputs that are built are stored in the cache. If ctx.Install is true, "install" is run
+// before "build" (using a custom per-OS/Arch "pkg" directory if ctx.Pkgdir is true) so that compiled packages are
+// reused on repeated runs, which is skipped in module mode because it only populates GOPATH.
+func executeBuild(runCtx context.Context, stdout io.Writer, buildSpec params.ProductBuildSpec, ctx Context, osArch osarch.OSArch) error {
 	name := buildSpec.ProductName
 
 	start := time.Now()
-	outputArtifactPath, ok := ArtifactPaths(buildSpec)[osArch]
+	allOutputPaths, err := OutputPaths(buildSpec)
+	if err != nil {
+		return err
+	}
+	outputPaths, ok := allOutputPaths[osArch]
 	if !ok {
 		return fmt.Errorf("failed to determine artifact path for %s for %s", name, osArch.String())
 	}
+	outputArtifactPath := outputPaths[0]
 	currOutputDir := path.Dir(outputArtifactPath)
 	fmt.Fprintf(stdout, "Building %s for %s at %s\n", name, osArch.String(), path.Join(currOutputDir, name))
 
 	if err := os.MkdirAll(currOutputDir, 0755); err != nil {
 		return errors.Wrapf(err, "failed to create directories for %s", currOutputDir)
 	}
-	if ctx.Install {
-		if err := doBuildAction(doInstall, buildSpec, "", osArch, ctx.Pkgdir); err != nil {
+
+	scriptArgs, err := buildArgsScriptOutput(buildSpec)
+	if err != nil {
+		return err
+	}
+	buildArgs, err := goBuildArgs(buildSpec, osArch, scriptArgs)
+	if err != nil {
+		return err
+	}
+
+	// if the key for the unit cannot be computed, build without using the cache
+	key, keyErr := cacheKey(buildSpec, osArch, buildArgs)
+	if keyErr == nil {
+		restored, err := restoreFromCache(buildSpec, osArch, key, outputPaths)
+		if err != nil {
+			return err
+		}
+		if restored {
+			elapsed := time.Since(start)
+			fmt.Fprintf(stdout, "Finished building %s for %s (restored from cache) (%.3fs)\n", name, osArch.String(), elapsed.Seconds())
+			return finishBuild(stdout, buildSpec, ctx, osArch, outputPaths, buildArgs)
+		}
+	}
+
+	// "install" only applies to executables (the other build modes do not support it for main packages) and only
+	// populates GOPATH, which is not used in module mode
+	if ctx.Install && params.IsExecutableBuildMode(buildSpec.Build.BuildMode) && !gomod.Enabled(buildSpec.ProjectDir) {
+		if err := doBuildAction(runCtx, doInstall, buildSpec, "", osArch, ctx.Pkgdir, buildArgs); err != nil {
 			return fmt.Errorf("go install failed: %v", err)
 		}
 	}
-	if err := doBuildAction(doBuild, buildSpec, currOutputDir, osArch, ctx.Pkgdir); err != nil {
+	// remove the existing output because "go build" does not overwrite a file that it did not create
+	if err := os.Remove(outputArtifactPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s", outputArtifactPath)
+	}
+	if err := doBuildAction(runCtx, doBuild, buildSpec, outputArtifactPath, osArch, ctx.Pkgdir, buildArgs); err != nil {
 		return errors.Wrapf(err, "go build failed")
 	}
 
+	if keyErr == nil {
+		if err := storeInCache(buildSpec, osArch, key, outputPaths); err != nil {
+			return err
+		}
+	}
+
 	elapsed := time.Since(start)
 	fmt.Fprintf(stdout, "Finished building %s for %s (%.3fs)\n", name, osArch.String(), elapsed.Seconds())
 
-	return nil
+	return finishBuild(stdout, buildSpec, ctx, osArch, outputPaths, buildArgs)
+}
+
+// finishBuild performs the actions that follow building or restoring the outputs for a unit: the outputs are recorded
+// in the build manifest of the product (see ManifestPath) and the build fails if the primary output is larger than the
+// MaxSize of the product. The size of the primary output is reported if requested.
+func finishBuild(stdout io.Writer, buildSpec params.ProductBuildSpec, ctx Context, osArch osarch.OSArch, outputPaths []string, buildArgs []string) error {
+	if err := recordInManifest(buildSpec, osArch, outputPaths, buildArgs); err != nil {
+		return err
+	}
+	return checkSize(stdout, buildSpec, ctx, osArch, outputPaths[0])
 }
 
 type buildAction int
@@ -209,58 +433,35 @@ const (
 	doInstall
 )
 
-func doBuildAction(action buildAction, buildSpec params.ProductBuildSpec, outputDir string, osArch osarch.OSArch, pkgdir bool) error {
-	cmd := exec.Command("go")
+func doBuildAction(runCtx context.Context, action buildAction, buildSpec params.ProductBuildSpec, outputPath string, osArch osarch.OSArch, pkgdir bool, buildArgs []string) error {
+	cmd := exec.CommandContext(runCtx, "go")
 	cmd.Dir = buildSpec.ProjectDir
 
-	var env []string
+	env := buildEnv(buildSpec, osArch)
 	goos := runtime.GOOS
 	if osArch.OS != "" {
-		env = append(env, "GOOS="+osArch.OS)
 		goos = osArch.OS
 	}
 	goarch := runtime.GOARCH
 	if osArch.Arch != "" {
-		env = append(env, "GOARCH="+osArch.Arch)
 		goarch = osArch.Arch
 	}
-	for k, v := range buildSpec.Build.Environment {
-		env = append(env, fmt.Sprintf("%v=%v", k, v))
-	}
-	cmd.Env = append(os.Environ(), env...)
+	cmd.Env = append(append(os.Environ(), gomod.Env(buildSpec.ProjectDir)...), env...)
 
 	args := []string{cmd.Path}
 	switch action {
 	case doBuild:
 		args = append(args, "build")
-		args = append(args, "-o", path.Join(outputDir, ExecutableName(buildSpec.ProductName, goos)))
+		args = append(args, "-o", outputPath)
 	case doInstall:
 		args = append(args, "install")
 	default:
 		return errors.Errorf("unrecognized action: %v", action)
 	}
+	args = append(args, buildArgs...)
 
-	// execute build args script
-	stdoutBuf := bytes.Buffer{}
-	stderrBuf := bytes.Buffer{}
-	combinedBuf := bytes.Buffer{}
-	stdoutMW := io.MultiWriter(&stdoutBuf, &combinedBuf)
-	stderrMW := io.MultiWriter(&stderrBuf, &combinedBuf)
-	if err := script.WriteAndExecute(buildSpec, buildSpec.Build.BuildArgsScript, stdoutMW, stderrMW, nil); err != nil || stderrBuf.String() != "" {
-		return errors.Wrapf(err, "failed to execute build args script for %v: %v", buildSpec.ProductName, combinedBuf.String())
-	}
-
-	buildArgsString := strings.TrimSpace(stdoutBuf.String())
-	if buildArgsString != "" {
-		buildArgs := strings.Split(buildArgsString, "\n")
-		args = append(args, buildArgs...)
-	}
-
-	if buildSpec.Build.VersionVar != "" {
-		args = append(args, "-ldflags", fmt.Sprintf("-X %v=%v", buildSpec.Build.VersionVar, buildSpec.ProductVersion))
-	}
-
-	if pkgdir {
+	// the custom pkgdir is located in GOPATH, so it is not used for projects in module mode
+	if pkgdir && !gomod.Enabled(buildSpec.ProjectDir) {
 		// specify custom pkgdir if isolation of packages is desired
 		args = append(args, "-pkgdir", fmt.Sprintf("%v/pkg/_%v_%v", os.Getenv("GOPATH"), goos, goarch))
 	}
@@ -280,6 +481,119 @@ func doBuildAction(action buildAction, buildSpec params.ProductBuildSpec, output
 	return nil
 }
 
+// buildEnv returns the environment variables (in "KEY=VALUE" form) that are set in addition to the environment of the
+// current process when building the provided spec for the provided OS/Arch. The environment of the product is merged
+// with the environment specified for the OS/Arch in its OSArchOverrides.
+func buildEnv(buildSpec params.ProductBuildSpec, osArch osarch.OSArch) []string {
+	var env []string
+	if osArch.OS != "" {
+		env = append(env, "GOOS="+osArch.OS)
+	}
+	if osArch.Arch != "" {
+		env = append(env, "GOARCH="+osArch.Arch)
+	}
+	for k, v := range buildSpec.Build.ForOSArch(osArch).Environment {
+		env = append(env, fmt.Sprintf("%v=%v", k, v))
+	}
+	return env
+}
+
+// GoContext returns the go/build context that matches the environment in which the provided spec is built for the
+// provided OS/Arch: its GOOS and GOARCH are those of the OS/Arch, its build tags are the tags for the OS/Arch and cgo
+// is enabled based on the CGO_ENABLED environment variable of the spec (or of the current process) in the same manner
+// as the go tool, which disables cgo by default when cross-compiling.
+func GoContext(buildSpec params.ProductBuildSpec, osArch osarch.OSArch) build.Context {
+	osArchBuild := buildSpec.Build.ForOSArch(osArch)
+	ctx := build.Default
+	ctx.GOOS = osArch.OS
+	ctx.GOARCH = osArch.Arch
+	ctx.BuildTags = osArchBuild.Tags
+
+	cgoEnabled, ok := osArchBuild.Environment["CGO_ENABLED"]
+	if !ok {
+		cgoEnabled = os.Getenv("CGO_ENABLED")
+	}
+	switch cgoEnabled {
+	case "0":
+		ctx.CgoEnabled = false
+	case "1":
+		ctx.CgoEnabled = true
+	default:
+		ctx.CgoEnabled = build.Default.CgoEnabled && osArch == osarch.Current()
+	}
+	return ctx
+}
+
+// EffectiveConfig returns lines that describe the environment, tags, ldflags and args that are used to build the
+// provided spec for the provided OS/Arch after the OSArchOverrides of the spec have been merged over its configuration.
+// Values that are empty are omitted.
+func EffectiveConfig(buildSpec params.ProductBuildSpec, osArch osarch.OSArch) []string {
+	osArchBuild := buildSpec.Build.ForOSArch(osArch)
+
+	var env []string
+	for k, v := range osArchBuild.Environment {
+		env = append(env, fmt.Sprintf("%v=%v", k, v))
+	}
+	sort.Strings(env)
+
+	var lines []string
+	for _, curr := range []struct {
+		name   string
+		values []string
+	}{
+		{name: "environment", values: env},
+		{name: "tags", values: osArchBuild.Tags},
+		{name: "ldflags", values: osArchBuild.Ldflags},
+		{name: "args", values: osArchBuild.Args},
+	} {
+		if len(curr.values) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", curr.name, strings.Join(curr.values, " ")))
+		}
+	}
+	return lines
+}
+
+// goBuildArgs returns the arguments that are provided to the "build" and "install" commands for the provided spec and
+// OS/Arch. The arguments consist of the "-buildmode" argument (if the spec specifies a build mode), the provided output
+// of the build args script and the args specified for the OS/Arch in the OSArchOverrides of the spec followed by the
+// arguments required by reproducible mode (if enabled) and the combined "-ldflags", "-gcflags", "-asmflags" and "-tags"
+// arguments for the spec.
+func goBuildArgs(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, scriptArgs []string) ([]string, error) {
+	allArgs := append(append([]string{}, scriptArgs...), buildSpec.Build.ForOSArch(osArch).Args...)
+	args, flagValues := extractBuildFlags(allArgs)
+	flagArgs, err := combinedFlagArgs(buildSpec, osArch, flagValues)
+	if err != nil {
+		return nil, err
+	}
+	if buildSpec.Build.BuildMode != "" {
+		args = append([]string{"-buildmode=" + buildSpec.Build.BuildMode}, args...)
+	}
+	return append(args, flagArgs...), nil
+}
+
+// buildArgsScriptOutput executes the build args script of the provided spec and returns the lines of its output.
+func buildArgsScriptOutput(buildSpec params.ProductBuildSpec) ([]string, error) {
+	var scriptArgs []string
+
+	// execute build args script
+	stdoutBuf := bytes.Buffer{}
+	stderrBuf := bytes.Buffer{}
+	combinedBuf := bytes.Buffer{}
+	stdoutMW := io.MultiWriter(&stdoutBuf, &combinedBuf)
+	stderrMW := io.MultiWriter(&stderrBuf, &combinedBuf)
+	if err := script.WriteAndExecute(buildSpec, buildSpec.Build.BuildArgsScript, stdoutMW, stderrMW, nil); err != nil {
+		return nil, errors.Wrapf(err, "failed to execute build args script for %v: %v", buildSpec.ProductName, combinedBuf.String())
+	} else if stderrBuf.String() != "" {
+		return nil, errors.Errorf("build args script for %v wrote to stderr: %v", buildSpec.ProductName, combinedBuf.String())
+	}
+
+	buildArgsString := strings.TrimSpace(stdoutBuf.String())
+	if buildArgsString != "" {
+		scriptArgs = strings.Split(buildArgsString, "\n")
+	}
+	return scriptArgs, nil
+}
+
 const installPermissionDenied = `^go install [a-zA-Z0-9_/]+: mkdir .+: permission denied$`
 
 func goInstallErrorMsg(osArch osarch.OSArch, err error) string {
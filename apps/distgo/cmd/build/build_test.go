@@ -16,6 +16,7 @@ package build_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -25,6 +26,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nmiyake/pkg/dirs"
 	"github.com/palantir/pkg/pkgpath"
@@ -263,7 +265,8 @@ echo "-X \"main.testVersionVar=$VALUE\""`,
 		} else {
 			assert.NoError(t, err, "Case %d", i)
 
-			artifactPaths := build.ArtifactPaths(buildSpec)
+			artifactPaths, err := build.ArtifactPaths(buildSpec)
+			require.NoError(t, err, "Case %d", i)
 			for _, currOSArch := range currCase.params.Build.OSArchs {
 				pathToCurrExecutable, ok := artifactPaths[currOSArch]
 				require.True(t, ok, "Case %d: could not find path for %s for %s", buildSpec.ProductName, currOSArch.String())
@@ -286,6 +289,558 @@ echo "-X \"main.testVersionVar=$VALUE\""`,
 	}
 }
 
+func TestBuildLdflagsVarsAndTags(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	gittest.InitGitDir(t, tmp)
+	gittest.CreateGitTag(t, tmp, testVersionValue)
+
+	for file, content := range map[string]string{
+		"foo/main.go": `package main
+
+import "fmt"
+
+var version, product, year, tagged string
+
+func main() {
+	fmt.Printf("%s|%s|%s|%s", version, product, year, tagged)
+}
+`,
+		"foo/tagged.go": `// +build customtag
+
+package main
+
+func init() {
+	tagged = "tagged"
+}
+`,
+	} {
+		err := os.MkdirAll(path.Join(tmp, path.Dir(file)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, file), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	gitProductInfo, err := git.NewProjectInfo(tmp)
+	require.NoError(t, err)
+
+	buildSpec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		gitProductInfo,
+		params.Product{
+			Build: params.Build{
+				MainPkg:    "./foo",
+				VersionVar: "main.version",
+				LdflagsVars: map[string]string{
+					"main.product": "{{.ProductName}} {{.VersionInfo.Branch}}",
+					// overridden by VersionVar
+					"main.version": "ignored",
+				},
+				Ldflags: []string{"-s"},
+				Tags:    []string{"customtag"},
+				BuildArgsScript: `echo "-ldflags"
+echo "-X main.year=2017"`,
+			},
+		},
+		params.Project{
+			BuildOutputDir: "bin",
+		},
+	)
+
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, ioutil.Discard)
+	require.NoError(t, err)
+
+	output, err := exec.Command(executablePath(t, buildSpec)).Output()
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s|foo %s|2017|tagged", gitProductInfo.Version, gitProductInfo.Branch), string(output))
+}
+
+func TestBuildOSArchOverrides(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for file, content := range map[string]string{
+		"foo/main.go": `package main
+
+import "fmt"
+
+var product, year, tagged string
+
+func main() {
+	fmt.Printf("%s|%s|%s", product, year, tagged)
+}
+`,
+		"foo/tagged.go": `// +build customtag
+
+package main
+
+func init() {
+	tagged = "tagged"
+}
+`,
+	} {
+		err := os.MkdirAll(path.Join(tmp, path.Dir(file)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, file), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	otherOSArch := osarch.OSArch{OS: "plan9", Arch: "386"}
+	buildSpec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		git.ProjectInfo{},
+		params.Product{
+			Build: params.Build{
+				MainPkg: "./foo",
+				Ldflags: []string{"-X main.product=foo"},
+				Environment: map[string]string{
+					"CGO_ENABLED": "0",
+				},
+				OSArchs: []osarch.OSArch{osarch.Current()},
+				OSArchOverrides: map[osarch.OSArch]params.OSArchBuild{
+					osarch.Current(): {
+						Environment: map[string]string{
+							"CGO_ENABLED": "1",
+						},
+						Tags:    []string{"customtag"},
+						Ldflags: []string{"-X main.year=2017"},
+						Args:    []string{"-ldflags", "-s"},
+					},
+					otherOSArch: {
+						Tags: []string{"other"},
+					},
+				},
+			},
+		},
+		params.Project{
+			BuildOutputDir: "bin",
+		},
+	)
+
+	assert.Equal(t, []string{
+		"environment: CGO_ENABLED=1",
+		"tags: customtag",
+		"ldflags: -X main.product=foo -X main.year=2017",
+		"args: -ldflags -s",
+	}, build.EffectiveConfig(buildSpec, osarch.Current()))
+	assert.Equal(t, []string{
+		"environment: CGO_ENABLED=0",
+		"tags: other",
+		"ldflags: -X main.product=foo",
+	}, build.EffectiveConfig(buildSpec, otherOSArch))
+
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, ioutil.Discard)
+	require.NoError(t, err)
+
+	output, err := exec.Command(executablePath(t, buildSpec)).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "foo|2017|tagged", string(output))
+}
+
+func TestBuildModes(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	mainFilePath := path.Join(tmp, "foo/main.go")
+	err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(mainFilePath, []byte(`package main
+
+import "C"
+
+//export Answer
+func Answer() C.int {
+	return 42
+}
+
+func main() {}
+`), 0644)
+	require.NoError(t, err)
+
+	for i, currCase := range []struct {
+		buildMode string
+		want      []string
+	}{
+		{
+			buildMode: params.BuildModeCArchive,
+			want:      []string{"foo.a", "foo.h"},
+		},
+		{
+			buildMode: params.BuildModeCShared,
+			want:      build.OutputNames("foo", params.BuildModeCShared, osarch.Current().OS),
+		},
+	} {
+		buildSpec := params.NewProductBuildSpec(
+			tmp,
+			"foo",
+			git.ProjectInfo{},
+			params.Product{
+				Build: params.Build{
+					MainPkg:   "./foo",
+					BuildMode: currCase.buildMode,
+					Environment: map[string]string{
+						"CGO_ENABLED": "1",
+					},
+					OSArchs: []osarch.OSArch{osarch.Current()},
+				},
+			},
+			params.Project{
+				BuildOutputDir: path.Join("bin", currCase.buildMode),
+			},
+		)
+
+		var want []string
+		for _, currName := range currCase.want {
+			want = append(want, path.Join(tmp, "bin", currCase.buildMode, osarch.Current().String(), currName))
+		}
+		outputPaths, err := build.OutputPaths(buildSpec)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, want, outputPaths[osarch.Current()], "Case %d", i)
+		assert.Equal(t, want[0], executablePath(t, buildSpec), "Case %d", i)
+
+		err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, ioutil.Discard)
+		require.NoError(t, err, "Case %d", i)
+
+		for _, currPath := range want {
+			_, err := os.Stat(currPath)
+			assert.NoError(t, err, "Case %d", i)
+		}
+		header, err := ioutil.ReadFile(want[1])
+		require.NoError(t, err, "Case %d", i)
+		assert.Contains(t, string(header), "Answer", "Case %d", i)
+
+		// header is restored from the cache along with the library
+		err = os.Remove(want[1])
+		require.NoError(t, err, "Case %d", i)
+		buf := &bytes.Buffer{}
+		err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, buf)
+		require.NoError(t, err, "Case %d", i)
+		assert.Contains(t, buf.String(), "(restored from cache)", "Case %d", i)
+		_, err = os.Stat(want[1])
+		assert.NoError(t, err, "Case %d", i)
+	}
+}
+
+func TestBuildModule(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for file, content := range map[string]string{
+		"go.mod":      "module example.com/foo\n",
+		"foo/main.go": "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/foo/lib\"\n)\n\nfunc main() {\n\tfmt.Print(lib.Value)\n}\n",
+		"lib/lib.go":  "package lib\n\nconst Value = \"module\"\n",
+	} {
+		err := os.MkdirAll(path.Join(tmp, path.Dir(file)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, file), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	orig, isSet := os.LookupEnv("GO111MODULE")
+	defer func() {
+		if isSet {
+			_ = os.Setenv("GO111MODULE", orig)
+		} else {
+			_ = os.Unsetenv("GO111MODULE")
+		}
+	}()
+	err = os.Unsetenv("GO111MODULE")
+	require.NoError(t, err)
+
+	buildSpec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		git.ProjectInfo{},
+		params.Product{
+			Build: params.Build{
+				MainPkg: "./foo",
+				OSArchs: []osarch.OSArch{osarch.Current()},
+			},
+		},
+		params.Project{
+			BuildOutputDir: "bin",
+		},
+	)
+
+	// GOPATH-only install and pkgdir are skipped in module mode
+	buildCtx := build.Context{
+		Install: true,
+		Pkgdir:  true,
+	}
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, buildCtx, ioutil.Discard)
+	require.NoError(t, err)
+
+	output, err := exec.Command(executablePath(t, buildSpec)).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "module", string(output))
+
+	// dependencies are resolved using the module, so the build can be restored from the cache
+	buf := &bytes.Buffer{}
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, buildCtx, buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "(restored from cache)")
+}
+
+func TestWatch(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	mainFilePath := path.Join(tmp, "foo/main.go")
+	err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(mainFilePath, []byte(testMain), 0644)
+	require.NoError(t, err)
+
+	buildSpec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		git.ProjectInfo{},
+		params.Product{
+			Build: params.Build{
+				MainPkg: "./foo",
+				OSArchs: []osarch.OSArch{osarch.Current()},
+			},
+		},
+		params.Project{
+			BuildOutputDir: "bin",
+		},
+	)
+	artifactPath := executablePath(t, buildSpec)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- build.Watch(runCtx, []params.ProductBuildSpec{buildSpec}, nil, build.Context{}, 50*time.Millisecond, ioutil.Discard)
+	}()
+
+	waitForOutput := func(want string) {
+		var output []byte
+		for start := time.Now(); time.Since(start) < 30*time.Second; time.Sleep(50 * time.Millisecond) {
+			if output, err = exec.Command(artifactPath).Output(); err == nil && string(output) == want {
+				return
+			}
+		}
+		assert.Fail(t, "timed out waiting for output", "want %q, got %q", want, string(output))
+	}
+	waitForOutput("defaultVersion\n")
+
+	// modification of a watched file causes a rebuild
+	err = ioutil.WriteFile(mainFilePath, []byte(strings.Replace(testMain, "defaultVersion", "rebuiltVersionValue", -1)), 0644)
+	require.NoError(t, err)
+	waitForOutput("rebuiltVersionValue\n")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestWatcherOnlyReportsChangedOSArchs(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	err = os.MkdirAll(path.Join(tmp, "foo"), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(tmp, "foo", "main.go"), []byte(testMain), 0644)
+	require.NoError(t, err)
+	windowsFilePath := path.Join(tmp, "foo", "version_windows.go")
+	err = ioutil.WriteFile(windowsFilePath, []byte("package main\n"), 0644)
+	require.NoError(t, err)
+
+	linuxAMD64 := osarch.OSArch{OS: "linux", Arch: "amd64"}
+	windowsAMD64 := osarch.OSArch{OS: "windows", Arch: "amd64"}
+	darwinAMD64 := osarch.OSArch{OS: "darwin", Arch: "amd64"}
+	buildSpec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		git.ProjectInfo{},
+		params.Product{
+			Build: params.Build{
+				MainPkg: "./foo",
+				OSArchs: []osarch.OSArch{linuxAMD64, windowsAMD64, darwinAMD64},
+			},
+		},
+		params.Project{},
+	)
+	w := build.NewWatcher([]params.ProductBuildSpec{buildSpec}, cmd.OSArchFilter{linuxAMD64, windowsAMD64}, 10*time.Millisecond)
+
+	// only the units that build the modified file are reported
+	err = ioutil.WriteFile(windowsFilePath, []byte("package main\n\nconst suffix = \".exe\"\n"), 0644)
+	require.NoError(t, err)
+	runCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	changed, err := w.Wait(runCtx)
+	require.NoError(t, err)
+	require.Len(t, changed, 1)
+	assert.Equal(t, "foo", changed[0].ProductName)
+	assert.Equal(t, []osarch.OSArch{windowsAMD64}, changed[0].Build.OSArchs)
+}
+
+func TestOutputNames(t *testing.T) {
+	for i, currCase := range []struct {
+		buildMode string
+		goos      string
+		want      []string
+	}{
+		{buildMode: "", goos: "linux", want: []string{"foo"}},
+		{buildMode: "", goos: "windows", want: []string{"foo.exe"}},
+		{buildMode: params.BuildModePIE, goos: "windows", want: []string{"foo.exe"}},
+		{buildMode: params.BuildModeCShared, goos: "linux", want: []string{"foo.so", "foo.h"}},
+		{buildMode: params.BuildModeCShared, goos: "darwin", want: []string{"foo.dylib", "foo.h"}},
+		{buildMode: params.BuildModeCShared, goos: "windows", want: []string{"foo.dll", "foo.h"}},
+		{buildMode: params.BuildModeCArchive, goos: "darwin", want: []string{"foo.a", "foo.h"}},
+		{buildMode: params.BuildModePlugin, goos: "linux", want: []string{"foo.so"}},
+	} {
+		assert.Equal(t, currCase.want, build.OutputNames("foo", currCase.buildMode, currCase.goos), "Case %d", i)
+	}
+}
+
+func TestBuildSizeReport(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	mainFilePath := path.Join(tmp, "foo/main.go")
+	err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(mainFilePath, []byte(testMain), 0644)
+	require.NoError(t, err)
+
+	buildSpec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		git.ProjectInfo{},
+		params.Product{
+			Build: params.Build{
+				MainPkg: "./foo",
+			},
+		},
+		params.Project{
+			BuildOutputDir: "bin",
+		},
+	)
+	osArchStr := osarch.Current().String()
+
+	buf := &bytes.Buffer{}
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{
+		SizeReport: true,
+	}, buf)
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`(?m)^Size of foo for `+osArchStr+`: [0-9.]+ MiB \(no previous build\)$`), buf.String())
+
+	// change in size from previous build is reported
+	err = ioutil.WriteFile(mainFilePath, []byte(testMain+`
+var large = [4096]byte{1}
+
+func init() {
+	fmt.Println(len(large))
+}
+`), 0644)
+	require.NoError(t, err)
+
+	buf = &bytes.Buffer{}
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{
+		SizeReportPackages: true,
+	}, buf)
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`(?m)^Size of foo for `+osArchStr+`: [0-9.]+ MiB \(\+[0-9.]+ (B|KiB|MiB), \+[0-9.]+%\)$`), buf.String())
+	assert.Regexp(t, regexp.MustCompile(`(?m)^  runtime: [0-9.]+ [KM]iB$`), buf.String())
+
+	// build fails if max-size is exceeded
+	buildSpec.Build.MaxSize = 1024
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, ioutil.Discard)
+	require.Error(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^size of foo for `+osArchStr+` is [0-9.]+ MiB, which exceeds its max-size of 1.00 KiB$`), err.Error())
+}
+
+func TestBuildVerifyReproducible(t *testing.T) {
+	for i, currCase := range []struct {
+		build     params.Build
+		wantError string
+	}{
+		// reproducible mode uses the Unix epoch as the build time if SOURCE_DATE_EPOCH is not set
+		{
+			build: params.Build{
+				MainPkg:      "./foo",
+				Reproducible: true,
+				LdflagsVars: map[string]string{
+					"main.testVersionVar": "{{.BuildTime.Unix}}",
+				},
+			},
+		},
+		// products that do not enable reproducible mode are verified in reproducible mode
+		{
+			build: params.Build{
+				MainPkg: "./foo",
+				LdflagsVars: map[string]string{
+					"main.testVersionVar": "{{.BuildTime.UnixNano}}",
+				},
+			},
+		},
+		// value that differs between builds causes verification to fail
+		{
+			build: params.Build{
+				MainPkg: "./foo",
+				BuildArgsScript: `echo "-ldflags"
+echo "-X main.testVersionVar=$(date +%s%N)"`,
+			},
+			wantError: `(?s)^1 of 1 \(product, OS/Arch\) units are not reproducible:\n  foo [^ ]+: sha256 [0-9a-f]{64} != [0-9a-f]{64} \(sizes [0-9]+ and [0-9]+ bytes, first difference at byte [0-9]+\)$`,
+		},
+	} {
+		tmp, cleanup, err := dirs.TempDir("", "")
+		defer cleanup()
+		require.NoError(t, err)
+
+		mainFilePath := path.Join(tmp, "foo/main.go")
+		err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(mainFilePath, []byte(testMain), 0644)
+		require.NoError(t, err)
+
+		buildSpec := params.NewProductBuildSpec(
+			tmp,
+			"foo",
+			git.ProjectInfo{},
+			params.Product{
+				Build: currCase.build,
+			},
+			params.Project{
+				BuildOutputDir: "bin",
+			},
+		)
+
+		err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{
+			VerifyReproducible: true,
+		}, ioutil.Discard)
+		if currCase.wantError != "" {
+			require.Error(t, err, "Case %d", i)
+			assert.Regexp(t, regexp.MustCompile(currCase.wantError), err.Error(), "Case %d", i)
+		} else {
+			require.NoError(t, err, "Case %d", i)
+		}
+
+		// verification does not write to the output directory
+		_, err = os.Stat(path.Join(tmp, "bin"))
+		assert.True(t, os.IsNotExist(err), "Case %d", i)
+
+		if currCase.wantError == "" && currCase.build.Reproducible {
+			err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, ioutil.Discard)
+			require.NoError(t, err, "Case %d", i)
+
+			output, err := exec.Command(executablePath(t, buildSpec)).Output()
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, "0\n", string(output), "Case %d", i)
+		}
+	}
+}
+
 func TestBuildOnlyDistinctSpecs(t *testing.T) {
 	tmp, cleanup, err := dirs.TempDir("", "")
 	defer cleanup()
@@ -320,6 +875,292 @@ func TestBuildOnlyDistinctSpecs(t *testing.T) {
 	assert.Equal(t, 1, strings.Count(buf.String(), "Finished building foo"))
 }
 
+func TestBuildRestoresFromCache(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	mainFilePath := path.Join(tmp, "foo/main.go")
+	err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(mainFilePath, []byte(testMain), 0644)
+	require.NoError(t, err)
+
+	buildSpec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		git.ProjectInfo{},
+		params.Product{
+			Build: params.Build{
+				MainPkg: "./foo",
+			},
+		},
+		params.Project{
+			BuildOutputDir: "bin",
+		},
+	)
+	artifactPath := executablePath(t, buildSpec)
+
+	buf := &bytes.Buffer{}
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "restored from cache")
+
+	// modify the source and build again
+	err = ioutil.WriteFile(mainFilePath, []byte(strings.Replace(testMain, "defaultVersion", "modifiedVersion", -1)), 0644)
+	require.NoError(t, err)
+	buf = &bytes.Buffer{}
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "restored from cache")
+
+	output, err := exec.Command(artifactPath).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "modifiedVersion", strings.TrimSpace(string(output)))
+
+	// revert the source: executable for original source should be restored from the cache
+	err = ioutil.WriteFile(mainFilePath, []byte(testMain), 0644)
+	require.NoError(t, err)
+	buf = &bytes.Buffer{}
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Finished building foo for "+osarch.Current().String()+" (restored from cache)")
+
+	output, err = exec.Command(artifactPath).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "defaultVersion", strings.TrimSpace(string(output)))
+}
+
+func TestRequiresBuildAfterTargetSourceChanges(t *testing.T) {
+	for i, currCase := range []struct {
+		osArch      osarch.OSArch
+		tags        []string
+		files       map[string]string
+		changedFile string
+	}{
+		// file only built for target OS
+		{
+			osArch: osarch.OSArch{OS: "windows", Arch: "amd64"},
+			files: map[string]string{
+				"foo/version_windows.go": `package main; const version = "windows"`,
+				"foo/version_other.go":   "// +build !windows\n\npackage main; const version = \"other\"",
+			},
+			changedFile: "foo/version_windows.go",
+		},
+		// file only built with configured tag
+		{
+			osArch: osarch.Current(),
+			tags:   []string{"custom"},
+			files: map[string]string{
+				"foo/version_custom.go": "// +build custom\n\npackage main; const version = \"custom\"",
+				"foo/version_other.go":  "// +build !custom\n\npackage main; const version = \"other\"",
+			},
+			changedFile: "foo/version_custom.go",
+		},
+	} {
+		tmp, cleanup, err := dirs.TempDir("", "")
+		require.NoError(t, err, "Case %d", i)
+
+		files := map[string]string{
+			"foo/main.go": `package main; import "fmt"; func main() { fmt.Println(version) }`,
+		}
+		for k, v := range currCase.files {
+			files[k] = v
+		}
+		for k, v := range files {
+			err = os.MkdirAll(path.Dir(path.Join(tmp, k)), 0755)
+			require.NoError(t, err, "Case %d", i)
+			err = ioutil.WriteFile(path.Join(tmp, k), []byte(v), 0644)
+			require.NoError(t, err, "Case %d", i)
+		}
+
+		buildSpec := params.NewProductBuildSpec(
+			tmp,
+			"foo",
+			git.ProjectInfo{},
+			params.Product{
+				Build: params.Build{
+					MainPkg: "./foo",
+					Tags:    currCase.tags,
+					OSArchs: []osarch.OSArch{currCase.osArch},
+				},
+			},
+			params.Project{
+				BuildOutputDir: "bin",
+			},
+		)
+		specWithDeps, err := params.NewProductBuildSpecWithDeps(buildSpec, nil)
+		require.NoError(t, err, "Case %d", i)
+
+		err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, ioutil.Discard)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, 0, len(build.RequiresBuild(specWithDeps, nil).Specs()), "Case %d", i)
+
+		err = ioutil.WriteFile(path.Join(tmp, currCase.changedFile), []byte(files[currCase.changedFile]+"; const unused = 1"), 0644)
+		require.NoError(t, err, "Case %d", i)
+		assert.True(t, build.RequiresBuild(specWithDeps, nil).RequiresBuild("foo", currCase.osArch), "Case %d", i)
+
+		cleanup()
+	}
+}
+
+func TestBuildCacheEvictsLeastRecentlyUsedEntries(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	mainFilePath := path.Join(tmp, "foo/main.go")
+	err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+	require.NoError(t, err)
+
+	buildSpec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		git.ProjectInfo{},
+		params.Product{
+			Build: params.Build{
+				MainPkg: "./foo",
+			},
+		},
+		params.Project{
+			BuildOutputDir: "bin",
+		},
+	)
+
+	cacheDir := path.Join(tmp, "bin", ".cache", "foo", osarch.Current().String())
+	for i := 0; i < 7; i++ {
+		err = ioutil.WriteFile(mainFilePath, []byte(strings.Replace(testMain, "defaultVersion", fmt.Sprintf("version%d", i), -1)), 0644)
+		require.NoError(t, err)
+		err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, ioutil.Discard)
+		require.NoError(t, err)
+	}
+	entries, err := ioutil.ReadDir(cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, 5, len(entries))
+
+	// most recent entry is retained and oldest entry is evicted
+	buf := &bytes.Buffer{}
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "(restored from cache)")
+
+	err = ioutil.WriteFile(mainFilePath, []byte(strings.Replace(testMain, "defaultVersion", "version0", -1)), 0644)
+	require.NoError(t, err)
+	buf = &bytes.Buffer{}
+	err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "(restored from cache)")
+}
+
+func TestBuildOutputPathTemplates(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	mainFilePath := path.Join(tmp, "foo/main.go")
+	err = os.MkdirAll(path.Dir(mainFilePath), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(mainFilePath, []byte(testMain), 0644)
+	require.NoError(t, err)
+
+	for i, currCase := range []struct {
+		outputPath   string
+		artifactName string
+		want         map[osarch.OSArch]string
+		wantError    string
+	}{
+		{
+			outputPath:   "{{.OSArch.OS}}/{{.OSArch.Arch}}",
+			artifactName: "{{.ProductName}}-{{.ProductVersion}}",
+			want: map[osarch.OSArch]string{
+				{OS: "darwin", Arch: "amd64"}:  "bin/darwin/amd64/foo-1.0.0",
+				{OS: "windows", Arch: "amd64"}: "bin/windows/amd64/foo-1.0.0.exe",
+			},
+		},
+		{
+			artifactName: "{{.ProductName}}-{{.OSArch}}",
+			want: map[osarch.OSArch]string{
+				{OS: "darwin", Arch: "amd64"}:  "bin/1.0.0/darwin-amd64/foo-darwin-amd64",
+				{OS: "windows", Arch: "amd64"}: "bin/1.0.0/windows-amd64/foo-windows-amd64.exe",
+			},
+		},
+		{
+			outputPath: "{{.Unknown}}",
+			wantError:  `failed to determine output path of foo for darwin-amd64: failed to execute template for output-path: template: output-path:1:2: executing "output-path" at <.Unknown>: can't evaluate field Unknown in type templating.PathConfig`,
+		},
+	} {
+		buildSpec := params.NewProductBuildSpec(
+			tmp,
+			"foo",
+			git.ProjectInfo{
+				Version: "1.0.0",
+			},
+			params.Product{
+				Build: params.Build{
+					MainPkg:      "./foo",
+					OutputPath:   currCase.outputPath,
+					ArtifactName: currCase.artifactName,
+					OSArchs: []osarch.OSArch{
+						{OS: "darwin", Arch: "amd64"},
+						{OS: "windows", Arch: "amd64"},
+					},
+				},
+			},
+			params.Project{
+				BuildOutputDir: "bin",
+			},
+		)
+
+		got, err := build.ArtifactPaths(buildSpec)
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		want := make(map[osarch.OSArch]string)
+		for k, v := range currCase.want {
+			want[k] = path.Join(tmp, v)
+		}
+		assert.Equal(t, want, got, "Case %d", i)
+
+		// products are built at the rendered paths and are considered up-to-date once built
+		specWithDeps, err := params.NewProductBuildSpecWithDeps(buildSpec, nil)
+		require.NoError(t, err, "Case %d", i)
+		err = build.Run(build.RequiresBuild(specWithDeps, nil).Specs(), nil, build.Context{}, ioutil.Discard)
+		require.NoError(t, err, "Case %d", i)
+		for _, currPath := range want {
+			_, err := os.Stat(currPath)
+			assert.NoError(t, err, "Case %d", i)
+		}
+		assert.Equal(t, 0, len(build.RequiresBuild(specWithDeps, nil).Specs()), "Case %d", i)
+	}
+}
+
+func TestOutputPathsMustBeUniquePerOSArch(t *testing.T) {
+	buildSpec := params.NewProductBuildSpec(
+		"/project",
+		"foo",
+		git.ProjectInfo{
+			Version: "1.0.0",
+		},
+		params.Product{
+			Build: params.Build{
+				MainPkg:    "./foo",
+				OutputPath: "{{.OSArch.OS}}",
+				OSArchs: []osarch.OSArch{
+					{OS: "linux", Arch: "amd64"},
+					{OS: "linux", Arch: "arm64"},
+				},
+			},
+		},
+		params.Project{
+			BuildOutputDir: "bin",
+		},
+	)
+	_, err := build.OutputPaths(buildSpec)
+	assert.EqualError(t, err, "output path of foo is /project/bin/linux/foo for both linux-amd64 and linux-arm64: the output path or artifact name must be different for every OS/Arch")
+}
+
 func TestBuildOnlySpecifiedOSArchs(t *testing.T) {
 	tmp, cleanup, err := dirs.TempDir("", "")
 	defer cleanup()
@@ -397,6 +1238,53 @@ func TestBuildOnlySpecifiedOSArchs(t *testing.T) {
 	}
 }
 
+func TestBuildInvalidOSArchs(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for i, currCase := range []struct {
+		build     params.Build
+		wantError string
+	}{
+		{
+			build: params.Build{
+				OSArchs: []osarch.OSArch{{OS: "linux", Arch: "amd46"}},
+			},
+			wantError: "invalid value in os-archs of foo: unknown OS/Arch linux-amd46: not supported by the installed Go toolchain (did you mean linux-amd64?)",
+		},
+		// patterns that do not match any target are not expanded by NewProductBuildSpec
+		{
+			build: params.Build{
+				OSArchs: []osarch.OSArch{{OS: "lnux", Arch: "*"}},
+			},
+			wantError: "invalid value in os-archs of foo: unknown OS/Arch lnux-*: not supported by the installed Go toolchain (did you mean linux-*?)",
+		},
+		{
+			build: params.Build{
+				OSArchOverrides: map[osarch.OSArch]params.OSArchBuild{
+					{OS: "windows", Arch: "amd46"}: {Tags: []string{"windows"}},
+				},
+			},
+			wantError: "invalid key in os-arch-overrides of foo: unknown OS/Arch windows-amd46: not supported by the installed Go toolchain (did you mean windows-amd64?)",
+		},
+	} {
+		currCase.build.MainPkg = "./foo"
+		buildSpec := params.NewProductBuildSpec(
+			tmp,
+			"foo",
+			git.ProjectInfo{},
+			params.Product{
+				Build: currCase.build,
+			},
+			params.Project{},
+		)
+
+		err = build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, ioutil.Discard)
+		assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+	}
+}
+
 func TestBuildErrorMessage(t *testing.T) {
 	tmp, cleanup, err := dirs.TempDir(".", "")
 	defer cleanup()
@@ -587,3 +1475,65 @@ func TestBuildAllParallel(t *testing.T) {
 		assert.NoError(t, err, "Case %d", i)
 	}
 }
+
+func TestBuildParallelCancelsOnFailure(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for file, content := range map[string]string{
+		"foo/main.go": `package main; asdfa`,
+		"bar/main.go": testMain,
+		"baz/main.go": testMain,
+	} {
+		err := os.MkdirAll(path.Join(tmp, path.Dir(file)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, file), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	var specs []params.ProductBuildSpec
+	for _, product := range []string{"foo", "bar", "baz"} {
+		specs = append(specs, params.NewProductBuildSpec(
+			tmp,
+			product,
+			git.ProjectInfo{},
+			params.Product{
+				Build: params.Build{
+					MainPkg: "./" + product,
+				},
+			},
+			params.Project{
+				BuildOutputDir: "bin",
+			},
+		))
+	}
+
+	// with a single worker, units are built in order, so the failure of "foo" cancels the remaining units
+	err = build.Run(specs, nil, build.Context{
+		Parallel: true,
+		Workers:  1,
+	}, ioutil.Discard)
+	require.Error(t, err)
+
+	buildErr, ok := err.(*build.ParallelBuildError)
+	require.True(t, ok, "unexpected error type %T: %v", err, err)
+
+	var got []string
+	for _, result := range buildErr.Results {
+		got = append(got, fmt.Sprintf("%s %s %s", result.Product, result.OSArch, result.Status))
+	}
+	assert.Equal(t, []string{
+		fmt.Sprintf("foo %s %s", osarch.Current(), build.UnitFailed),
+		fmt.Sprintf("bar %s %s", osarch.Current(), build.UnitCancelled),
+		fmt.Sprintf("baz %s %s", osarch.Current(), build.UnitCancelled),
+	}, got)
+	assert.Regexp(t, `^failed to build 1 of 3 \(product, OS/Arch\) units:\n  foo .+: failed: go build failed`, err.Error())
+}
+
+// executablePath returns the path to the executable of the provided spec for the current OS/Arch.
+func executablePath(t *testing.T, buildSpec params.ProductBuildSpec) string {
+	artifactPaths, err := build.ArtifactPaths(buildSpec)
+	require.NoError(t, err)
+	return artifactPaths[osarch.Current()]
+}
@@ -0,0 +1,306 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/termie/go-shutil"
+
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/imports"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+)
+
+const (
+	// cacheDirName is the name of the directory within the build output directory that stores cached executables.
+	cacheDirName = ".cache"
+	// cacheDigestFileName is the name of the file in a cache entry that contains the SHA-256 hash of the executable.
+	cacheDigestFileName = "sha256"
+	// cacheTmpSuffix is part of the names of the directories in which cache entries are written before they are
+	// renamed to their key.
+	cacheTmpSuffix = ".tmp"
+	// maxCacheEntries is the maximum number of entries kept in the cache for each product and OS/Arch. When an entry is
+	// stored, the least recently used entries beyond this number are removed.
+	maxCacheEntries = 5
+)
+
+// cacheRootDir returns the path to the directory that stores the build cache for the provided spec.
+func cacheRootDir(buildSpec params.ProductBuildSpec) string {
+	return path.Join(outputDir(buildSpec), cacheDirName)
+}
+
+// cacheDir returns the path to the directory that stores the build cache entries for the provided spec and OS/Arch.
+func cacheDir(buildSpec params.ProductBuildSpec, osArch osarch.OSArch) string {
+	return path.Join(cacheRootDir(buildSpec), buildSpec.ProductName, osArch.String())
+}
+
+// cacheKey returns the key that identifies the output of building the provided spec for the provided OS/Arch. The key
+// is the SHA-256 hash of the content of all of the source files required to build the main package of the spec for the
+// OS/Arch, the environment variables set for the build, the cgo environment variables of the current process, the
+// build arguments (which include the ldflags) and the version of the Go toolchain. Returns an error if any of these
+// values cannot be determined.
+func cacheKey(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, buildArgs []string) (string, error) {
+	goFiles, err := imports.ContextFiles(GoContext(buildSpec, osArch), path.Join(buildSpec.ProjectDir, buildSpec.Build.MainPkg))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine source files for %s", buildSpec.ProductName)
+	}
+	sourceHash, err := goFiles.Hash()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to hash source files for %s", buildSpec.ProductName)
+	}
+	version, err := goVersion()
+	if err != nil {
+		return "", err
+	}
+
+	env := buildEnv(buildSpec, osArch)
+	sort.Strings(env)
+
+	h := sha256.New()
+	for _, part := range [][]string{
+		{"product", buildSpec.ProductName},
+		{"main-pkg", buildSpec.Build.MainPkg},
+		{"os-arch", osArch.String()},
+		{"go-version", version},
+		{"source", sourceHash},
+		append([]string{"env"}, env...),
+		append([]string{"cgo-env"}, cgoEnv()...),
+		append([]string{"args"}, buildArgs...),
+	} {
+		if _, err := fmt.Fprintf(h, "%q\n", part); err != nil {
+			return "", errors.Wrapf(err, "failed to compute cache key")
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cgoEnv returns the environment variables of the current process (in "KEY=VALUE" form) that affect how cgo code is
+// built, sorted by key.
+func cgoEnv() []string {
+	var env []string
+	for _, currVar := range os.Environ() {
+		key := strings.SplitN(currVar, "=", 2)[0]
+		if key == "CC" || key == "CXX" || strings.HasPrefix(key, "CGO_") {
+			env = append(env, currVar)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
+
+var (
+	goVersionOnce   sync.Once
+	goVersionOutput string
+	goVersionErr    error
+)
+
+// goVersion returns the output of "go version" for the Go toolchain used to build products. The command is only run
+// once per process.
+func goVersion() (string, error) {
+	goVersionOnce.Do(func() {
+		output, err := exec.Command("go", "version").CombinedOutput()
+		if err != nil {
+			goVersionErr = errors.Wrapf(err, "failed to determine Go version: %s", string(output))
+			return
+		}
+		goVersionOutput = strings.TrimSpace(string(output))
+	})
+	return goVersionOutput, goVersionErr
+}
+
+// cacheEntryDir returns the path to the cache entry for the provided key.
+func cacheEntryDir(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, key string) string {
+	return path.Join(cacheDir(buildSpec, osArch), key)
+}
+
+// cachedDigest returns the SHA-256 hash of the executable stored in the cache for the provided key. Returns false if
+// no such entry exists in the cache.
+func cachedDigest(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, key string) (string, bool) {
+	digest, err := ioutil.ReadFile(path.Join(cacheEntryDir(buildSpec, osArch, key), cacheDigestFileName))
+	if err != nil {
+		return "", false
+	}
+	return string(digest), true
+}
+
+// cacheEntries returns the entries in the cache for the provided spec and OS/Arch. Entries that are still being written
+// are omitted.
+func cacheEntries(buildSpec params.ProductBuildSpec, osArch osarch.OSArch) []os.FileInfo {
+	fileInfos, err := ioutil.ReadDir(cacheDir(buildSpec, osArch))
+	if err != nil {
+		return nil
+	}
+	var entries []os.FileInfo
+	for _, currFileInfo := range fileInfos {
+		if currFileInfo.IsDir() && !strings.Contains(currFileInfo.Name(), cacheTmpSuffix) {
+			entries = append(entries, currFileInfo)
+		}
+	}
+	return entries
+}
+
+// touchCacheEntry sets the modification time of the entry for the provided key to the current time, which records
+// that it is the most recently used entry.
+func touchCacheEntry(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, key string) {
+	now := time.Now()
+	_ = os.Chtimes(cacheEntryDir(buildSpec, osArch, key), now, now)
+}
+
+// pruneCache removes the least recently used entries in the cache for the provided spec and OS/Arch so that at most
+// maxCacheEntries remain.
+func pruneCache(buildSpec params.ProductBuildSpec, osArch osarch.OSArch) error {
+	entries := cacheEntries(buildSpec, osArch)
+	if len(entries) <= maxCacheEntries {
+		return nil
+	}
+	sort.Sort(byModTimeDesc(entries))
+	for _, currEntry := range entries[maxCacheEntries:] {
+		entryDir := cacheEntryDir(buildSpec, osArch, currEntry.Name())
+		if err := os.RemoveAll(entryDir); err != nil {
+			return errors.Wrapf(err, "failed to remove build cache entry %s", entryDir)
+		}
+	}
+	return nil
+}
+
+type byModTimeDesc []os.FileInfo
+
+func (a byModTimeDesc) Len() int           { return len(a) }
+func (a byModTimeDesc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byModTimeDesc) Less(i, j int) bool { return a[i].ModTime().After(a[j].ModTime()) }
+
+// restoreFromCache copies the outputs stored in the cache for the provided key to outputPaths. The first path is the
+// path of the executable (or library) whose digest identifies the entry. Returns true if the outputs were restored and
+// false if the cache does not contain a complete entry for the key. If the outputs already exist and the first one
+// matches the cached digest, they are not copied again.
+func restoreFromCache(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, key string, outputPaths []string) (bool, error) {
+	digest, ok := cachedDigest(buildSpec, osArch, key)
+	if !ok {
+		return false, nil
+	}
+	touchCacheEntry(buildSpec, osArch, key)
+	if currDigest, err := FileDigest(outputPaths[0]); err == nil && currDigest == digest && allExist(outputPaths[1:]) {
+		return true, nil
+	}
+
+	var cachedPaths []string
+	for _, currPath := range outputPaths {
+		cachedPaths = append(cachedPaths, path.Join(cacheEntryDir(buildSpec, osArch, key), path.Base(currPath)))
+	}
+	if !allExist(cachedPaths) {
+		// entry is incomplete: treat as a cache miss
+		return false, nil
+	}
+	for i, currPath := range outputPaths {
+		if _, err := shutil.Copy(cachedPaths[i], currPath, false); err != nil {
+			return false, errors.Wrapf(err, "failed to restore %s from build cache", currPath)
+		}
+	}
+	return true, nil
+}
+
+// allExist returns true if a file exists at each of the provided paths.
+func allExist(paths []string) bool {
+	for _, currPath := range paths {
+		if _, err := os.Stat(currPath); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// storeInCache stores the outputs at outputPaths in the cache using the provided key and then prunes the cache. The
+// digest of the entry is the digest of the first output. The entry is written to a temporary directory and then renamed
+// so that concurrent builds never observe a partially written entry.
+func storeInCache(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, key string, outputPaths []string) error {
+	if _, ok := cachedDigest(buildSpec, osArch, key); ok {
+		touchCacheEntry(buildSpec, osArch, key)
+		return nil
+	}
+	if err := writeCacheEntry(buildSpec, osArch, key, outputPaths); err != nil {
+		return err
+	}
+	return pruneCache(buildSpec, osArch)
+}
+
+func writeCacheEntry(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, key string, outputPaths []string) (rErr error) {
+	dir := cacheDir(buildSpec, osArch)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create build cache directory %s", dir)
+	}
+	tmpDir, err := ioutil.TempDir(dir, key+cacheTmpSuffix)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary directory in %s", dir)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to remove directory %s", tmpDir)
+		}
+	}()
+
+	artifactPath := outputPaths[0]
+	digest, err := FileDigest(artifactPath)
+	if err != nil {
+		return err
+	}
+	for _, currPath := range outputPaths {
+		if _, err := shutil.Copy(currPath, path.Join(tmpDir, path.Base(currPath)), false); err != nil {
+			return errors.Wrapf(err, "failed to copy %s to build cache", currPath)
+		}
+	}
+	if err := ioutil.WriteFile(path.Join(tmpDir, cacheDigestFileName), []byte(digest), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write digest for %s to build cache", artifactPath)
+	}
+	if err := os.Rename(tmpDir, cacheEntryDir(buildSpec, osArch, key)); err != nil {
+		if _, ok := cachedDigest(buildSpec, osArch, key); ok {
+			// another build stored the same entry concurrently
+			return nil
+		}
+		return errors.Wrapf(err, "failed to store %s in build cache", artifactPath)
+	}
+	return nil
+}
+
+// FileDigest returns the hex-encoded SHA-256 hash of the file at the provided path.
+func FileDigest(filePath string) (rDigest string, rErr error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer func() {
+		if err := f.Close(); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to close %s", filePath)
+		}
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "failed to compute digest of %s", filePath)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
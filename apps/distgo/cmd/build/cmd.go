@@ -25,9 +25,13 @@ import (
 )
 
 const (
-	parallelFlagName = "parallel"
-	installFlagName  = "install"
-	pkgDirFlagName   = "pkgdir"
+	parallelFlagName           = "parallel"
+	installFlagName            = "install"
+	pkgDirFlagName             = "pkgdir"
+	verifyFlagName             = "verify-reproducible"
+	sizeReportFlagName         = "size-report"
+	sizeReportPackagesFlagName = "size-report-packages"
+	watchFlagName              = "watch"
 )
 
 var (
@@ -36,6 +40,10 @@ var (
 		Usage: "Build binaries in parallel",
 		Value: true,
 	}
+	workersFlag = flag.StringFlag{
+		Name:  cmd.WorkersFlagName,
+		Usage: "Number of units to build concurrently when building in parallel (defaults to the number of logical processors)",
+	}
 	installFlag = flag.BoolFlag{
 		Name:  installFlagName,
 		Usage: "Run 'install' before 'build'",
@@ -45,6 +53,22 @@ var (
 		Name:  pkgDirFlagName,
 		Usage: "Use a custom 'pkg' directory for 'install' action (only takes effect if 'install' is true)",
 	}
+	verifyFlag = flag.BoolFlag{
+		Name:  verifyFlagName,
+		Usage: "Build each binary twice in reproducible mode in temporary directories and fail if the outputs differ",
+	}
+	sizeReportFlag = flag.BoolFlag{
+		Name:  sizeReportFlagName,
+		Usage: "Print the size of each binary and its change from the previous build",
+	}
+	sizeReportPackagesFlag = flag.BoolFlag{
+		Name:  sizeReportPackagesFlagName,
+		Usage: "Print the size report with a breakdown of the largest packages in each binary",
+	}
+	watchFlag = flag.BoolFlag{
+		Name:  watchFlagName,
+		Usage: "Build products and then rebuild them whenever their Go source files change",
+	}
 )
 
 func DefaultContext() Context {
@@ -62,15 +86,28 @@ func Command() cli.Command {
 		Flags: []flag.Flag{
 			cmd.ProductsParam,
 			parallelFlag,
+			workersFlag,
 			installFlag,
 			pkgDirFlag,
+			verifyFlag,
+			sizeReportFlag,
+			sizeReportPackagesFlag,
+			watchFlag,
 			cmd.OSArchFlag,
 		},
 		Action: func(ctx cli.Context) error {
+			workers, err := cmd.ParseWorkers(ctx.String(cmd.WorkersFlagName))
+			if err != nil {
+				return err
+			}
 			buildCtx := Context{
-				Parallel: ctx.Bool(parallelFlagName),
-				Install:  ctx.Bool(installFlagName),
-				Pkgdir:   ctx.Bool(pkgDirFlagName),
+				Parallel:           ctx.Bool(parallelFlagName),
+				Workers:            workers,
+				Install:            ctx.Bool(installFlagName),
+				Pkgdir:             ctx.Bool(pkgDirFlagName),
+				VerifyReproducible: ctx.Bool(verifyFlagName),
+				SizeReport:         ctx.Bool(sizeReportFlagName),
+				SizeReportPackages: ctx.Bool(sizeReportPackagesFlagName),
 			}
 
 			cfg, err := config.Load(cfgcli.ConfigPath, cfgcli.ConfigJSON)
@@ -87,6 +124,11 @@ func Command() cli.Command {
 			if err != nil {
 				return err
 			}
+			if ctx.Bool(watchFlagName) {
+				watchCtx, cancel := cmd.InterruptContext()
+				defer cancel()
+				return WatchProducts(watchCtx, ctx.Slice(cmd.ProductsParamName), osArchs, buildCtx, cfg, wd, ctx.App.Stdout)
+			}
 			return Products(ctx.Slice(cmd.ProductsParamName), osArchs, buildCtx, cfg, wd, ctx.App.Stdout)
 		},
 	}
@@ -14,6 +14,10 @@
 
 package build
 
+import (
+	"github.com/palantir/godel/apps/distgo/params"
+)
+
 func ExecutableName(productName, goos string) string {
 	executableName := productName
 	if goos == "windows" {
@@ -21,3 +25,27 @@ func ExecutableName(productName, goos string) string {
 	}
 	return executableName
 }
+
+// OutputNames returns the names of the files that are created by building the product with the provided name using the
+// provided build mode (see params.Build.BuildMode) for the provided GOOS. The first name is the name of the primary
+// output, which is the executable, library or plugin. Builds that use the "c-shared" or "c-archive" build modes also
+// create a C header file whose name is the second name.
+func OutputNames(productName, buildMode, goos string) []string {
+	switch buildMode {
+	case params.BuildModeCShared:
+		ext := ".so"
+		switch goos {
+		case "darwin":
+			ext = ".dylib"
+		case "windows":
+			ext = ".dll"
+		}
+		return []string{productName + ext, productName + ".h"}
+	case params.BuildModeCArchive:
+		return []string{productName + ".a", productName + ".h"}
+	case params.BuildModePlugin:
+		return []string{productName + ".so"}
+	default:
+		return []string{ExecutableName(productName, goos)}
+	}
+}
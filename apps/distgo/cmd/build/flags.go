@@ -0,0 +1,161 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+	"github.com/palantir/godel/apps/distgo/templating"
+)
+
+const (
+	ldflagsFlag  = "ldflags"
+	gcflagsFlag  = "gcflags"
+	asmflagsFlag = "asmflags"
+	tagsFlag     = "tags"
+)
+
+// extractBuildFlags removes the "-ldflags", "-gcflags", "-asmflags" and "-tags" flags and their values from the
+// provided arguments. Returns the remaining arguments and a map from the flag name to the values that were specified
+// for it. The "build" command only honors the last occurrence of each of these flags, so values from all sources must
+// be combined into a single argument.
+func extractBuildFlags(args []string) ([]string, map[string][]string) {
+	var remaining []string
+	values := make(map[string][]string)
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		if !strings.HasPrefix(args[i], "-") {
+			remaining = append(remaining, args[i])
+			continue
+		}
+		if parts := strings.SplitN(name, "=", 2); len(parts) == 2 && isCombinedFlag(parts[0]) {
+			values[parts[0]] = append(values[parts[0]], parts[1])
+			continue
+		}
+		if isCombinedFlag(name) && i+1 < len(args) {
+			values[name] = append(values[name], args[i+1])
+			i++
+			continue
+		}
+		remaining = append(remaining, args[i])
+	}
+	return remaining, values
+}
+
+func isCombinedFlag(name string) bool {
+	return name == ldflagsFlag || name == gcflagsFlag || name == asmflagsFlag || name == tagsFlag
+}
+
+// combinedFlagArgs returns the "-ldflags", "-gcflags", "-asmflags" and "-tags" arguments for the provided spec. Each
+// flag is provided at most once and combines the values required by reproducible mode, the values from the
+// configuration of the spec for the provided OS/Arch and the provided values that were specified as arguments. If the
+// spec is built in reproducible mode, any other arguments required by that mode precede the combined flags. The ldflags
+// are ordered such that the "-X" flags for LdflagsVars and VersionVar are last, so they take precedence over values set
+// for the same variables by other sources.
+func combinedFlagArgs(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, argValues map[string][]string) ([]string, error) {
+	args, reproducibleValues, err := reproducibleFlagValues(buildSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	osArchBuild := buildSpec.Build.ForOSArch(osArch)
+	ldflags := append(append(append([]string{}, reproducibleValues[ldflagsFlag]...), osArchBuild.Ldflags...), argValues[ldflagsFlag]...)
+	varFlags, err := ldflagsVarFlags(buildSpec)
+	if err != nil {
+		return nil, err
+	}
+	ldflags = append(ldflags, varFlags...)
+	if buildSpec.Build.VersionVar != "" {
+		ldflags = append(ldflags, fmt.Sprintf("-X %v=%v", buildSpec.Build.VersionVar, buildSpec.ProductVersion))
+	}
+
+	gcflags := append(append(append([]string{}, reproducibleValues[gcflagsFlag]...), buildSpec.Build.Gcflags...), argValues[gcflagsFlag]...)
+	asmflags := append(append([]string{}, reproducibleValues[asmflagsFlag]...), argValues[asmflagsFlag]...)
+
+	var tags []string
+	for _, currTags := range append(append([]string{}, osArchBuild.Tags...), argValues[tagsFlag]...) {
+		tags = append(tags, strings.FieldsFunc(currTags, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\'' || r == '"'
+		})...)
+	}
+
+	for _, currFlag := range []struct {
+		name   string
+		values []string
+	}{
+		{name: ldflagsFlag, values: ldflags},
+		{name: gcflagsFlag, values: gcflags},
+		{name: asmflagsFlag, values: asmflags},
+		{name: tagsFlag, values: tags},
+	} {
+		if len(currFlag.values) > 0 {
+			args = append(args, "-"+currFlag.name, strings.Join(currFlag.values, " "))
+		}
+	}
+	return args, nil
+}
+
+// ldflagsVarFlags renders the LdflagsVars templates of the provided spec and returns the "-X" flags that set them. The
+// flags are sorted by variable name.
+func ldflagsVarFlags(buildSpec params.ProductBuildSpec) ([]string, error) {
+	vars := make([]string, 0, len(buildSpec.Build.LdflagsVars))
+	for k := range buildSpec.Build.LdflagsVars {
+		vars = append(vars, k)
+	}
+	sort.Strings(vars)
+
+	templateCfg := templating.ConvertBuildSpec(buildSpec)
+	var flags []string
+	for _, currVar := range vars {
+		t, err := template.New(currVar).Parse(buildSpec.Build.LdflagsVars[currVar])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse template for ldflags variable %s of %s", currVar, buildSpec.ProductName)
+		}
+		buf := bytes.Buffer{}
+		if err := t.Execute(&buf, templateCfg); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template for ldflags variable %s of %s", currVar, buildSpec.ProductName)
+		}
+		value, err := quoteLdflagsValue(currVar + "=" + buf.String())
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for ldflags variable %s of %s", currVar, buildSpec.ProductName)
+		}
+		flags = append(flags, "-X "+value)
+	}
+	return flags, nil
+}
+
+// quoteLdflagsValue quotes the provided value so that it is treated as a single field by the "build" command if it
+// contains whitespace or quotes. The build command does not support escapes within quoted fields, so values that
+// contain both single and double quotes cannot be represented.
+func quoteLdflagsValue(value string) (string, error) {
+	switch {
+	case !strings.ContainsAny(value, " \t\n\r'\""):
+		return value, nil
+	case !strings.Contains(value, "'"):
+		return "'" + value + "'", nil
+	case !strings.Contains(value, `"`):
+		return `"` + value + `"`, nil
+	default:
+		return "", errors.Errorf("value %q contains both single and double quotes", value)
+	}
+}
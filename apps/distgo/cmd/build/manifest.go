@@ -0,0 +1,101 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/git"
+	"github.com/palantir/godel/apps/distgo/pkg/manifest"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+)
+
+// ManifestPath returns the path to the build manifest for the current version of the product of the provided spec. The
+// manifest is written to a directory named after the product in the version directory of the build output directory.
+func ManifestPath(buildSpec params.ProductBuildSpec) string {
+	return path.Join(outputDir(buildSpec), buildSpec.VersionInfo.Version, buildSpec.ProductName, manifest.FileName)
+}
+
+// GoVersion returns the version of the Go toolchain used to build products (for example, "go1.7.4").
+func GoVersion() (string, error) {
+	output, err := goVersion()
+	if err != nil {
+		return "", err
+	}
+	// output is of the form "go version go1.7.4 linux/amd64"
+	if fields := strings.Fields(output); len(fields) >= 3 {
+		return fields[2], nil
+	}
+	return output, nil
+}
+
+// NewManifestArtifact returns a manifest entry for the file at the provided path that was produced for the provided
+// spec. The path of the entry is relative to the project directory and the Go version and git revision of the entry are
+// those of the current toolchain and project. The git revision is omitted if the project is not in a git repository.
+func NewManifestArtifact(buildSpec params.ProductBuildSpec, artifactType, artifactPath string, osArchs []osarch.OSArch) (manifest.Artifact, error) {
+	relPath, err := filepath.Rel(buildSpec.ProjectDir, artifactPath)
+	if err != nil {
+		return manifest.Artifact{}, errors.Wrapf(err, "failed to determine path of %s relative to %s", artifactPath, buildSpec.ProjectDir)
+	}
+	goVersion, err := GoVersion()
+	if err != nil {
+		return manifest.Artifact{}, err
+	}
+	artifact := manifest.Artifact{
+		Path:      relPath,
+		Type:      artifactType,
+		OSArchs:   make([]string, len(osArchs)),
+		GoVersion: goVersion,
+	}
+	for i, osArch := range osArchs {
+		artifact.OSArchs[i] = osArch.String()
+	}
+	if revision, err := git.ProjectCommit(buildSpec.ProjectDir); err == nil {
+		artifact.GitRevision = revision
+	}
+	if err := artifact.SetFileInfo(artifactPath); err != nil {
+		return manifest.Artifact{}, err
+	}
+	return artifact, nil
+}
+
+// recordInManifest adds the outputs at the provided paths that were built for the provided spec and OS/Arch using the
+// provided build arguments to the build manifest of the spec.
+func recordInManifest(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, outputPaths []string, buildArgs []string) error {
+	var ldflags string
+	if _, flagValues := extractBuildFlags(buildArgs); len(flagValues[ldflagsFlag]) > 0 {
+		ldflags = flagValues[ldflagsFlag][len(flagValues[ldflagsFlag])-1]
+	}
+	var artifacts []manifest.Artifact
+	for _, currPath := range outputPaths {
+		artifact, err := NewManifestArtifact(buildSpec, manifest.BuildArtifactType, currPath, []osarch.OSArch{osArch})
+		if err != nil {
+			return errors.Wrapf(err, "failed to create manifest entry for %s", currPath)
+		}
+		artifact.Ldflags = ldflags
+		artifacts = append(artifacts, artifact)
+	}
+	return UpdateManifest(buildSpec, artifacts...)
+}
+
+// UpdateManifest adds the provided artifacts to the build manifest of the provided spec.
+func UpdateManifest(buildSpec params.ProductBuildSpec, artifacts ...manifest.Artifact) error {
+	return manifest.Update(ManifestPath(buildSpec), buildSpec.ProductName, buildSpec.ProductVersion, artifacts...)
+}
@@ -0,0 +1,194 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/templating"
+)
+
+// trimpathMinorVersion is the minor version of the first Go release whose "build" command supports the "-trimpath"
+// flag.
+const trimpathMinorVersion = 13
+
+// reproducibleFlagValues returns the arguments and the values for the combined flags that are added to the build of
+// the provided spec in reproducible mode. The build ID is omitted from the executable and file system paths are trimmed
+// from it using the "-trimpath" flag if the Go toolchain supports it or the "-trimpath" flags of the compiler and
+// assembler otherwise. Returns nothing if the spec is not built in reproducible mode.
+func reproducibleFlagValues(buildSpec params.ProductBuildSpec) ([]string, map[string][]string, error) {
+	if !buildSpec.Build.Reproducible {
+		return nil, nil, nil
+	}
+	// fail rather than silently embedding the current time if SOURCE_DATE_EPOCH is malformed
+	if _, _, err := templating.SourceDateEpoch(); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to determine build time for %s", buildSpec.ProductName)
+	}
+
+	values := map[string][]string{
+		ldflagsFlag: {"-buildid="},
+	}
+	if supportsTrimpath() {
+		return []string{"-trimpath"}, values, nil
+	}
+	trimpath := "-trimpath=" + projectGoPath(buildSpec.ProjectDir)
+	values[gcflagsFlag] = []string{trimpath}
+	values[asmflagsFlag] = []string{trimpath}
+	return nil, values, nil
+}
+
+// supportsTrimpath returns true if the "build" command of the Go toolchain supports the "-trimpath" flag. Development
+// versions of the toolchain are assumed to support it.
+func supportsTrimpath() bool {
+	version, err := goVersion()
+	if err != nil {
+		return false
+	}
+	match := regexp.MustCompile(`\bgo1\.([0-9]+)`).FindStringSubmatch(version)
+	if match == nil {
+		return strings.Contains(version, "devel")
+	}
+	minor, err := strconv.Atoi(match[1])
+	return err == nil && minor >= trimpathMinorVersion
+}
+
+// projectGoPath returns the entry of the GOPATH that contains the provided project directory. If no entry contains the
+// directory, the first entry is returned.
+func projectGoPath(projectDir string) string {
+	goPaths := filepath.SplitList(build.Default.GOPATH)
+	for _, currGoPath := range goPaths {
+		if rel, err := filepath.Rel(currGoPath, projectDir); err == nil && !strings.HasPrefix(rel, "..") {
+			return currGoPath
+		}
+	}
+	if len(goPaths) == 0 {
+		return ""
+	}
+	return goPaths[0]
+}
+
+// verifyReproducible builds each of the provided units twice in separate temporary output directories and verifies
+// that both builds produce identical executables. The units are built in reproducible mode even if their products do
+// not enable it because builds that embed file system paths and the current time are not expected to be identical. The
+// build cache is not used because each build writes to a new output directory. Returns an error that describes the
+// difference for every unit whose builds differ.
+func verifyReproducible(runCtx context.Context, units []buildUnit, ctx Context, stdout io.Writer) error {
+	var failures []string
+	for _, currUnit := range units {
+		diff, err := verifyUnitReproducible(runCtx, currUnit, ctx, stdout)
+		if err != nil {
+			return err
+		}
+		if diff != "" {
+			failures = append(failures, fmt.Sprintf("  %s %s: %s", currUnit.buildSpec.ProductName, currUnit.osArch.String(), diff))
+			continue
+		}
+		fmt.Fprintf(stdout, "Verified that build of %s for %s is reproducible\n", currUnit.buildSpec.ProductName, currUnit.osArch.String())
+	}
+	if len(failures) > 0 {
+		return errors.Errorf("%d of %d (product, OS/Arch) units are not reproducible:\n%s", len(failures), len(units), strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// verifyUnitReproducible builds the provided unit twice and returns a description of the difference between the
+// outputs of the builds. Returns an empty string if the outputs are identical.
+func verifyUnitReproducible(runCtx context.Context, unit buildUnit, ctx Context, stdout io.Writer) (rDiff string, rErr error) {
+	tmpDir, err := ioutil.TempDir("", "distgo-reproducible-")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create temporary directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to remove directory %s", tmpDir)
+		}
+	}()
+
+	var artifactPaths, digests []string
+	for _, currDir := range []string{"first", "second"} {
+		currSpec := unit.buildSpec
+		currSpec.Build.OutputDir = path.Join(tmpDir, currDir)
+		currSpec.Build.Reproducible = true
+		if err := executeBuild(runCtx, stdout, currSpec, ctx, unit.osArch); err != nil {
+			return "", err
+		}
+		currArtifactPaths, err := ArtifactPaths(currSpec)
+		if err != nil {
+			return "", err
+		}
+		artifactPath := currArtifactPaths[unit.osArch]
+		digest, err := FileDigest(artifactPath)
+		if err != nil {
+			return "", err
+		}
+		artifactPaths = append(artifactPaths, artifactPath)
+		digests = append(digests, digest)
+	}
+	if digests[0] == digests[1] {
+		return "", nil
+	}
+
+	offset, sizes, err := firstDifference(artifactPaths[0], artifactPaths[1])
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256 %s != %s (sizes %d and %d bytes, first difference at byte %d)", digests[0], digests[1], sizes[0], sizes[1], offset), nil
+}
+
+// firstDifference returns the offset of the first byte that differs between the provided files and the sizes of the
+// files. If one file is a prefix of the other, the offset is the size of the shorter file.
+func firstDifference(pathA, pathB string) (int64, [2]int64, error) {
+	var sizes [2]int64
+	var readers [2]*bufio.Reader
+	for i, currPath := range []string{pathA, pathB} {
+		f, err := os.Open(currPath)
+		if err != nil {
+			return 0, sizes, errors.Wrapf(err, "failed to open %s", currPath)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		fi, err := f.Stat()
+		if err != nil {
+			return 0, sizes, errors.Wrapf(err, "failed to stat %s", currPath)
+		}
+		sizes[i] = fi.Size()
+		readers[i] = bufio.NewReader(f)
+	}
+
+	var offset int64
+	for {
+		a, errA := readers[0].ReadByte()
+		b, errB := readers[1].ReadByte()
+		if errA != nil || errB != nil || a != b {
+			return offset, sizes, nil
+		}
+		offset++
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+)
+
+const (
+	// sizesFileName is the name of the file in the build cache directory that records the size of the most recent
+	// executable built for each (product, OS/Arch) pair.
+	sizesFileName = "sizes.json"
+	// sizeReportPackages is the number of packages listed in the per-package breakdown of the size report.
+	sizeReportPackages = 10
+)
+
+// checkSize verifies that the executable at the provided path does not exceed the MaxSize of the provided spec, records
+// its size and, if ctx.SizeReport is true, writes a report of its size and of the change in size from the previous
+// executable built for the product and OS/Arch to stdout.
+func checkSize(stdout io.Writer, buildSpec params.ProductBuildSpec, ctx Context, osArch osarch.OSArch, artifactPath string) error {
+	fi, err := os.Stat(artifactPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine size of %s", artifactPath)
+	}
+	size := fi.Size()
+
+	previous, hasPrevious, err := recordSize(buildSpec, osArch, size)
+	if err != nil {
+		return err
+	}
+
+	if ctx.SizeReport || ctx.SizeReportPackages {
+		change := "no previous build"
+		if hasPrevious {
+			change = formatSizeChange(previous, size)
+		}
+		lines := []string{fmt.Sprintf("Size of %s for %s: %s (%s)", buildSpec.ProductName, osArch.String(), formatSize(size), change)}
+		if ctx.SizeReportPackages {
+			pkgSizes, err := packageSizes(artifactPath)
+			if err != nil {
+				return err
+			}
+			for _, currPkg := range pkgSizes {
+				lines = append(lines, fmt.Sprintf("  %s: %s", currPkg.pkg, formatSize(currPkg.size)))
+			}
+		}
+		fmt.Fprintln(stdout, strings.Join(lines, "\n"))
+	}
+
+	if buildSpec.Build.MaxSize > 0 && size > buildSpec.Build.MaxSize {
+		return errors.Errorf("size of %s for %s is %s, which exceeds its max-size of %s", buildSpec.ProductName, osArch.String(), formatSize(size), formatSize(buildSpec.Build.MaxSize))
+	}
+	return nil
+}
+
+// sizesLock serializes updates to the recorded sizes so that concurrent builds within the process do not lose entries.
+var sizesLock sync.Mutex
+
+// recordSize records the provided size for the provided spec and OS/Arch and returns the size that was previously
+// recorded. Returns false if no size was previously recorded.
+func recordSize(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, size int64) (int64, bool, error) {
+	sizesLock.Lock()
+	defer sizesLock.Unlock()
+
+	sizesPath := path.Join(cacheRootDir(buildSpec), sizesFileName)
+	sizes := make(map[string]int64)
+	if content, err := ioutil.ReadFile(sizesPath); err == nil {
+		if err := json.Unmarshal(content, &sizes); err != nil {
+			// an unreadable record is replaced
+			sizes = make(map[string]int64)
+		}
+	}
+
+	key := buildSpec.ProductName + " " + osArch.String()
+	previous, hasPrevious := sizes[key]
+	sizes[key] = size
+
+	content, err := json.MarshalIndent(sizes, "", "  ")
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "failed to marshal sizes")
+	}
+	if err := os.MkdirAll(path.Dir(sizesPath), 0755); err != nil {
+		return 0, false, errors.Wrapf(err, "failed to create directories for %s", sizesPath)
+	}
+	if err := ioutil.WriteFile(sizesPath, content, 0644); err != nil {
+		return 0, false, errors.Wrapf(err, "failed to write %s", sizesPath)
+	}
+	return previous, hasPrevious, nil
+}
+
+type packageSize struct {
+	pkg  string
+	size int64
+}
+
+// packageSizes returns the total size of the symbols of each package in the executable at the provided path as reported
+// by "go tool nm". The result is sorted by size in descending order and contains at most sizeReportPackages entries.
+func packageSizes(artifactPath string) ([]packageSize, error) {
+	cmd := exec.Command("go", "tool", "nm", "-size", artifactPath)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read symbol table of %s: %s", artifactPath, strings.TrimSpace(stderr.String()))
+	}
+
+	sizes := make(map[string]int64)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		// lines are of the form "address size type name", where the address is blank for undefined symbols
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes[symbolPackage(strings.Join(fields[3:], " "))] += size
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read symbol table of %s", artifactPath)
+	}
+
+	var pkgSizes []packageSize
+	for pkg, size := range sizes {
+		pkgSizes = append(pkgSizes, packageSize{pkg: pkg, size: size})
+	}
+	sort.Sort(bySizeDesc(pkgSizes))
+	if len(pkgSizes) > sizeReportPackages {
+		pkgSizes = pkgSizes[:sizeReportPackages]
+	}
+	return pkgSizes, nil
+}
+
+// symbolPackage returns the import path of the package that defines the symbol with the provided name. Symbols that
+// are not defined by a package (such as type descriptors and linker-generated symbols) are attributed to "<other>".
+func symbolPackage(name string) string {
+	// type arguments and receivers may contain other import paths
+	base := name
+	if i := strings.IndexAny(base, "[("); i >= 0 {
+		base = base[:i]
+	}
+	lastSlash := strings.LastIndex(base, "/")
+	dot := strings.Index(base[lastSlash+1:], ".")
+	if dot <= 0 || strings.ContainsAny(base[:lastSlash+1+dot], ":* ") {
+		return "<other>"
+	}
+	return base[:lastSlash+1+dot]
+}
+
+type bySizeDesc []packageSize
+
+func (a bySizeDesc) Len() int      { return len(a) }
+func (a bySizeDesc) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a bySizeDesc) Less(i, j int) bool {
+	if a[i].size != a[j].size {
+		return a[i].size > a[j].size
+	}
+	return a[i].pkg < a[j].pkg
+}
+
+// formatSize returns a human-readable representation of the provided number of bytes.
+func formatSize(size int64) string {
+	abs := size
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= 1<<20:
+		return fmt.Sprintf("%.2f MiB", float64(size)/(1<<20))
+	case abs >= 1<<10:
+		return fmt.Sprintf("%.2f KiB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// formatSizeChange returns a human-readable representation of the change from the previous size to the current size.
+func formatSizeChange(previous, current int64) string {
+	delta := current - previous
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+		delta = -delta
+	}
+	if previous == 0 {
+		return fmt.Sprintf("%s%s", sign, formatSize(delta))
+	}
+	return fmt.Sprintf("%s%s, %s%.2f%%", sign, formatSize(delta), sign, float64(delta)*100/float64(previous))
+}
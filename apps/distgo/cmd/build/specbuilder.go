@@ -17,7 +17,6 @@ package build
 import (
 	"fmt"
 	"io"
-	"os"
 	"path"
 	"sort"
 
@@ -28,37 +27,66 @@ import (
 	"github.com/palantir/godel/apps/distgo/cmd"
 	"github.com/palantir/godel/apps/distgo/params"
 	"github.com/palantir/godel/apps/distgo/pkg/git"
-	"github.com/palantir/godel/apps/distgo/pkg/imports"
 	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+	"github.com/palantir/godel/pkg/gomod"
 )
 
 // RequiresBuild returns a slice that contains the ProductBuildSpecs that have not been built for the provided
 // ProductBuildSpecWithDeps matching the provided osArchs filter. A product is considered to require building if its
-// output executable does not exist or if the output executable's modification date is older than any of the Go files
-// required to build the product.
+// output executable (or any other output of its build mode) does not exist or if the output executable does not match
+// the executable stored in the build cache for the current content of the source files, environment, build arguments and
+// Go version of the product. If the cache does not contain an entry for the current inputs, the product is considered to
+// require building.
 func RequiresBuild(specWithDeps params.ProductBuildSpecWithDeps, osArchs cmd.OSArchFilter) RequiresBuildInfo {
 	info := newRequiresBuildInfo(specWithDeps, osArchs)
 	for _, currSpec := range specWithDeps.AllSpecs() {
-		paths := ArtifactPaths(currSpec)
+		// if the output paths cannot be determined, consider spec to require build (building it will report the error)
+		paths, pathsErr := OutputPaths(currSpec)
+		var scriptArgs []string
+		var scriptArgsErr error
+		scriptArgsComputed := false
 		for _, currOSArch := range currSpec.Build.OSArchs {
-			if osArchs.Matches(currOSArch) {
-				if fi, err := os.Stat(paths[currOSArch]); err == nil {
-					if goFiles, err := imports.AllFiles(path.Join(currSpec.ProjectDir, currSpec.Build.MainPkg)); err == nil {
-						if newerThan, err := goFiles.NewerThan(fi); err == nil && !newerThan {
-							// if the build artifact for the product already exists and none of the source files for the
-							// product are newer than the build artifact, consider spec up-to-date
-							continue
-						}
-					}
+			if !osArchs.Matches(currOSArch) {
+				continue
+			}
+			if pathsErr == nil && allExist(paths[currOSArch][1:]) {
+				if !scriptArgsComputed {
+					scriptArgs, scriptArgsErr = buildArgsScriptOutput(currSpec)
+					scriptArgsComputed = true
+				}
+				if upToDate(currSpec, currOSArch, paths[currOSArch][0], scriptArgs, scriptArgsErr) {
+					continue
 				}
-				// spec/osArch combination requires build
-				info.addInfo(currSpec, currOSArch)
 			}
+			// spec/osArch combination requires build
+			info.addInfo(currSpec, currOSArch)
 		}
 	}
 	return info
 }
 
+// upToDate returns true if the executable at the provided path is the up-to-date output of building the provided spec
+// for the provided OS/Arch as described by RequiresBuild.
+func upToDate(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, artifactPath string, scriptArgs []string, scriptArgsErr error) bool {
+	if scriptArgsErr != nil {
+		return false
+	}
+	buildArgs, err := goBuildArgs(buildSpec, osArch, scriptArgs)
+	if err != nil {
+		return false
+	}
+	key, err := cacheKey(buildSpec, osArch, buildArgs)
+	if err != nil {
+		return false
+	}
+	digest, ok := cachedDigest(buildSpec, osArch, key)
+	if !ok {
+		return false
+	}
+	currDigest, err := FileDigest(artifactPath)
+	return err == nil && currDigest == digest
+}
+
 type RequiresBuildInfo interface {
 	Specs() []params.ProductBuildSpec
 	RequiresBuild(product string, osArch osarch.OSArch) bool
@@ -232,6 +260,14 @@ func addMainPkgsToConfig(cfg params.Project, projectDir string) error {
 
 func mainPkgPaths(projectDir string) ([]string, error) {
 	// TODO: this should use Exclude specified in config to determine directories to examine
+	if gomod.Enabled(projectDir) {
+		mainPkgPaths, err := gomod.MainPackages(projectDir, matcher.Name("vendor"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list packages in project %v", projectDir)
+		}
+		return mainPkgPaths, nil
+	}
+
 	pkgs, err := pkgpath.PackagesInDir(projectDir, matcher.Name("vendor"))
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to list packages in project %v", projectDir)
@@ -0,0 +1,190 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/palantir/godel/apps/distgo/cmd"
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/imports"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+)
+
+// DefaultWatchInterval is the interval at which the source files of watched products are checked for changes.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// Watcher detects changes to the files that are required to build the main packages of a set of specs. The files are
+// tracked separately for each (product, OS/Arch) unit: the files for a unit are the files returned by
+// imports.ContextFiles for the main package of the spec using the context returned by GoContext for the OS/Arch, so
+// adding or removing an import or a file that is only built for some OS/Archs only affects the units that build it.
+// Files are polled rather than observed using OS notifications.
+type Watcher struct {
+	specs     []params.ProductBuildSpec
+	osArchs   cmd.OSArchFilter
+	interval  time.Duration
+	snapshots map[watchUnit]sourceSnapshot
+}
+
+// watchUnit identifies the (product, OS/Arch) unit of a snapshot.
+type watchUnit struct {
+	product string
+	osArch  osarch.OSArch
+}
+
+// sourceSnapshot maps the path of each source file of a unit to its state. A nil snapshot indicates that the source
+// files could not be determined (for example, because an import is invalid).
+type sourceSnapshot map[string]fileState
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher returns a Watcher for the units of the provided specs whose OS/Archs match osArchs that polls at the
+// provided interval (DefaultWatchInterval if the interval is not positive). The current state of the source files is
+// recorded when the Watcher is created.
+func NewWatcher(buildSpecs []params.ProductBuildSpec, osArchs cmd.OSArchFilter, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w := &Watcher{
+		specs:     distinct(buildSpecs),
+		osArchs:   osArchs,
+		interval:  interval,
+		snapshots: make(map[watchUnit]sourceSnapshot),
+	}
+	for _, currSpec := range w.specs {
+		for _, currOSArch := range w.unitOSArchs(currSpec) {
+			w.snapshots[watchUnit{product: currSpec.ProductName, osArch: currOSArch}] = snapshot(currSpec, currOSArch)
+		}
+	}
+	return w
+}
+
+// Wait blocks until the source files of at least one of the watched units change and returns the specs of the units
+// whose source files changed. The OSArchs of each returned spec are only the OS/Archs of the units of the spec whose
+// source files changed. Returns the error of the provided context if it is done before a change occurs.
+func (w *Watcher) Wait(runCtx context.Context) ([]params.ProductBuildSpec, error) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-runCtx.Done():
+			return nil, runCtx.Err()
+		case <-ticker.C:
+		}
+
+		var changed []params.ProductBuildSpec
+		for _, currSpec := range w.specs {
+			var changedOSArchs []osarch.OSArch
+			for _, currOSArch := range w.unitOSArchs(currSpec) {
+				unit := watchUnit{product: currSpec.ProductName, osArch: currOSArch}
+				current := snapshot(currSpec, currOSArch)
+				if !current.equal(w.snapshots[unit]) {
+					w.snapshots[unit] = current
+					changedOSArchs = append(changedOSArchs, currOSArch)
+				}
+			}
+			if len(changedOSArchs) > 0 {
+				changedSpec := currSpec
+				changedSpec.Build.OSArchs = changedOSArchs
+				changed = append(changed, changedSpec)
+			}
+		}
+		if len(changed) > 0 {
+			return changed, nil
+		}
+	}
+}
+
+// unitOSArchs returns the OS/Archs of the watched units of the provided spec.
+func (w *Watcher) unitOSArchs(buildSpec params.ProductBuildSpec) []osarch.OSArch {
+	var osArchs []osarch.OSArch
+	for _, currOSArch := range buildSpec.Build.OSArchs {
+		if w.osArchs.Matches(currOSArch) {
+			osArchs = append(osArchs, currOSArch)
+		}
+	}
+	return osArchs
+}
+
+// snapshot returns the current state of the source files of the provided spec for the provided OS/Arch.
+func snapshot(buildSpec params.ProductBuildSpec, osArch osarch.OSArch) sourceSnapshot {
+	goFiles, err := imports.ContextFiles(GoContext(buildSpec, osArch), path.Join(buildSpec.ProjectDir, buildSpec.Build.MainPkg))
+	if err != nil {
+		return nil
+	}
+	s := make(sourceSnapshot)
+	for pkg, files := range goFiles {
+		for _, currFile := range files {
+			currPath := path.Join(pkg, currFile)
+			// files that cannot be stat'd are recorded with a zero state so that their reappearance is a change
+			var state fileState
+			if fi, err := os.Stat(currPath); err == nil {
+				state = fileState{modTime: fi.ModTime(), size: fi.Size()}
+			}
+			s[currPath] = state
+		}
+	}
+	return s
+}
+
+func (s sourceSnapshot) equal(other sourceSnapshot) bool {
+	if (s == nil) != (other == nil) || len(s) != len(other) {
+		return false
+	}
+	for k, v := range s {
+		if otherV, ok := other[k]; !ok || !v.modTime.Equal(otherV.modTime) || v.size != otherV.size {
+			return false
+		}
+	}
+	return true
+}
+
+// Watch builds the provided specs and then rebuilds the (product, OS/Arch) units whose source files change (see Watcher)
+// until the provided context is done. Build failures are written to stdout rather than ending the watch so that the
+// build is attempted again on the next change. Builds that are in progress when the context is done are killed.
+// Returns nil when the context is done.
+func Watch(runCtx context.Context, buildSpecs []params.ProductBuildSpec, osArchs cmd.OSArchFilter, ctx Context, interval time.Duration, stdout io.Writer) error {
+	w := NewWatcher(buildSpecs, osArchs, interval)
+	buildSpecs = distinct(buildSpecs)
+	for {
+		err := run(runCtx, buildSpecs, osArchs, ctx, stdout)
+		if runCtx.Err() != nil {
+			return nil
+		}
+		reportBuild(err, stdout)
+
+		if buildSpecs, err = w.Wait(runCtx); err != nil {
+			return nil
+		}
+		for _, currSpec := range buildSpecs {
+			fmt.Fprintf(stdout, "Detected change in source files of %s for %v\n", currSpec.ProductName, currSpec.Build.OSArchs)
+		}
+	}
+}
+
+func reportBuild(err error, stdout io.Writer) {
+	if err != nil {
+		fmt.Fprintf(stdout, "Build failed: %v\n", err)
+	}
+	fmt.Fprintln(stdout, "Watching for changes...")
+}
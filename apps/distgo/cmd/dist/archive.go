@@ -0,0 +1,223 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/git"
+	"github.com/palantir/godel/apps/distgo/templating"
+)
+
+// archiveOptions are the options used to write the entries of an archive. Entries are written with normalized
+// metadata so that archives of identical directories are byte-for-byte identical.
+type archiveOptions struct {
+	format params.ArchiveFormat
+	// modTime is the modification time of every entry.
+	modTime time.Time
+	// uid and gid are the user and group IDs of every entry in tar archives.
+	uid, gid int
+}
+
+// writeArchive writes an archive in the format specified by the provided options (a gzip-compressed tar archive if
+// the format is blank) to the provided path. The archive contains the provided directory as its top-level directory.
+// Files and directories whose paths relative to srcDir are in exclude are omitted from the archive.
+func writeArchive(dst string, opts archiveOptions, srcDir string, exclude map[string]bool) (rErr error) {
+	out, err := os.Create(dst)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", dst)
+	}
+	defer func() {
+		if err := out.Close(); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to close %s", dst)
+		}
+	}()
+
+	switch opts.format {
+	case "", params.TGZArchiveFormat:
+		// the gzip header is left empty so that it does not record a file name or modification time
+		gw := gzip.NewWriter(out)
+		tw := tar.NewWriter(gw)
+		if err := walkArchiveFiles(srcDir, exclude, func(name, src string, info os.FileInfo) error {
+			return writeTarEntry(tw, name, src, info, opts)
+		}); err != nil {
+			return err
+		}
+		if err := tw.Close(); err != nil {
+			return errors.Wrapf(err, "failed to close tar writer for %s", dst)
+		}
+		if err := gw.Close(); err != nil {
+			return errors.Wrapf(err, "failed to close gzip writer for %s", dst)
+		}
+	case params.ZipArchiveFormat:
+		zw := zip.NewWriter(out)
+		if err := walkArchiveFiles(srcDir, exclude, func(name, src string, info os.FileInfo) error {
+			return writeZipEntry(zw, name, src, info, opts)
+		}); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return errors.Wrapf(err, "failed to close zip writer for %s", dst)
+		}
+	default:
+		return errors.Errorf("unknown archive format: %v", opts.format)
+	}
+	return nil
+}
+
+// walkArchiveFiles calls the provided function for every file and directory in srcDir (including srcDir itself) that
+// is not excluded. The name provided to the function is the path of the file in the archive, which starts with the
+// base name of srcDir and ends with a slash for directories. Files are visited in lexical order, so the order of the
+// entries of an archive does not depend on the order in which the file system returns them.
+func walkArchiveFiles(srcDir string, exclude map[string]bool, f func(name, src string, info os.FileInfo) error) error {
+	baseDir := filepath.Base(srcDir)
+	return filepath.Walk(srcDir, func(currPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "failed to walk %s", currPath)
+		}
+		relPath, err := filepath.Rel(srcDir, currPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine path of %s relative to %s", currPath, srcDir)
+		}
+		relPath = filepath.ToSlash(relPath)
+		if exclude[relPath] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := path.Join(baseDir, relPath)
+		if info.IsDir() {
+			name += "/"
+		}
+		return f(name, currPath, info)
+	})
+}
+
+// archiveMode returns the normalized permissions of an archive entry for a file with the provided mode: 0755 for
+// directories and files that are executable by anyone, 0777 for symbolic links and 0644 for all other files.
+func archiveMode(mode os.FileMode) os.FileMode {
+	switch {
+	case mode&os.ModeSymlink != 0:
+		return 0777
+	case mode.IsDir(), mode&0111 != 0:
+		return 0755
+	default:
+		return 0644
+	}
+}
+
+func writeTarEntry(tw *tar.Writer, name, src string, info os.FileInfo, opts archiveOptions) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    int64(archiveMode(info.Mode())),
+		Uid:     opts.uid,
+		Gid:     opts.gid,
+		ModTime: opts.modTime,
+	}
+	switch {
+	case info.IsDir():
+		header.Typeflag = tar.TypeDir
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(src)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read link %s", src)
+		}
+		header.Typeflag = tar.TypeSymlink
+		header.Linkname = link
+	case info.Mode().IsRegular():
+		header.Typeflag = tar.TypeReg
+		header.Size = info.Size()
+	default:
+		return errors.Errorf("cannot add %s to archive: unsupported file mode %v", src, info.Mode())
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return errors.Wrapf(err, "failed to write tar header for %s", src)
+	}
+	if header.Typeflag == tar.TypeReg {
+		return copyFileContent(tw, src)
+	}
+	return nil
+}
+
+func writeZipEntry(zw *zip.Writer, name, src string, info os.FileInfo, opts archiveOptions) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create zip header for %s", src)
+	}
+	header.Name = name
+	header.SetModTime(opts.modTime)
+	header.SetMode(info.Mode()&^os.ModePerm | archiveMode(info.Mode()))
+	if !info.IsDir() {
+		header.Method = zip.Deflate
+	}
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write zip header for %s", src)
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		// the content of a symbolic link in a zip archive is its target
+		link, err := os.Readlink(src)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read link %s", src)
+		}
+		if _, err := io.WriteString(w, link); err != nil {
+			return errors.Wrapf(err, "failed to write zip entry for %s", src)
+		}
+	case info.Mode().IsRegular():
+		return copyFileContent(w, src)
+	}
+	return nil
+}
+
+// archiveModTime returns the modification time used for the entries of the archives of the project in the provided
+// directory. This is the time specified by the SOURCE_DATE_EPOCH environment variable if it is set or the time of the
+// commit that is checked out in the project otherwise. If the project is not a git repository, the Unix epoch is used.
+func archiveModTime(projectDir string) (time.Time, error) {
+	if epoch, ok, err := templating.SourceDateEpoch(); err != nil {
+		return time.Time{}, err
+	} else if ok {
+		return epoch, nil
+	}
+	if commitTime, err := git.ProjectCommitTime(projectDir); err == nil {
+		return commitTime, nil
+	}
+	return time.Unix(0, 0).UTC(), nil
+}
+
+func copyFileContent(w io.Writer, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := io.Copy(w, f); err != nil {
+		return errors.Wrapf(err, "failed to copy %s to archive", src)
+	}
+	return nil
+}
@@ -101,5 +101,5 @@ func binDist(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg params.D
 		}
 	}
 
-	return tgzPackager(buildSpec, distCfg, outputProductDir), nil
+	return archivePackager(buildSpec, distCfg, outputProductDir, "bin"), nil
 }
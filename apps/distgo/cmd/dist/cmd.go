@@ -26,6 +26,8 @@ import (
 
 const (
 	forceBuildFlagName = "force-build"
+	forceFlagName      = "force"
+	parallelFlagName   = "parallel"
 )
 
 var (
@@ -33,6 +35,19 @@ var (
 		Name:  forceBuildFlagName,
 		Usage: "Build all input build specs for distribution",
 	}
+	forceFlag = flag.BoolFlag{
+		Name:  forceFlagName,
+		Usage: "Create all distributions even if they are up-to-date",
+	}
+	parallelFlag = flag.BoolFlag{
+		Name:  parallelFlagName,
+		Usage: "Create distributions of products in parallel",
+		Value: true,
+	}
+	workersFlag = flag.StringFlag{
+		Name:  cmd.WorkersFlagName,
+		Usage: "Number of distributions to create concurrently when creating in parallel (defaults to the number of logical processors)",
+	}
 )
 
 func Command() cli.Command {
@@ -42,8 +57,22 @@ func Command() cli.Command {
 		Flags: []flag.Flag{
 			cmd.ProductsParam,
 			forceBuildFlag,
+			forceFlag,
+			parallelFlag,
+			workersFlag,
 		},
 		Action: func(ctx cli.Context) error {
+			workers, err := cmd.ParseWorkers(ctx.String(cmd.WorkersFlagName))
+			if err != nil {
+				return err
+			}
+			distCtx := Context{
+				ForceBuild: ctx.Bool(forceBuildFlagName),
+				Force:      ctx.Bool(forceFlagName),
+				Parallel:   ctx.Bool(parallelFlagName),
+				Workers:    workers,
+			}
+
 			cfg, err := config.Load(cfgcli.ConfigPath, cfgcli.ConfigJSON)
 			if err != nil {
 				return err
@@ -53,7 +82,7 @@ func Command() cli.Command {
 				return err
 			}
 
-			return Products(ctx.Slice(cmd.ProductsParamName), cfg, ctx.Bool(forceBuildFlagName), wd, ctx.App.Stdout)
+			return Products(ctx.Slice(cmd.ProductsParamName), cfg, distCtx, wd, ctx.App.Stdout)
 		},
 	}
 }
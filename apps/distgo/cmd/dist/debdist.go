@@ -0,0 +1,67 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/linuxpkg"
+)
+
+const defaultDebRevision = "1"
+
+func debDist(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg params.Dist, outputProductDir string) (Packager, error) {
+	buildSpec := buildSpecWithDeps.Spec
+	debDistInfo, ok := distCfg.Info.(*params.DebDistInfo)
+	if !ok {
+		debDistInfo = &params.DebDistInfo{}
+		distCfg.Info = debDistInfo
+	}
+
+	if err := validateLinuxPackageArchs(buildSpec, params.DebDistType, linuxpkg.DebArch); err != nil {
+		return nil, err
+	}
+	requires, provides, conflicts, err := parseRelations(debDistInfo.Requires, debDistInfo.Provides, debDistInfo.Conflicts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid Debian package configuration for %v", buildSpec.ProductName)
+	}
+
+	pkg := linuxpkg.Package{
+		Name:                buildSpec.ProductName,
+		Version:             buildSpec.ProductVersion,
+		Release:             debRevision(distCfg),
+		Maintainer:          debDistInfo.Maintainer,
+		Requires:            requires,
+		Provides:            provides,
+		Conflicts:           conflicts,
+		BeforeInstallScript: debDistInfo.BeforeInstallScript,
+		AfterInstallScript:  debDistInfo.AfterInstallScript,
+		BeforeRemoveScript:  debDistInfo.BeforeRemoveScript,
+		AfterRemoveScript:   debDistInfo.AfterRemoveScript,
+	}
+	return linuxPackager(buildSpec, distCfg, outputProductDir, pkg, linuxPackageFiles{
+		files:       debDistInfo.Files,
+		configFiles: debDistInfo.ConfigFiles,
+		binDir:      debDistInfo.BinDir,
+	}, linuxpkg.WriteDeb), nil
+}
+
+func debRevision(distCfg params.Dist) string {
+	if debDistInfo, ok := distCfg.Info.(*params.DebDistInfo); ok && debDistInfo.Revision != "" {
+		return debDistInfo.Revision
+	}
+	return defaultDebRevision
+}
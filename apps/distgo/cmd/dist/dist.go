@@ -20,8 +20,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
+	"sync"
 
-	"github.com/nmiyake/archiver"
 	"github.com/palantir/pkg/specdir"
 	"github.com/pkg/errors"
 	"github.com/termie/go-shutil"
@@ -29,16 +30,41 @@ import (
 	"github.com/palantir/godel/apps/distgo/cmd"
 	"github.com/palantir/godel/apps/distgo/cmd/build"
 	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/linuxpkg"
 	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+	"github.com/palantir/godel/apps/distgo/pkg/sbom"
 	"github.com/palantir/godel/apps/distgo/pkg/script"
+	"github.com/palantir/godel/apps/distgo/pkg/signing"
 	"github.com/palantir/godel/apps/distgo/pkg/slsspec"
+	"github.com/palantir/godel/apps/distgo/templating"
 )
 
-func Products(products []string, cfg params.Project, forceBuild bool, wd string, stdout io.Writer) error {
+// Context specifies how Products creates distributions.
+type Context struct {
+	// ForceBuild specifies that all of the products required by the distributions are built even if their build
+	// outputs are up-to-date.
+	ForceBuild bool
+	// Force specifies that all of the distributions are created even if they are up-to-date.
+	Force bool
+	// Parallel specifies that distributions are created concurrently using RunParallel.
+	Parallel bool
+	// Workers is the number of distributions that are created concurrently when Parallel is true. If it is not
+	// positive, the number of logical processors is used.
+	Workers int
+}
+
+// DefaultContext returns the Context used by the "dist" command when no flags are specified.
+func DefaultContext() Context {
+	return Context{
+		Parallel: parallelFlag.Value,
+	}
+}
+
+func Products(products []string, cfg params.Project, ctx Context, wd string, stdout io.Writer) error {
 	return build.RunBuildFunc(func(buildSpecWithDeps []params.ProductBuildSpecWithDeps, stdout io.Writer) error {
 		var specsToBuild []params.ProductBuildSpec
 		for _, currSpecWithDeps := range buildSpecWithDeps {
-			if forceBuild {
+			if ctx.ForceBuild {
 				specsToBuild = append(specsToBuild, currSpecWithDeps.AllSpecs()...)
 			} else {
 				specsToBuild = append(specsToBuild, build.RequiresBuild(currSpecWithDeps, nil).Specs()...)
@@ -49,14 +75,70 @@ func Products(products []string, cfg params.Project, forceBuild bool, wd string,
 				return errors.Wrapf(err, "Failed to build products required for dist")
 			}
 		}
-		return cmd.ProcessSerially(Run)(buildSpecWithDeps, stdout)
+		if !ctx.Parallel {
+			runFunc := Run
+			if ctx.Force {
+				runFunc = ForceRun
+			}
+			return cmd.ProcessSerially(runFunc)(buildSpecWithDeps, stdout)
+		}
+		if err := RunParallel(buildSpecWithDeps, ctx.Workers, ctx.Force, stdout); err != nil {
+			if specErrors, ok := err.(*cmd.SpecErrors); ok {
+				return errors.New(specErrorsSummary(specErrors, len(buildSpecWithDeps)))
+			}
+			return err
+		}
+		return nil
 	}, cfg, products, wd, stdout)
 }
 
-// Run produces a directory and artifact (tgz or rpm) for the specified product using the specified build specification.
-// The binaries for the distribution must already exist in the expected locations. The distribution directory and
-// artifact are written to the directory specified by "buildSpecWithDeps.Spec.DistCfgs.*.OutputDir".
+// Run produces a directory and artifacts for each distribution of the specified product using the specified build
+// specification. The binaries for the distribution must already exist in the expected locations. Distributions that are
+// up-to-date are not created again. The artifacts are recorded in the build manifest of the product and are signed once
+// all of the distributions have been created if signing is configured.
 func Run(buildSpecWithDeps params.ProductBuildSpecWithDeps, stdout io.Writer) error {
+	return run(buildSpecWithDeps, false, stdout)
+}
+
+// ForceRun creates the distributions of the product of the provided spec like Run, but creates every distribution even
+// if it is up-to-date and signs the artifacts again.
+func ForceRun(buildSpecWithDeps params.ProductBuildSpecWithDeps, stdout io.Writer) error {
+	return run(buildSpecWithDeps, true, stdout)
+}
+
+func run(buildSpecWithDeps params.ProductBuildSpecWithDeps, force bool, stdout io.Writer) error {
+	d, err := newProductDist(buildSpecWithDeps, force)
+	if err != nil {
+		return err
+	}
+	var allArtifacts []Artifact
+	for _, currDistCfg := range buildSpecWithDeps.Spec.Dist {
+		artifacts, err := d.create(currDistCfg, stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		allArtifacts = append(allArtifacts, artifacts...)
+	}
+	return d.sign(allArtifacts, stdout)
+}
+
+// productDist creates the distributions of a single product.
+type productDist struct {
+	buildSpecWithDeps params.ProductBuildSpecWithDeps
+	signers           []signing.Signer
+	// force specifies that distributions are created even if they are up-to-date.
+	force bool
+
+	// sbomDoc is the SBOM of the product, which is determined when it is first required by SBOMs or third-party
+	// notices. Guarded by sbomLock because the distributions of a product may be created concurrently.
+	sbomDoc  *sbom.Document
+	sbomLock sync.Mutex
+}
+
+// newProductDist returns a productDist for the product of the provided spec. Returns an error if the build outputs
+// required by the distributions of the product do not exist. Signing keys are read before any distributions are
+// created so that invalid keys are reported immediately.
+func newProductDist(buildSpecWithDeps params.ProductBuildSpecWithDeps, force bool) (*productDist, error) {
 	// verify that required build outputs exist
 	missingBinaries := build.RequiresBuild(buildSpecWithDeps, nil).Specs()
 	if len(missingBinaries) > 0 {
@@ -64,111 +146,251 @@ func Run(buildSpecWithDeps params.ProductBuildSpecWithDeps, stdout io.Writer) er
 		for i, currSpec := range missingBinaries {
 			missingProducts[i] = currSpec.ProductName
 		}
-		return errors.Errorf("required output not present for build specs: %v", missingProducts)
+		return nil, errors.Errorf("required output not present for build specs: %v", missingProducts)
 	}
 
-	buildSpec := buildSpecWithDeps.Spec
-	for _, currDistCfg := range buildSpec.Dist {
-		if currDistCfg.Info.Type() == params.RPMDistType {
-			osArchs := buildSpec.Build.OSArchs
-			expected := osarch.OSArch{OS: "linux", Arch: "amd64"}
-			if len(osArchs) != 1 || osArchs[0] != expected {
-				return fmt.Errorf("RPM is only supported for %v", expected)
-			}
-			if err := checkRPMDependencies(); err != nil {
-				return err
-			}
+	signers, err := newSigners(buildSpecWithDeps.Spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read signing keys for %v", buildSpecWithDeps.Spec.ProductName)
+	}
+	return &productDist{
+		buildSpecWithDeps: buildSpecWithDeps,
+		signers:           signers,
+		force:             force,
+	}, nil
+}
+
+// sbom returns the SBOM of the product, determining it if this is the first call.
+func (d *productDist) sbom() (sbom.Document, error) {
+	d.sbomLock.Lock()
+	defer d.sbomLock.Unlock()
+	if d.sbomDoc == nil {
+		doc, err := SBOM(d.buildSpecWithDeps)
+		if err != nil {
+			return sbom.Document{}, err
 		}
+		d.sbomDoc = &doc
+	}
+	return *d.sbomDoc, nil
+}
 
-		outputDir := path.Join(buildSpec.ProjectDir, currDistCfg.OutputDir)
+// create creates the provided distribution of the product, records its artifacts in the build manifest of the product
+// along with the fingerprint of the distribution and returns the artifacts. Unless d.force is true, the distribution is
+// not created again if its artifacts are recorded in the build manifest with its current fingerprint (see upToDate).
+// The error output of the dist script is written to stderr.
+func (d *productDist) create(distCfg params.Dist, stdout, stderr io.Writer) ([]Artifact, error) {
+	buildSpecWithDeps := d.buildSpecWithDeps
+	buildSpec := buildSpecWithDeps.Spec
 
-		fmt.Fprintf(stdout, "Creating distribution for %v at %v\n", buildSpec.ProductName, ArtifactPath(buildSpec, currDistCfg))
+	artifactPaths, err := ArtifactPaths(buildSpec, distCfg)
+	if err != nil {
+		return nil, err
+	}
 
-		spec := slsspec.New()
-		values := slsspec.TemplateValues(buildSpec.ProductName, buildSpec.ProductVersion)
+	// if the fingerprint cannot be determined, the distribution is created and the error (if any) is reported by the
+	// step that fails
+	fingerprint, err := d.fingerprint(distCfg)
+	if err != nil {
+		fingerprint = ""
+	} else if !d.force && upToDate(buildSpec, distCfg, fingerprint) {
+		fmt.Fprintf(stdout, "Distribution for %v at %v is up-to-date\n", buildSpec.ProductName, strings.Join(artifactPaths, ", "))
+		return Artifacts(buildSpec, distCfg)
+	}
 
-		// remove output directory if it already exists
-		outputProductDir := path.Join(outputDir, spec.RootDirName(values))
-		if err := os.RemoveAll(outputProductDir); err != nil {
-			return errors.Wrapf(err, "Failed to remove directory %v", outputProductDir)
-		}
+	fmt.Fprintf(stdout, "Creating distribution for %v at %v\n", buildSpec.ProductName, strings.Join(artifactPaths, ", "))
 
-		// create output root directory
-		if err := os.MkdirAll(outputProductDir, 0755); err != nil {
-			return errors.Wrapf(err, "failed to create directories for %v", outputProductDir)
-		}
+	spec := slsspec.New()
+	values := slsspec.TemplateValues(buildSpec.ProductName, buildSpec.ProductVersion)
 
-		// if input directory is specified, copy its contents
-		if currDistCfg.InputDir != "" {
-			inputDir := path.Join(buildSpec.ProjectDir, currDistCfg.InputDir)
+	// remove output directory if it already exists
+	outputProductDir := distDir(buildSpec, distCfg)
+	if err := os.RemoveAll(outputProductDir); err != nil {
+		return nil, errors.Wrapf(err, "Failed to remove directory %v", outputProductDir)
+	}
 
-			fileInfos, err := ioutil.ReadDir(inputDir)
-			if err != nil {
-				return errors.Wrapf(err, "failed to list files in directory %v", inputDir)
-			}
+	// create output root directory
+	if err := os.MkdirAll(outputProductDir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create directories for %v", outputProductDir)
+	}
 
-			for _, currFileInfo := range fileInfos {
-				currFileName := currFileInfo.Name()
-				srcPath := path.Join(inputDir, currFileName)
-				dstPath := path.Join(outputProductDir, currFileName)
-
-				if currFileInfo.IsDir() {
-					if err := shutil.CopyTree(srcPath, dstPath, &shutil.CopyTreeOptions{
-						CopyFunction: shutil.Copy,
-						// do not copy ".gitkeep" files
-						Ignore: func(dir string, files []os.FileInfo) []string {
-							return []string{".gitkeep"}
-						},
-					}); err != nil {
-						return errors.Wrapf(err, "failed to copy directory %v", currFileName)
-					}
-				} else if currFileName != ".gitkeep" {
-					if _, err := shutil.Copy(srcPath, dstPath, false); err != nil {
-						return errors.Wrapf(err, "failed to copy directory %v", currFileName)
-					}
+	// if input directory is specified, copy its contents
+	if distCfg.InputDir != "" {
+		inputDir := path.Join(buildSpec.ProjectDir, distCfg.InputDir)
+
+		fileInfos, err := ioutil.ReadDir(inputDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list files in directory %v", inputDir)
+		}
+
+		for _, currFileInfo := range fileInfos {
+			currFileName := currFileInfo.Name()
+			srcPath := path.Join(inputDir, currFileName)
+			dstPath := path.Join(outputProductDir, currFileName)
+
+			if currFileInfo.IsDir() {
+				if err := shutil.CopyTree(srcPath, dstPath, &shutil.CopyTreeOptions{
+					CopyFunction: shutil.Copy,
+					// do not copy ".gitkeep" files
+					Ignore: func(dir string, files []os.FileInfo) []string {
+						return []string{".gitkeep"}
+					},
+				}); err != nil {
+					return nil, errors.Wrapf(err, "failed to copy directory %v", currFileName)
+				}
+			} else if currFileName != ".gitkeep" {
+				if _, err := shutil.Copy(srcPath, dstPath, false); err != nil {
+					return nil, errors.Wrapf(err, "failed to copy directory %v", currFileName)
 				}
 			}
 		}
 
-		var packager Packager
-		var err error
-		switch currDistCfg.Info.Type() {
-		case params.SLSDistType:
-			if packager, err = slsDist(buildSpecWithDeps, currDistCfg, outputProductDir, spec, values); err != nil {
-				return err
-			}
-		case params.BinDistType:
-			if packager, err = binDist(buildSpecWithDeps, currDistCfg, outputProductDir); err != nil {
-				return err
-			}
-		case params.RPMDistType:
-			if packager, err = rpmDist(buildSpecWithDeps, currDistCfg, outputProductDir, stdout); err != nil {
-				return err
-			}
-		default:
-			return errors.Errorf("unknown dist type: %v", currDistCfg.Info.Type())
+		if err := renderTemplates(buildSpec, distCfg, inputDir, outputProductDir); err != nil {
+			return nil, errors.Wrapf(err, "failed to render templates for %v", buildSpec.ProductName)
+		}
+	}
+
+	var packager Packager
+	switch distCfg.Info.Type() {
+	case params.SLSDistType:
+		if packager, err = slsDist(buildSpecWithDeps, distCfg, outputProductDir, spec, values); err != nil {
+			return nil, err
+		}
+	case params.BinDistType:
+		if packager, err = binDist(buildSpecWithDeps, distCfg, outputProductDir); err != nil {
+			return nil, err
+		}
+	case params.RPMDistType:
+		if packager, err = rpmDist(buildSpecWithDeps, distCfg, outputProductDir); err != nil {
+			return nil, err
+		}
+	case params.DebDistType:
+		if packager, err = debDist(buildSpecWithDeps, distCfg, outputProductDir); err != nil {
+			return nil, err
+		}
+	case params.OCIDistType:
+		if packager, err = ociDist(buildSpecWithDeps, distCfg, outputProductDir); err != nil {
+			return nil, err
 		}
+	default:
+		return nil, errors.Errorf("unknown dist type: %v", distCfg.Info.Type())
+	}
 
-		// execute dist script
-		distEnvVars := cmd.ScriptEnvVariables(buildSpec, outputProductDir)
-		if err := script.WriteAndExecute(buildSpec, currDistCfg.Script, stdout, os.Stderr, distEnvVars); err != nil {
-			return errors.Wrapf(err, "failed to execute dist script for %v", buildSpec.ProductName)
+	var sbomDoc sbom.Document
+	if distCfg.SBOM != nil || distCfg.Licenses != nil {
+		if sbomDoc, err = d.sbom(); err != nil {
+			return nil, err
+		}
+	}
+	if distCfg.SBOM != nil {
+		if err := writeSBOMs(buildSpec, distCfg, sbomDoc, outputProductDir, stdout); err != nil {
+			return nil, errors.Wrapf(err, "failed to write SBOMs for %v", buildSpec.ProductName)
 		}
+	}
+	if distCfg.Licenses != nil {
+		if err := writeNotices(buildSpec, distCfg, sbomDoc, outputProductDir, stdout); err != nil {
+			return nil, errors.Wrapf(err, "license check failed for %v", buildSpec.ProductName)
+		}
+	}
 
-		// create artifact for distribution
-		if err := packager.Package(); err != nil {
-			return errors.Wrapf(err, "failed to create artifact for %v from path %v", buildSpec.ProductName, outputProductDir)
+	// execute dist script
+	distEnvVars := cmd.ScriptEnvVariables(buildSpec, outputProductDir)
+	if err := script.WriteAndExecute(buildSpec, distCfg.Script, stdout, stderr, distEnvVars); err != nil {
+		return nil, errors.Wrapf(err, "failed to execute dist script for %v", buildSpec.ProductName)
+	}
+
+	// create artifact for distribution
+	for _, currArtifactPath := range artifactPaths {
+		if err := os.MkdirAll(path.Dir(currArtifactPath), 0755); err != nil {
+			return nil, errors.Wrapf(err, "failed to create directories for %v", currArtifactPath)
 		}
+	}
+	if err := packager.Package(); err != nil {
+		return nil, errors.Wrapf(err, "failed to create artifact for %v from path %v", buildSpec.ProductName, outputProductDir)
+	}
 
-		fmt.Fprintf(stdout, "Finished creating distribution for %v\n", buildSpec.ProductName)
+	// record artifacts in build manifest
+	artifacts, err := Artifacts(buildSpec, distCfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, currArtifact := range artifacts {
+		artifact, err := build.NewManifestArtifact(buildSpec, string(distCfg.Info.Type()), currArtifact.Path, currArtifact.OSArchs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create manifest entry for distribution of %v", buildSpec.ProductName)
+		}
+		artifact.Fingerprint = fingerprint
+		if err := build.UpdateManifest(buildSpec, artifact); err != nil {
+			return nil, err
+		}
+	}
+	if err := recordSBOMs(buildSpec, distCfg); err != nil {
+		return nil, err
 	}
 
+	fmt.Fprintf(stdout, "Finished creating distribution for %v\n", buildSpec.ProductName)
+	return artifacts, nil
+}
+
+// distDir returns the path of the distribution directory of the provided distribution: the directory in the output
+// directory of the distribution whose name is the root directory name of the product specified by slsspec.
+func distDir(buildSpec params.ProductBuildSpec, distCfg params.Dist) string {
+	values := slsspec.TemplateValues(buildSpec.ProductName, buildSpec.ProductVersion)
+	return path.Join(buildSpec.ProjectDir, distCfg.OutputDir, slsspec.New().RootDirName(values))
+}
+
+// sign writes the checksums file and the signatures for the provided artifacts if signing is configured for the
+// product. Unless d.force is true, the artifacts are not signed again if their signatures are up-to-date (see
+// signaturesUpToDate).
+func (d *productDist) sign(artifacts []Artifact, stdout io.Writer) error {
+	buildSpec := d.buildSpecWithDeps.Spec
+	if buildSpec.Signing == nil {
+		return nil
+	}
+	if !d.force && signaturesUpToDate(buildSpec, artifacts) {
+		fmt.Fprintf(stdout, "Signatures for %v are up-to-date\n", buildSpec.ProductName)
+		return nil
+	}
+	if err := signArtifacts(buildSpec, artifacts, d.signers, stdout); err != nil {
+		return errors.Wrapf(err, "failed to sign artifacts for %v", buildSpec.ProductName)
+	}
 	return nil
 }
 
-func tgzPackager(buildSpec params.ProductBuildSpec, distCfg params.Dist, outputProductDir string) packager {
+// archivePackager returns a packager that writes the artifacts of the provided distribution as archives of
+// outputProductDir. binDir is the path of the directory relative to outputProductDir that contains a directory of
+// executables for each OS/Arch. If the distribution is split by OS/Arch, the directories of the other OS/Archs are
+// omitted from each artifact. The archives are written deterministically using the modification time returned by
+// archiveModTime and the user and group IDs of the distribution.
+func archivePackager(buildSpec params.ProductBuildSpec, distCfg params.Dist, outputProductDir, binDir string) packager {
 	return packager(func() error {
-		return archiver.TarGz(ArtifactPath(buildSpec, distCfg), []string{outputProductDir})
+		artifacts, err := Artifacts(buildSpec, distCfg)
+		if err != nil {
+			return err
+		}
+		modTime, err := archiveModTime(buildSpec.ProjectDir)
+		if err != nil {
+			return err
+		}
+		opts := archiveOptions{
+			format:  distCfg.ArchiveFormat,
+			modTime: modTime,
+			uid:     distCfg.ArchiveUID,
+			gid:     distCfg.ArchiveGID,
+		}
+		for _, currArtifact := range artifacts {
+			exclude := make(map[string]bool)
+			if distCfg.SplitByOSArch {
+				for _, currOSArch := range buildSpec.Build.OSArchs {
+					if currOSArch != currArtifact.OSArchs[0] {
+						exclude[path.Join(binDir, currOSArch.String())] = true
+					}
+				}
+			}
+			if err := writeArchive(currArtifact.Path, opts, outputProductDir, exclude); err != nil {
+				return errors.Wrapf(err, "failed to create archive %s", currArtifact.Path)
+			}
+		}
+		return nil
 	})
 }
 
@@ -191,38 +413,152 @@ func copyBuildArtifactsToBinDir(buildSpecWithDeps params.ProductBuildSpecWithDep
 }
 
 func copyBuildArtifacts(buildSpec params.ProductBuildSpec, binSpecDir specdir.SpecDir) error {
-	artifactPaths := build.ArtifactPaths(buildSpec)
+	outputPaths, err := build.OutputPaths(buildSpec)
+	if err != nil {
+		return err
+	}
 	for _, currOSArch := range buildSpec.Build.OSArchs {
-		currBuildArtifact, ok := artifactPaths[currOSArch]
+		currOutputPaths, ok := outputPaths[currOSArch]
 		if !ok {
 			return fmt.Errorf("could not determine artifact path for %s for %s", buildSpec.ProductName, currOSArch.String())
 		}
 		if binOSArchDir := binSpecDir.Path(currOSArch.String()); binOSArchDir != "" {
-			dst := path.Join(binOSArchDir, build.ExecutableName(buildSpec.ProductName, currOSArch.OS))
-			if _, err := shutil.Copy(currBuildArtifact, dst, false); err != nil {
-				return errors.Wrapf(err, "failed to copy build artifact from %v to %v", currBuildArtifact, dst)
+			// copy all outputs of the build (including C header files for build modes that create them). The outputs
+			// are named after the product regardless of the artifact name used for the build.
+			distNames := build.OutputNames(buildSpec.ProductName, buildSpec.Build.BuildMode, currOSArch.OS)
+			for i, currBuildArtifact := range currOutputPaths {
+				dst := path.Join(binOSArchDir, distNames[i])
+				if _, err := shutil.Copy(currBuildArtifact, dst, false); err != nil {
+					return errors.Wrapf(err, "failed to copy build artifact from %v to %v", currBuildArtifact, dst)
+				}
 			}
 		}
 	}
 	return nil
 }
 
-func ArtifactPath(buildSpec params.ProductBuildSpec, distCfg params.Dist) string {
-	var fileName string
+// Artifact is an artifact created by a distribution.
+type Artifact struct {
+	// Path is the path to the artifact.
+	Path string
+	// OSArchs are the OS/Archs of the executables contained in the artifact.
+	OSArchs []osarch.OSArch
+}
+
+// Artifacts returns the artifacts created by the provided distribution of the provided product. A distribution creates a
+// single artifact unless it is split by OS/Arch, in which case it creates one artifact for each OS/Arch of the product
+// in the order in which the OS/Archs are declared. RPM and Debian distributions create one package for each linux
+// OS/Arch of the product. The paths of the artifacts are determined by rendering the OutputPath and ArtifactName
+// templates of the distribution (if they are specified). Returns an error if the templates render to the same path for
+// different OS/Archs.
+func Artifacts(buildSpec params.ProductBuildSpec, distCfg params.Dist) ([]Artifact, error) {
+	artifactOSArchs := buildSpec.Build.OSArchs
+	switch {
+	case distCfg.Info.Type() == params.RPMDistType || distCfg.Info.Type() == params.DebDistType:
+		linuxOSArchs, err := linuxPackageOSArchs(buildSpec, distCfg.Info.Type())
+		if err != nil {
+			return nil, err
+		}
+		artifactOSArchs = linuxOSArchs
+	case !distCfg.SplitByOSArch:
+		artifactPath, err := artifactPath(buildSpec, distCfg, osarch.OSArch{})
+		if err != nil {
+			return nil, err
+		}
+		return []Artifact{{
+			Path:    artifactPath,
+			OSArchs: buildSpec.Build.OSArchs,
+		}}, nil
+	}
+	artifacts := make([]Artifact, len(artifactOSArchs))
+	pathOSArchs := make(map[string]osarch.OSArch)
+	for i, currOSArch := range artifactOSArchs {
+		artifactPath, err := artifactPath(buildSpec, distCfg, currOSArch)
+		if err != nil {
+			return nil, err
+		}
+		if prevOSArch, ok := pathOSArchs[artifactPath]; ok {
+			return nil, errors.Errorf("artifact path of %s distribution of %s is %s for both %s and %s: the output path or artifact name must be different for every OS/Arch", distCfg.Info.Type(), buildSpec.ProductName, artifactPath, prevOSArch.String(), currOSArch.String())
+		}
+		pathOSArchs[artifactPath] = currOSArch
+		artifacts[i] = Artifact{
+			Path:    artifactPath,
+			OSArchs: []osarch.OSArch{currOSArch},
+		}
+	}
+	return artifacts, nil
+}
+
+// ArtifactPaths returns the paths of the artifacts returned by Artifacts.
+func ArtifactPaths(buildSpec params.ProductBuildSpec, distCfg params.Dist) ([]string, error) {
+	artifacts, err := Artifacts(buildSpec, distCfg)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(artifacts))
+	for i, currArtifact := range artifacts {
+		paths[i] = currArtifact.Path
+	}
+	return paths, nil
+}
+
+// artifactPath returns the path of the artifact of the provided distribution that contains the outputs for the
+// provided OS/Arch (which is the zero value if the artifact contains the outputs for multiple OS/Archs).
+func artifactPath(buildSpec params.ProductBuildSpec, distCfg params.Dist, osArch osarch.OSArch) (string, error) {
+	templateCfg := templating.PathConfig{
+		Config: templating.ConvertSpec(buildSpec, distCfg),
+		OSArch: templating.OSArch(osArch),
+	}
+	outputDir := path.Join(buildSpec.ProjectDir, distCfg.OutputDir)
+	if distCfg.OutputPath != "" {
+		outputPath, err := templating.RenderPath("output-path", distCfg.OutputPath, templateCfg)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to determine output path of %s distribution of %s", distCfg.Info.Type(), buildSpec.ProductName)
+		}
+		outputDir = path.Join(outputDir, outputPath)
+	}
+	if distCfg.ArtifactName == "" {
+		return path.Join(outputDir, defaultArtifactName(buildSpec, distCfg, osArch)), nil
+	}
+	artifactName, err := templating.RenderPath("artifact-name", distCfg.ArtifactName, templateCfg)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine artifact name of %s distribution of %s", distCfg.Info.Type(), buildSpec.ProductName)
+	}
+	return path.Join(outputDir, artifactName), nil
+}
+
+// defaultArtifactName returns the file name of the artifact of the provided distribution if the distribution does not
+// specify an artifact name. The names of archives of distributions that are split by OS/Arch contain the OS/Arch and the
+// names of RPM and Debian packages contain the architecture of the package.
+func defaultArtifactName(buildSpec params.ProductBuildSpec, distCfg params.Dist, osArch osarch.OSArch) string {
+	var suffix string
+	if distCfg.SplitByOSArch {
+		suffix = "-" + osArch.String()
+	}
 	switch distCfg.Info.Type() {
 	case params.SLSDistType:
 		values := slsspec.TemplateValues(buildSpec.ProductName, buildSpec.ProductVersion)
-		fileName = slsspec.New().RootDirName(values) + ".sls.tgz"
+		return slsspec.New().RootDirName(values) + suffix + ".sls." + archiveExtension(distCfg)
 	case params.BinDistType:
-		fileName = fmt.Sprintf("%v-%v.tgz", buildSpec.ProductName, buildSpec.ProductVersion)
+		return fmt.Sprintf("%v-%v%v.%v", buildSpec.ProductName, buildSpec.ProductVersion, suffix, archiveExtension(distCfg))
 	case params.RPMDistType:
-		release := defaultRPMRelease
-		if rpmDistInfo, ok := distCfg.Info.(*params.RPMDistInfo); ok && rpmDistInfo.Release != "" {
-			release = rpmDistInfo.Release
-		}
-		fileName = fmt.Sprintf("%v-%v-%v.x86_64.rpm", buildSpec.ProductName, buildSpec.ProductVersion, release)
+		arch := linuxPackageArch(osArch, linuxpkg.RPMArch)
+		return fmt.Sprintf("%v-%v-%v.%v.rpm", buildSpec.ProductName, buildSpec.ProductVersion, rpmRelease(distCfg), arch)
+	case params.DebDistType:
+		arch := linuxPackageArch(osArch, linuxpkg.DebArch)
+		return fmt.Sprintf("%v_%v-%v_%v.deb", buildSpec.ProductName, buildSpec.ProductVersion, debRevision(distCfg), arch)
+	case params.OCIDistType:
+		return fmt.Sprintf("%v-%v.oci.tar", buildSpec.ProductName, buildSpec.ProductVersion)
 	default:
-		fileName = fmt.Sprintf("%v-%v", buildSpec.ProductName, buildSpec.ProductVersion)
+		return fmt.Sprintf("%v-%v", buildSpec.ProductName, buildSpec.ProductVersion)
+	}
+}
+
+// archiveExtension returns the file extension (without the leading ".") of archives in the format of the provided
+// distribution.
+func archiveExtension(distCfg params.Dist) string {
+	if distCfg.ArchiveFormat == params.ZipArchiveFormat {
+		return "zip"
 	}
-	return path.Join(buildSpec.ProjectDir, distCfg.OutputDir, fileName)
+	return "tgz"
 }
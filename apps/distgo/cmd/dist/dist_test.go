@@ -15,12 +15,20 @@
 package dist_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
 	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,12 +37,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/palantir/godel/apps/distgo/cmd"
 	"github.com/palantir/godel/apps/distgo/cmd/build"
 	"github.com/palantir/godel/apps/distgo/cmd/dist"
 	"github.com/palantir/godel/apps/distgo/params"
 	"github.com/palantir/godel/apps/distgo/pkg/git"
 	"github.com/palantir/godel/apps/distgo/pkg/git/gittest"
+	"github.com/palantir/godel/apps/distgo/pkg/manifest"
+	"github.com/palantir/godel/apps/distgo/pkg/oci"
 	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+	"github.com/palantir/godel/apps/distgo/pkg/sbom"
 )
 
 const (
@@ -240,7 +252,7 @@ If these files are known to be correct, exclude them from validation using the S
 			},
 		},
 		{
-			name: "copies executable from build location if it already exists",
+			name: "copies executable from build location if it is up-to-date",
 			spec: func(projectDir string) params.ProductBuildSpecWithDeps {
 				specWithDeps, err := params.NewProductBuildSpecWithDeps(params.NewProductBuildSpec(
 					projectDir,
@@ -251,12 +263,6 @@ If these files are known to be correct, exclude them from validation using the S
 					params.Product{
 						Build: params.Build{
 							MainPkg: "./.",
-							OSArchs: []osarch.OSArch{
-								{
-									OS:   "fake",
-									Arch: "fake",
-								},
-							},
 						},
 					},
 					params.Project{
@@ -269,20 +275,19 @@ If these files are known to be correct, exclude them from validation using the S
 			preDistAction: func(projectDir string, buildSpec params.ProductBuildSpec) {
 				gittest.CreateGitTag(t, projectDir, "0.1.0")
 
-				// write fake executable
-				artifactPath, ok := build.ArtifactPaths(buildSpec)[osarch.OSArch{OS: "fake", Arch: "fake"}]
-				require.True(t, ok)
-
-				err := os.MkdirAll(path.Dir(artifactPath), 0755)
+				err := build.Run([]params.ProductBuildSpec{buildSpec}, nil, build.Context{}, ioutil.Discard)
 				require.NoError(t, err)
 
-				err = ioutil.WriteFile(artifactPath, []byte("test-content"), 0755)
+				// set modification time of executable so that it can be verified that the executable is not rebuilt
+				artifactPaths, err := build.ArtifactPaths(buildSpec)
+				require.NoError(t, err)
+				err = os.Chtimes(artifactPaths[osarch.Current()], time.Unix(0, 0), time.Unix(0, 0))
 				require.NoError(t, err)
 			},
 			validate: func(caseNum int, name string, projectDir string) {
-				bytes, err := ioutil.ReadFile(path.Join(projectDir, "build", "0.1.0", "fake-fake", "foo"))
+				fi, err := os.Stat(path.Join(projectDir, "build", "0.1.0", osarch.Current().String(), "foo"))
 				require.NoError(t, err)
-				assert.Equal(t, "test-content", string(bytes), "Case %d: %s", caseNum, name)
+				assert.Equal(t, time.Unix(0, 0), fi.ModTime(), "Case %d: %s", caseNum, name)
 			},
 		},
 		{
@@ -310,10 +315,12 @@ If these files are known to be correct, exclude them from validation using the S
 				gittest.CreateGitTag(t, projectDir, "0.1.0")
 
 				// write fake executable
-				artifactPath, ok := build.ArtifactPaths(buildSpec)[osarch.Current()]
+				artifactPaths, err := build.ArtifactPaths(buildSpec)
+				require.NoError(t, err)
+				artifactPath, ok := artifactPaths[osarch.Current()]
 				require.True(t, ok)
 
-				err := os.MkdirAll(path.Dir(artifactPath), 0755)
+				err = os.MkdirAll(path.Dir(artifactPath), 0755)
 				require.NoError(t, err)
 				err = ioutil.WriteFile(artifactPath, []byte("test-content"), 0755)
 				require.NoError(t, err)
@@ -964,12 +971,6 @@ daemon: true
 		},
 		{
 			name: "builds rpm",
-			skip: func() bool {
-				// Run this case only if both fpm and rpmbuild are available
-				_, fpmErr := exec.LookPath("fpm")
-				_, rpmbuildErr := exec.LookPath("rpmbuild")
-				return fpmErr != nil || rpmbuildErr != nil
-			},
 			spec: func(projectDir string) params.ProductBuildSpecWithDeps {
 				specWithDeps, err := params.NewProductBuildSpecWithDeps(params.NewProductBuildSpec(
 					projectDir,
@@ -1111,3 +1112,1137 @@ daemon: true
 		}
 	}
 }
+
+func TestOCIDist(t *testing.T) {
+	tmp, cleanup := newDistProject(t, map[string]string{
+		"resources/etc/config.yml": "config",
+	})
+	defer cleanup()
+
+	specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+		Build: params.Build{
+			MainPkg: "./.",
+			OSArchs: []osarch.OSArch{
+				{OS: "linux", Arch: "amd64"},
+				{OS: "linux", Arch: "arm64"},
+				{OS: "darwin", Arch: "amd64"},
+			},
+		},
+		Dist: []params.Dist{{
+			InputDir: "resources",
+			Info: &params.OCIDistInfo{
+				Cmd: []string{"server"},
+				Env: map[string]string{
+					"FOO": "bar",
+				},
+			},
+			Publish: params.Publish{
+				Almanac: params.Almanac{
+					Metadata: map[string]string{
+						"team": "infra",
+					},
+				},
+			},
+		}},
+	})
+	err := dist.Run(specWithDeps, ioutil.Discard)
+	require.NoError(t, err)
+
+	artifactPath := path.Join(tmp, "dist", "foo-0.1.0.oci.tar")
+	assertArtifactPaths(t, []string{artifactPath}, specWithDeps.Spec, specWithDeps.Spec.Dist[0])
+	files := readTarFiles(t, artifactPath)
+
+	var index oci.Index
+	err = json.Unmarshal(files["index.json"], &index)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(index.Manifests))
+	assert.Equal(t, oci.MediaTypeImageIndex, index.Manifests[0].MediaType)
+	assert.Equal(t, "0.1.0", index.Manifests[0].Annotations[oci.AnnotationRefName])
+
+	// image index contains images for the linux OS/Archs
+	var imageIndex oci.Index
+	err = json.Unmarshal(files["blobs/"+strings.Replace(index.Manifests[0].Digest, ":", "/", 1)], &imageIndex)
+	require.NoError(t, err)
+	var platforms []string
+	for _, currDesc := range imageIndex.Manifests {
+		platforms = append(platforms, currDesc.Platform.String())
+	}
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, platforms)
+
+	var manifest oci.Manifest
+	err = json.Unmarshal(files["blobs/"+strings.Replace(imageIndex.Manifests[0].Digest, ":", "/", 1)], &manifest)
+	require.NoError(t, err)
+	var config oci.ImageConfig
+	err = json.Unmarshal(files["blobs/"+strings.Replace(manifest.Config.Digest, ":", "/", 1)], &config)
+	require.NoError(t, err)
+	assert.Equal(t, oci.ContainerConfig{
+		Entrypoint: []string{"/usr/local/bin/foo"},
+		Cmd:        []string{"server"},
+		Env:        []string{"FOO=bar"},
+		Labels: map[string]string{
+			"org.opencontainers.image.title":   "foo",
+			"org.opencontainers.image.version": "0.1.0",
+			"team":                             "infra",
+		},
+	}, config.Config)
+	// layers for executable and distribution directory
+	assert.Equal(t, 2, len(manifest.Layers))
+}
+
+func TestLinuxPackageDist(t *testing.T) {
+	tmp, cleanup := newDistProject(t, map[string]string{
+		"root/etc/foo/foo.yml": "config",
+		"root/var/lib/foo/":    "",
+	})
+	defer cleanup()
+
+	files := map[string]params.PackageFile{
+		"/usr/bin/*":   {Mode: 0750},
+		"/var/lib/foo": {Owner: "foo", Group: "foo"},
+	}
+	product := params.Product{
+		Build: params.Build{
+			MainPkg: "./.",
+			OSArchs: []osarch.OSArch{
+				{OS: "linux", Arch: "amd64"},
+				{OS: "darwin", Arch: "amd64"},
+				{OS: "linux", Arch: "arm64"},
+			},
+		},
+		Dist: []params.Dist{{
+			InputDir: "root",
+			Info: &params.RPMDistInfo{
+				ConfigFiles: []string{"/etc/foo/foo.yml"},
+				Requires:    []string{"bash >= 4.0"},
+				Files:       files,
+				BinDir:      "/usr/bin",
+			},
+		}, {
+			InputDir: "root",
+			Info: &params.DebDistInfo{
+				ConfigFiles: []string{"/etc/foo/foo.yml"},
+				Requires:    []string{"bash >= 4.0"},
+				Files:       files,
+				BinDir:      "/usr/bin",
+			},
+		}, {
+			InputDir:  "root",
+			OutputDir: "dist/nobin",
+			Info:      &params.DebDistInfo{},
+		}},
+	}
+	specWithDeps := buildDistSpec(t, tmp, "foo", product)
+	err := dist.Run(specWithDeps, ioutil.Discard)
+	require.NoError(t, err)
+
+	// a package is created for each linux OS/Arch
+	rpmPaths := []string{
+		path.Join(tmp, "dist", "foo-0.1.0-1.x86_64.rpm"),
+		path.Join(tmp, "dist", "foo-0.1.0-1.aarch64.rpm"),
+	}
+	assertArtifactPaths(t, rpmPaths, specWithDeps.Spec, specWithDeps.Spec.Dist[0])
+	for _, currPath := range rpmPaths {
+		_, err = os.Stat(currPath)
+		require.NoError(t, err)
+	}
+
+	debPaths := []string{
+		path.Join(tmp, "dist", "foo_0.1.0-1_amd64.deb"),
+		path.Join(tmp, "dist", "foo_0.1.0-1_arm64.deb"),
+	}
+	assertArtifactPaths(t, debPaths, specWithDeps.Spec, specWithDeps.Spec.Dist[1])
+	outputPaths, err := build.OutputPaths(specWithDeps.Spec)
+	require.NoError(t, err)
+	for i, currOSArch := range []osarch.OSArch{{OS: "linux", Arch: "amd64"}, {OS: "linux", Arch: "arm64"}} {
+		members := readArMembers(t, debPaths[i])
+		assert.Equal(t, "2.0\n", string(members["debian-binary"]))
+
+		control := readTarGzHeaders(t, members["control.tar.gz"])
+		require.NotNil(t, control["./control"])
+		data := readTarGzHeaders(t, members["data.tar.gz"])
+		assert.Contains(t, data, "./etc/foo/foo.yml")
+		require.NotNil(t, data["./var/lib/foo/"])
+		assert.Equal(t, "foo", data["./var/lib/foo/"].Uname)
+
+		// the executable for the OS/Arch of the package is installed in /usr/bin
+		require.NotNil(t, data["./usr/bin/foo"])
+		assert.Equal(t, int64(0750), data["./usr/bin/foo"].Mode)
+		fi, err := os.Stat(outputPaths[currOSArch][0])
+		require.NoError(t, err)
+		assert.Equal(t, fi.Size(), data["./usr/bin/foo"].Size, "%s", currOSArch)
+	}
+
+	// executables are not installed if a bin directory is not specified
+	members := readArMembers(t, path.Join(tmp, "dist", "nobin", "foo_0.1.0-1_amd64.deb"))
+	data := readTarGzHeaders(t, members["data.tar.gz"])
+	assert.Contains(t, data, "./etc/foo/foo.yml")
+	assert.NotContains(t, data, "./usr/bin/foo")
+}
+
+func TestLinuxPackageDistRequiresLinuxOSArch(t *testing.T) {
+	tmp, cleanup := newDistProject(t, nil)
+	defer cleanup()
+
+	for i, currCase := range []struct {
+		info      params.DistInfo
+		wantError string
+	}{
+		{
+			info:      &params.RPMDistInfo{},
+			wantError: "rpm distribution requires the product to be built for a linux OS/Arch, but foo is built for [darwin-amd64]",
+		},
+		{
+			info:      &params.DebDistInfo{},
+			wantError: "deb distribution requires the product to be built for a linux OS/Arch, but foo is built for [darwin-amd64]",
+		},
+	} {
+		specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+			Build: params.Build{
+				MainPkg: "./.",
+				OSArchs: []osarch.OSArch{
+					{OS: "darwin", Arch: "amd64"},
+				},
+			},
+			Dist: []params.Dist{{
+				Info: currCase.info,
+			}},
+		})
+		err := dist.Run(specWithDeps, ioutil.Discard)
+		assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+	}
+}
+
+func TestSystemdDist(t *testing.T) {
+	tmp, cleanup := newDistProject(t, nil)
+	defer cleanup()
+
+	systemd := params.Systemd{
+		User:            "foo",
+		EnvironmentFile: "-/etc/sysconfig/foo",
+		Limits:          map[string]string{"NPROC": "4096", "NOFILE": "65536"},
+	}
+	slsSystemd := systemd
+	slsSystemd.WorkingDirectory = "/opt/foo"
+	specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+		Build: params.Build{
+			MainPkg: "./.",
+			OSArchs: []osarch.OSArch{{OS: "linux", Arch: "arm64"}},
+		},
+		Dist: []params.Dist{{
+			OutputDir: "dist/sls",
+			Info: &params.SLSDistInfo{
+				ServiceArgs: "server var/conf/foo.yml",
+				Systemd:     &slsSystemd,
+			},
+		}, {
+			OutputDir: "dist/rpm",
+			Info: &params.RPMDistInfo{
+				BinDir:              "/usr/bin",
+				ServiceArgs:         "server",
+				BeforeInstallScript: "mkdir -p /var/log/foo",
+				AfterInstallScript:  "echo installed\n",
+				Systemd:             &systemd,
+			},
+		}},
+	})
+	err := dist.Run(specWithDeps, ioutil.Discard)
+	require.NoError(t, err)
+
+	slsUnit, err := ioutil.ReadFile(path.Join(tmp, "dist", "sls", "foo-0.1.0", "service", "bin", "foo.service"))
+	require.NoError(t, err)
+	assert.Equal(t, `[Unit]
+Description=foo
+After=network.target
+
+[Service]
+Type=simple
+User=foo
+WorkingDirectory=/opt/foo
+EnvironmentFile=-/etc/sysconfig/foo
+ExecStart=/opt/foo/service/bin/linux-arm64/foo server var/conf/foo.yml
+Restart=on-failure
+LimitNOFILE=65536
+LimitNPROC=4096
+
+[Install]
+WantedBy=multi-user.target
+`, string(slsUnit))
+	slsContent, err := ioutil.ReadFile(path.Join(tmp, "dist", "sls", "foo-0.1.0.sls.tgz"))
+	require.NoError(t, err)
+	assert.Contains(t, readTarGzHeaders(t, slsContent), "foo-0.1.0/service/bin/foo.service")
+
+	rpmUnit, err := ioutil.ReadFile(path.Join(tmp, "dist", "rpm", "foo-0.1.0", "usr", "lib", "systemd", "system", "foo.service"))
+	require.NoError(t, err)
+	assert.Contains(t, string(rpmUnit), "\nExecStart=/usr/bin/foo server\n")
+	assert.NotContains(t, string(rpmUnit), "WorkingDirectory=")
+
+	// the scripts are stored uncompressed in the header of the RPM
+	rpmContent, err := ioutil.ReadFile(path.Join(tmp, "dist", "rpm", "foo-0.1.0-1.aarch64.rpm"))
+	require.NoError(t, err)
+	for _, want := range []string{
+		"getent group foo >/dev/null || groupadd -r foo\n" +
+			"getent passwd foo >/dev/null || useradd -r -g foo -d / -M -s /sbin/nologin foo\n" +
+			"mkdir -p /var/log/foo\n",
+		"echo installed\n" +
+			"systemctl daemon-reload >/dev/null 2>&1 || :\n" +
+			"if [ \"$1\" -eq 1 ]; then\n" +
+			"    systemctl enable foo.service >/dev/null 2>&1 || :\n",
+		"if [ \"$1\" -eq 0 ]; then\n" +
+			"    systemctl stop foo.service >/dev/null 2>&1 || :\n",
+	} {
+		assert.Contains(t, string(rpmContent), want)
+	}
+}
+
+func TestSLSSystemdDistRequiresSingleLinuxOSArch(t *testing.T) {
+	tmp, cleanup := newDistProject(t, nil)
+	defer cleanup()
+
+	for i, currCase := range []struct {
+		osArchs   []osarch.OSArch
+		wantError string
+	}{
+		{
+			osArchs:   []osarch.OSArch{{OS: "linux", Arch: "amd64"}, {OS: "linux", Arch: "arm64"}},
+			wantError: "failed to write systemd unit: systemd unit of sls distribution requires the product to be built for exactly one linux OS/Arch, but foo is built for [linux-amd64 linux-arm64]",
+		},
+		{
+			osArchs:   []osarch.OSArch{{OS: "darwin", Arch: "amd64"}},
+			wantError: "failed to write systemd unit: systemd unit of sls distribution requires the product to be built for exactly one linux OS/Arch, but foo is built for [darwin-amd64]",
+		},
+	} {
+		specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+			Build: params.Build{
+				MainPkg: "./.",
+				OSArchs: currCase.osArchs,
+			},
+			Dist: []params.Dist{{
+				Info: &params.SLSDistInfo{
+					Systemd: &params.Systemd{
+						WorkingDirectory: "/opt/foo",
+					},
+				},
+			}},
+		})
+		err := dist.Run(specWithDeps, ioutil.Discard)
+		assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+	}
+}
+
+func TestArchiveDistSplitByOSArch(t *testing.T) {
+	tmp, cleanup := newDistProject(t, nil)
+	defer cleanup()
+
+	linuxAMD64 := osarch.OSArch{OS: "linux", Arch: "amd64"}
+	windowsAMD64 := osarch.OSArch{OS: "windows", Arch: "amd64"}
+	specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+		Build: params.Build{
+			MainPkg: "./.",
+			OSArchs: []osarch.OSArch{linuxAMD64, windowsAMD64},
+		},
+		Dist: []params.Dist{{
+			Info:          &params.BinDistInfo{OmitInitSh: true},
+			ArchiveFormat: params.ZipArchiveFormat,
+			SplitByOSArch: true,
+		}, {
+			Info:          &params.SLSDistInfo{},
+			SplitByOSArch: true,
+		}},
+	})
+	err := dist.Run(specWithDeps, ioutil.Discard)
+	require.NoError(t, err)
+
+	gotArtifacts, err := dist.Artifacts(specWithDeps.Spec, specWithDeps.Spec.Dist[0])
+	require.NoError(t, err)
+	assert.Equal(t, []dist.Artifact{
+		{Path: path.Join(tmp, "dist", "foo-0.1.0-linux-amd64.zip"), OSArchs: []osarch.OSArch{linuxAMD64}},
+		{Path: path.Join(tmp, "dist", "foo-0.1.0-windows-amd64.zip"), OSArchs: []osarch.OSArch{windowsAMD64}},
+	}, gotArtifacts)
+	assertArtifactPaths(t, []string{
+		path.Join(tmp, "dist", "foo-0.1.0-linux-amd64.sls.tgz"),
+		path.Join(tmp, "dist", "foo-0.1.0-windows-amd64.sls.tgz"),
+	}, specWithDeps.Spec, specWithDeps.Spec.Dist[1])
+
+	// each zip archive only contains the executable for its OS/Arch
+	zr, err := zip.OpenReader(path.Join(tmp, "dist", "foo-0.1.0-windows-amd64.zip"))
+	require.NoError(t, err)
+	defer func() {
+		_ = zr.Close()
+	}()
+	var zipFiles []string
+	for _, currFile := range zr.File {
+		zipFiles = append(zipFiles, currFile.Name)
+	}
+	assert.Equal(t, []string{
+		"foo-0.1.0/",
+		"foo-0.1.0/bin/",
+		"foo-0.1.0/bin/windows-amd64/",
+		"foo-0.1.0/bin/windows-amd64/foo.exe",
+	}, zipFiles)
+
+	tgzContent, err := ioutil.ReadFile(path.Join(tmp, "dist", "foo-0.1.0-linux-amd64.sls.tgz"))
+	require.NoError(t, err)
+	headers := readTarGzHeaders(t, tgzContent)
+	assert.Contains(t, headers, "foo-0.1.0/service/bin/linux-amd64/foo")
+	assert.Contains(t, headers, "foo-0.1.0/service/bin/init.sh")
+	assert.NotContains(t, headers, "foo-0.1.0/service/bin/windows-amd64/")
+	assert.NotContains(t, headers, "foo-0.1.0/service/bin/windows-amd64/foo.exe")
+}
+
+func TestDeterministicArchiveDist(t *testing.T) {
+	tmp, cleanup := newDistProject(t, map[string]string{
+		"resources/config.yml": "key: value\n",
+	})
+	defer cleanup()
+	// the permissions of input files are normalized in the archive
+	err := os.Chmod(path.Join(tmp, "resources"), 0700)
+	require.NoError(t, err)
+	err = os.Chmod(path.Join(tmp, "resources", "config.yml"), 0600)
+	require.NoError(t, err)
+
+	origEpoch, epochSet := os.LookupEnv("SOURCE_DATE_EPOCH")
+	defer func() {
+		if epochSet {
+			_ = os.Setenv("SOURCE_DATE_EPOCH", origEpoch)
+		} else {
+			_ = os.Unsetenv("SOURCE_DATE_EPOCH")
+		}
+	}()
+	err = os.Setenv("SOURCE_DATE_EPOCH", "1483326245")
+	require.NoError(t, err)
+
+	specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+		Build: params.Build{
+			MainPkg: "./.",
+			OSArchs: []osarch.OSArch{{OS: "linux", Arch: "amd64"}},
+		},
+		Dist: []params.Dist{{
+			Info:       &params.SLSDistInfo{},
+			InputDir:   "resources",
+			ArchiveUID: 1000,
+			ArchiveGID: 100,
+		}},
+	})
+
+	var archives [][]byte
+	for i := 0; i < 2; i++ {
+		err = dist.Run(specWithDeps, ioutil.Discard)
+		require.NoError(t, err)
+		content, err := ioutil.ReadFile(path.Join(tmp, "dist", "foo-0.1.0.sls.tgz"))
+		require.NoError(t, err)
+		archives = append(archives, content)
+	}
+	assert.Equal(t, archives[0], archives[1], "archives of identical distributions differ")
+
+	gr, err := gzip.NewReader(bytes.NewReader(archives[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "", gr.Name)
+	assert.True(t, gr.ModTime.IsZero() || gr.ModTime.Unix() == 0, "gzip header has modification time %v", gr.ModTime)
+
+	headers := readTarGzHeaders(t, archives[0])
+	for name, hdr := range headers {
+		assert.Equal(t, 1000, hdr.Uid, name)
+		assert.Equal(t, 100, hdr.Gid, name)
+		assert.Equal(t, "", hdr.Uname, name)
+		assert.Equal(t, "", hdr.Gname, name)
+		assert.Equal(t, int64(1483326245), hdr.ModTime.Unix(), name)
+	}
+	require.Contains(t, headers, "foo-0.1.0/config.yml")
+	assert.Equal(t, int64(0644), headers["foo-0.1.0/config.yml"].Mode)
+	require.Contains(t, headers, "foo-0.1.0/service/bin/linux-amd64/foo")
+	assert.Equal(t, int64(0755), headers["foo-0.1.0/service/bin/linux-amd64/foo"].Mode)
+	require.Contains(t, headers, "foo-0.1.0/service/")
+	assert.Equal(t, int64(0755), headers["foo-0.1.0/service/"].Mode)
+}
+
+func TestDistPathTemplates(t *testing.T) {
+	tmp, cleanup := newDistProject(t, nil)
+	defer cleanup()
+
+	linuxAMD64 := osarch.OSArch{OS: "linux", Arch: "amd64"}
+	specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+		Build: params.Build{
+			MainPkg:      "./.",
+			ArtifactName: "{{.ProductName}}-{{.ProductVersion}}",
+			OSArchs:      []osarch.OSArch{linuxAMD64},
+		},
+		Dist: []params.Dist{{
+			Info:          &params.BinDistInfo{OmitInitSh: true},
+			OutputPath:    "{{.ProductVersion}}/{{.OSArch.OS}}",
+			ArtifactName:  "{{.ProductName}}-{{.Dist.Type}}-{{.OSArch.Arch}}.tgz",
+			SplitByOSArch: true,
+		}},
+	})
+	err := dist.Run(specWithDeps, ioutil.Discard)
+	require.NoError(t, err)
+
+	artifactPath := path.Join(tmp, "dist", "0.1.0", "linux", "foo-bin-amd64.tgz")
+	assertArtifactPaths(t, []string{artifactPath}, specWithDeps.Spec, specWithDeps.Spec.Dist[0])
+
+	// executable is named after the product in the distribution regardless of its artifact name
+	tgzContent, err := ioutil.ReadFile(artifactPath)
+	require.NoError(t, err)
+	assert.Contains(t, readTarGzHeaders(t, tgzContent), "foo-0.1.0/bin/linux-amd64/foo")
+}
+
+func TestDistPathTemplatesMustBeUniquePerOSArch(t *testing.T) {
+	buildSpec := params.NewProductBuildSpec(
+		"/project",
+		"foo",
+		git.ProjectInfo{
+			Version: "0.1.0",
+		},
+		params.Product{
+			Build: params.Build{
+				MainPkg: "./.",
+				OSArchs: []osarch.OSArch{
+					{OS: "linux", Arch: "amd64"},
+					{OS: "darwin", Arch: "amd64"},
+				},
+			},
+		},
+		params.Project{},
+	)
+	distCfg := params.Dist{
+		Info:          &params.BinDistInfo{},
+		OutputDir:     "dist",
+		ArtifactName:  "{{.ProductName}}-{{.OSArch.Arch}}.tgz",
+		SplitByOSArch: true,
+	}
+	_, err := dist.Artifacts(buildSpec, distCfg)
+	assert.EqualError(t, err, "artifact path of bin distribution of foo is /project/dist/foo-amd64.tgz for both linux-amd64 and darwin-amd64: the output path or artifact name must be different for every OS/Arch")
+}
+
+func TestSignedDist(t *testing.T) {
+	tmp, cleanup := newDistProject(t, nil)
+	defer cleanup()
+
+	keyring, err := ioutil.ReadFile(path.Join("..", "..", "pkg", "signing", "testdata", "rsa.gpg"))
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(tmp, "keyring.gpg"), keyring, 0600)
+	require.NoError(t, err)
+
+	specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+		Build: params.Build{
+			MainPkg: "./.",
+			OSArchs: []osarch.OSArch{{OS: "linux", Arch: "amd64"}},
+		},
+		Dist: []params.Dist{
+			{Info: &params.SLSDistInfo{}},
+			{Info: &params.BinDistInfo{}},
+		},
+		Signing: &params.Signing{
+			OpenPGP: &params.OpenPGPSigning{
+				Keyring: "keyring.gpg",
+			},
+		},
+	})
+	err = dist.Run(specWithDeps, ioutil.Discard)
+	require.NoError(t, err)
+
+	checksumsPath := path.Join(tmp, "dist", "foo-0.1.0-SHA256SUMS")
+	assert.Equal(t, checksumsPath, dist.ChecksumsPath(specWithDeps.Spec))
+
+	checksums, err := ioutil.ReadFile(checksumsPath)
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile("^[0-9a-f]{64}  foo-0\\.1\\.0\\.sls\\.tgz\n[0-9a-f]{64}  foo-0\\.1\\.0\\.tgz\n$"), string(checksums))
+
+	signingPaths, err := dist.SigningPaths(specWithDeps.Spec, specWithDeps.Spec.Dist[1])
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		path.Join(tmp, "dist", "foo-0.1.0.tgz.asc"),
+		checksumsPath,
+		checksumsPath + ".asc",
+	}, signingPaths)
+
+	for _, currPath := range []string{
+		path.Join(tmp, "dist", "foo-0.1.0.sls.tgz.asc"),
+		path.Join(tmp, "dist", "foo-0.1.0.tgz.asc"),
+		checksumsPath + ".asc",
+	} {
+		content, err := ioutil.ReadFile(currPath)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(content), "-----BEGIN PGP SIGNATURE-----\n"), "unexpected content of %s:\n%s", currPath, string(content))
+	}
+
+	// artifacts are not signed again if none of them have changed
+	buf := &bytes.Buffer{}
+	err = dist.Run(specWithDeps, buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Signatures for foo are up-to-date\n")
+
+	// artifacts are signed again if a signature is missing or if distributions are forced
+	err = os.Remove(path.Join(tmp, "dist", "foo-0.1.0.tgz.asc"))
+	require.NoError(t, err)
+	buf = &bytes.Buffer{}
+	err = dist.Run(specWithDeps, buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "Signatures for foo are up-to-date\n")
+	_, err = os.Stat(path.Join(tmp, "dist", "foo-0.1.0.tgz.asc"))
+	assert.NoError(t, err)
+
+	buf = &bytes.Buffer{}
+	err = dist.ForceRun(specWithDeps, buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "Signatures for foo are up-to-date\n")
+}
+
+func TestSBOMDist(t *testing.T) {
+	tmp, cleanup := newDistProject(t, nil)
+	defer cleanup()
+
+	specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+		Build: params.Build{
+			MainPkg: "./.",
+			OSArchs: []osarch.OSArch{{OS: "linux", Arch: "amd64"}},
+		},
+		Dist: []params.Dist{
+			{
+				Info: &params.SLSDistInfo{},
+				SBOM: &params.SBOM{Dir: "deployment"},
+			},
+			{
+				Info: &params.BinDistInfo{},
+				SBOM: &params.SBOM{Formats: []params.SBOMFormat{params.CycloneDXSBOMFormat}},
+			},
+		},
+	})
+	err := dist.Run(specWithDeps, ioutil.Discard)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		path.Join(tmp, "dist", "foo-0.1.0.spdx.json"),
+		path.Join(tmp, "dist", "foo-0.1.0.cdx.json"),
+	}, dist.SBOMPaths(specWithDeps.Spec, specWithDeps.Spec.Dist[0]))
+
+	spdxContent, err := ioutil.ReadFile(path.Join(tmp, "dist", "foo-0.1.0.spdx.json"))
+	require.NoError(t, err)
+	var spdxDoc struct {
+		Name     string `json:"name"`
+		Packages []struct {
+			Name        string `json:"name"`
+			VersionInfo string `json:"versionInfo"`
+		} `json:"packages"`
+	}
+	err = json.Unmarshal(spdxContent, &spdxDoc)
+	require.NoError(t, err)
+	assert.Equal(t, "foo-0.1.0", spdxDoc.Name)
+	// main package only imports the standard library
+	require.Len(t, spdxDoc.Packages, 1)
+	assert.Equal(t, "foo", spdxDoc.Packages[0].Name)
+	assert.Equal(t, "0.1.0", spdxDoc.Packages[0].VersionInfo)
+
+	slsContent, err := ioutil.ReadFile(path.Join(tmp, "dist", "foo-0.1.0.sls.tgz"))
+	require.NoError(t, err)
+	slsHeaders := readTarGzHeaders(t, slsContent)
+	assert.Contains(t, slsHeaders, "foo-0.1.0/deployment/foo-0.1.0.spdx.json")
+	assert.Contains(t, slsHeaders, "foo-0.1.0/deployment/foo-0.1.0.cdx.json")
+
+	binContent, err := ioutil.ReadFile(path.Join(tmp, "dist", "foo-0.1.0.tgz"))
+	require.NoError(t, err)
+	binHeaders := readTarGzHeaders(t, binContent)
+	assert.Contains(t, binHeaders, "foo-0.1.0/foo-0.1.0.cdx.json")
+	assert.NotContains(t, binHeaders, "foo-0.1.0/foo-0.1.0.spdx.json")
+}
+
+func TestLicensesDist(t *testing.T) {
+	tmp, cleanup := newDistProject(t, nil)
+	defer cleanup()
+
+	specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+		Build: params.Build{
+			MainPkg: "./.",
+			OSArchs: []osarch.OSArch{{OS: "linux", Arch: "amd64"}},
+		},
+		Dist: []params.Dist{
+			{
+				Info:     &params.SLSDistInfo{},
+				Licenses: &params.Licenses{Deny: []string{"copyleft"}},
+			},
+		},
+	})
+	err := dist.Run(specWithDeps, ioutil.Discard)
+	require.NoError(t, err)
+
+	notices, err := ioutil.ReadFile(path.Join(tmp, "dist", "foo-0.1.0", dist.NoticesFileName))
+	require.NoError(t, err)
+	// main package only imports the standard library
+	assert.Equal(t, "THIRD-PARTY SOFTWARE NOTICES\n\nfoo 0.1.0 does not include any third-party software.\n", string(notices))
+
+	slsContent, err := ioutil.ReadFile(path.Join(tmp, "dist", "foo-0.1.0.sls.tgz"))
+	require.NoError(t, err)
+	assert.Contains(t, readTarGzHeaders(t, slsContent), "foo-0.1.0/"+dist.NoticesFileName)
+}
+
+func TestTemplatesDist(t *testing.T) {
+	for i, currCase := range []struct {
+		templates map[string]string
+		want      map[string]string
+		wantError string
+	}{
+		{
+			templates: map[string]string{
+				"config.yml":           "version: {{.ProductVersion}}\n",
+				"deployment/unit.tmpl": "[Service]\nExecStart=/opt/{{.ProductName}}/bin/{{.ProductName}}\n# {{.Publish.GroupID}}\n",
+				"static.txt":           "{{not rendered}}\n",
+			},
+			want: map[string]string{
+				"config.yml":           "version: 0.1.0\n",
+				"deployment/unit.tmpl": "[Service]\nExecStart=/opt/foo/bin/foo\n# com.test.group\n",
+				"static.txt":           "{{not rendered}}\n",
+			},
+		},
+		{
+			templates: map[string]string{
+				"config.yml": "product: {{.ProductName}}\nversion: {{.ProductVersion}\n",
+			},
+			wantError: `failed to parse template input/config.yml: template: config.yml:2:`,
+		},
+		{
+			templates: map[string]string{
+				"config.yml": "product: {{.ProductName}}\nrelease: {{.Release}}\n",
+			},
+			wantError: `failed to render template input/config.yml: template: config.yml:2:11: executing "config.yml" at <.Release>`,
+		},
+	} {
+		files := make(map[string]string)
+		for k, v := range currCase.templates {
+			files[path.Join("input", k)] = v
+		}
+		tmp, cleanup := newDistProject(t, files)
+		defer cleanup()
+
+		specWithDeps := buildDistSpec(t, tmp, "foo", params.Product{
+			Build: params.Build{
+				MainPkg: "./.",
+				OSArchs: []osarch.OSArch{{OS: "linux", Arch: "amd64"}},
+			},
+			Dist: []params.Dist{
+				{
+					Info:      &params.BinDistInfo{},
+					InputDir:  "input",
+					Templates: []string{"*.yml", "deployment/*.tmpl"},
+					Publish: params.Publish{
+						GroupID: "com.test.group",
+					},
+				},
+			},
+		})
+		err := dist.Run(specWithDeps, ioutil.Discard)
+		if currCase.wantError != "" {
+			require.Error(t, err, "Case %d", i)
+			assert.Contains(t, strings.Replace(err.Error(), tmp+"/", "", -1), currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+
+		for k, v := range currCase.want {
+			content, err := ioutil.ReadFile(path.Join(tmp, "dist", "foo-0.1.0", k))
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, v, string(content), "Case %d: %s", i, k)
+		}
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	tmp, cleanup := newDistProject(t, map[string]string{
+		"foo/main.go": testMain,
+		"bar/main.go": testMain,
+		"baz/main.go": testMain,
+	})
+	defer cleanup()
+
+	products := map[string][]params.Dist{
+		"foo": {
+			{
+				Info:   &params.SLSDistInfo{},
+				Script: "echo foo sls script; echo foo sls error >&2",
+			},
+			{
+				OutputDir: "dist-bin",
+				Info:      &params.BinDistInfo{},
+				Script:    "echo foo bin script; echo foo bin error >&2",
+			},
+		},
+		"bar": {
+			{
+				Info:   &params.BinDistInfo{},
+				Script: "echo bar bin script; echo bar bin error >&2",
+			},
+		},
+		"baz": {
+			{
+				Info:   &params.BinDistInfo{},
+				Script: "echo baz bin script; exit 1",
+			},
+		},
+	}
+	var specs []params.ProductBuildSpecWithDeps
+	for _, currProduct := range []string{"bar", "baz", "foo"} {
+		specWithDeps := buildDistSpec(t, tmp, currProduct, params.Product{
+			Build: params.Build{
+				MainPkg: "./" + currProduct,
+				OSArchs: []osarch.OSArch{{OS: "linux", Arch: "amd64"}},
+			},
+			Dist: products[currProduct],
+		})
+		specs = append(specs, specWithDeps)
+	}
+
+	// the error output of dist scripts is written to os.Stderr
+	stderrFile, err := os.Create(path.Join(tmp, "stderr"))
+	require.NoError(t, err)
+	defer func(stderr *os.File) {
+		os.Stderr = stderr
+	}(os.Stderr)
+	os.Stderr = stderrFile
+
+	buf := &bytes.Buffer{}
+	err = dist.RunParallel(specs, 2, false, buf)
+	require.Error(t, err)
+	specErrors, ok := err.(*cmd.SpecErrors)
+	require.True(t, ok, "unexpected error type: %T", err)
+	require.Len(t, specErrors.Errors, 1)
+	assert.EqualError(t, specErrors.Errors["baz"], "failed to execute dist script for baz: Dist script for baz failed: exit status 1")
+
+	for _, currPath := range []string{
+		"dist/foo-0.1.0.sls.tgz",
+		"dist-bin/foo-0.1.0.tgz",
+		"dist/bar-0.1.0.tgz",
+	} {
+		_, err := os.Stat(path.Join(tmp, currPath))
+		assert.NoError(t, err, "%s was not created", currPath)
+	}
+
+	// the lines of the output of each product are contiguous
+	var outputProducts []string
+	for _, currLine := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		for _, currProduct := range []string{"foo", "bar", "baz"} {
+			if strings.Contains(currLine, currProduct) && (len(outputProducts) == 0 || outputProducts[len(outputProducts)-1] != currProduct) {
+				assert.NotContains(t, outputProducts, currProduct, "output of %s is not grouped:\n%s", currProduct, buf.String())
+				outputProducts = append(outputProducts, currProduct)
+			}
+		}
+	}
+	assert.Len(t, outputProducts, 3, "output:\n%s", buf.String())
+	assert.Contains(t, buf.String(), "foo sls script\n")
+	assert.Contains(t, buf.String(), "foo bin script\n")
+	assert.Contains(t, buf.String(), "baz bin script\n")
+
+	// the error output of each product is contiguous
+	err = stderrFile.Close()
+	require.NoError(t, err)
+	stderr, err := ioutil.ReadFile(path.Join(tmp, "stderr"))
+	require.NoError(t, err)
+	assert.Contains(t, string(stderr), "foo sls error\nfoo bin error\n")
+	assert.Contains(t, string(stderr), "bar bin error\n")
+}
+
+func TestIncrementalDist(t *testing.T) {
+	tmp, cleanup := newDistProject(t, map[string]string{
+		"input/config.yml": "key: value\n",
+	})
+	defer cleanup()
+
+	newSpec := func(script string) params.ProductBuildSpecWithDeps {
+		return buildDistSpec(t, tmp, "foo", params.Product{
+			Build: params.Build{
+				MainPkg: "./.",
+				OSArchs: []osarch.OSArch{{OS: "linux", Arch: "amd64"}},
+			},
+			Dist: []params.Dist{
+				{
+					Info:     &params.BinDistInfo{},
+					InputDir: "input",
+					Script:   script,
+				},
+			},
+		})
+	}
+	specWithDeps := newSpec("")
+
+	artifactPath := path.Join(tmp, "dist", "foo-0.1.0.tgz")
+	for i, currCase := range []struct {
+		name        string
+		setup       func() params.ProductBuildSpecWithDeps
+		runFunc     func(params.ProductBuildSpecWithDeps, io.Writer) error
+		wantCreated bool
+	}{
+		{
+			name:        "first run creates distribution",
+			wantCreated: true,
+		},
+		{
+			name: "unchanged distribution is skipped",
+		},
+		{
+			name:        "force creates distribution",
+			runFunc:     dist.ForceRun,
+			wantCreated: true,
+		},
+		{
+			name: "change to input directory creates distribution",
+			setup: func() params.ProductBuildSpecWithDeps {
+				err := ioutil.WriteFile(path.Join(tmp, "input", "config.yml"), []byte("key: other\n"), 0644)
+				require.NoError(t, err)
+				return specWithDeps
+			},
+			wantCreated: true,
+		},
+		{
+			name: "change to dist script creates distribution",
+			setup: func() params.ProductBuildSpecWithDeps {
+				return newSpec("touch $DIST_DIR/file.txt")
+			},
+			wantCreated: true,
+		},
+		{
+			name: "unchanged distribution with script is skipped",
+			setup: func() params.ProductBuildSpecWithDeps {
+				return newSpec("touch $DIST_DIR/file.txt")
+			},
+		},
+		{
+			name: "modified artifact creates distribution",
+			setup: func() params.ProductBuildSpecWithDeps {
+				err := ioutil.WriteFile(artifactPath, []byte("modified"), 0644)
+				require.NoError(t, err)
+				return newSpec("touch $DIST_DIR/file.txt")
+			},
+			wantCreated: true,
+		},
+	} {
+		currSpec := specWithDeps
+		if currCase.setup != nil {
+			currSpec = currCase.setup()
+		}
+		runFunc := dist.Run
+		if currCase.runFunc != nil {
+			runFunc = currCase.runFunc
+		}
+		buf := &bytes.Buffer{}
+		err := runFunc(currSpec, buf)
+		require.NoError(t, err, "Case %d: %s", i, currCase.name)
+
+		if currCase.wantCreated {
+			assert.Contains(t, buf.String(), "Creating distribution for foo", "Case %d: %s", i, currCase.name)
+		} else {
+			assert.Equal(t, fmt.Sprintf("Distribution for foo at %s is up-to-date\n", artifactPath), buf.String(), "Case %d: %s", i, currCase.name)
+		}
+
+		m, err := manifest.Read(build.ManifestPath(currSpec.Spec))
+		require.NoError(t, err, "Case %d: %s", i, currCase.name)
+		var fingerprint string
+		for _, currEntry := range m.Artifacts {
+			if currEntry.Path == "dist/foo-0.1.0.tgz" {
+				fingerprint = currEntry.Fingerprint
+			}
+		}
+		assert.NotEmpty(t, fingerprint, "Case %d: %s", i, currCase.name)
+	}
+}
+
+func TestCheckLicenses(t *testing.T) {
+	pkgs := []sbom.Package{
+		{ImportPath: "github.com/org/apache", License: "Apache-2.0", LicensePath: "/vendor/github.com/org/apache/LICENSE"},
+		{ImportPath: "github.com/org/gpl", License: "GPL-3.0", LicensePath: "/vendor/github.com/org/gpl/COPYING"},
+		{ImportPath: "github.com/org/none"},
+		{ImportPath: "github.com/org/other", LicensePath: "/vendor/github.com/org/other/LICENSE"},
+	}
+	for i, currCase := range []struct {
+		deny    []string
+		wantErr string
+	}{
+		{
+			deny: nil,
+			wantErr: "third-party packages have missing or denied licenses:\n" +
+				"\tgithub.com/org/none: no license file found",
+		},
+		{
+			deny: []string{"GPL-3.0", "unknown"},
+			wantErr: "third-party packages have missing or denied licenses:\n" +
+				"\tgithub.com/org/gpl: license GPL-3.0 (copyleft) in /vendor/github.com/org/gpl/COPYING is denied\n" +
+				"\tgithub.com/org/none: no license file found\n" +
+				"\tgithub.com/org/other: license unknown (unknown) in /vendor/github.com/org/other/LICENSE is denied",
+		},
+		{
+			deny: []string{"permissive"},
+			wantErr: "third-party packages have missing or denied licenses:\n" +
+				"\tgithub.com/org/apache: license Apache-2.0 (permissive) in /vendor/github.com/org/apache/LICENSE is denied\n" +
+				"\tgithub.com/org/none: no license file found",
+		},
+	} {
+		err := dist.CheckLicenses(pkgs, params.Licenses{Deny: currCase.deny})
+		require.Error(t, err, "Case %d", i)
+		assert.EqualError(t, err, currCase.wantErr, "Case %d", i)
+	}
+
+	err := dist.CheckLicenses(pkgs[:2], params.Licenses{Deny: []string{"AGPL-3.0", "weak-copyleft"}})
+	assert.NoError(t, err)
+}
+
+func TestWriteNotices(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for k, v := range map[string]string{
+		"bar/LICENSE": "Apache License\nVersion 2.0, January 2004\n",
+		"bar/NOTICE":  "bar\nCopyright 2016 Org\n",
+		"foo/LICENSE": "MIT License\n",
+	} {
+		err := os.MkdirAll(path.Join(tmp, path.Dir(k)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, k), []byte(v), 0644)
+		require.NoError(t, err)
+	}
+
+	buf := &bytes.Buffer{}
+	err = dist.WriteNotices(buf, sbom.Document{
+		Name:    "product",
+		Version: "1.0.0",
+		Packages: []sbom.Package{
+			{ImportPath: "github.com/org/bar/baz", Version: "v0.1.0", License: "Apache-2.0", LicensePath: path.Join(tmp, "bar/LICENSE"), NoticePath: path.Join(tmp, "bar/NOTICE")},
+			{ImportPath: "github.com/org/bar/qux", Version: "v0.1.0", License: "Apache-2.0", LicensePath: path.Join(tmp, "bar/LICENSE"), NoticePath: path.Join(tmp, "bar/NOTICE")},
+			{ImportPath: "github.com/org/foo", License: "MIT", LicensePath: path.Join(tmp, "foo/LICENSE")},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `THIRD-PARTY SOFTWARE NOTICES
+
+product 1.0.0 includes the following third-party software:
+
+  github.com/org/bar/baz (Apache-2.0)
+  github.com/org/bar/qux (Apache-2.0)
+  github.com/org/foo (MIT)
+
+================================================================================
+github.com/org/bar/baz
+github.com/org/bar/qux
+Version: v0.1.0
+License: Apache-2.0 (permissive)
+--------------------------------------------------------------------------------
+Apache License
+Version 2.0, January 2004
+--------------------------------------------------------------------------------
+bar
+Copyright 2016 Org
+
+================================================================================
+github.com/org/foo
+License: MIT (permissive)
+--------------------------------------------------------------------------------
+MIT License
+`, buf.String())
+}
+
+// assertArtifactPaths asserts that the paths of the artifacts of the provided distribution are the provided paths.
+// newDistProject creates a git project in a new temporary directory that contains the main package of testMain at its
+// root and the provided files, whose keys are slash-separated paths relative to the project directory. Keys that end in
+// "/" are created as empty directories. Returns the project directory and a function that removes it.
+func newDistProject(t *testing.T, files map[string]string) (string, func()) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	require.NoError(t, err)
+
+	gittest.InitGitDir(t, tmp)
+	err = ioutil.WriteFile(path.Join(tmp, "main.go"), []byte(testMain), 0644)
+	require.NoError(t, err)
+	for file, content := range files {
+		if strings.HasSuffix(file, "/") {
+			err := os.MkdirAll(path.Join(tmp, file), 0755)
+			require.NoError(t, err)
+			continue
+		}
+		err := os.MkdirAll(path.Join(tmp, path.Dir(file)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, file), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+	gittest.CommitAllFiles(t, tmp, "Commit")
+	gittest.CreateGitTag(t, tmp, "0.1.0")
+	return tmp, cleanup
+}
+
+// buildDistSpec returns the spec of version 0.1.0 of the provided product in the provided project directory and builds
+// the executables of the product.
+func buildDistSpec(t *testing.T, projectDir, productName string, product params.Product) params.ProductBuildSpecWithDeps {
+	specWithDeps, err := params.NewProductBuildSpecWithDeps(params.NewProductBuildSpec(
+		projectDir,
+		productName,
+		git.ProjectInfo{
+			Version: "0.1.0",
+		},
+		product,
+		params.Project{
+			GroupID: "com.test.group",
+		},
+	), nil)
+	require.NoError(t, err)
+
+	err = build.Run(build.RequiresBuild(specWithDeps, nil).Specs(), nil, build.Context{}, ioutil.Discard)
+	require.NoError(t, err)
+	return specWithDeps
+}
+
+func assertArtifactPaths(t *testing.T, want []string, buildSpec params.ProductBuildSpec, distCfg params.Dist) {
+	got, err := dist.ArtifactPaths(buildSpec, distCfg)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+// readArMembers returns the content of the members of the ar archive at the provided path.
+func readArMembers(t *testing.T, archivePath string) map[string][]byte {
+	content, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(content), "!<arch>\n"))
+
+	members := make(map[string][]byte)
+	for offset := len("!<arch>\n"); offset < len(content); {
+		header := string(content[offset : offset+60])
+		size, err := strconv.Atoi(strings.TrimSpace(header[48:58]))
+		require.NoError(t, err)
+		offset += 60
+		members[strings.TrimSpace(header[:16])] = content[offset : offset+size]
+		offset += size + size%2
+	}
+	return members
+}
+
+// readTarGzHeaders returns the headers of the entries of the provided gzip-compressed tar archive keyed by name.
+func readTarGzHeaders(t *testing.T, archive []byte) map[string]*tar.Header {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	headers := make(map[string]*tar.Header)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		headers[hdr.Name] = hdr
+	}
+	return headers
+}
+
+func readTarFiles(t *testing.T, archivePath string) map[string][]byte {
+	f, err := os.Open(archivePath)
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = content
+	}
+	return files
+}
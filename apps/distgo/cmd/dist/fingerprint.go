@@ -0,0 +1,180 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/cmd"
+	"github.com/palantir/godel/apps/distgo/cmd/build"
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/manifest"
+)
+
+// fingerprint returns the fingerprint of the provided distribution of the product. The fingerprint is the SHA-256 hash
+// of the inputs of the distribution: the name and version information of the product, the configuration of the
+// distribution, the SHA-256 hashes of the executables of the product and of its dependent products, the paths, modes
+// and contents of the files in the input directory, the rendered dist script and its environment variables, the
+// modification time used for the entries of archives and, if the distribution writes SBOMs or third-party notices, the
+// third-party packages of the product. Returns an error if any of these inputs cannot be determined.
+func (d *productDist) fingerprint(distCfg params.Dist) (string, error) {
+	buildSpecWithDeps := d.buildSpecWithDeps
+	buildSpec := buildSpecWithDeps.Spec
+
+	distCfgJSON, err := json.Marshal(distCfg)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal dist configuration")
+	}
+
+	var binaries []string
+	for _, currSpec := range buildSpecWithDeps.AllSpecs() {
+		paths, err := build.ArtifactPaths(currSpec)
+		if err != nil {
+			return "", err
+		}
+		for _, currOSArch := range currSpec.Build.OSArchs {
+			digest, err := build.FileDigest(paths[currOSArch])
+			if err != nil {
+				return "", err
+			}
+			binaries = append(binaries, fmt.Sprintf("%s %s %s", currSpec.ProductName, currOSArch, digest))
+		}
+	}
+
+	var inputFiles []string
+	if distCfg.InputDir != "" {
+		if inputFiles, err = inputDirFingerprint(filepath.Join(buildSpec.ProjectDir, distCfg.InputDir)); err != nil {
+			return "", err
+		}
+	}
+
+	var scriptEnv []string
+	for k, v := range cmd.ScriptEnvVariables(buildSpec, distDir(buildSpec, distCfg)) {
+		scriptEnv = append(scriptEnv, k+"="+v)
+	}
+	sort.Strings(scriptEnv)
+
+	modTime, err := archiveModTime(buildSpec.ProjectDir)
+	if err != nil {
+		return "", err
+	}
+
+	var pkgsJSON []byte
+	if distCfg.SBOM != nil || distCfg.Licenses != nil {
+		doc, err := d.sbom()
+		if err != nil {
+			return "", err
+		}
+		if pkgsJSON, err = json.Marshal(doc.Packages); err != nil {
+			return "", errors.Wrapf(err, "failed to marshal packages")
+		}
+	}
+
+	h := sha256.New()
+	for _, part := range [][]string{
+		{"product", buildSpec.ProductName, buildSpec.ProductVersion},
+		{"version-info", buildSpec.VersionInfo.Version, buildSpec.VersionInfo.Branch, buildSpec.VersionInfo.Revision},
+		{"dist", string(distCfg.Info.Type()), string(distCfgJSON)},
+		append([]string{"binaries"}, binaries...),
+		append([]string{"input-dir"}, inputFiles...),
+		{"script", distCfg.Script},
+		append([]string{"script-env"}, scriptEnv...),
+		{"mod-time", modTime.UTC().String()},
+		{"packages", string(pkgsJSON)},
+	} {
+		if _, err := fmt.Fprintf(h, "%q\n", part); err != nil {
+			return "", errors.Wrapf(err, "failed to compute fingerprint")
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// inputDirFingerprint returns an entry for every file in the provided input directory (in lexical order) that consists
+// of the path of the file relative to the directory, its mode and the SHA-256 hash of its content (or the target of
+// the link for symbolic links).
+func inputDirFingerprint(inputDir string) ([]string, error) {
+	var entries []string
+	if err := filepath.Walk(inputDir, func(currPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "failed to walk %s", currPath)
+		}
+		relPath, err := filepath.Rel(inputDir, currPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine path of %s relative to %s", currPath, inputDir)
+		}
+		var content string
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			if content, err = os.Readlink(currPath); err != nil {
+				return errors.Wrapf(err, "failed to read link %s", currPath)
+			}
+		case info.Mode().IsRegular():
+			if content, err = build.FileDigest(currPath); err != nil {
+				return err
+			}
+		}
+		entries = append(entries, fmt.Sprintf("%s %v %s", filepath.ToSlash(relPath), info.Mode(), content))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// upToDate returns true if every artifact of the provided distribution is recorded in the build manifest of the
+// product with the provided fingerprint and still has the content recorded in the manifest, and if every SBOM of the
+// distribution exists.
+func upToDate(buildSpec params.ProductBuildSpec, distCfg params.Dist, fingerprint string) bool {
+	m, err := manifest.Read(build.ManifestPath(buildSpec))
+	if err != nil {
+		return false
+	}
+	entries := make(map[string]manifest.Artifact)
+	for _, currEntry := range m.Artifacts {
+		entries[currEntry.Path] = currEntry
+	}
+
+	artifacts, err := Artifacts(buildSpec, distCfg)
+	if err != nil {
+		return false
+	}
+	for _, currArtifact := range artifacts {
+		relPath, err := filepath.Rel(buildSpec.ProjectDir, currArtifact.Path)
+		if err != nil {
+			return false
+		}
+		entry, ok := entries[relPath]
+		if !ok || entry.Fingerprint != fingerprint {
+			return false
+		}
+		if digest, err := build.FileDigest(currArtifact.Path); err != nil || digest != entry.SHA256 {
+			return false
+		}
+	}
+	for _, currPath := range SBOMPaths(buildSpec, distCfg) {
+		if _, err := os.Stat(currPath); err != nil {
+			return false
+		}
+	}
+	return true
+}
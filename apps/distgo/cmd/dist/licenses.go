@@ -0,0 +1,167 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/license"
+	"github.com/palantir/godel/apps/distgo/pkg/sbom"
+)
+
+// NoticesFileName is the name of the file in the root of the distribution directory that contains the license and
+// NOTICE files of the third-party packages linked into the product.
+const NoticesFileName = "THIRD_PARTY_NOTICES"
+
+const (
+	noticesSectionSeparator = "================================================================================"
+	noticesFileSeparator    = "--------------------------------------------------------------------------------"
+)
+
+// CheckLicenses returns an error that lists every provided package that does not have a license file or that has a
+// license that is denied by the provided configuration. A license is denied if its SPDX identifier or its category is
+// one of the entries of the deny list. Licenses that are not recognized have the category license.Unknown.
+func CheckLicenses(pkgs []sbom.Package, licensesCfg params.Licenses) error {
+	denied := make(map[string]bool)
+	for _, currDeny := range licensesCfg.Deny {
+		denied[currDeny] = true
+	}
+	var problems []string
+	for _, currPkg := range pkgs {
+		category := license.CategoryOf(currPkg.License)
+		switch {
+		case currPkg.LicensePath == "":
+			problems = append(problems, fmt.Sprintf("%s: no license file found", currPkg.ImportPath))
+		case denied[currPkg.License] || denied[string(category)]:
+			problems = append(problems, fmt.Sprintf("%s: license %s (%s) in %s is denied", currPkg.ImportPath, licenseName(currPkg.License), category, currPkg.LicensePath))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("third-party packages have missing or denied licenses:\n\t%s", strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+// WriteNotices writes the third-party notices for the provided SBOM to w. The notices start with a summary of the
+// packages and their licenses followed by a section for every distinct license file that contains the text of the
+// license file and of the NOTICE file of the packages that share it (typically the packages of a single repository).
+// Packages that do not have a license file are omitted.
+func WriteNotices(w io.Writer, doc sbom.Document) error {
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "THIRD-PARTY SOFTWARE NOTICES")
+	fmt.Fprintln(buf)
+	if len(doc.Packages) == 0 {
+		fmt.Fprintf(buf, "%s %s does not include any third-party software.\n", doc.Name, doc.Version)
+		_, err := buf.WriteTo(w)
+		return err
+	}
+	fmt.Fprintf(buf, "%s %s includes the following third-party software:\n\n", doc.Name, doc.Version)
+	for _, currPkg := range doc.Packages {
+		fmt.Fprintf(buf, "  %s (%s)\n", currPkg.ImportPath, licenseName(currPkg.License))
+	}
+
+	for _, currSection := range noticesSections(doc.Packages) {
+		first := currSection[0]
+		fmt.Fprintf(buf, "\n%s\n", noticesSectionSeparator)
+		for _, currPkg := range currSection {
+			fmt.Fprintln(buf, currPkg.ImportPath)
+		}
+		if first.Version != "" {
+			fmt.Fprintf(buf, "Version: %s\n", first.Version)
+		}
+		fmt.Fprintf(buf, "License: %s (%s)\n", licenseName(first.License), license.CategoryOf(first.License))
+		for _, currPath := range []string{first.LicensePath, first.NoticePath} {
+			if currPath == "" {
+				continue
+			}
+			content, err := ioutil.ReadFile(currPath)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read %s", currPath)
+			}
+			fmt.Fprintln(buf, noticesFileSeparator)
+			fmt.Fprintln(buf, strings.TrimRight(string(content), "\n"))
+		}
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// noticesSections groups the provided packages that have a license file by license file. Sections are ordered by the
+// import path of their first package.
+func noticesSections(pkgs []sbom.Package) [][]sbom.Package {
+	var licensePaths []string
+	sections := make(map[string][]sbom.Package)
+	for _, currPkg := range pkgs {
+		if currPkg.LicensePath == "" {
+			continue
+		}
+		if _, ok := sections[currPkg.LicensePath]; !ok {
+			licensePaths = append(licensePaths, currPkg.LicensePath)
+		}
+		sections[currPkg.LicensePath] = append(sections[currPkg.LicensePath], currPkg)
+	}
+	sort.Sort(byFirstImportPath{paths: licensePaths, sections: sections})
+	var sorted [][]sbom.Package
+	for _, currPath := range licensePaths {
+		sorted = append(sorted, sections[currPath])
+	}
+	return sorted
+}
+
+type byFirstImportPath struct {
+	paths    []string
+	sections map[string][]sbom.Package
+}
+
+func (a byFirstImportPath) Len() int      { return len(a.paths) }
+func (a byFirstImportPath) Swap(i, j int) { a.paths[i], a.paths[j] = a.paths[j], a.paths[i] }
+func (a byFirstImportPath) Less(i, j int) bool {
+	return a.sections[a.paths[i]][0].ImportPath < a.sections[a.paths[j]][0].ImportPath
+}
+
+// licenseName returns the SPDX identifier of a license or "unknown" if it is blank.
+func licenseName(id string) string {
+	if id == "" {
+		return string(license.Unknown)
+	}
+	return id
+}
+
+// writeNotices checks the licenses of the packages in the provided SBOM and writes the third-party notices file to the
+// root of the distribution directory.
+func writeNotices(buildSpec params.ProductBuildSpec, distCfg params.Dist, doc sbom.Document, outputProductDir string, stdout io.Writer) error {
+	if err := CheckLicenses(doc.Packages, *distCfg.Licenses); err != nil {
+		return err
+	}
+	noticesPath := path.Join(outputProductDir, NoticesFileName)
+	fmt.Fprintf(stdout, "Writing third-party notices for %v to %v\n", buildSpec.ProductName, noticesPath)
+	buf := &bytes.Buffer{}
+	if err := WriteNotices(buf, doc); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(noticesPath, buf.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", noticesPath)
+	}
+	return nil
+}
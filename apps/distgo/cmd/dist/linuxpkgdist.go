@@ -0,0 +1,166 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"io"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/cmd/build"
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/linuxpkg"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+)
+
+// linuxPackageOSArchs returns the linux OS/Archs of the provided spec in the order in which they are declared. An RPM
+// or Debian package is created for each of them. Returns an error if the product is not built for any linux OS/Arch.
+func linuxPackageOSArchs(buildSpec params.ProductBuildSpec, distType params.DistInfoType) ([]osarch.OSArch, error) {
+	var linuxOSArchs []osarch.OSArch
+	for _, currOSArch := range buildSpec.Build.OSArchs {
+		if currOSArch.OS == "linux" {
+			linuxOSArchs = append(linuxOSArchs, currOSArch)
+		}
+	}
+	if len(linuxOSArchs) == 0 {
+		return nil, errors.Errorf("%s distribution requires the product to be built for a linux OS/Arch, but %s is built for %v", distType, buildSpec.ProductName, buildSpec.Build.OSArchs)
+	}
+	return linuxOSArchs, nil
+}
+
+// linuxPackageArch returns the name of the architecture of the package for the provided OS/Arch as converted by
+// toArch. Returns the GOARCH of the OS/Arch if it cannot be converted.
+func linuxPackageArch(osArch osarch.OSArch, toArch func(goarch string) (string, error)) string {
+	arch, err := toArch(osArch.Arch)
+	if err != nil {
+		return osArch.Arch
+	}
+	return arch
+}
+
+// validateLinuxPackageArchs returns an error if the architecture of any of the packages of the provided spec cannot
+// be converted by toArch.
+func validateLinuxPackageArchs(buildSpec params.ProductBuildSpec, distType params.DistInfoType, toArch func(goarch string) (string, error)) error {
+	osArchs, err := linuxPackageOSArchs(buildSpec, distType)
+	if err != nil {
+		return err
+	}
+	for _, currOSArch := range osArchs {
+		if _, err := toArch(currOSArch.Arch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linuxPackageFiles contains the configuration for the files of an RPM or Debian package.
+type linuxPackageFiles struct {
+	files       map[string]params.PackageFile
+	configFiles []string
+	binDir      string
+}
+
+// linuxPackager returns a packager that writes a package for every artifact of the provided distribution using the
+// provided write function. The files of each package are the content of the output directory (which is the root of the
+// package) at the time the packager is run and, if a bin directory is configured, the executables of the OS/Arch of the
+// artifact. The architecture of each package is the architecture of its OS/Arch.
+func linuxPackager(buildSpec params.ProductBuildSpec, distCfg params.Dist, outputProductDir string, pkg linuxpkg.Package, pkgFiles linuxPackageFiles, write func(io.Writer, linuxpkg.Package) error) packager {
+	return packager(func() error {
+		attrs := make(map[string]linuxpkg.FileAttributes, len(pkgFiles.files))
+		for k, v := range pkgFiles.files {
+			attrs[k] = linuxpkg.FileAttributes{
+				Owner: v.Owner,
+				Group: v.Group,
+				Mode:  v.Mode,
+			}
+		}
+		files, err := linuxpkg.FilesInDir(outputProductDir, attrs, pkgFiles.configFiles)
+		if err != nil {
+			return err
+		}
+		outputPaths, err := build.OutputPaths(buildSpec)
+		if err != nil {
+			return err
+		}
+		artifacts, err := Artifacts(buildSpec, distCfg)
+		if err != nil {
+			return err
+		}
+		buildTime, err := archiveModTime(buildSpec.ProjectDir)
+		if err != nil {
+			return err
+		}
+		for _, currArtifact := range artifacts {
+			osArch := currArtifact.OSArchs[0]
+			currPkg := pkg
+			currPkg.Arch = osArch.Arch
+			currPkg.BuildTime = buildTime
+			currPkg.Files = append(append([]linuxpkg.File(nil), files...), linuxPackageExecutables(buildSpec, osArch, outputPaths[osArch], pkgFiles.binDir, attrs, pkgFiles.configFiles)...)
+			if err := writeLinuxPackage(currArtifact.Path, currPkg, write); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// linuxPackageExecutables returns the files for the provided build outputs of the executable of the product for the
+// provided OS/Arch, which are installed in binDir. Returns nil if binDir is blank or if the build mode of the product
+// does not create executables.
+func linuxPackageExecutables(buildSpec params.ProductBuildSpec, osArch osarch.OSArch, outputPaths []string, binDir string, attrs map[string]linuxpkg.FileAttributes, configFiles []string) []linuxpkg.File {
+	if binDir == "" {
+		return nil
+	}
+	switch buildSpec.Build.BuildMode {
+	case "", params.BuildModeExe, params.BuildModePIE:
+	default:
+		return nil
+	}
+	var files []linuxpkg.File
+	names := build.OutputNames(buildSpec.ProductName, buildSpec.Build.BuildMode, osArch.OS)
+	for i, currOutputPath := range outputPaths {
+		files = append(files, linuxpkg.NewFile(path.Join(binDir, names[i]), currOutputPath, attrs, configFiles))
+	}
+	return files
+}
+
+func writeLinuxPackage(artifactPath string, pkg linuxpkg.Package, write func(io.Writer, linuxpkg.Package) error) (rErr error) {
+	f, err := os.Create(artifactPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", artifactPath)
+	}
+	defer func() {
+		if err := f.Close(); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to close %s", artifactPath)
+		}
+	}()
+	return write(f, pkg)
+}
+
+// parseRelations parses the relationships of an RPM or Debian package.
+func parseRelations(requires, provides, conflicts []string) (r, p, c []linuxpkg.Relation, err error) {
+	if r, err = linuxpkg.ParseRelations(requires); err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "invalid requires")
+	}
+	if p, err = linuxpkg.ParseRelations(provides); err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "invalid provides")
+	}
+	if c, err = linuxpkg.ParseRelations(conflicts); err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "invalid conflicts")
+	}
+	return r, p, c, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/cmd/build"
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/oci"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+)
+
+const scratchBaseImage = "scratch"
+
+// ociDist returns a packager that writes an OCI image layout archive that contains an image for every linux OS/Arch of
+// the product. Each image consists of the base image, a layer that contains the executables of the product and its
+// input products and, if the distribution directory is not empty, a layer that contains the content of the
+// distribution directory rooted at "/". If there are multiple linux OS/Archs, the archive contains a multi-platform
+// image index.
+func ociDist(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg params.Dist, outputProductDir string) (Packager, error) {
+	buildSpec := buildSpecWithDeps.Spec
+	ociDistInfo, ok := distCfg.Info.(*params.OCIDistInfo)
+	if !ok {
+		ociDistInfo = &params.OCIDistInfo{}
+		distCfg.Info = ociDistInfo
+	}
+	if !params.IsExecutableBuildMode(buildSpec.Build.BuildMode) {
+		return nil, errors.Errorf("OCI image distribution requires an executable, but build-mode of %s is %s", buildSpec.ProductName, buildSpec.Build.BuildMode)
+	}
+
+	var osArchs []osarch.OSArch
+	for _, currOSArch := range buildSpec.Build.OSArchs {
+		if currOSArch.OS == "linux" {
+			osArchs = append(osArchs, currOSArch)
+		}
+	}
+	if len(osArchs) == 0 {
+		return nil, errors.Errorf("OCI image distribution requires a linux OS/Arch, but %s is only built for %v", buildSpec.ProductName, buildSpec.Build.OSArchs)
+	}
+
+	binaryPath := ociDistInfo.BinaryPath
+	if binaryPath == "" {
+		binaryPath = path.Join("/usr/local/bin", buildSpec.ProductName)
+	}
+	if !path.IsAbs(binaryPath) {
+		return nil, errors.Errorf("binary-path must be an absolute path: %s", binaryPath)
+	}
+	entrypoint := ociDistInfo.Entrypoint
+	if len(entrypoint) == 0 {
+		entrypoint = []string{binaryPath}
+	}
+	labels := map[string]string{
+		"org.opencontainers.image.title":   buildSpec.ProductName,
+		"org.opencontainers.image.version": buildSpec.ProductVersion,
+	}
+	for k, v := range distCfg.Publish.Almanac.Metadata {
+		labels[k] = v
+	}
+	var env []string
+	for k, v := range ociDistInfo.Env {
+		env = append(env, fmt.Sprintf("%v=%v", k, v))
+	}
+	sort.Strings(env)
+	containerCfg := oci.ContainerConfig{
+		Entrypoint: entrypoint,
+		Cmd:        ociDistInfo.Cmd,
+		Env:        env,
+		WorkingDir: ociDistInfo.WorkingDir,
+		User:       ociDistInfo.User,
+		Labels:     labels,
+	}
+
+	var depNames []string
+	for currDep := range buildSpecWithDeps.Deps {
+		depNames = append(depNames, currDep)
+	}
+	sort.Strings(depNames)
+
+	return packager(func() error {
+		var base *oci.Layout
+		if ociDistInfo.BaseImage != "" && ociDistInfo.BaseImage != scratchBaseImage {
+			var err error
+			if base, err = oci.ReadLayout(path.Join(buildSpec.ProjectDir, ociDistInfo.BaseImage)); err != nil {
+				return errors.Wrapf(err, "failed to read base image")
+			}
+		}
+
+		// the content of the distribution directory (input directory and output of the dist script) is the same for
+		// all images
+		var distDirLayer *oci.Layer
+		if fileInfos, err := ioutil.ReadDir(outputProductDir); err != nil {
+			return errors.Wrapf(err, "failed to list files in directory %v", outputProductDir)
+		} else if len(fileInfos) > 0 {
+			layer, err := oci.DirLayer(outputProductDir, "/", "distgo: add distribution directory")
+			if err != nil {
+				return err
+			}
+			distDirLayer = &layer
+		}
+
+		execPaths, err := build.ArtifactPaths(buildSpec)
+		if err != nil {
+			return err
+		}
+		depExecPaths := make(map[string]map[osarch.OSArch]string, len(depNames))
+		for _, currDep := range depNames {
+			if depExecPaths[currDep], err = build.ArtifactPaths(buildSpecWithDeps.Deps[currDep]); err != nil {
+				return err
+			}
+		}
+
+		var images []oci.Image
+		for _, currOSArch := range osArchs {
+			binLayer := oci.Layer{
+				Files: []oci.File{{
+					Path: binaryPath,
+					Src:  execPaths[currOSArch],
+				}},
+				CreatedBy: "distgo: add executables",
+			}
+			for _, currDep := range depNames {
+				if depPath, ok := depExecPaths[currDep][currOSArch]; ok {
+					binLayer.Files = append(binLayer.Files, oci.File{
+						Path: path.Join(path.Dir(binaryPath), currDep),
+						Src:  depPath,
+					})
+				}
+			}
+			layers := []oci.Layer{binLayer}
+			if distDirLayer != nil {
+				layers = append(layers, *distDirLayer)
+			}
+			images = append(images, oci.Image{
+				Platform: oci.Platform{
+					OS:           currOSArch.OS,
+					Architecture: currOSArch.Arch,
+				},
+				Layers: layers,
+				Config: containerCfg,
+			})
+		}
+
+		artifactPaths, err := ArtifactPaths(buildSpec, distCfg)
+		if err != nil {
+			return err
+		}
+		artifactPath := artifactPaths[0]
+		f, err := os.Create(artifactPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s", artifactPath)
+		}
+		if err := oci.Write(f, images, base, buildSpec.ProductVersion); err != nil {
+			_ = f.Close()
+			return errors.Wrapf(err, "failed to write OCI image layout to %s", artifactPath)
+		}
+		if err := f.Close(); err != nil {
+			return errors.Wrapf(err, "failed to close %s", artifactPath)
+		}
+		return nil
+	}), nil
+}
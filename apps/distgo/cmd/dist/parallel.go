@@ -0,0 +1,202 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/palantir/godel/apps/distgo/cmd"
+	"github.com/palantir/godel/apps/distgo/params"
+)
+
+// distUnit is a unit of work for RunParallel: a set of distributions of a single product that share a distribution
+// directory and must therefore be created serially.
+type distUnit struct {
+	product *productRun
+	// distIdxs are the indices of the distributions of the unit in the Dist slice of the spec of the product.
+	distIdxs []int
+	// output and errOutput are the output and the error output of creating the distributions of the unit.
+	output    bytes.Buffer
+	errOutput bytes.Buffer
+	err       error
+}
+
+// productRun tracks the units of a single product that are created by RunParallel.
+type productRun struct {
+	dist  *productDist
+	units []*distUnit
+	// artifacts are the artifacts of each distribution of the product in the order of its Dist slice.
+	artifacts [][]Artifact
+	// remaining is the number of units of the product that have not completed.
+	remaining int
+}
+
+// RunParallel creates the distributions of the provided specs like Run (or like ForceRun if force is true), but uses a
+// pool of workers to create up to nWorkers distributions concurrently (runtime.NumCPU() if nWorkers is not positive).
+// The distributions of different products are created concurrently, as are the distributions of a single product that
+// have different distribution directories. Distributions of a product that share a distribution directory are created
+// serially in the order in which they are configured. The output of each product is buffered and written to stdout once
+// all of its distributions have been created and its artifacts have been signed, so the output of different products is
+// not interleaved. The error output of dist scripts is buffered in the same manner and written to os.Stderr. A failure does not stop the creation of the distributions of other products; if any product fails, a
+// *cmd.SpecErrors that contains the error of every product that failed is returned.
+func RunParallel(buildSpecsWithDeps []params.ProductBuildSpecWithDeps, nWorkers int, force bool, stdout io.Writer) error {
+	errs := make(map[string]error)
+	var units []*distUnit
+	for _, currSpecWithDeps := range buildSpecsWithDeps {
+		d, err := newProductDist(currSpecWithDeps, force)
+		if err != nil {
+			errs[currSpecWithDeps.Spec.ProductName] = err
+			continue
+		}
+		product := &productRun{
+			dist:      d,
+			artifacts: make([][]Artifact, len(currSpecWithDeps.Spec.Dist)),
+		}
+		unitsByDir := make(map[string]*distUnit)
+		for i, currDistCfg := range currSpecWithDeps.Spec.Dist {
+			dir := distDir(currSpecWithDeps.Spec, currDistCfg)
+			unit, ok := unitsByDir[dir]
+			if !ok {
+				unit = &distUnit{
+					product: product,
+				}
+				unitsByDir[dir] = unit
+				product.units = append(product.units, unit)
+			}
+			unit.distIdxs = append(unit.distIdxs, i)
+		}
+		product.remaining = len(product.units)
+		if product.remaining == 0 {
+			// product has no distributions, but its (empty) set of artifacts is still signed as it is by Run
+			output, errOutput, err := finishProduct(product)
+			_, _ = output.WriteTo(stdout)
+			_, _ = errOutput.WriteTo(os.Stderr)
+			if err != nil {
+				errs[currSpecWithDeps.Spec.ProductName] = err
+			}
+			continue
+		}
+		units = append(units, product.units...)
+	}
+
+	if len(units) > 0 {
+		if nWorkers <= 0 {
+			nWorkers = runtime.NumCPU()
+		}
+		if len(units) < nWorkers {
+			nWorkers = len(units)
+		}
+
+		jobs := make(chan *distUnit, len(units))
+		for _, currUnit := range units {
+			jobs <- currUnit
+		}
+		close(jobs)
+
+		// lock guards the remaining counts of the products, errs and writes to stdout
+		var lock sync.Mutex
+		var wg sync.WaitGroup
+		wg.Add(nWorkers)
+		for i := 0; i < nWorkers; i++ {
+			go func() {
+				defer wg.Done()
+				for unit := range jobs {
+					createUnit(unit)
+
+					product := unit.product
+					lock.Lock()
+					product.remaining--
+					done := product.remaining == 0
+					lock.Unlock()
+					if !done {
+						continue
+					}
+
+					// the last unit of the product to complete finishes the product
+					output, errOutput, err := finishProduct(product)
+					lock.Lock()
+					_, _ = output.WriteTo(stdout)
+					_, _ = errOutput.WriteTo(os.Stderr)
+					if err != nil {
+						errs[product.dist.buildSpecWithDeps.Spec.ProductName] = err
+					}
+					lock.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	if len(errs) > 0 {
+		return &cmd.SpecErrors{Errors: errs}
+	}
+	return nil
+}
+
+// createUnit creates the distributions of the provided unit serially. Stops at the first distribution that fails.
+func createUnit(unit *distUnit) {
+	spec := unit.product.dist.buildSpecWithDeps.Spec
+	for _, currIdx := range unit.distIdxs {
+		artifacts, err := unit.product.dist.create(spec.Dist[currIdx], &unit.output, &unit.errOutput)
+		if err != nil {
+			unit.err = err
+			return
+		}
+		unit.product.artifacts[currIdx] = artifacts
+	}
+}
+
+// finishProduct signs the artifacts of a product whose units have all completed successfully. Returns the output and
+// the error output of the product and the error of the first unit of the product that failed or the error from signing.
+func finishProduct(product *productRun) (*bytes.Buffer, *bytes.Buffer, error) {
+	output := &bytes.Buffer{}
+	errOutput := &bytes.Buffer{}
+	for _, currUnit := range product.units {
+		_, _ = currUnit.output.WriteTo(output)
+		_, _ = currUnit.errOutput.WriteTo(errOutput)
+	}
+	for _, currUnit := range product.units {
+		if currUnit.err != nil {
+			return output, errOutput, currUnit.err
+		}
+	}
+	var allArtifacts []Artifact
+	for _, currArtifacts := range product.artifacts {
+		allArtifacts = append(allArtifacts, currArtifacts...)
+	}
+	return output, errOutput, product.dist.sign(allArtifacts, output)
+}
+
+// specErrorsSummary returns a summary of the provided errors of the products that failed out of nProducts products.
+// The products are listed in alphabetical order.
+func specErrorsSummary(specErrors *cmd.SpecErrors, nProducts int) string {
+	var products []string
+	for product := range specErrors.Errors {
+		products = append(products, product)
+	}
+	sort.Strings(products)
+	lines := []string{fmt.Sprintf("failed to create distributions for %d of %d products:", len(products), nProducts)}
+	for _, currProduct := range products {
+		lines = append(lines, fmt.Sprintf("  %s: %v", currProduct, specErrors.Errors[currProduct]))
+	}
+	return strings.Join(lines, "\n")
+}
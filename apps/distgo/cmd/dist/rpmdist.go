@@ -15,35 +15,17 @@
 package dist
 
 import (
-	"fmt"
-	"io"
-	"os"
-	"os/exec"
-	"strings"
+	"path"
 
 	"github.com/pkg/errors"
 
 	"github.com/palantir/godel/apps/distgo/params"
-	"github.com/palantir/godel/apps/distgo/pkg/script"
+	"github.com/palantir/godel/apps/distgo/pkg/linuxpkg"
 )
 
 const defaultRPMRelease = "1"
 
-func checkRPMDependencies() error {
-	var missing []string
-	if _, err := exec.LookPath("fpm"); err != nil {
-		missing = append(missing, "Missing `fpm` command required to build RPMs. Install with `gem install fpm`.")
-	}
-	if _, err := exec.LookPath("rpmbuild"); err != nil {
-		missing = append(missing, "Missing `rpmbuild` command required to build RPMs. Install with `yum install rpm-build` or `apt-get install rpm` or `brew install rpm`.")
-	}
-	if len(missing) > 0 {
-		return errors.New(strings.Join(missing, "\n"))
-	}
-	return nil
-}
-
-func rpmDist(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg params.Dist, outputProductDir string, stdout io.Writer) (p Packager, rErr error) {
+func rpmDist(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg params.Dist, outputProductDir string) (Packager, error) {
 	buildSpec := buildSpecWithDeps.Spec
 	rpmDistInfo, ok := distCfg.Info.(*params.RPMDistInfo)
 	if !ok {
@@ -51,79 +33,65 @@ func rpmDist(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg params.D
 		distCfg.Info = rpmDistInfo
 	}
 
-	release := defaultRPMRelease
-	if rpmDistInfo.Release != "" {
-		release = rpmDistInfo.Release
+	if err := validateLinuxPackageArchs(buildSpec, params.RPMDistType, linuxpkg.RPMArch); err != nil {
+		return nil, err
 	}
-
-	// These are run after the cmd is executed.
-	var cleanups []func() error
-	// clean up unless everything below succeeds
-	runCleanups := true
-	defer func() {
-		if runCleanups {
-			var errs []string
-			for _, cleanup := range cleanups {
-				if err := cleanup(); err != nil {
-					errs = append(errs, err.Error())
-				}
-			}
-			if len(errs) > 0 {
-				rErr = errors.Errorf(strings.Join(append([]string{"encountered errors during cleanup:"}, errs...), "\n"))
-			}
-		}
-	}()
-
-	cmd := exec.Command("fpm")
-	cmd.Dir = buildSpec.ProjectDir
-	cmd.Stdout = stdout
-	cmd.Stderr = os.Stderr
-
-	cmd.Args = []string{
-		"fpm",
-		"-t", "rpm",
-		"-n", buildSpec.ProductName,
-		"-v", buildSpec.ProductVersion,
-		"--iteration", release,
-		"-p", ArtifactPath(buildSpec, distCfg),
-		"-s", "dir",
-		"-C", outputProductDir,
+	requires, provides, conflicts, err := parseRelations(rpmDistInfo.Requires, rpmDistInfo.Provides, rpmDistInfo.Conflicts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid RPM configuration for %v", buildSpec.ProductName)
 	}
 
-	for _, configFile := range rpmDistInfo.ConfigFiles {
-		cmd.Args = append(cmd.Args, "--config-files", configFile)
+	pkg := linuxpkg.Package{
+		Name:                buildSpec.ProductName,
+		Version:             buildSpec.ProductVersion,
+		Release:             rpmRelease(distCfg),
+		Requires:            requires,
+		Provides:            provides,
+		Conflicts:           conflicts,
+		BeforeInstallScript: rpmDistInfo.BeforeInstallScript,
+		AfterInstallScript:  rpmDistInfo.AfterInstallScript,
+		BeforeRemoveScript:  rpmDistInfo.BeforeRemoveScript,
+		AfterRemoveScript:   rpmDistInfo.AfterRemoveScript,
 	}
-
-	scriptArg := func(name string, content string) error {
-		if content == "" {
-			return nil
-		}
-		f, cleanup, err := script.Write(buildSpec, rpmDistInfo.BeforeInstallScript)
-		if err != nil {
-			return errors.Wrapf(err, "failed to write %v script for %v", name, buildSpec.ProductName)
+	if rpmDistInfo.Systemd != nil {
+		if err := addRPMSystemdUnit(buildSpec, *rpmDistInfo, outputProductDir, &pkg); err != nil {
+			return nil, errors.Wrapf(err, "failed to add systemd unit to RPM for %v", buildSpec.ProductName)
 		}
-		cleanups = append(cleanups, cleanup)
-		cmd.Args = append(cmd.Args, "--"+name, f)
-		return nil
-	}
-	if err := scriptArg("before-install", rpmDistInfo.BeforeInstallScript); err != nil {
-		return nil, err
-	}
-	if err := scriptArg("after-install", rpmDistInfo.AfterInstallScript); err != nil {
-		return nil, err
-	}
-	if err := scriptArg("after-remove", rpmDistInfo.AfterRemoveScript); err != nil {
-		return nil, err
 	}
+	return linuxPackager(buildSpec, distCfg, outputProductDir, pkg, linuxPackageFiles{
+		files:       rpmDistInfo.Files,
+		configFiles: rpmDistInfo.ConfigFiles,
+		binDir:      rpmDistInfo.BinDir,
+	}, linuxpkg.WriteRPM), nil
+}
 
-	runCleanups = false
-	return packager(func() error {
-		err := cmd.Run()
-		for _, cleanup := range cleanups {
-			if err := cleanup(); err != nil {
-				fmt.Fprintln(os.Stderr, err)
-			}
+// addRPMSystemdUnit writes the systemd unit configured for the RPM to the output directory and adds the commands that
+// manage the unit to the scripts of the provided package.
+func addRPMSystemdUnit(buildSpec params.ProductBuildSpec, rpmDistInfo params.RPMDistInfo, outputProductDir string, pkg *linuxpkg.Package) error {
+	systemd := *rpmDistInfo.Systemd
+	executable := systemd.Executable
+	if executable == "" {
+		if rpmDistInfo.BinDir == "" {
+			return errors.Errorf("executable of systemd unit must be specified if the bin directory of the RPM is not")
 		}
+		executable = path.Join(rpmDistInfo.BinDir, buildSpec.ProductName)
+	}
+	unit := systemdUnit(buildSpec.ProductName, systemd, systemdExecStart(executable, rpmDistInfo.ServiceArgs))
+	if err := writeSystemdUnit(path.Join(outputProductDir, rpmSystemdUnitDir, buildSpec.ProductName+".service"), unit); err != nil {
 		return err
-	}), nil
+	}
+
+	scripts := newRPMSystemdScripts(buildSpec.ProductName, systemd)
+	pkg.BeforeInstallScript = joinScripts(scripts.beforeInstall, pkg.BeforeInstallScript)
+	pkg.AfterInstallScript = joinScripts(pkg.AfterInstallScript, scripts.afterInstall)
+	pkg.BeforeRemoveScript = joinScripts(scripts.beforeRemove, pkg.BeforeRemoveScript)
+	pkg.AfterRemoveScript = joinScripts(scripts.afterRemove, pkg.AfterRemoveScript)
+	return nil
+}
+
+func rpmRelease(distCfg params.Dist) string {
+	if rpmDistInfo, ok := distCfg.Info.(*params.RPMDistInfo); ok && rpmDistInfo.Release != "" {
+		return rpmDistInfo.Release
+	}
+	return defaultRPMRelease
 }
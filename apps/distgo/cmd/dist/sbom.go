@@ -0,0 +1,152 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/cmd/build"
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/manifest"
+	"github.com/palantir/godel/apps/distgo/pkg/sbom"
+)
+
+// SBOM returns the software bill of materials for the product of the provided spec. The SBOM lists the third-party
+// packages required to build the main packages of the product and of its dependent products (whose executables are
+// included in the distributions of the product) for any of the OS/Archs for which they are built. The creation time of the SBOM is the modification time used for the
+// entries of archives so that the SBOMs of identical sources are identical.
+func SBOM(buildSpecWithDeps params.ProductBuildSpecWithDeps) (sbom.Document, error) {
+	buildSpec := buildSpecWithDeps.Spec
+	var mainPkgs []sbom.MainPkg
+	for _, currSpec := range buildSpecWithDeps.AllSpecs() {
+		mainPkg := sbom.MainPkg{
+			Dir: path.Join(currSpec.ProjectDir, currSpec.Build.MainPkg),
+		}
+		for _, currOSArch := range currSpec.Build.OSArchs {
+			mainPkg.Contexts = append(mainPkg.Contexts, build.GoContext(currSpec, currOSArch))
+		}
+		mainPkgs = append(mainPkgs, mainPkg)
+	}
+	pkgs, err := sbom.Packages(buildSpec.ProjectDir, mainPkgs)
+	if err != nil {
+		return sbom.Document{}, errors.Wrapf(err, "failed to determine packages linked into %s", buildSpec.ProductName)
+	}
+	created, err := archiveModTime(buildSpec.ProjectDir)
+	if err != nil {
+		return sbom.Document{}, err
+	}
+	return sbom.Document{
+		Name:     buildSpec.ProductName,
+		Version:  buildSpec.ProductVersion,
+		Created:  created,
+		Packages: pkgs,
+	}, nil
+}
+
+// WriteSBOM writes the provided SBOM to w in the provided format.
+func WriteSBOM(w io.Writer, doc sbom.Document, format params.SBOMFormat) error {
+	switch format {
+	case params.SPDXSBOMFormat:
+		return sbom.WriteSPDX(w, doc)
+	case params.CycloneDXSBOMFormat:
+		return sbom.WriteCycloneDX(w, doc)
+	default:
+		return errors.Errorf("unknown SBOM format: %v", format)
+	}
+}
+
+// SBOMFileName returns the name of the file to which the SBOM of the product of the provided spec is written in the
+// provided format: "{{product}}-{{version}}.spdx.json" or "{{product}}-{{version}}.cdx.json".
+func SBOMFileName(buildSpec params.ProductBuildSpec, format params.SBOMFormat) string {
+	extension := string(format)
+	if format == params.CycloneDXSBOMFormat {
+		extension = "cdx"
+	}
+	return fmt.Sprintf("%s-%s.%s.json", buildSpec.ProductName, buildSpec.ProductVersion, extension)
+}
+
+// SBOMFormats returns the formats of the SBOMs written for the provided distribution. Returns nil if the distribution
+// does not write SBOMs.
+func SBOMFormats(distCfg params.Dist) []params.SBOMFormat {
+	if distCfg.SBOM == nil {
+		return nil
+	}
+	if len(distCfg.SBOM.Formats) == 0 {
+		return params.SBOMFormats
+	}
+	return distCfg.SBOM.Formats
+}
+
+// SBOMPaths returns the paths of the SBOMs that are written to the output directory of the provided distribution in
+// the order of the formats returned by SBOMFormats.
+func SBOMPaths(buildSpec params.ProductBuildSpec, distCfg params.Dist) []string {
+	var paths []string
+	for _, currFormat := range SBOMFormats(distCfg) {
+		paths = append(paths, path.Join(buildSpec.ProjectDir, distCfg.OutputDir, SBOMFileName(buildSpec, currFormat)))
+	}
+	return paths
+}
+
+// writeSBOMs writes the provided SBOM in each of the formats of the provided distribution to the SBOM directory of the
+// distribution directory (so that it is included in the artifacts) and to the output directory of the distribution.
+func writeSBOMs(buildSpec params.ProductBuildSpec, distCfg params.Dist, doc sbom.Document, outputProductDir string, stdout io.Writer) error {
+	sbomDir := path.Join(outputProductDir, distCfg.SBOM.Dir)
+	if err := os.MkdirAll(sbomDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", sbomDir)
+	}
+	for i, currOutputPath := range SBOMPaths(buildSpec, distCfg) {
+		format := SBOMFormats(distCfg)[i]
+		fmt.Fprintf(stdout, "Writing %s SBOM for %v to %v\n", format, buildSpec.ProductName, currOutputPath)
+		for _, currPath := range []string{path.Join(sbomDir, SBOMFileName(buildSpec, format)), currOutputPath} {
+			if err := WriteSBOMFile(currPath, doc, format); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteSBOMFile writes the provided SBOM in the provided format to the file at the provided path.
+func WriteSBOMFile(filePath string, doc sbom.Document, format params.SBOMFormat) (rErr error) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", filePath)
+	}
+	defer func() {
+		if err := f.Close(); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to close %s", filePath)
+		}
+	}()
+	return WriteSBOM(f, doc, format)
+}
+
+// recordSBOMs records the SBOMs written to the output directory of the provided distribution in the build manifest of
+// the product.
+func recordSBOMs(buildSpec params.ProductBuildSpec, distCfg params.Dist) error {
+	var entries []manifest.Artifact
+	for _, currPath := range SBOMPaths(buildSpec, distCfg) {
+		entry, err := build.NewManifestArtifact(buildSpec, manifest.SBOMArtifactType, currPath, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create manifest entry for %v", currPath)
+		}
+		entries = append(entries, entry)
+	}
+	return build.UpdateManifest(buildSpec, entries...)
+}
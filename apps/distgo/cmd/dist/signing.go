@@ -0,0 +1,232 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/cmd/build"
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/manifest"
+	"github.com/palantir/godel/apps/distgo/pkg/signing"
+	"github.com/palantir/godel/apps/distgo/pkg/slsspec"
+)
+
+// ChecksumsPath returns the path of the SHA256SUMS file that is written for the artifacts of the product of the
+// provided spec if signing is configured for the product. The file is written to the output directory of the first
+// distribution of the product. Returns an empty string if signing is not configured.
+func ChecksumsPath(buildSpec params.ProductBuildSpec) string {
+	if buildSpec.Signing == nil || len(buildSpec.Dist) == 0 {
+		return ""
+	}
+	values := slsspec.TemplateValues(buildSpec.ProductName, buildSpec.ProductVersion)
+	return path.Join(buildSpec.ProjectDir, buildSpec.Dist[0].OutputDir, slsspec.New().RootDirName(values)+"-SHA256SUMS")
+}
+
+// SignatureExtensions returns the extensions of the detached signatures that are created for every artifact of the
+// product of the provided spec. The path of a signature is the path of the signed file followed by the extension.
+// Returns nil if signing is not configured for the product.
+func SignatureExtensions(buildSpec params.ProductBuildSpec) []string {
+	if buildSpec.Signing == nil {
+		return nil
+	}
+	var extensions []string
+	if buildSpec.Signing.OpenPGP != nil {
+		extensions = append(extensions, signing.OpenPGPExtension)
+	}
+	if buildSpec.Signing.Minisign != nil {
+		extensions = append(extensions, signing.MinisignExtension)
+	}
+	return extensions
+}
+
+// SigningPaths returns the paths of the files that are created by signing the artifacts of the provided distribution:
+// the signatures of the artifacts of the distribution followed by the SHA256SUMS file of the product and its
+// signatures. Returns nil if signing is not configured for the product.
+func SigningPaths(buildSpec params.ProductBuildSpec, distCfg params.Dist) ([]string, error) {
+	checksumsPath := ChecksumsPath(buildSpec)
+	if checksumsPath == "" {
+		return nil, nil
+	}
+	artifactPaths, err := ArtifactPaths(buildSpec, distCfg)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, currPath := range append(artifactPaths, checksumsPath) {
+		if currPath == checksumsPath {
+			paths = append(paths, checksumsPath)
+		}
+		for _, currExt := range SignatureExtensions(buildSpec) {
+			paths = append(paths, currPath+currExt)
+		}
+	}
+	return paths, nil
+}
+
+// newSigners returns the signers for the signing configuration of the provided spec in the order of the extensions
+// returned by SignatureExtensions. Returns an error if a key cannot be read.
+func newSigners(buildSpec params.ProductBuildSpec) ([]signing.Signer, error) {
+	if buildSpec.Signing == nil {
+		return nil, nil
+	}
+	var signers []signing.Signer
+	if cfg := buildSpec.Signing.OpenPGP; cfg != nil {
+		keyringPath := projectPath(buildSpec, cfg.Keyring)
+		keyring, err := ioutil.ReadFile(keyringPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read OpenPGP keyring")
+		}
+		passphrase, err := signingPassphrase(cfg.PassphraseEnvVar)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := signing.NewOpenPGPSigner(keyring, cfg.KeyID, passphrase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read OpenPGP key from %s", keyringPath)
+		}
+		signers = append(signers, signer)
+	}
+	if cfg := buildSpec.Signing.Minisign; cfg != nil {
+		var key []byte
+		if cfg.KeyPath != "" {
+			var err error
+			if key, err = ioutil.ReadFile(projectPath(buildSpec, cfg.KeyPath)); err != nil {
+				return nil, errors.Wrapf(err, "failed to read minisign secret key")
+			}
+		} else if key = []byte(os.Getenv(cfg.KeyEnvVar)); len(key) == 0 {
+			return nil, errors.Errorf("environment variable %s that should contain the minisign secret key is not set", cfg.KeyEnvVar)
+		}
+		passphrase, err := signingPassphrase(cfg.PassphraseEnvVar)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := signing.NewMinisignSigner(key, passphrase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read minisign secret key")
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// projectPath returns the provided path resolved against the project directory of the provided spec if it is relative.
+func projectPath(buildSpec params.ProductBuildSpec, p string) string {
+	if path.IsAbs(p) {
+		return p
+	}
+	return path.Join(buildSpec.ProjectDir, p)
+}
+
+// signingPassphrase returns the value of the provided environment variable. Returns nil if the name of the environment
+// variable is empty and an error if the variable is not set.
+func signingPassphrase(envVar string) ([]byte, error) {
+	if envVar == "" {
+		return nil, nil
+	}
+	passphrase, ok := os.LookupEnv(envVar)
+	if !ok {
+		return nil, errors.Errorf("environment variable %s that should contain the passphrase of the signing key is not set", envVar)
+	}
+	return []byte(passphrase), nil
+}
+
+// signaturesUpToDate returns true if the SHA256SUMS file of the product of the provided spec contains the current
+// checksums of the provided artifacts and the SHA256SUMS file and the signatures of the artifacts and of the SHA256SUMS
+// file are recorded in the build manifest of the product with their current digests. Because the SHA256SUMS file is
+// signed, this means that none of the artifacts have changed since they were signed.
+func signaturesUpToDate(buildSpec params.ProductBuildSpec, artifacts []Artifact) bool {
+	checksumsPath := ChecksumsPath(buildSpec)
+	checksums, err := ioutil.ReadFile(checksumsPath)
+	if err != nil {
+		return false
+	}
+	artifactPaths := make([]string, len(artifacts))
+	for i, currArtifact := range artifacts {
+		artifactPaths[i] = currArtifact.Path
+	}
+	if wantChecksums, err := signing.SHA256Sums(artifactPaths); err != nil || !bytes.Equal(checksums, wantChecksums) {
+		return false
+	}
+
+	m, err := manifest.Read(build.ManifestPath(buildSpec))
+	if err != nil {
+		return false
+	}
+	digests := make(map[string]string)
+	for _, currEntry := range m.Artifacts {
+		digests[currEntry.Path] = currEntry.SHA256
+	}
+	signingPaths := []string{checksumsPath}
+	for _, currPath := range append(artifactPaths, checksumsPath) {
+		for _, currExt := range SignatureExtensions(buildSpec) {
+			signingPaths = append(signingPaths, currPath+currExt)
+		}
+	}
+	for _, currPath := range signingPaths {
+		relPath, err := filepath.Rel(buildSpec.ProjectDir, currPath)
+		if err != nil {
+			return false
+		}
+		if digest, err := build.FileDigest(currPath); err != nil || digest != digests[relPath] {
+			return false
+		}
+	}
+	return true
+}
+
+// signArtifacts writes the SHA256SUMS file for the provided artifacts of the product of the provided spec and signs
+// the artifacts and the SHA256SUMS file using the provided signers. The SHA256SUMS file and the signatures are recorded
+// in the build manifest of the product.
+func signArtifacts(buildSpec params.ProductBuildSpec, artifacts []Artifact, signers []signing.Signer, stdout io.Writer) error {
+	checksumsPath := ChecksumsPath(buildSpec)
+	artifactPaths := make([]string, len(artifacts))
+	for i, currArtifact := range artifacts {
+		artifactPaths[i] = currArtifact.Path
+	}
+	fmt.Fprintf(stdout, "Writing checksums for %v to %v\n", buildSpec.ProductName, checksumsPath)
+	if err := signing.WriteSHA256Sums(checksumsPath, artifactPaths); err != nil {
+		return errors.Wrapf(err, "failed to write checksums for %v", buildSpec.ProductName)
+	}
+	checksumsEntry, err := build.NewManifestArtifact(buildSpec, manifest.ChecksumsArtifactType, checksumsPath, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create manifest entry for %v", checksumsPath)
+	}
+	manifestEntries := []manifest.Artifact{checksumsEntry}
+
+	for _, currArtifact := range append(artifacts, Artifact{Path: checksumsPath}) {
+		for _, currSigner := range signers {
+			sigPath, err := signing.SignFile(currSigner, currArtifact.Path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "Signed %v: %v\n", currArtifact.Path, sigPath)
+			entry, err := build.NewManifestArtifact(buildSpec, manifest.SignatureArtifactType, sigPath, currArtifact.OSArchs)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create manifest entry for %v", sigPath)
+			}
+			manifestEntries = append(manifestEntries, entry)
+		}
+	}
+	return build.UpdateManifest(buildSpec, manifestEntries...)
+}
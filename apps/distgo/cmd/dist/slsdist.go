@@ -196,6 +196,12 @@ func slsDist(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg params.D
 		return nil, errors.Wrapf(err, "failed to write init.sh")
 	}
 
+	if slsDistInfo.Systemd != nil {
+		if err := writeSLSSystemdUnit(buildSpec, slsDistInfo, specDir); err != nil {
+			return nil, errors.Wrapf(err, "failed to write systemd unit")
+		}
+	}
+
 	serviceBinDir := specDir.Path(slsspec.ServiceBin)
 	binSpec := binspec.New(buildSpec.Build.OSArchs, buildSpec.ProductName)
 	binSpecDir, err := specdir.New(serviceBinDir, binSpec, nil, specdir.Create)
@@ -211,7 +217,7 @@ func slsDist(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg params.D
 		if err := slsspec.Validate(outputProductDir, values, slsDistInfo.YMLValidationExclude); err != nil {
 			return errors.Wrapf(err, "distribution directory failed SLS validation")
 		}
-		if err := tgzPackager(buildSpec, distCfg, outputProductDir).Package(); err != nil {
+		if err := archivePackager(buildSpec, distCfg, outputProductDir, "service/bin").Package(); err != nil {
 			return err
 		}
 		return nil
@@ -234,7 +240,7 @@ func writeSLSManifest(buildSpec params.ProductBuildSpec, distCfg params.Dist, sl
 		manifestTemplateString = manifestBuf.String()
 	} else {
 		var err error
-		manifestTemplateString, err = manifest(distCfg.Publish.GroupID, buildSpec.ProductName, buildSpec.ProductVersion, slsDistInfo.ProductType, slsDistInfo.ManifestExtensions)
+		manifestTemplateString, err = renderManifest(distCfg.Publish.GroupID, buildSpec.ProductName, buildSpec.ProductVersion, slsDistInfo.ProductType, slsDistInfo.ManifestExtensions)
 		if err != nil {
 			return errors.Wrapf(err, "failed to create manifest for SLS distribution")
 		}
@@ -254,7 +260,7 @@ type slsManifest struct {
 	Extensions      map[string]interface{} `yaml:"extensions,omitempty"`
 }
 
-func manifest(groupID, name, version, productType string, extensions map[string]interface{}) (string, error) {
+func renderManifest(groupID, name, version, productType string, extensions map[string]interface{}) (string, error) {
 	var missingRequired []string
 	if groupID == "" {
 		missingRequired = append(missingRequired, "group-id")
@@ -309,3 +315,22 @@ func writeSLSInitSh(buildSpec params.ProductBuildSpec, distCfg params.Dist, slsD
 	}
 	return nil
 }
+
+// writeSLSSystemdUnit writes the systemd unit configured for the distribution to the service/bin directory. The unit runs
+// the executable for the linux OS/Arch of the product from the working directory of the unit, which is the directory in
+// which the distribution is installed. Returns an error if the product is not built for exactly one linux OS/Arch.
+func writeSLSSystemdUnit(buildSpec params.ProductBuildSpec, slsDistInfo params.SLSDistInfo, specDir specdir.SpecDir) error {
+	systemd := *slsDistInfo.Systemd
+	if !path.IsAbs(systemd.WorkingDirectory) {
+		return errors.Errorf("working directory of the systemd unit of an SLS distribution must be the absolute path of the directory in which the distribution is installed, was %q", systemd.WorkingDirectory)
+	}
+	// an error is returned if there are no linux OS/Archs, in which case the length check fails
+	linuxOSArchs, _ := linuxPackageOSArchs(buildSpec, params.SLSDistType)
+	if len(linuxOSArchs) != 1 {
+		return errors.Errorf("systemd unit of sls distribution requires the product to be built for exactly one linux OS/Arch, but %s is built for %v", buildSpec.ProductName, buildSpec.Build.OSArchs)
+	}
+	osArch := linuxOSArchs[0]
+	executable := path.Join(systemd.WorkingDirectory, slsspec.ServiceBin, osArch.String(), buildSpec.ProductName)
+	unit := systemdUnit(buildSpec.ProductName, systemd, systemdExecStart(executable, slsDistInfo.ServiceArgs))
+	return writeSystemdUnit(path.Join(specDir.Path(slsspec.ServiceBin), buildSpec.ProductName+".service"), unit)
+}
@@ -0,0 +1,188 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+)
+
+const (
+	defaultSystemdRestart = "on-failure"
+	// rpmSystemdUnitDir is the directory in which RPMs install systemd units.
+	rpmSystemdUnitDir = "/usr/lib/systemd/system"
+)
+
+// shellSafe matches values that do not need to be quoted in a shell script.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// systemdUnit returns the content of the systemd service unit for the provided product that runs the provided command.
+func systemdUnit(product string, systemd params.Systemd, execStart string) string {
+	description := systemd.Description
+	if description == "" {
+		description = product
+	}
+	restart := systemd.Restart
+	if restart == "" {
+		restart = defaultSystemdRestart
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "[Unit]")
+	fmt.Fprintf(buf, "Description=%s\n", description)
+	fmt.Fprintln(buf, "After=network.target")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "[Service]")
+	fmt.Fprintln(buf, "Type=simple")
+	for _, currDirective := range []struct {
+		name  string
+		value string
+	}{
+		{"User", systemd.User},
+		{"Group", systemd.Group},
+		{"WorkingDirectory", systemd.WorkingDirectory},
+		{"EnvironmentFile", systemd.EnvironmentFile},
+	} {
+		if currDirective.value != "" {
+			fmt.Fprintf(buf, "%s=%s\n", currDirective.name, currDirective.value)
+		}
+	}
+	fmt.Fprintf(buf, "ExecStart=%s\n", execStart)
+	fmt.Fprintf(buf, "Restart=%s\n", restart)
+	var resources []string
+	for k := range systemd.Limits {
+		resources = append(resources, k)
+	}
+	sort.Strings(resources)
+	for _, currResource := range resources {
+		fmt.Fprintf(buf, "Limit%s=%s\n", currResource, systemd.Limits[currResource])
+	}
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "[Install]")
+	fmt.Fprintln(buf, "WantedBy=multi-user.target")
+	return buf.String()
+}
+
+// systemdExecStart returns the command line of the executable at the provided path with the provided arguments.
+func systemdExecStart(executable, serviceArgs string) string {
+	return strings.TrimSpace(executable + " " + serviceArgs)
+}
+
+// writeSystemdUnit writes the provided unit to the provided path. Returns an error if a file already exists at the path
+// (for example, because it was copied from the input directory of the distribution).
+func writeSystemdUnit(unitPath, unit string) error {
+	if _, err := os.Lstat(unitPath); err == nil {
+		return errors.Errorf("%s already exists", unitPath)
+	}
+	if err := os.MkdirAll(path.Dir(unitPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", path.Dir(unitPath))
+	}
+	if err := ioutil.WriteFile(unitPath, []byte(unit), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", unitPath)
+	}
+	return nil
+}
+
+// rpmSystemdScripts contains the commands that are run by the scripts of an RPM to manage its systemd unit.
+type rpmSystemdScripts struct {
+	beforeInstall string
+	afterInstall  string
+	beforeRemove  string
+	afterRemove   string
+}
+
+// newRPMSystemdScripts returns the scripts that manage the systemd unit of the provided product. The RPM scripts are
+// provided the number of instances of the package that will remain installed after the operation as their first
+// argument, which distinguishes an install from an upgrade and a removal from the removal of the old version of the
+// package during an upgrade.
+func newRPMSystemdScripts(product string, systemd params.Systemd) rpmSystemdScripts {
+	unitName := product + ".service"
+
+	beforeInstall := &bytes.Buffer{}
+	user := systemd.User
+	if user == "root" {
+		user = ""
+	}
+	group := systemd.Group
+	if group == "" {
+		group = user
+	}
+	if group != "" && group != "root" {
+		fmt.Fprintf(beforeInstall, "getent group %s >/dev/null || groupadd -r %s\n", group, group)
+	}
+	if user != "" {
+		home := systemd.WorkingDirectory
+		if home == "" {
+			home = "/"
+		}
+		groupFlag := ""
+		if group != "" {
+			groupFlag = " -g " + group
+		}
+		fmt.Fprintf(beforeInstall, "getent passwd %s >/dev/null || useradd -r%s -d %s -M -s /sbin/nologin %s\n", user, groupFlag, shellQuote(home), user)
+	}
+
+	return rpmSystemdScripts{
+		beforeInstall: beforeInstall.String(),
+		afterInstall: "" +
+			"systemctl daemon-reload >/dev/null 2>&1 || :\n" +
+			"if [ \"$1\" -eq 1 ]; then\n" +
+			"    systemctl enable " + unitName + " >/dev/null 2>&1 || :\n" +
+			"    systemctl start " + unitName + " >/dev/null 2>&1 || :\n" +
+			"else\n" +
+			"    systemctl try-restart " + unitName + " >/dev/null 2>&1 || :\n" +
+			"fi\n",
+		beforeRemove: "" +
+			"if [ \"$1\" -eq 0 ]; then\n" +
+			"    systemctl stop " + unitName + " >/dev/null 2>&1 || :\n" +
+			"    systemctl disable " + unitName + " >/dev/null 2>&1 || :\n" +
+			"fi\n",
+		afterRemove: "systemctl daemon-reload >/dev/null 2>&1 || :\n",
+	}
+}
+
+// shellQuote returns the provided value quoted as a single word of a shell script. Values that only contain characters
+// that are not special to the shell are returned unchanged.
+func shellQuote(value string) string {
+	if shellSafe.MatchString(value) {
+		return value
+	}
+	return "'" + strings.Replace(value, "'", `'"'"'`, -1) + "'"
+}
+
+// joinScripts returns a script that runs the commands of the provided scripts in order. Blank scripts are ignored.
+func joinScripts(scripts ...string) string {
+	var parts []string
+	for _, currScript := range scripts {
+		if currScript == "" {
+			continue
+		}
+		if !strings.HasSuffix(currScript, "\n") {
+			currScript += "\n"
+		}
+		parts = append(parts, currScript)
+	}
+	return strings.Join(parts, "")
+}
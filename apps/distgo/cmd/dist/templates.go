@@ -0,0 +1,92 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dist
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/templating"
+)
+
+// renderTemplates renders the files in the input directory of the provided distribution that match its template
+// patterns into outputProductDir, replacing the copies of the files that were created when the input directory was
+// copied. Each template is named after its path relative to the input directory, so parse and execution errors report
+// the file and line of the error.
+func renderTemplates(buildSpec params.ProductBuildSpec, distCfg params.Dist, inputDir, outputProductDir string) error {
+	if len(distCfg.Templates) == 0 {
+		return nil
+	}
+	data := templating.ConvertSpec(buildSpec, distCfg)
+	return filepath.Walk(inputDir, func(currPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "failed to walk %s", currPath)
+		}
+		if !info.Mode().IsRegular() || info.Name() == ".gitkeep" {
+			return nil
+		}
+		relPath, err := filepath.Rel(inputDir, currPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine path of %s relative to %s", currPath, inputDir)
+		}
+		relPath = filepath.ToSlash(relPath)
+		if !isTemplate(distCfg.Templates, relPath) {
+			return nil
+		}
+		return renderTemplate(relPath, currPath, path.Join(outputProductDir, relPath), info.Mode(), data)
+	})
+}
+
+// isTemplate returns true if the file at the provided path relative to the input directory matches any of the
+// provided patterns.
+func isTemplate(patterns []string, relPath string) bool {
+	for _, currPattern := range patterns {
+		name := relPath
+		if !strings.Contains(currPattern, "/") {
+			name = path.Base(relPath)
+		}
+		if ok, _ := path.Match(currPattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func renderTemplate(name, src, dst string, mode os.FileMode, data templating.Config) error {
+	content, err := ioutil.ReadFile(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", src)
+	}
+	t, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse template %s", src)
+	}
+	buf := &bytes.Buffer{}
+	if err := t.Execute(buf, data); err != nil {
+		return errors.Wrapf(err, "failed to render template %s", src)
+	}
+	if err := ioutil.WriteFile(dst, buf.Bytes(), mode.Perm()); err != nil {
+		return errors.Wrapf(err, "failed to write %s", dst)
+	}
+	return nil
+}
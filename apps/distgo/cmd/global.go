@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/palantir/pkg/cli/flag"
@@ -26,6 +27,7 @@ import (
 const (
 	ProductsParamName = "products"
 	OSArchFlagName    = "os-arch"
+	WorkersFlagName   = "workers"
 )
 
 var (
@@ -36,7 +38,7 @@ var (
 	}
 	OSArchFlag = flag.StringFlag{
 		Name:  OSArchFlagName,
-		Usage: "GOOS-GOARCH for the command (comma-separate for multiple values)",
+		Usage: "GOOS-GOARCH for the command (comma-separate for multiple values). Values may be patterns such as 'linux-*', '*-arm64' or 'first-class'",
 	}
 )
 
@@ -55,6 +57,9 @@ func (f OSArchFilter) Matches(osArch osarch.OSArch) bool {
 	return false
 }
 
+// NewOSArchFilter returns a filter for the provided comma-separated OS/Arch values. Values may be patterns accepted by
+// osarch.NewPattern, which are expanded to the targets they match. Returns an error if any value is malformed or is not
+// supported by the installed Go toolchain.
 func NewOSArchFilter(osArchs string) (OSArchFilter, error) {
 	if osArchs == "" {
 		return nil, nil
@@ -64,7 +69,7 @@ func NewOSArchFilter(osArchs string) (OSArchFilter, error) {
 	var invalidValues []string
 	// if value was provided for flag, parse
 	for _, osArchStr := range strings.Split(osArchs, ",") {
-		if osArch, err := osarch.New(osArchStr); err == nil {
+		if osArch, err := osarch.NewPattern(osArchStr); err == nil {
 			filterArchs = append(filterArchs, osArch)
 		} else {
 			invalidValues = append(invalidValues, osArchStr)
@@ -74,5 +79,27 @@ func NewOSArchFilter(osArchs string) (OSArchFilter, error) {
 		return nil, fmt.Errorf("invalid os-arch values: %v", invalidValues)
 	}
 
-	return OSArchFilter(filterArchs), nil
+	for _, osArch := range filterArchs {
+		if err := osarch.Validate(osArch); err != nil {
+			return nil, fmt.Errorf("invalid os-arch value: %v", err)
+		}
+	}
+	expanded, err := osarch.Expand(filterArchs)
+	if err != nil {
+		return nil, err
+	}
+	return OSArchFilter(expanded), nil
+}
+
+// ParseWorkers parses the value of the "workers" flag of the commands that run in parallel. An empty value is returned
+// as 0, which uses the default number of workers.
+func ParseWorkers(workers string) (int, error) {
+	if workers == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(workers)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid value for --%s: %q must be a positive integer", WorkersFlagName, workers)
+	}
+	return n, nil
 }
@@ -18,14 +18,25 @@ import (
 	"github.com/nmiyake/pkg/dirs"
 	"github.com/palantir/pkg/cli"
 	"github.com/palantir/pkg/cli/cfgcli"
+	"github.com/palantir/pkg/cli/flag"
 
 	"github.com/palantir/godel/apps/distgo/config"
 )
 
+const verboseFlagName = "verbose"
+
+var verboseFlag = flag.BoolFlag{
+	Name:  verboseFlagName,
+	Usage: "Print the OS/Archs of each product and the effective build configuration for each OS/Arch",
+}
+
 func Command() cli.Command {
 	return cli.Command{
 		Name:  "products",
 		Usage: "List the products in this project",
+		Flags: []flag.Flag{
+			verboseFlag,
+		},
 		Action: func(ctx cli.Context) error {
 			cfg, err := config.Load(cfgcli.ConfigPath, cfgcli.ConfigJSON)
 			if err != nil {
@@ -35,7 +46,7 @@ func Command() cli.Command {
 			if err != nil {
 				return err
 			}
-			return PrintProducts(cfg, wd, ctx.App.Stdout)
+			return PrintProducts(cfg, wd, ctx.Bool(verboseFlagName), ctx.App.Stdout)
 		},
 	}
 }
@@ -22,10 +22,21 @@ import (
 	"github.com/palantir/godel/apps/distgo/params"
 )
 
-func PrintProducts(cfg params.Project, wd string, stdout io.Writer) error {
+// PrintProducts prints the names of the products in the provided project. If verbose is true, the name of each product
+// is followed by the OS/Archs for which it is built and the effective build configuration for each OS/Arch.
+func PrintProducts(cfg params.Project, wd string, verbose bool, stdout io.Writer) error {
 	return build.RunBuildFunc(func(buildSpec []params.ProductBuildSpecWithDeps, stdout io.Writer) error {
 		for _, spec := range buildSpec {
 			fmt.Fprintln(stdout, spec.Spec.ProductName)
+			if !verbose {
+				continue
+			}
+			for _, osArch := range spec.Spec.Build.OSArchs {
+				fmt.Fprintf(stdout, "  %s\n", osArch.String())
+				for _, line := range build.EffectiveConfig(spec.Spec, osArch) {
+					fmt.Fprintf(stdout, "    %s\n", line)
+				}
+			}
 		}
 		return nil
 	}, cfg, nil, wd, stdout)
@@ -90,14 +90,22 @@ func (a ArtifactoryConnectionInfo) Publish(buildSpec params.ProductBuildSpec, pa
 }
 
 func computeArtifactChecksums(artifactoryURL, repoKey, username, password string, paths ProductPaths, stdout io.Writer) error {
-	artifactURL := strings.Join([]string{paths.productPath, path.Base(paths.artifactPath)}, "/")
-	if err := artifactorySetSHA256Checksum(artifactoryURL, repoKey, artifactURL, username, password, stdout); err != nil {
-		return errors.Wrapf(err, "")
+	for _, currArtifactPath := range paths.artifactPaths {
+		artifactURL := strings.Join([]string{paths.productPath, path.Base(currArtifactPath)}, "/")
+		if err := artifactorySetSHA256Checksum(artifactoryURL, repoKey, artifactURL, username, password, stdout); err != nil {
+			return errors.Wrapf(err, "")
+		}
 	}
 	pomPath := strings.Join([]string{paths.productPath, path.Base(paths.pomFilePath)}, "/")
 	if err := artifactorySetSHA256Checksum(artifactoryURL, repoKey, pomPath, username, password, stdout); err != nil {
 		return errors.Wrapf(err, "")
 	}
+	for _, currSigningPath := range paths.signingPaths {
+		signingURL := strings.Join([]string{paths.productPath, path.Base(currSigningPath)}, "/")
+		if err := artifactorySetSHA256Checksum(artifactoryURL, repoKey, signingURL, username, password, stdout); err != nil {
+			return errors.Wrapf(err, "")
+		}
+	}
 	return nil
 }
 
@@ -61,8 +61,13 @@ func (b BintrayConnectionInfo) release(buildSpec params.ProductBuildSpec, stdout
 }
 
 func (b BintrayConnectionInfo) addToDownloadsList(buildSpec params.ProductBuildSpec, paths ProductPaths, stdout io.Writer) (rErr error) {
-	downloadsListURLString := strings.Join([]string{b.URL, "file_metadata", b.Subject, b.Repository, paths.productPath, path.Base(paths.artifactPath)}, "/")
-	return b.runBintrayCommand(downloadsListURLString, http.MethodPut, `{"list_in_downloads":true}`, "adding artifact to Bintray downloads list for package", stdout)
+	for _, currArtifactPath := range paths.artifactPaths {
+		downloadsListURLString := strings.Join([]string{b.URL, "file_metadata", b.Subject, b.Repository, paths.productPath, path.Base(currArtifactPath)}, "/")
+		if err := b.runBintrayCommand(downloadsListURLString, http.MethodPut, `{"list_in_downloads":true}`, "adding artifact to Bintray downloads list for package", stdout); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (b BintrayConnectionInfo) runBintrayCommand(urlString, httpMethod, jsonContent, cmdMsg string, stdout io.Writer) (rErr error) {
@@ -40,8 +40,16 @@ func (l LocalPublishInfo) Publish(buildSpec params.ProductBuildSpec, paths Produ
 		return "", errors.Wrapf(err, "Failed to copy POM file")
 	}
 
-	if err := copyArtifact(paths.artifactPath, productPath, stdout); err != nil {
-		return "", errors.Wrapf(err, "Failed to copy artifact file")
+	for _, currArtifactPath := range paths.artifactPaths {
+		if err := copyArtifact(currArtifactPath, productPath, stdout); err != nil {
+			return "", errors.Wrapf(err, "Failed to copy artifact file")
+		}
+	}
+
+	for _, currSigningPath := range paths.signingPaths {
+		if err := copyArtifact(currSigningPath, productPath, stdout); err != nil {
+			return "", errors.Wrapf(err, "Failed to copy checksums or signature file")
+		}
 	}
 
 	return "", nil
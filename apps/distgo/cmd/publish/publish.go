@@ -66,9 +66,23 @@ func Run(buildSpecWithDeps params.ProductBuildSpecWithDeps, publisher Publisher,
 	buildSpec := buildSpecWithDeps.Spec
 	for _, currDistCfg := range buildSpec.Dist {
 		// verify that distribution to publish exists
-		artifactPath := dist.ArtifactPath(buildSpec, currDistCfg)
-		if _, err := os.Stat(artifactPath); os.IsNotExist(err) {
-			return errors.Errorf("distribution for %v does not exist at %v", buildSpec.ProductName, artifactPath)
+		artifactPaths, err := dist.ArtifactPaths(buildSpec, currDistCfg)
+		if err != nil {
+			return err
+		}
+		for _, currArtifactPath := range artifactPaths {
+			if _, err := os.Stat(currArtifactPath); os.IsNotExist(err) {
+				return errors.Errorf("distribution for %v does not exist at %v", buildSpec.ProductName, currArtifactPath)
+			}
+		}
+		signingPaths, err := dist.SigningPaths(buildSpec, currDistCfg)
+		if err != nil {
+			return err
+		}
+		for _, currSigningPath := range signingPaths {
+			if _, err := os.Stat(currSigningPath); os.IsNotExist(err) {
+				return errors.Errorf("checksums or signature for %v does not exist at %v", buildSpec.ProductName, currSigningPath)
+			}
 		}
 
 		paths, err := productPath(buildSpecWithDeps, currDistCfg)
@@ -95,8 +109,15 @@ func DistsNotBuilt(buildSpecWithDeps []params.ProductBuildSpecWithDeps) []params
 	for _, currBuildSpecWithDeps := range buildSpecWithDeps {
 		currBuildSpec := currBuildSpecWithDeps.Spec
 		for _, currDistCfg := range currBuildSpec.Dist {
-			artifactPath := dist.ArtifactPath(currBuildSpec, currDistCfg)
-			if _, err := os.Stat(artifactPath); os.IsNotExist(err) {
+			// if the artifact paths cannot be determined, consider the distribution not built (creating it will report
+			// the error)
+			artifactPaths, err := dist.ArtifactPaths(currBuildSpec, currDistCfg)
+			if err != nil || !artifactsExist(artifactPaths) {
+				distsNotBuilt = append(distsNotBuilt, currBuildSpecWithDeps)
+				continue
+			}
+			// a signed distribution is not considered built if its checksums or signatures do not exist
+			if signingPaths, err := dist.SigningPaths(currBuildSpec, currDistCfg); err != nil || !artifactsExist(signingPaths) {
 				distsNotBuilt = append(distsNotBuilt, currBuildSpecWithDeps)
 			}
 		}
@@ -104,17 +125,30 @@ func DistsNotBuilt(buildSpecWithDeps []params.ProductBuildSpecWithDeps) []params
 	return distsNotBuilt
 }
 
+func artifactsExist(artifactPaths []string) bool {
+	for _, currArtifactPath := range artifactPaths {
+		if _, err := os.Stat(currArtifactPath); os.IsNotExist(err) {
+			return false
+		}
+	}
+	return true
+}
+
 type ProductPaths struct {
 	// path of the form "{{GroupID}}/{{ProductName}}/{{ProductVersion}}". For example, "com/group/foo-service/1.0.1".
-	productPath  string
-	pomFilePath  string
-	artifactPath string
+	productPath string
+	pomFilePath string
+	// paths of the artifacts of the distribution. Contains more than one path if the distribution is split by OS/Arch.
+	artifactPaths []string
+	// paths of the signatures of the artifacts and the SHA256SUMS file of the product and its signatures. Empty if the
+	// product is not signed.
+	signingPaths []string
 }
 
 func productPath(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg params.Dist) (ProductPaths, error) {
 	buildSpec := buildSpecWithDeps.Spec
 
-	distType, err := packagingType(distCfg.Info.Type())
+	distType, err := packagingType(distCfg)
 	if err != nil {
 		return ProductPaths{}, err
 	}
@@ -129,39 +163,70 @@ func productPath(buildSpecWithDeps params.ProductBuildSpecWithDeps, distCfg para
 		return ProductPaths{}, errors.Wrapf(err, "failed to execute template")
 	}
 
+	artifactPaths, err := dist.ArtifactPaths(buildSpec, distCfg)
+	if err != nil {
+		return ProductPaths{}, err
+	}
+	signingPaths, err := dist.SigningPaths(buildSpec, distCfg)
+	if err != nil {
+		return ProductPaths{}, err
+	}
+
 	pomFilePath := pomFilePath(buildSpec, distCfg)
 	if err := ioutil.WriteFile(pomFilePath, pomFileBuf.Bytes(), 0644); err != nil {
 		return ProductPaths{}, errors.Wrapf(err, "failed to write POM file to %v", pomFilePath)
 	}
 
 	return ProductPaths{
-		productPath:  path.Join(path.Join(strings.Split(distCfg.Publish.GroupID, ".")...), buildSpec.ProductName, buildSpec.ProductVersion),
-		pomFilePath:  pomFilePath,
-		artifactPath: dist.ArtifactPath(buildSpec, distCfg),
+		productPath:   path.Join(path.Join(strings.Split(distCfg.Publish.GroupID, ".")...), buildSpec.ProductName, buildSpec.ProductVersion),
+		pomFilePath:   pomFilePath,
+		artifactPaths: artifactPaths,
+		signingPaths:  signingPaths,
 	}, nil
 }
 
-func packagingType(distType params.DistInfoType) (string, error) {
-	switch distType {
+func packagingType(distCfg params.Dist) (string, error) {
+	archiveType := "tgz"
+	if distCfg.ArchiveFormat == params.ZipArchiveFormat {
+		archiveType = "zip"
+	}
+	switch distType := distCfg.Info.Type(); distType {
 	case params.SLSDistType:
-		return "sls.tgz", nil
+		return "sls." + archiveType, nil
 	case params.BinDistType:
-		return "tgz", nil
+		return archiveType, nil
 	case params.RPMDistType:
 		return "rpm", nil
+	case params.DebDistType:
+		return "deb", nil
+	case params.OCIDistType:
+		return "oci.tar", nil
 	default:
 		return "", fmt.Errorf("unknown dist type: %v", distType)
 	}
 }
 
+// uploadArtifacts uploads the artifacts, POM file, checksums and signatures of the provided paths and returns the URL of
+// the first artifact.
 func (b BasicConnectionInfo) uploadArtifacts(baseURL string, paths ProductPaths, artifactExists artifactExistsFunc, stdout io.Writer) (string, error) {
-	artifactURL, err := b.uploadFile(paths.artifactPath, baseURL, paths.artifactPath, artifactExists, stdout)
-	if err != nil {
-		return artifactURL, err
+	var artifactURL string
+	for i, currArtifactPath := range paths.artifactPaths {
+		currArtifactURL, err := b.uploadFile(currArtifactPath, baseURL, currArtifactPath, artifactExists, stdout)
+		if i == 0 {
+			artifactURL = currArtifactURL
+		}
+		if err != nil {
+			return artifactURL, err
+		}
 	}
 	if _, err := b.uploadFile(paths.pomFilePath, baseURL, paths.pomFilePath, artifactExists, stdout); err != nil {
 		return artifactURL, err
 	}
+	for _, currSigningPath := range paths.signingPaths {
+		if _, err := b.uploadFile(currSigningPath, baseURL, currSigningPath, artifactExists, stdout); err != nil {
+			return artifactURL, err
+		}
+	}
 	return artifactURL, nil
 }
 
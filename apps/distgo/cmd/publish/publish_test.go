@@ -19,6 +19,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/palantir/godel/apps/distgo/params"
 	"github.com/palantir/godel/apps/distgo/pkg/git"
 	"github.com/palantir/godel/apps/distgo/pkg/git/gittest"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
 )
 
 const (
@@ -52,6 +54,9 @@ func TestPublishLocal(t *testing.T) {
 	defer cleanup()
 	require.NoError(t, err)
 
+	keyring, err := filepath.Abs(path.Join("..", "..", "pkg", "signing", "testdata", "rsa.gpg"))
+	require.NoError(t, err)
+
 	for i, currCase := range []struct {
 		buildSpec   func(projectDir string) params.ProductBuildSpecWithDeps
 		skip        func() bool
@@ -180,6 +185,71 @@ func TestPublishLocal(t *testing.T) {
 				"com/palantir/pcloud-rpm/test/0.0.1/test-0.0.1.pom": "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<project xsi:schemaLocation=\"http://maven.apache.org/POM/4.0.0 http://maven.apache.org/xsd/maven-4.0.0.xsd\" xmlns=\"http://maven.apache.org/POM/4.0.0\"\nxmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\n<modelVersion>4.0.0</modelVersion>\n<groupId>com.palantir.pcloud-rpm</groupId>\n<artifactId>test</artifactId>\n<version>0.0.1</version>\n<packaging>rpm</packaging>\n</project>\n",
 			},
 		},
+		{
+			buildSpec: func(projectDir string) params.ProductBuildSpecWithDeps {
+				specWithDeps, err := params.NewProductBuildSpecWithDeps(params.NewProductBuildSpec(projectDir, "test", git.ProjectInfo{
+					Version:  "0.0.1",
+					Branch:   "0.0.1",
+					Revision: "0",
+				}, params.Product{
+					Build: params.Build{
+						MainPkg: "./.",
+						OSArchs: []osarch.OSArch{
+							{OS: "darwin", Arch: "amd64"},
+							{OS: "windows", Arch: "amd64"},
+						},
+					},
+					Dist: []params.Dist{{
+						Info:          &params.BinDistInfo{},
+						ArchiveFormat: params.ZipArchiveFormat,
+						SplitByOSArch: true,
+					}},
+					DefaultPublish: params.Publish{
+						GroupID: "com.palantir.distgo-publish-test",
+					},
+				}, params.Project{}), nil)
+				require.NoError(t, err)
+				return specWithDeps
+			},
+			wantPaths: []string{
+				"com/palantir/distgo-publish-test/test/0.0.1/test-0.0.1.pom",
+				"com/palantir/distgo-publish-test/test/0.0.1/test-0.0.1-darwin-amd64.zip",
+				"com/palantir/distgo-publish-test/test/0.0.1/test-0.0.1-windows-amd64.zip",
+			},
+			wantContent: map[string]string{
+				"com/palantir/distgo-publish-test/test/0.0.1/test-0.0.1.pom": "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<project xsi:schemaLocation=\"http://maven.apache.org/POM/4.0.0 http://maven.apache.org/xsd/maven-4.0.0.xsd\" xmlns=\"http://maven.apache.org/POM/4.0.0\"\nxmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\n<modelVersion>4.0.0</modelVersion>\n<groupId>com.palantir.distgo-publish-test</groupId>\n<artifactId>test</artifactId>\n<version>0.0.1</version>\n<packaging>zip</packaging>\n</project>\n",
+			},
+		},
+		{
+			buildSpec: func(projectDir string) params.ProductBuildSpecWithDeps {
+				specWithDeps, err := params.NewProductBuildSpecWithDeps(params.NewProductBuildSpec(projectDir, "publish-test-service", git.ProjectInfo{
+					Version:  "0.0.1",
+					Branch:   "0.0.1",
+					Revision: "0",
+				}, params.Product{
+					Build: params.Build{
+						MainPkg: "./.",
+					},
+					DefaultPublish: params.Publish{
+						GroupID: "com.palantir.distgo-publish-test",
+					},
+					Signing: &params.Signing{
+						OpenPGP: &params.OpenPGPSigning{
+							Keyring: keyring,
+						},
+					},
+				}, params.Project{}), nil)
+				require.NoError(t, err)
+				return specWithDeps
+			},
+			wantPaths: []string{
+				"com/palantir/distgo-publish-test/publish-test-service/0.0.1/publish-test-service-0.0.1.pom",
+				"com/palantir/distgo-publish-test/publish-test-service/0.0.1/publish-test-service-0.0.1.sls.tgz",
+				"com/palantir/distgo-publish-test/publish-test-service/0.0.1/publish-test-service-0.0.1.sls.tgz.asc",
+				"com/palantir/distgo-publish-test/publish-test-service/0.0.1/publish-test-service-0.0.1-SHA256SUMS",
+				"com/palantir/distgo-publish-test/publish-test-service/0.0.1/publish-test-service-0.0.1-SHA256SUMS.asc",
+			},
+		},
 	} {
 		if currCase.skip != nil && currCase.skip() {
 			fmt.Printf("Skipping case %d\n", i)
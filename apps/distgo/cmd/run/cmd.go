@@ -15,28 +15,50 @@
 package run
 
 import (
+	"sort"
+
 	"github.com/nmiyake/pkg/dirs"
 	"github.com/palantir/pkg/cli"
 	"github.com/palantir/pkg/cli/cfgcli"
 	"github.com/palantir/pkg/cli/flag"
 	"github.com/pkg/errors"
 
+	"github.com/palantir/godel/apps/distgo/cmd"
 	"github.com/palantir/godel/apps/distgo/cmd/build"
 	"github.com/palantir/godel/apps/distgo/config"
+	"github.com/palantir/godel/apps/distgo/params"
 )
 
-const productFlag = "product"
+const (
+	productFlag = "product"
+	groupFlag   = "group"
+	watchFlag   = "watch"
+	noColorFlag = "no-color"
+)
 
 func Command() cli.Command {
 	return cli.Command{
 		Name:  "run",
-		Usage: "Run a product in the project",
+		Usage: "Run one or more products in the project",
 		Flags: []flag.Flag{
+			cmd.ProductsParam,
 			flag.StringFlag{
 				Name:     productFlag,
 				Usage:    "Product to run",
 				Required: false,
 			},
+			flag.StringFlag{
+				Name:  groupFlag,
+				Usage: "Run group (declared in run-groups) whose products should be run",
+			},
+			flag.BoolFlag{
+				Name:  watchFlag,
+				Usage: "Build and run the product and rebuild and restart it whenever its Go source files change",
+			},
+			flag.BoolFlag{
+				Name:  noColorFlag,
+				Usage: "Do not color the product name prefixes of output when running multiple products",
+			},
 		},
 		Action: func(ctx cli.Context) error {
 			cfg, err := config.Load(cfgcli.ConfigPath, cfgcli.ConfigJSON)
@@ -44,11 +66,17 @@ func Command() cli.Command {
 				return err
 			}
 
-			var products []string
-			product := ctx.String(productFlag)
-			if product != "" {
+			products := ctx.Slice(cmd.ProductsParamName)
+			if product := ctx.String(productFlag); product != "" {
 				products = append(products, product)
 			}
+			if group := ctx.String(groupFlag); group != "" {
+				groupProducts, ok := cfg.RunGroups[group]
+				if !ok {
+					return errors.Errorf("unknown run group %s: valid run groups are %v", group, runGroupNames(cfg))
+				}
+				products = append(products, groupProducts...)
+			}
 
 			wd, err := dirs.GetwdEvalSymLinks()
 			if err != nil {
@@ -60,15 +88,60 @@ func Command() cli.Command {
 				return err
 			}
 
-			if len(buildSpecsWithDeps) > 1 {
+			if len(products) == 0 && len(buildSpecsWithDeps) > 1 {
 				programNames := make([]string, len(buildSpecsWithDeps))
 				for i, currSpec := range buildSpecsWithDeps {
 					programNames[i] = currSpec.Spec.ProductName
 				}
-				return errors.Errorf("more than one product exists, so product to run must be specified using the '--%v' flag: %v", productFlag, programNames)
+				return errors.Errorf("more than one product exists, so products to run must be specified as arguments, using the '--%v' flag or using the '--%v' flag: %v", productFlag, groupFlag, programNames)
+			}
+
+			if len(buildSpecsWithDeps) == 1 && len(buildSpecsWithDeps[0].Spec.Run.DependsOn) == 0 {
+				if ctx.Bool(watchFlag) {
+					watchCtx, cancel := cmd.InterruptContext()
+					defer cancel()
+					return DoRunWatch(watchCtx, buildSpecsWithDeps[0].Spec, nil, DefaultStopTimeout, ctx.App.Stdout, ctx.App.Stderr)
+				}
+				return DoRun(buildSpecsWithDeps[0].Spec, nil, ctx.App.Stdout, ctx.App.Stderr)
 			}
 
-			return DoRun(buildSpecsWithDeps[0].Spec, nil, ctx.App.Stdout, ctx.App.Stderr)
+			if ctx.Bool(watchFlag) {
+				return errors.Errorf("'--%v' cannot be used when running multiple products", watchFlag)
+			}
+			buildSpecs, err := groupSpecs(buildSpecsWithDeps, cfg, wd)
+			if err != nil {
+				return err
+			}
+			runCtx, cancel := cmd.InterruptContext()
+			defer cancel()
+			return DoRunGroup(runCtx, buildSpecs, DefaultStopTimeout, !ctx.Bool(noColorFlag), ctx.App.Stdout, ctx.App.Stderr)
 		},
 	}
 }
+
+// groupSpecs returns the specs of the provided products and the products they depend on in the order returned by
+// StartOrder.
+func groupSpecs(buildSpecsWithDeps []params.ProductBuildSpecWithDeps, cfg params.Project, wd string) ([]params.ProductBuildSpec, error) {
+	allSpecsWithDeps, err := build.SpecsWithDepsForArgs(cfg, nil, wd)
+	if err != nil {
+		return nil, err
+	}
+	allSpecs := make(map[string]params.ProductBuildSpec, len(allSpecsWithDeps))
+	for _, currSpec := range allSpecsWithDeps {
+		allSpecs[currSpec.Spec.ProductName] = currSpec.Spec
+	}
+	products := make([]string, len(buildSpecsWithDeps))
+	for i, currSpec := range buildSpecsWithDeps {
+		products[i] = currSpec.Spec.ProductName
+	}
+	return StartOrder(products, allSpecs)
+}
+
+func runGroupNames(cfg params.Project) []string {
+	var names []string
+	for currName := range cfg.RunGroups {
+		names = append(names, currName)
+	}
+	sort.Strings(names)
+	return names
+}
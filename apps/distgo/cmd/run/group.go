@@ -0,0 +1,219 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/params"
+)
+
+const (
+	// DefaultReadyTimeout is the amount of time that a product that specifies a readiness check has to become ready
+	// after it is started if its check does not specify a timeout.
+	DefaultReadyTimeout = 30 * time.Second
+
+	// readyCheckInterval is the interval at which readiness checks are attempted.
+	readyCheckInterval = 100 * time.Millisecond
+	// readyProbeTimeout is the timeout for a single attempt of a readiness check.
+	readyProbeTimeout = time.Second
+)
+
+// StartOrder returns the specs of the provided products and of all of the products that they depend on (directly or
+// transitively) in the order in which they should be started: every product is preceded by the products that it
+// depends on. The order is deterministic. Returns an error if any of the products is not in allSpecs or if the
+// dependencies of the products contain a cycle.
+func StartOrder(products []string, allSpecs map[string]params.ProductBuildSpec) ([]params.ProductBuildSpec, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	var ordered []params.ProductBuildSpec
+
+	var visit func(product string, dependents []string) error
+	visit = func(product string, dependents []string) error {
+		switch state[product] {
+		case visited:
+			return nil
+		case visiting:
+			return errors.Errorf("run dependencies of products contain a cycle: %s", strings.Join(append(dependents, product), " -> "))
+		}
+		spec, ok := allSpecs[product]
+		if !ok {
+			if len(dependents) == 0 {
+				return errors.Errorf("unknown product: %s", product)
+			}
+			return errors.Errorf("%s depends on unknown product %s", dependents[len(dependents)-1], product)
+		}
+
+		state[product] = visiting
+		for _, currDep := range sortedCopy(spec.Run.DependsOn) {
+			if err := visit(currDep, append(dependents, product)); err != nil {
+				return err
+			}
+		}
+		state[product] = visited
+		ordered = append(ordered, spec)
+		return nil
+	}
+
+	for _, currProduct := range sortedCopy(products) {
+		if err := visit(currProduct, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func sortedCopy(in []string) []string {
+	out := append([]string{}, in...)
+	sort.Strings(out)
+	return out
+}
+
+// DoRunGroup builds the products of the provided specs for the current OS/Arch and runs them concurrently. The products
+// are started in the order of the provided specs (which should be the order returned by StartOrder). If a product
+// specifies a readiness check, the next product is not started until the check succeeds. Every line of output of a
+// product is prefixed with its name (in color if color is true). Runs until the provided context is done or one of the
+// products exits, at which point the running products are stopped in the reverse of the order in which they were
+// started. A product is stopped by sending it SIGTERM and killing it if it has not exited after stopTimeout. Returns an
+// error if a product fails to build, start or become ready or if a product exits with an error.
+func DoRunGroup(runCtx context.Context, buildSpecs []params.ProductBuildSpec, stopTimeout time.Duration, color bool, stdout, stderr io.Writer) error {
+	executables := make([]string, len(buildSpecs))
+	for i, currSpec := range buildSpecs {
+		executable, err := buildExecutable(currSpec, stdout)
+		if err != nil {
+			return err
+		}
+		executables[i] = executable
+	}
+
+	// all output is written while holding the same lock so that lines of different products are not interleaved
+	outputLock := &sync.Mutex{}
+	lockedStdout := &lockedWriter{w: stdout, lock: outputLock}
+	prefixes := outputPrefixes(buildSpecs, color)
+
+	var running []*process
+	var writers []*prefixWriter
+	defer func() {
+		for i := len(running) - 1; i >= 0; i-- {
+			fmt.Fprintf(lockedStdout, "Stopping %s\n", buildSpecs[i].ProductName)
+			running[i].stop(stopTimeout)
+		}
+		for _, currWriter := range writers {
+			currWriter.Flush()
+		}
+	}()
+
+	exited := make(chan int, len(buildSpecs))
+	for i, currSpec := range buildSpecs {
+		productStdout := newPrefixWriter(stdout, prefixes[i], outputLock)
+		productStderr := newPrefixWriter(stderr, prefixes[i], outputLock)
+		writers = append(writers, productStdout, productStderr)
+
+		cmd := runCommand(currSpec, executables[i], nil, productStdout, productStderr)
+		// run products in their own process group so that an interrupt from the terminal is not delivered to all of
+		// them at once, which allows them to be stopped in order
+		cmd.SysProcAttr = groupSysProcAttr()
+		p, err := startProcess(cmd, lockedStdout)
+		if err != nil {
+			return err
+		}
+		running = append(running, p)
+		go func(i int, p *process) {
+			<-p.done
+			exited <- i
+		}(i, p)
+
+		if err := waitReady(runCtx, currSpec, p); err != nil {
+			if runCtx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+
+	select {
+	case <-runCtx.Done():
+		return nil
+	case i := <-exited:
+		if err := running[i].err; err != nil {
+			return errors.Wrapf(err, "%s failed", buildSpecs[i].ProductName)
+		}
+		fmt.Fprintf(lockedStdout, "%s exited\n", buildSpecs[i].ProductName)
+		return nil
+	}
+}
+
+// waitReady blocks until the readiness check of the provided spec succeeds. Returns immediately if the spec does not
+// specify a readiness check. Returns an error if the check does not succeed within its timeout, if the process exits or
+// if the provided context is done before the check succeeds.
+func waitReady(runCtx context.Context, buildSpec params.ProductBuildSpec, p *process) error {
+	check := buildSpec.Run.Ready
+	if !check.IsSet() {
+		return nil
+	}
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultReadyTimeout
+	}
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(readyCheckInterval)
+	defer ticker.Stop()
+	for !isReady(check) {
+		select {
+		case <-runCtx.Done():
+			return runCtx.Err()
+		case <-p.done:
+			return errors.Errorf("%s exited before it was ready", buildSpec.ProductName)
+		case <-deadline:
+			return errors.Errorf("%s was not ready within %v", buildSpec.ProductName, timeout)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// isReady performs a single attempt of the provided readiness check.
+func isReady(check params.ReadyCheck) bool {
+	if check.TCP != "" {
+		conn, err := net.DialTimeout("tcp", check.TCP, readyProbeTimeout)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}
+	client := &http.Client{
+		Timeout: readyProbeTimeout,
+	}
+	resp, err := client.Get(check.HTTP)
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
@@ -0,0 +1,103 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/palantir/godel/apps/distgo/params"
+)
+
+// prefixColors are the ANSI color codes used for the output prefixes of products.
+var prefixColors = []int{36, 33, 32, 35, 34, 31}
+
+// outputPrefixes returns the prefix for the output of each of the provided specs. A prefix consists of the name of the
+// product padded to the length of the longest name followed by " | ". If color is true, each prefix is colored with
+// the next color in prefixColors.
+func outputPrefixes(buildSpecs []params.ProductBuildSpec, color bool) []string {
+	width := 0
+	for _, currSpec := range buildSpecs {
+		if len(currSpec.ProductName) > width {
+			width = len(currSpec.ProductName)
+		}
+	}
+	prefixes := make([]string, len(buildSpecs))
+	for i, currSpec := range buildSpecs {
+		prefixes[i] = fmt.Sprintf("%-*s | ", width, currSpec.ProductName)
+		if color {
+			prefixes[i] = fmt.Sprintf("\x1b[%dm%s\x1b[0m", prefixColors[i%len(prefixColors)], prefixes[i])
+		}
+	}
+	return prefixes
+}
+
+// lockedWriter is a writer that holds a lock while writing to the underlying writer.
+type lockedWriter struct {
+	w    io.Writer
+	lock sync.Locker
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.w.Write(p)
+}
+
+// prefixWriter is a writer that writes every line written to it to the underlying writer with a prefix. Lines are
+// only written once they are complete, and the lock is held while writing them.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	lock   sync.Locker
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, prefix string, lock sync.Locker) *prefixWriter {
+	return &prefixWriter{
+		w:      w,
+		prefix: prefix,
+		lock:   lock,
+	}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := fmt.Fprintf(w.w, "%s%s", w.prefix, w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any incomplete line that has been written to the writer followed by a newline.
+func (w *prefixWriter) Flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.buf) > 0 {
+		fmt.Fprintf(w.w, "%s%s\n", w.prefix, w.buf)
+		w.buf = nil
+	}
+}
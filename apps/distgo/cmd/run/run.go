@@ -21,41 +21,110 @@ import (
 	"go/token"
 	"io"
 	"io/ioutil"
+	"os"
 	"os/exec"
+	"os/signal"
 	"path"
 	"strings"
+	"syscall"
 
 	"github.com/pkg/errors"
 
+	"github.com/palantir/godel/apps/distgo/cmd/build"
 	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
 )
 
+// forwardedSignals are the signals received by the "run" task that are forwarded to the running product.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// DoRun builds the product of the provided spec for the current OS/Arch and runs the executable with the run arguments
+// of the spec followed by the provided arguments. The product is built using build.Run, so the executable is the same
+// as the one produced by the "build" task (it uses the environment, ldflags and build arguments of the product) and is
+// restored from the build cache if it is up-to-date. The executable is run in the working directory and with the
+// environment specified by the run configuration of the spec, and the interrupt and termination signals received while
+// it runs are forwarded to it.
 func DoRun(buildSpec params.ProductBuildSpec, runArgs []string, stdout, stderr io.Writer) error {
-	mainPkgDir := path.Join(buildSpec.ProjectDir, buildSpec.Build.MainPkg)
-	mainPkgFileNames, err := mainPkgFileNames(mainPkgDir)
+	executable, err := buildExecutable(buildSpec, stdout)
 	if err != nil {
-		return errors.Wrapf(err, "failed to find main file")
+		return err
 	}
 
-	cmd := exec.Command("go")
-	args := []string{cmd.Path, "run"}
-	for _, name := range mainPkgFileNames {
-		args = append(args, path.Join(buildSpec.ProjectDir, buildSpec.Build.MainPkg, name))
+	cmd := runCommand(buildSpec, executable, runArgs, stdout, stderr)
+	fmt.Fprintln(stdout, strings.Join(cmd.Args, " "))
+	if err := cmd.Start(); err != nil {
+		return errors.Wrapf(err, "failed to start %s", executable)
 	}
-	args = append(args, buildSpec.Run.Args...)
-	args = append(args, runArgs...)
-	cmd.Args = args
 
-	cmd.Stdout = stdout
-	cmd.Stderr = stderr
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
 
-	fmt.Fprintln(stdout, strings.Join(args, " "))
-	if err := cmd.Run(); err != nil {
-		return errors.Wrapf(err, "go run failed")
+	if err := cmd.Wait(); err != nil {
+		return errors.Wrapf(err, "%s failed", buildSpec.ProductName)
 	}
 	return nil
 }
 
+// runBuildContext is the context used to build products for the "run" task. Products are only built for the current
+// OS/Arch, so the "install" step (which speeds up repeated builds for multiple OS/Archs) is not run.
+var runBuildContext = build.Context{}
+
+// buildExecutable builds the product of the provided spec for the current OS/Arch and returns the path to its
+// executable.
+func buildExecutable(buildSpec params.ProductBuildSpec, stdout io.Writer) (string, error) {
+	if !params.IsExecutableBuildMode(buildSpec.Build.BuildMode) {
+		return "", errors.Errorf("%s cannot be run because its build-mode %s does not produce an executable", buildSpec.ProductName, buildSpec.Build.BuildMode)
+	}
+	if _, err := mainPkgFileNames(path.Join(buildSpec.ProjectDir, buildSpec.Build.MainPkg)); err != nil {
+		return "", errors.Wrapf(err, "failed to find main file")
+	}
+
+	buildSpec.Build.OSArchs = []osarch.OSArch{osarch.Current()}
+	if err := build.Run([]params.ProductBuildSpec{buildSpec}, nil, runBuildContext, stdout); err != nil {
+		return "", errors.Wrapf(err, "failed to build %s", buildSpec.ProductName)
+	}
+	artifactPaths, err := build.ArtifactPaths(buildSpec)
+	if err != nil {
+		return "", err
+	}
+	return artifactPaths[osarch.Current()], nil
+}
+
+// runCommand returns the command that runs the provided executable for the provided spec with the run arguments of the
+// spec followed by the provided arguments in the working directory and with the environment specified by the run
+// configuration of the spec.
+func runCommand(buildSpec params.ProductBuildSpec, executable string, runArgs []string, stdout, stderr io.Writer) *exec.Cmd {
+	args := append(append([]string{}, buildSpec.Run.Args...), runArgs...)
+	cmd := exec.Command(executable, args...)
+	if workingDir := buildSpec.Run.WorkingDir; workingDir != "" {
+		if !path.IsAbs(workingDir) {
+			workingDir = path.Join(buildSpec.ProjectDir, workingDir)
+		}
+		cmd.Dir = workingDir
+	}
+	env := os.Environ()
+	for k, v := range buildSpec.Run.Environment {
+		env = append(env, fmt.Sprintf("%v=%v", k, v))
+	}
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd
+}
+
 // getMainPkgFiles returns the names of all of the files in the "main" pkg of the specified directory. Returns an error
 // if there are no files in the "main" package that declares a "main" function (or if there are multiple such files).
 func mainPkgFileNames(mainPkgDir string) ([]string, error) {
@@ -15,13 +15,18 @@
 package run_test
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/nmiyake/pkg/dirs"
 	"github.com/stretchr/testify/assert"
@@ -352,3 +357,364 @@ func main() {
 		}
 	}
 }
+
+func TestRunUsesBuildAndRunConfig(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(path.Join(tmp, "main.go"), []byte(`package main
+
+import (
+	"fmt"
+	"os"
+)
+
+var version = "unset"
+
+func main() {
+	wd, _ := os.Getwd()
+	fmt.Printf("%s|%s|%s|%s", version, os.Getenv("BUILD_ENV"), os.Getenv("RUN_ENV"), wd)
+}
+`), 0644)
+	require.NoError(t, err)
+	err = os.MkdirAll(path.Join(tmp, "work"), 0755)
+	require.NoError(t, err)
+
+	spec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		git.ProjectInfo{
+			Version: "1.2.3",
+		},
+		params.Product{
+			Build: params.Build{
+				MainPkg:    "./.",
+				VersionVar: "main.version",
+				Environment: map[string]string{
+					"BUILD_ENV": "build",
+				},
+			},
+			Run: params.Run{
+				Environment: map[string]string{
+					"RUN_ENV": "run",
+				},
+				WorkingDir: "work",
+			},
+		},
+		params.Project{
+			BuildOutputDir: "build",
+		},
+	)
+
+	buf := &bytes.Buffer{}
+	err = run.DoRun(spec, nil, buf, ioutil.Discard)
+	require.NoError(t, err)
+	// the build environment only applies to the build, while the run environment applies to the executable
+	assert.True(t, strings.HasSuffix(buf.String(), "\n1.2.3||run|"+path.Join(tmp, "work")), "Unexpected output: %s", buf.String())
+}
+
+const runWatchTestMain = `package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+)
+
+const version = "{{VERSION}}"
+
+func main() {
+	ioutil.WriteFile(path.Join("{{OUTPUT_PATH}}", "started.txt"), []byte(fmt.Sprintf("%v %v", version, os.Args[1:])), 0644)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	<-signals
+	ioutil.WriteFile(path.Join("{{OUTPUT_PATH}}", "stopped-"+version+".txt"), nil, 0644)
+}
+`
+
+func TestRunWatch(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	writeMain := func(version string) {
+		content := strings.Replace(strings.Replace(runWatchTestMain, "{{OUTPUT_PATH}}", tmp, -1), "{{VERSION}}", version, -1)
+		err := ioutil.WriteFile(path.Join(tmp, "main.go"), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+	waitForFile := func(name, want string) {
+		var content []byte
+		for start := time.Now(); time.Since(start) < 30*time.Second; time.Sleep(50 * time.Millisecond) {
+			if content, err = ioutil.ReadFile(path.Join(tmp, name)); err == nil && string(content) == want {
+				return
+			}
+		}
+		assert.Fail(t, "timed out waiting for file", "%s: want %q, got %q", name, want, string(content))
+	}
+	writeMain("first")
+
+	// use module mode so that the project does not need to be in GOPATH
+	err = ioutil.WriteFile(path.Join(tmp, "go.mod"), []byte("module example.com/foo\n"), 0644)
+	require.NoError(t, err)
+	orig, isSet := os.LookupEnv("GO111MODULE")
+	defer func() {
+		if isSet {
+			_ = os.Setenv("GO111MODULE", orig)
+		} else {
+			_ = os.Unsetenv("GO111MODULE")
+		}
+	}()
+	err = os.Unsetenv("GO111MODULE")
+	require.NoError(t, err)
+
+	spec := params.NewProductBuildSpec(
+		tmp,
+		"foo",
+		git.ProjectInfo{},
+		params.Product{
+			Build: params.Build{
+				MainPkg: "./.",
+			},
+			Run: params.Run{
+				Args: []string{"cfgArg"},
+			},
+		},
+		params.Project{
+			BuildOutputDir: "build",
+		},
+	)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- run.DoRunWatch(runCtx, spec, []string{"runArg"}, 10*time.Second, ioutil.Discard, ioutil.Discard)
+	}()
+	waitForFile("started.txt", "first [cfgArg runArg]")
+
+	// change causes running process to be stopped gracefully and the rebuilt product to be started
+	writeMain("second-version")
+	waitForFile("stopped-first.txt", "")
+	waitForFile("started.txt", "second-version [cfgArg runArg]")
+
+	// process is stopped when the watch ends
+	cancel()
+	assert.NoError(t, <-done)
+	waitForFile("stopped-second-version.txt", "")
+}
+
+func TestStartOrder(t *testing.T) {
+	specs := func(dependsOn map[string][]string) map[string]params.ProductBuildSpec {
+		allSpecs := make(map[string]params.ProductBuildSpec)
+		for product, deps := range dependsOn {
+			allSpecs[product] = params.ProductBuildSpec{
+				ProductName: product,
+				Product: params.Product{
+					Run: params.Run{
+						DependsOn: deps,
+					},
+				},
+			}
+		}
+		return allSpecs
+	}
+
+	for i, currCase := range []struct {
+		products  []string
+		dependsOn map[string][]string
+		want      []string
+		wantError string
+	}{
+		{
+			products:  []string{"b", "a"},
+			dependsOn: map[string][]string{"a": nil, "b": nil, "c": nil},
+			want:      []string{"a", "b"},
+		},
+		{
+			products:  []string{"api"},
+			dependsOn: map[string][]string{"api": {"queue", "db"}, "queue": {"db"}, "db": nil, "web": {"api"}},
+			want:      []string{"db", "queue", "api"},
+		},
+		{
+			products:  []string{"web", "db"},
+			dependsOn: map[string][]string{"api": {"db"}, "db": nil, "web": {"api"}},
+			want:      []string{"db", "api", "web"},
+		},
+		{
+			products:  []string{"api"},
+			dependsOn: map[string][]string{"api": {"cache"}},
+			wantError: "api depends on unknown product cache",
+		},
+		{
+			products:  []string{"a"},
+			dependsOn: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			wantError: "run dependencies of products contain a cycle: a -> b -> c -> a",
+		},
+		{
+			products:  []string{"missing"},
+			dependsOn: map[string][]string{"a": nil},
+			wantError: "unknown product: missing",
+		},
+	} {
+		got, err := run.StartOrder(currCase.products, specs(currCase.dependsOn))
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		var gotProducts []string
+		for _, currSpec := range got {
+			gotProducts = append(gotProducts, currSpec.ProductName)
+		}
+		assert.Equal(t, currCase.want, gotProducts, "Case %d", i)
+	}
+}
+
+const runGroupTestMain = `package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+	"time"
+)
+
+func main() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+
+	if addr := os.Getenv("LISTEN_ADDR"); addr != "" {
+		// delay listening so that dependent products are only started because of the readiness check
+		time.Sleep(500 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			panic(err)
+		}
+		defer l.Close()
+	}
+	if addr := os.Getenv("DB_ADDR"); addr != "" {
+		if _, err := net.Dial("tcp", addr); err != nil {
+			fmt.Println("{{NAME}} could not connect to db")
+		} else {
+			fmt.Println("{{NAME}} connected to db")
+		}
+	}
+	fmt.Println("{{NAME}} started")
+
+	<-signals
+	f, _ := os.OpenFile(path.Join("{{OUTPUT_PATH}}", "stopped.txt"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	fmt.Fprintln(f, "{{NAME}}")
+	f.Close()
+}
+`
+
+func TestRunGroup(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for _, currProduct := range []string{"api", "db"} {
+		err := os.MkdirAll(path.Join(tmp, currProduct), 0755)
+		require.NoError(t, err)
+		content := strings.Replace(strings.Replace(runGroupTestMain, "{{OUTPUT_PATH}}", tmp, -1), "{{NAME}}", currProduct, -1)
+		err = ioutil.WriteFile(path.Join(tmp, currProduct, "main.go"), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	// use module mode so that the project does not need to be in GOPATH
+	err = ioutil.WriteFile(path.Join(tmp, "go.mod"), []byte("module example.com/foo\n"), 0644)
+	require.NoError(t, err)
+	orig, isSet := os.LookupEnv("GO111MODULE")
+	defer func() {
+		if isSet {
+			_ = os.Setenv("GO111MODULE", orig)
+		} else {
+			_ = os.Unsetenv("GO111MODULE")
+		}
+	}()
+	err = os.Unsetenv("GO111MODULE")
+	require.NoError(t, err)
+
+	// determine a free port for the db product
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	dbAddr := l.Addr().String()
+	err = l.Close()
+	require.NoError(t, err)
+
+	allSpecs := map[string]params.ProductBuildSpec{
+		"api": params.NewProductBuildSpec(tmp, "api", git.ProjectInfo{}, params.Product{
+			Build: params.Build{
+				MainPkg: "./api",
+			},
+			Run: params.Run{
+				Environment: map[string]string{
+					"DB_ADDR": dbAddr,
+				},
+				DependsOn: []string{"db"},
+			},
+		}, params.Project{}),
+		"db": params.NewProductBuildSpec(tmp, "db", git.ProjectInfo{}, params.Product{
+			Build: params.Build{
+				MainPkg: "./db",
+			},
+			Run: params.Run{
+				Environment: map[string]string{
+					"LISTEN_ADDR": dbAddr,
+				},
+				Ready: params.ReadyCheck{
+					TCP: dbAddr,
+				},
+			},
+		}, params.Project{}),
+	}
+	specs, err := run.StartOrder([]string{"api"}, allSpecs)
+	require.NoError(t, err)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	buf := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- run.DoRunGroup(runCtx, specs, 10*time.Second, false, buf, buf)
+	}()
+	for start := time.Now(); !strings.Contains(buf.String(), "api started") && time.Since(start) < 60*time.Second; {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// products are stopped in reverse order when the context is done
+	cancel()
+	require.NoError(t, <-done)
+	stopped, err := ioutil.ReadFile(path.Join(tmp, "stopped.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "api\ndb\n", string(stopped))
+
+	output := buf.String()
+	assert.Contains(t, output, "db  | db started\n")
+	assert.Contains(t, output, "api | api connected to db\n")
+	assert.Contains(t, output, "api | api started\n")
+	assert.True(t, strings.Index(output, "db  | db started") < strings.Index(output, "api | api started"), "Unexpected output: %s", output)
+}
+
+// syncBuffer is a bytes.Buffer that can be used concurrently.
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/cmd/build"
+	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+)
+
+// DefaultStopTimeout is the amount of time that a running product is given to exit after it is sent SIGTERM before it
+// is killed.
+const DefaultStopTimeout = 10 * time.Second
+
+// DoRunWatch builds and runs the product of the provided spec like DoRun. Whenever the source files of the product
+// change, the product is rebuilt and, if the build succeeds, the running process is stopped and the product is started
+// again. A process is stopped by sending it SIGTERM and killing it if it has not exited after stopTimeout. If a rebuild
+// fails, the error is written to stdout and the previous process keeps running. Runs until the provided context is
+// done, at which point the running process is stopped.
+func DoRunWatch(runCtx context.Context, buildSpec params.ProductBuildSpec, runArgs []string, stopTimeout time.Duration, stdout, stderr io.Writer) error {
+	var running *process
+	defer func() {
+		if running != nil {
+			running.stop(stopTimeout)
+		}
+	}()
+
+	// only the executable for the current OS/Arch is built and run
+	watchSpec := buildSpec
+	watchSpec.Build.OSArchs = []osarch.OSArch{osarch.Current()}
+	watcher := build.NewWatcher([]params.ProductBuildSpec{watchSpec}, nil, build.DefaultWatchInterval)
+	for {
+		if executable, err := buildExecutable(buildSpec, stdout); err != nil {
+			fmt.Fprintf(stdout, "Build failed: %v\n", err)
+		} else {
+			if running != nil {
+				fmt.Fprintf(stdout, "Stopping %s\n", buildSpec.ProductName)
+				running.stop(stopTimeout)
+			}
+			p, err := startProcess(runCommand(buildSpec, executable, runArgs, stdout, stderr), stdout)
+			if err != nil {
+				return err
+			}
+			running = p
+		}
+		fmt.Fprintln(stdout, "Watching for changes...")
+
+		if _, err := watcher.Wait(runCtx); err != nil {
+			return nil
+		}
+		fmt.Fprintf(stdout, "Detected change in source files of %s\n", buildSpec.ProductName)
+	}
+}
+
+// process is a running product.
+type process struct {
+	cmd *exec.Cmd
+	// done is closed when the process has exited.
+	done chan struct{}
+	// err is the error returned by waiting for the process. Only valid once done is closed.
+	err error
+}
+
+// startProcess starts the provided command and returns the process for it.
+func startProcess(cmd *exec.Cmd, stdout io.Writer) (*process, error) {
+	executable := cmd.Args[0]
+	fmt.Fprintln(stdout, strings.Join(cmd.Args, " "))
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "failed to start %s", executable)
+	}
+	p := &process{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+	go func() {
+		if p.err = cmd.Wait(); p.err != nil {
+			fmt.Fprintf(stdout, "%s exited: %v\n", executable, p.err)
+		}
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// stop sends SIGTERM to the process and waits for it to exit. If the process does not exit within the provided timeout
+// (or cannot be sent SIGTERM, which is the case on Windows), it is killed.
+func (p *process) stop(timeout time.Duration) {
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err == nil {
+		select {
+		case <-p.done:
+			return
+		case <-time.After(timeout):
+		}
+	}
+	_ = p.cmd.Process.Kill()
+	<-p.done
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/cfgcli"
+	"github.com/palantir/pkg/cli/flag"
+
+	"github.com/palantir/godel/apps/distgo/cmd"
+	"github.com/palantir/godel/apps/distgo/config"
+	"github.com/palantir/godel/apps/distgo/params"
+)
+
+const (
+	formatFlagName    = "format"
+	outputDirFlagName = "output-dir"
+)
+
+var (
+	formatFlag = flag.StringFlag{
+		Name:  formatFlagName,
+		Usage: "Format of the SBOM: 'spdx' (SPDX 2.2 JSON) or 'cyclonedx' (CycloneDX 1.4 JSON)",
+		Value: string(params.SPDXSBOMFormat),
+	}
+	outputDirFlag = flag.StringFlag{
+		Name:  outputDirFlagName,
+		Usage: "Directory to which the SBOM of each product is written. If not specified, the SBOM is printed",
+	}
+)
+
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "sbom",
+		Usage: "Print or write a software bill of materials that lists the third-party packages linked into products",
+		Flags: []flag.Flag{
+			cmd.ProductsParam,
+			formatFlag,
+			outputDirFlag,
+		},
+		Action: func(ctx cli.Context) error {
+			cfg, err := config.Load(cfgcli.ConfigPath, cfgcli.ConfigJSON)
+			if err != nil {
+				return err
+			}
+			wd, err := dirs.GetwdEvalSymLinks()
+			if err != nil {
+				return err
+			}
+			return Products(ctx.Slice(cmd.ProductsParamName), cfg, params.SBOMFormat(ctx.String(formatFlagName)), ctx.String(outputDirFlagName), wd, ctx.App.Stdout)
+		},
+	}
+}
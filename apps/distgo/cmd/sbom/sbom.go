@@ -0,0 +1,71 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/cmd/build"
+	"github.com/palantir/godel/apps/distgo/cmd/dist"
+	"github.com/palantir/godel/apps/distgo/params"
+)
+
+// Products writes the software bills of materials of the provided products in the provided format. If outputDir is
+// blank, the SBOM is written to stdout, in which case only a single product can be specified (or the project must
+// contain a single product). Otherwise, the SBOM of each product is written to the file named by dist.SBOMFileName in
+// outputDir (which is resolved against wd if it is relative).
+func Products(products []string, cfg params.Project, format params.SBOMFormat, outputDir, wd string, stdout io.Writer) error {
+	switch format {
+	case params.SPDXSBOMFormat, params.CycloneDXSBOMFormat:
+	default:
+		return errors.Errorf("invalid SBOM format %q: must be one of %q or %q", format, params.SPDXSBOMFormat, params.CycloneDXSBOMFormat)
+	}
+	return build.RunBuildFunc(func(buildSpecsWithDeps []params.ProductBuildSpecWithDeps, stdout io.Writer) error {
+		if outputDir == "" {
+			if len(buildSpecsWithDeps) != 1 {
+				return errors.Errorf("an output directory must be specified to write the SBOMs of multiple products")
+			}
+			doc, err := dist.SBOM(buildSpecsWithDeps[0])
+			if err != nil {
+				return err
+			}
+			return dist.WriteSBOM(stdout, doc, format)
+		}
+
+		if !path.IsAbs(outputDir) {
+			outputDir = path.Join(wd, outputDir)
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return errors.Wrapf(err, "failed to create directory %s", outputDir)
+		}
+		for _, currSpecWithDeps := range buildSpecsWithDeps {
+			doc, err := dist.SBOM(currSpecWithDeps)
+			if err != nil {
+				return err
+			}
+			sbomPath := path.Join(outputDir, dist.SBOMFileName(currSpecWithDeps.Spec, format))
+			if err := dist.WriteSBOMFile(sbomPath, doc, format); err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "Wrote %s SBOM for %s to %s\n", format, currSpecWithDeps.Spec.ProductName, sbomPath)
+		}
+		return nil
+	}, cfg, products, wd, stdout)
+}
@@ -17,7 +17,12 @@ package config
 import (
 	"encoding/json"
 	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/palantir/pkg/matcher"
@@ -25,7 +30,9 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/palantir/godel/apps/distgo/params"
+	"github.com/palantir/godel/apps/distgo/pkg/license"
 	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+	"github.com/palantir/godel/apps/distgo/templating"
 )
 
 type Project struct {
@@ -51,6 +58,10 @@ type Project struct {
 
 	// Exclude matches the paths to exclude when determining the projects to build.
 	Exclude matcher.NamesPathsCfg `yaml:"exclude" json:"exclude"`
+
+	// RunGroups maps the names of run groups to the products in the group. All of the products in a group can be
+	// run together using the "run" task.
+	RunGroups map[string][]string `yaml:"run-groups" json:"run-groups"`
 }
 
 // Product represents user-specified configuration on how to build a specific product.
@@ -67,6 +78,48 @@ type Product struct {
 	// DefaultPublish specifies the publish configuration that is applied to distributions that do not specify their
 	// own publish configurations.
 	DefaultPublish Publish `yaml:"publish" json:"publish"`
+
+	// Signing specifies how the artifacts of the distributions of the product are signed. If signing is specified,
+	// the "dist" task writes a SHA256SUMS file that contains the checksums of all of the artifacts of the product and
+	// creates detached signatures for the artifacts and the SHA256SUMS file using each of the keys that is specified.
+	// The checksum file and signatures are published along with the artifacts. Optional.
+	Signing *Signing `yaml:"signing" json:"signing"`
+}
+
+type Signing struct {
+	// OpenPGP specifies the OpenPGP key used to create ASCII-armored signatures (".asc" files). Optional.
+	OpenPGP OpenPGPSigning `yaml:"openpgp" json:"openpgp"`
+
+	// Minisign specifies the minisign key used to create minisign signatures (".minisig" files). Optional.
+	Minisign MinisignSigning `yaml:"minisign" json:"minisign"`
+}
+
+type OpenPGPSigning struct {
+	// Keyring is the path (relative to the project root, or absolute) to a binary or ASCII-armored keyring file that
+	// contains the secret key used to sign (for example, a file created using "gpg --export-secret-keys"). Only RSA
+	// keys are supported.
+	Keyring string `yaml:"keyring" json:"keyring"`
+
+	// KeyID is the key ID or fingerprint of the key in the keyring that is used to sign. If blank, the first RSA
+	// signing key in the keyring is used.
+	KeyID string `yaml:"key-id" json:"key-id"`
+
+	// PassphraseEnvVar is the name of the environment variable that contains the passphrase of the key. Required if
+	// the key is protected by a passphrase.
+	PassphraseEnvVar string `yaml:"passphrase-env-var" json:"passphrase-env-var"`
+}
+
+type MinisignSigning struct {
+	// KeyPath is the path (relative to the project root, or absolute) to the minisign secret key file used to sign.
+	KeyPath string `yaml:"key-path" json:"key-path"`
+
+	// KeyEnvVar is the name of the environment variable that contains the minisign secret key (either the content of
+	// the secret key file or the base64-encoded key). Only one of KeyPath and KeyEnvVar can be specified.
+	KeyEnvVar string `yaml:"key-env-var" json:"key-env-var"`
+
+	// PassphraseEnvVar is the name of the environment variable that contains the passphrase of the key. Required if
+	// the key is encrypted.
+	PassphraseEnvVar string `yaml:"passphrase-env-var" json:"passphrase-env-var"`
 }
 
 type Build struct {
@@ -88,6 +141,18 @@ type Build struct {
 	// OutputDir is the directory to which the executable is written.
 	OutputDir string `yaml:"output-dir" json:"output-dir"`
 
+	// OutputPath is a Go template for the path of the directory relative to OutputDir to which the outputs for an
+	// OS/Arch are written. The template is rendered using the values of a templating.Config for the product and the
+	// OS/Arch as {{.OSArch}} ({{.OSArch.OS}} and {{.OSArch.Arch}} are also available). If blank, defaults to
+	// "{{.VersionInfo.Version}}/{{.OSArch}}". Templates should not use {{.BuildTime}} since the path would change
+	// between invocations.
+	OutputPath string `yaml:"output-path" json:"output-path"`
+
+	// ArtifactName is a Go template for the name of the outputs for an OS/Arch without the extension determined by the
+	// build mode and OS (such as ".exe"). It is rendered in the same manner as OutputPath. If blank, defaults to
+	// "{{.ProductName}}".
+	ArtifactName string `yaml:"artifact-name" json:"artifact-name"`
+
 	// BuildArgsScript is the content of a script that is written to a file and run before this product is built
 	// to provide supplemental build arguments for the product. The contents of this value are written to a file
 	// with a header `#!/bin/bash` and executed. The script process inherits the environment variables of the Go
@@ -106,6 +171,46 @@ type Build struct {
 	// ldflag.
 	VersionVar string `yaml:"version-var" json:"version-var"`
 
+	// LdflagsVars maps the path of string variables to Go templates for their values. Each value is rendered using a
+	// templating.Config for the product (so values such as {{.VersionInfo.Revision}}, {{.VersionInfo.Branch}} and
+	// {{.BuildTime.Unix}} are available) and is provided to the "build" command as an "-X" ldflag. For example:
+	//
+	//   ldflags-vars:
+	//     main.revision: "{{.VersionInfo.Revision}}"
+	//     main.buildTime: "{{.BuildTime.Unix}}"
+	LdflagsVars map[string]string `yaml:"ldflags-vars" json:"ldflags-vars"`
+
+	// Ldflags contains additional flags that are provided to the linker. All ldflags for the build (including those
+	// specified by LdflagsVars, VersionVar and any "-ldflags" arguments output by BuildArgsScript) are combined into a
+	// single "-ldflags" argument.
+	Ldflags []string `yaml:"ldflags" json:"ldflags"`
+
+	// Gcflags contains flags that are provided to the compiler. They are combined with any "-gcflags" arguments
+	// output by BuildArgsScript into a single "-gcflags" argument.
+	Gcflags []string `yaml:"gcflags" json:"gcflags"`
+
+	// Tags contains the build tags used for the build. They are combined with any "-tags" arguments output by
+	// BuildArgsScript into a single "-tags" argument.
+	Tags []string `yaml:"tags" json:"tags"`
+
+	// Reproducible specifies whether the product is built in reproducible mode. In reproducible mode, file system paths
+	// are trimmed from the executable, the build ID is omitted and the {{.BuildTime}} available to LdflagsVars
+	// templates is taken from the SOURCE_DATE_EPOCH environment variable (or is the Unix epoch if the variable is not
+	// set), so that building the same source with the same toolchain produces identical output.
+	Reproducible bool `yaml:"reproducible" json:"reproducible"`
+
+	// BuildMode is the value provided to the "-buildmode" flag of the build. Must be blank or one of "exe", "pie",
+	// "c-shared", "c-archive" or "plugin". The names of the outputs depend on the build mode and the OS: "c-shared"
+	// creates "{{product}}.so" ("{{product}}.dylib" on darwin and "{{product}}.dll" on windows), "c-archive" creates
+	// "{{product}}.a" and "plugin" creates "{{product}}.so". The "c-shared" and "c-archive" build modes also create a
+	// C header file named "{{product}}.h". If blank, the default build mode (which produces an executable) is used.
+	BuildMode string `yaml:"build-mode" json:"build-mode"`
+
+	// MaxSize is the maximum size of the executable for each OS/Arch. If an executable is larger, the build fails.
+	// The value is a number of bytes optionally followed by a unit: "KB", "MB" and "GB" are powers of 1000 and "KiB",
+	// "MiB" and "GiB" are powers of 1024. For example, "20MB" or "15MiB". If blank, the size is not limited.
+	MaxSize string `yaml:"max-size" json:"max-size"`
+
 	// Environment specifies values for the environment variables that should be set for the build. For example,
 	// the following sets CGO to false:
 	//
@@ -114,24 +219,105 @@ type Build struct {
 	Environment map[string]string `yaml:"environment" json:"environment"`
 
 	// OSArchs specifies the GOOS and GOARCH pairs for which the product is built. If blank, defaults to the GOOS
-	// and GOARCH of the host system at runtime.
+	// and GOARCH of the host system at runtime. Entries may be specified as mappings with "os" and "arch" keys or as
+	// strings of the form "GOOS-GOARCH". Entries may also be patterns that are expanded to all of the matching targets
+	// supported by the Go toolchain: "linux-*" matches all architectures for linux, "*-arm64" matches all operating
+	// systems for arm64 and "first-class" matches all first-class ports (which requires Go 1.12 or later). Every entry
+	// must be or match a target reported by "go tool dist list".
 	OSArchs []osarch.OSArch `yaml:"os-archs" json:"os-archs"`
+
+	// OSArchOverrides specifies build configuration that only applies when the product is built for a specific
+	// GOOS and GOARCH pair. The keys are of the form "GOOS-GOARCH" and the configuration for an OS/Arch is merged
+	// over the configuration of the product. For example, the following uses a different C compiler and an
+	// additional tag when building for "linux-arm64":
+	//
+	//   os-arch-overrides:
+	//     linux-arm64:
+	//       environment:
+	//         CGO_ENABLED: "1"
+	//         CC: aarch64-linux-gnu-gcc
+	//       tags:
+	//         - arm
+	OSArchOverrides map[string]OSArchBuild `yaml:"os-arch-overrides" json:"os-arch-overrides"`
+}
+
+type OSArchBuild struct {
+	// Environment specifies values for environment variables that are set for the build. Values take precedence
+	// over the values for the same variables in Build.Environment.
+	Environment map[string]string `yaml:"environment" json:"environment"`
+
+	// Tags contains build tags that are used in addition to Build.Tags.
+	Tags []string `yaml:"tags" json:"tags"`
+
+	// Ldflags contains flags that are provided to the linker in addition to Build.Ldflags.
+	Ldflags []string `yaml:"ldflags" json:"ldflags"`
+
+	// Args contains additional arguments that are provided to the "build" command. They are handled in the same
+	// manner as the output of BuildArgsScript.
+	Args []string `yaml:"args" json:"args"`
 }
 
 type Run struct {
 	// Args contain the arguments provided to the product when invoked using the "run" task.
 	Args []string `yaml:"args" json:"args"`
+
+	// Environment specifies values for environment variables that are set for the product when it is invoked using
+	// the "run" task in addition to the environment of the "run" task.
+	Environment map[string]string `yaml:"environment" json:"environment"`
+
+	// WorkingDir is the directory in which the product is invoked by the "run" task. A relative path is resolved
+	// against the project directory. If blank, the working directory of the "run" task is used.
+	WorkingDir string `yaml:"working-dir" json:"working-dir"`
+
+	// DependsOn contains the names of the products that must be started (and, if they specify a readiness check, be
+	// ready) before this product is started when multiple products are run together. Running this product also runs
+	// the products it depends on.
+	DependsOn []string `yaml:"depends-on" json:"depends-on"`
+
+	// Ready specifies how to determine that the product is ready when multiple products are run together.
+	Ready ReadyCheck `yaml:"ready" json:"ready"`
+}
+
+type ReadyCheck struct {
+	// TCP is an address ("host:port") that accepts connections once the product is ready.
+	TCP string `yaml:"tcp" json:"tcp"`
+
+	// HTTP is a URL that returns a 2xx or 3xx response to a GET request once the product is ready.
+	HTTP string `yaml:"http" json:"http"`
+
+	// Timeout is the amount of time that the product has to become ready after it is started (for example, "30s").
+	// If blank, the default timeout of the "run" task is used.
+	Timeout string `yaml:"timeout" json:"timeout"`
 }
 
 type Dist struct {
 	// OutputDir is the directory to which the distribution is written.
 	OutputDir string `yaml:"output-dir" json:"output-dir"`
 
+	// OutputPath is a Go template for the path of the directory relative to OutputDir to which the artifacts of the
+	// distribution are written. The template is rendered using the values of a templating.Config for the product and
+	// distribution and, for artifacts that contain the outputs for a single OS/Arch (such as the artifacts of a
+	// distribution that is split by OS/Arch), the OS/Arch as {{.OSArch}}. If blank, artifacts are written to OutputDir.
+	OutputPath string `yaml:"output-path" json:"output-path"`
+
+	// ArtifactName is a Go template for the file name of the artifacts of the distribution. It is rendered in the same
+	// manner as OutputPath. For example, "{{.ProductName}}-{{.ProductVersion}}-{{.OSArch}}.tgz". If blank, the name
+	// is determined by the type of the distribution.
+	ArtifactName string `yaml:"artifact-name" json:"artifact-name"`
+
 	// InputDir is the path (from the project root) to a directory whose contents will be copied into the output
 	// distribution directory at the beginning of the "dist" command. Can be used to include static resources and
 	// other files required in a distribution.
 	InputDir string `yaml:"input-dir" json:"input-dir"`
 
+	// Templates are glob patterns (as supported by path.Match) for the files in InputDir that are rendered as Go
+	// templates when they are copied into the output distribution directory. A pattern that contains a slash is
+	// matched against the path of a file relative to InputDir and a pattern without a slash is matched against the
+	// name of a file. Templates are rendered with the fields of templating.Config ({{.ProductName}},
+	// {{.ProductVersion}}, {{.VersionInfo.Branch}}, {{.VersionInfo.Revision}}, {{.Publish.GroupID}} and so on) and
+	// referencing a key that does not exist is an error. Requires InputDir.
+	Templates []string `yaml:"templates" json:"templates"`
+
 	// InputProducts is a slice of the names of products in the project (other than the current one) whose binaries
 	// are required for the "dist" task. The "dist" task will ensure that the outputs of "build" exist for all of
 	// the products specified in this slice (and will build the products as part of the task if necessary) and make
@@ -158,10 +344,57 @@ type Dist struct {
 	// defaults to a DistInfo of type SLSDistType.
 	DistType DistInfo `yaml:"dist-type" json:"dist-type"`
 
+	// ArchiveFormat is the format of the archive created for "sls" and "bin" distributions: "tgz" or "zip". Default
+	// is "tgz".
+	ArchiveFormat string `yaml:"archive-format" json:"archive-format"`
+
+	// SplitByOSArch specifies that an archive is created for every OS/Arch of the product (for example,
+	// "product-1.2.3-linux-amd64.tgz") rather than a single archive for all of them. Only supported for "sls" and
+	// "bin" distributions.
+	SplitByOSArch bool `yaml:"split-by-os-arch" json:"split-by-os-arch"`
+
+	// ArchiveUID is the user ID of the entries of the tgz archives created for "sls" and "bin" distributions. The
+	// archives are deterministic: entries are sorted, have normalized permissions (0755 for directories and
+	// executables and 0644 for other files) and have the modification time specified by the SOURCE_DATE_EPOCH
+	// environment variable or, if it is not set, the time of the commit that is checked out. Default is 0.
+	ArchiveUID int `yaml:"archive-uid" json:"archive-uid"`
+
+	// ArchiveGID is the group ID of the entries of the tgz archives created for "sls" and "bin" distributions.
+	// Default is 0.
+	ArchiveGID int `yaml:"archive-gid" json:"archive-gid"`
+
+	// SBOM specifies that software bills of materials that list the third-party packages linked into the executables
+	// of the product (along with their vendor paths, versions and licenses) are written for the distribution. The
+	// SBOMs are included in the artifacts of the distribution and are also written to OutputDir. Optional.
+	SBOM *SBOM `yaml:"sbom" json:"sbom"`
+
+	// Licenses specifies that the licenses of the third-party packages linked into the executables of the product are
+	// checked and that a "THIRD_PARTY_NOTICES" file that contains their license and NOTICE files is written to the
+	// root of the distribution directory. Creating the distribution fails if a package has no license file or has a
+	// license that is denied. Optional.
+	Licenses *Licenses `yaml:"licenses" json:"licenses"`
+
 	// Publish is the configuration for the "publish" task.
 	Publish Publish `yaml:"publish" json:"publish"`
 }
 
+type SBOM struct {
+	// Formats are the formats of the SBOMs: "spdx" (SPDX 2.2 JSON) and/or "cyclonedx" (CycloneDX 1.4 JSON). If
+	// empty, SBOMs are written in both formats.
+	Formats []string `yaml:"formats" json:"formats"`
+
+	// Dir is the path of the directory relative to the root of the distribution directory to which the SBOMs are
+	// written. If blank, the SBOMs are written to the root of the distribution directory.
+	Dir string `yaml:"dir" json:"dir"`
+}
+
+type Licenses struct {
+	// Deny are the licenses that third-party packages may not have. Each entry is either the SPDX identifier of a
+	// license (such as "GPL-3.0") or a license category: "permissive", "weak-copyleft", "copyleft", "public-domain" or
+	// "unknown" (a license file whose license is not recognized).
+	Deny []string `yaml:"deny" json:"deny"`
+}
+
 type DistInfo struct {
 	// Type is the type of the distribution. Value should be a valid value defined by params.DistInfoType.
 	Type string `yaml:"type" json:"type"`
@@ -205,6 +438,37 @@ type SLSDist struct {
 	// "*.yaml" files in the distribution are syntactically valid. If a distribution is known to ship with YML files
 	// that are not valid YML, this parameter can be used to exclude those files from validation.
 	YMLValidationExclude matcher.NamesPathsCfg `yaml:"yml-validation-exclude" json:"yml-validation-exclude"`
+
+	// Systemd specifies the systemd unit that is generated for the distribution at
+	// "service/bin/{{ProductName}}.service". The working-directory of the unit must be the directory in which the
+	// distribution is installed. Requires the product to be built for exactly one linux OS/Arch.
+	Systemd *Systemd `yaml:"systemd" json:"systemd"`
+}
+
+type OCIDist struct {
+	// BaseImage is the path (relative to the project root) to an OCI image layout archive (which may be compressed
+	// using gzip) that contains the base image for every linux OS/Arch of the product. If blank or "scratch", the
+	// images are based on an empty image.
+	BaseImage string `yaml:"base-image" json:"base-image"`
+
+	// BinaryPath is the absolute path of the product executable in the image. The executables of the input products
+	// of the distribution are added to the same directory. Default is "/usr/local/bin/{{ProductName}}".
+	BinaryPath string `yaml:"binary-path" json:"binary-path"`
+
+	// Entrypoint is the entrypoint of the image. Default is the executable of the product.
+	Entrypoint []string `yaml:"entrypoint" json:"entrypoint"`
+
+	// Cmd contains the default arguments provided to the entrypoint.
+	Cmd []string `yaml:"cmd" json:"cmd"`
+
+	// Env specifies values for environment variables that are set in the image.
+	Env map[string]string `yaml:"env" json:"env"`
+
+	// WorkingDir is the working directory of the image.
+	WorkingDir string `yaml:"working-dir" json:"working-dir"`
+
+	// User is the user (and optionally the group, using the form "user:group") that runs the entrypoint.
+	User string `yaml:"user" json:"user"`
 }
 
 type RPMDist struct {
@@ -222,8 +486,127 @@ type RPMDist struct {
 	// AfterInstallScript is the content of shell script to run immediately after this RPM is installed. Optional.
 	AfterInstallScript string `yaml:"after-install-script" json:"after-install-script"`
 
+	// BeforeRemoveScript is the content of shell script to run before this RPM is removed. Optional.
+	BeforeRemoveScript string `yaml:"before-remove-script" json:"before-remove-script"`
+
 	// AfterRemoveScript is the content of shell script to clean up after this RPM is removed. Optional.
 	AfterRemoveScript string `yaml:"after-remove-script" json:"after-remove-script"`
+
+	// Requires are the packages required by the RPM. Each entry is a package name optionally followed by a
+	// comparison operator and a version, such as "bash >= 4.0".
+	Requires []string `yaml:"requires" json:"requires"`
+
+	// Provides are the capabilities provided by the RPM in addition to the RPM itself.
+	Provides []string `yaml:"provides" json:"provides"`
+
+	// Conflicts are the packages that cannot be installed alongside the RPM.
+	Conflicts []string `yaml:"conflicts" json:"conflicts"`
+
+	// Files specifies the ownership and permissions of files in the RPM. Keys are absolute paths or patterns that
+	// match paths of files in the RPM.
+	Files map[string]PackageFile `yaml:"files" json:"files"`
+
+	// BinDir is the absolute path of the directory of the RPM in which the executables of the product for the
+	// architecture of the RPM are installed, such as "/usr/bin". If blank, the RPM only contains the content of the
+	// distribution directory.
+	BinDir string `yaml:"bin-dir" json:"bin-dir"`
+
+	// ServiceArgs is the string provided as the arguments of the executable of the generated systemd unit.
+	ServiceArgs string `yaml:"service-args" json:"service-args"`
+
+	// Systemd specifies the systemd unit that is generated for the RPM at
+	// "/usr/lib/systemd/system/{{ProductName}}.service". The scripts of the RPM create the user and group of the unit
+	// and enable, start and stop the unit in addition to running the commands of the configured scripts.
+	Systemd *Systemd `yaml:"systemd" json:"systemd"`
+}
+
+type DebDist struct {
+	// Revision is the Debian revision of the package. Default is "1".
+	Revision string `yaml:"revision" json:"revision"`
+
+	// Maintainer is the name and email address of the maintainer of the package.
+	Maintainer string `yaml:"maintainer" json:"maintainer"`
+
+	// ConfigFiles is a slice of absolute paths within the package that correspond to configuration files.
+	ConfigFiles []string `yaml:"config-files" json:"config-files"`
+
+	// BeforeInstallScript is the content of the preinst script of the package. Optional.
+	BeforeInstallScript string `yaml:"before-install-script" json:"before-install-script"`
+
+	// AfterInstallScript is the content of the postinst script of the package. Optional.
+	AfterInstallScript string `yaml:"after-install-script" json:"after-install-script"`
+
+	// BeforeRemoveScript is the content of the prerm script of the package. Optional.
+	BeforeRemoveScript string `yaml:"before-remove-script" json:"before-remove-script"`
+
+	// AfterRemoveScript is the content of the postrm script of the package. Optional.
+	AfterRemoveScript string `yaml:"after-remove-script" json:"after-remove-script"`
+
+	// Requires are the packages the package depends on. Each entry is a package name optionally followed by a
+	// comparison operator and a version, such as "bash >= 4.0".
+	Requires []string `yaml:"requires" json:"requires"`
+
+	// Provides are the virtual packages provided by the package.
+	Provides []string `yaml:"provides" json:"provides"`
+
+	// Conflicts are the packages that cannot be installed alongside the package.
+	Conflicts []string `yaml:"conflicts" json:"conflicts"`
+
+	// Files specifies the ownership and permissions of files in the package. Keys are absolute paths or patterns
+	// that match paths of files in the package.
+	Files map[string]PackageFile `yaml:"files" json:"files"`
+
+	// BinDir is the absolute path of the directory of the package in which the executables of the product for the
+	// architecture of the package are installed, such as "/usr/bin". If blank, the package only contains the content
+	// of the distribution directory.
+	BinDir string `yaml:"bin-dir" json:"bin-dir"`
+}
+
+type PackageFile struct {
+	// Owner is the name of the user that owns the file. Default is "root".
+	Owner string `yaml:"owner" json:"owner"`
+
+	// Group is the name of the group that owns the file. Default is "root".
+	Group string `yaml:"group" json:"group"`
+
+	// Mode is the permissions of the file as an octal string such as "0750". If blank, the permissions of the file
+	// in the distribution directory are used.
+	Mode string `yaml:"mode" json:"mode"`
+}
+
+type Systemd struct {
+	// Description is the description of the unit. Default is the name of the product.
+	Description string `yaml:"description" json:"description"`
+
+	// Executable is the absolute path of the executable that is run by the unit. Only supported by RPM
+	// distributions, for which the default is the executable of the product in the bin-dir of the RPM (and which is
+	// required if bin-dir is not specified).
+	Executable string `yaml:"executable" json:"executable"`
+
+	// WorkingDirectory is the absolute path of the working directory of the service. Required for SLS
+	// distributions.
+	WorkingDirectory string `yaml:"working-directory" json:"working-directory"`
+
+	// User is the user that runs the service, which must consist of lowercase letters, digits, underscores and hyphens.
+	// Default is root.
+	User string `yaml:"user" json:"user"`
+
+	// Group is the group that runs the service, which must consist of lowercase letters, digits, underscores and
+	// hyphens. Default is the primary group of the user.
+	Group string `yaml:"group" json:"group"`
+
+	// EnvironmentFile is the absolute path of a file that sets environment variables for the service. A path that
+	// starts with "-" is ignored if the file does not exist.
+	EnvironmentFile string `yaml:"environment-file" json:"environment-file"`
+
+	// Restart is the restart policy of the service: "no", "on-success", "on-failure", "on-abnormal",
+	// "on-watchdog", "on-abort" or "always". Default is "on-failure".
+	Restart string `yaml:"restart" json:"restart"`
+
+	// Limits are the resource limits of the service keyed by resource, such as "nofile: 65536". Resources are the
+	// names of the "Limit" directives of systemd without the prefix: "cpu", "fsize", "data", "stack", "core", "rss",
+	// "nofile", "as", "nproc", "memlock", "locks", "sigpending", "msgqueue", "nice", "rtprio" and "rttime".
+	Limits map[string]string `yaml:"limits" json:"limits"`
 }
 
 type Publish struct {
@@ -247,7 +630,7 @@ func (cfg *Project) ToParams() (params.Project, error) {
 	for k, v := range cfg.Products {
 		productParam, err := v.ToParam()
 		if err != nil {
-			return params.Project{}, err
+			return params.Project{}, errors.Wrapf(err, "invalid configuration for product %s", k)
 		}
 		products[k] = productParam
 	}
@@ -258,6 +641,7 @@ func (cfg *Project) ToParams() (params.Project, error) {
 		DistScriptInclude: cfg.DistScriptInclude,
 		GroupID:           cfg.GroupID,
 		Exclude:           cfg.Exclude.Matcher(),
+		RunGroups:         cfg.RunGroups,
 	}, nil
 }
 
@@ -271,26 +655,135 @@ func (cfg *Product) ToParam() (params.Product, error) {
 		dists = append(dists, dist)
 	}
 
+	build, err := cfg.Build.ToParam()
+	if err != nil {
+		return params.Product{}, err
+	}
+
+	run, err := cfg.Run.ToParam()
+	if err != nil {
+		return params.Product{}, err
+	}
+
+	var signing *params.Signing
+	if cfg.Signing != nil {
+		if signing, err = cfg.Signing.ToParam(); err != nil {
+			return params.Product{}, err
+		}
+	}
+
 	return params.Product{
-		Build:          cfg.Build.ToParam(),
-		Run:            cfg.Run.ToParam(),
+		Build:          build,
+		Run:            run,
 		Dist:           dists,
 		DefaultPublish: cfg.DefaultPublish.ToParams(),
+		Signing:        signing,
 	}, nil
 }
 
-func (cfg *Build) ToParam() params.Build {
+func (cfg *Build) ToParam() (params.Build, error) {
+	for _, osArch := range cfg.OSArchs {
+		if err := osarch.Validate(osArch); err != nil {
+			return params.Build{}, errors.Wrapf(err, "invalid value in os-archs")
+		}
+	}
+
+	switch cfg.BuildMode {
+	case "", params.BuildModeExe, params.BuildModePIE, params.BuildModeCShared, params.BuildModeCArchive, params.BuildModePlugin:
+	default:
+		return params.Build{}, errors.Errorf("invalid value for build-mode: %q is not one of %v", cfg.BuildMode, []string{params.BuildModeExe, params.BuildModePIE, params.BuildModeCShared, params.BuildModeCArchive, params.BuildModePlugin})
+	}
+
+	maxSize, err := parseSize(cfg.MaxSize)
+	if err != nil {
+		return params.Build{}, errors.Wrapf(err, "invalid value for max-size")
+	}
+
+	if err := validatePathTemplates(cfg.OutputPath, cfg.ArtifactName); err != nil {
+		return params.Build{}, err
+	}
+
+	var overrides map[osarch.OSArch]params.OSArchBuild
+	for k, v := range cfg.OSArchOverrides {
+		osArch, err := osarch.New(k)
+		if err != nil {
+			return params.Build{}, errors.Wrapf(err, "invalid key in os-arch-overrides")
+		}
+		if err := osarch.Validate(osArch); err != nil {
+			return params.Build{}, errors.Wrapf(err, "invalid key in os-arch-overrides")
+		}
+		if overrides == nil {
+			overrides = make(map[osarch.OSArch]params.OSArchBuild)
+		}
+		overrides[osArch] = v.ToParam()
+	}
+
 	return params.Build{
 		Script:          cfg.Script,
 		MainPkg:         cfg.MainPkg,
 		OutputDir:       cfg.OutputDir,
+		OutputPath:      cfg.OutputPath,
+		ArtifactName:    cfg.ArtifactName,
 		BuildArgsScript: cfg.BuildArgsScript,
 		VersionVar:      cfg.VersionVar,
+		LdflagsVars:     cfg.LdflagsVars,
+		Ldflags:         cfg.Ldflags,
+		Gcflags:         cfg.Gcflags,
+		Tags:            cfg.Tags,
+		Reproducible:    cfg.Reproducible,
+		BuildMode:       cfg.BuildMode,
+		MaxSize:         maxSize,
 		Environment:     cfg.Environment,
 		OSArchs:         cfg.OSArchs,
+		OSArchOverrides: overrides,
+	}, nil
+}
+
+func (cfg *OSArchBuild) ToParam() params.OSArchBuild {
+	return params.OSArchBuild{
+		Environment: cfg.Environment,
+		Tags:        cfg.Tags,
+		Ldflags:     cfg.Ldflags,
+		Args:        cfg.Args,
 	}
 }
 
+// sizeUnits are the units accepted by parseSize, ordered such that no unit is a suffix of a unit that follows it.
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{suffix: "KiB", multiplier: 1 << 10},
+	{suffix: "MiB", multiplier: 1 << 20},
+	{suffix: "GiB", multiplier: 1 << 30},
+	{suffix: "KB", multiplier: 1000},
+	{suffix: "MB", multiplier: 1000 * 1000},
+	{suffix: "GB", multiplier: 1000 * 1000 * 1000},
+	{suffix: "B", multiplier: 1},
+}
+
+// parseSize parses a size of the form accepted by Build.MaxSize and returns the number of bytes it specifies. Returns 0
+// if the input is blank.
+func parseSize(input string) (int64, error) {
+	value := strings.TrimSpace(input)
+	if value == "" {
+		return 0, nil
+	}
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, errors.Errorf("%q is not a positive size", input)
+	}
+	return n * multiplier, nil
+}
+
 type RawDistConfigs []Dist
 
 func (out *RawDistConfigs) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -317,20 +810,182 @@ func (cfg *Dist) ToParam() (params.Dist, error) {
 	if err != nil {
 		return params.Dist{}, err
 	}
+
+	archiveFormat := params.ArchiveFormat(cfg.ArchiveFormat)
+	switch archiveFormat {
+	case "", params.TGZArchiveFormat, params.ZipArchiveFormat:
+	default:
+		return params.Dist{}, errors.Errorf("invalid value for archive-format: %q is not one of %q or %q", cfg.ArchiveFormat, params.TGZArchiveFormat, params.ZipArchiveFormat)
+	}
+	if cfg.ArchiveUID < 0 || cfg.ArchiveGID < 0 {
+		return params.Dist{}, errors.Errorf("invalid value for archive-uid or archive-gid: IDs cannot be negative")
+	}
+	if archiveFormat != "" || cfg.SplitByOSArch || cfg.ArchiveUID != 0 || cfg.ArchiveGID != 0 {
+		switch params.DistInfoType(cfg.DistType.Type) {
+		case "", params.SLSDistType, params.BinDistType:
+		default:
+			return params.Dist{}, errors.Errorf("archive-format, split-by-os-arch, archive-uid and archive-gid are only supported for %s and %s distributions", params.SLSDistType, params.BinDistType)
+		}
+	}
+
+	if err := validatePathTemplates(cfg.OutputPath, cfg.ArtifactName); err != nil {
+		return params.Dist{}, err
+	}
+
+	if len(cfg.Templates) > 0 && cfg.InputDir == "" {
+		return params.Dist{}, errors.Errorf("templates requires input-dir to be specified")
+	}
+	for _, currTemplate := range cfg.Templates {
+		if _, err := path.Match(currTemplate, ""); err != nil {
+			return params.Dist{}, errors.Errorf("invalid value for templates: %q is not a valid pattern", currTemplate)
+		}
+	}
+
+	var sbom *params.SBOM
+	if cfg.SBOM != nil {
+		if sbom, err = cfg.SBOM.ToParam(); err != nil {
+			return params.Dist{}, err
+		}
+	}
+
+	var licenses *params.Licenses
+	if cfg.Licenses != nil {
+		if licenses, err = cfg.Licenses.ToParam(); err != nil {
+			return params.Dist{}, err
+		}
+	}
+
 	return params.Dist{
 		OutputDir:     cfg.OutputDir,
+		OutputPath:    cfg.OutputPath,
+		ArtifactName:  cfg.ArtifactName,
 		InputDir:      cfg.InputDir,
+		Templates:     cfg.Templates,
 		InputProducts: cfg.InputProducts,
 		Script:        cfg.Script,
 		Info:          info,
+		ArchiveFormat: archiveFormat,
+		SplitByOSArch: cfg.SplitByOSArch,
+		ArchiveUID:    cfg.ArchiveUID,
+		ArchiveGID:    cfg.ArchiveGID,
+		SBOM:          sbom,
+		Licenses:      licenses,
 		Publish:       cfg.Publish.ToParams(),
 	}, nil
 }
 
-func (cfg *Run) ToParam() params.Run {
+func (cfg *SBOM) ToParam() (*params.SBOM, error) {
+	var formats []params.SBOMFormat
+	for _, currFormat := range cfg.Formats {
+		format := params.SBOMFormat(currFormat)
+		switch format {
+		case params.SPDXSBOMFormat, params.CycloneDXSBOMFormat:
+		default:
+			return nil, errors.Errorf("invalid value for sbom formats: %q is not one of %q or %q", currFormat, params.SPDXSBOMFormat, params.CycloneDXSBOMFormat)
+		}
+		formats = append(formats, format)
+	}
+	if path.IsAbs(cfg.Dir) || strings.HasPrefix(path.Clean(cfg.Dir), "..") {
+		return nil, errors.Errorf("invalid value for sbom dir: %q is not a path within the distribution directory", cfg.Dir)
+	}
+	return &params.SBOM{
+		Formats: formats,
+		Dir:     cfg.Dir,
+	}, nil
+}
+
+func (cfg *Licenses) ToParam() (*params.Licenses, error) {
+	var deny []string
+	for _, currDeny := range cfg.Deny {
+		denied := ""
+		for _, currID := range license.IDs() {
+			if strings.EqualFold(currDeny, currID) {
+				denied = currID
+			}
+		}
+		for _, currCategory := range license.Categories {
+			if strings.EqualFold(currDeny, string(currCategory)) {
+				denied = string(currCategory)
+			}
+		}
+		if denied == "" {
+			return nil, errors.Errorf("invalid value for licenses deny: %q is not a recognized license identifier or category", currDeny)
+		}
+		deny = append(deny, denied)
+	}
+	return &params.Licenses{
+		Deny: deny,
+	}, nil
+}
+
+// validatePathTemplates returns an error if the provided output-path or artifact-name templates cannot be parsed.
+func validatePathTemplates(outputPath, artifactName string) error {
+	if _, err := templating.ParsePathTemplate("output-path", outputPath); err != nil {
+		return err
+	}
+	if _, err := templating.ParsePathTemplate("artifact-name", artifactName); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cfg *Run) ToParam() (params.Run, error) {
+	ready, err := cfg.Ready.ToParam()
+	if err != nil {
+		return params.Run{}, err
+	}
 	return params.Run{
-		Args: cfg.Args,
+		Args:        cfg.Args,
+		Environment: cfg.Environment,
+		WorkingDir:  cfg.WorkingDir,
+		DependsOn:   cfg.DependsOn,
+		Ready:       ready,
+	}, nil
+}
+
+func (cfg *Signing) ToParam() (*params.Signing, error) {
+	signing := &params.Signing{}
+	switch {
+	case cfg.OpenPGP.Keyring != "":
+		signing.OpenPGP = &params.OpenPGPSigning{
+			Keyring:          cfg.OpenPGP.Keyring,
+			KeyID:            cfg.OpenPGP.KeyID,
+			PassphraseEnvVar: cfg.OpenPGP.PassphraseEnvVar,
+		}
+	case cfg.OpenPGP.KeyID != "" || cfg.OpenPGP.PassphraseEnvVar != "":
+		return nil, errors.Errorf("invalid value for signing: openpgp keyring must be specified")
 	}
+	switch {
+	case cfg.Minisign.KeyPath != "" && cfg.Minisign.KeyEnvVar != "":
+		return nil, errors.Errorf("invalid value for signing: only one of minisign key-path and key-env-var can be specified")
+	case cfg.Minisign.KeyPath != "" || cfg.Minisign.KeyEnvVar != "":
+		signing.Minisign = &params.MinisignSigning{
+			KeyPath:          cfg.Minisign.KeyPath,
+			KeyEnvVar:        cfg.Minisign.KeyEnvVar,
+			PassphraseEnvVar: cfg.Minisign.PassphraseEnvVar,
+		}
+	case cfg.Minisign.PassphraseEnvVar != "":
+		return nil, errors.Errorf("invalid value for signing: minisign key-path or key-env-var must be specified")
+	}
+	return signing, nil
+}
+
+func (cfg *ReadyCheck) ToParam() (params.ReadyCheck, error) {
+	if cfg.TCP != "" && cfg.HTTP != "" {
+		return params.ReadyCheck{}, errors.Errorf("invalid value for ready: only one of tcp and http can be specified")
+	}
+	var timeout time.Duration
+	if cfg.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(cfg.Timeout); err != nil || timeout <= 0 {
+			return params.ReadyCheck{}, errors.Errorf("invalid value for ready timeout: %q is not a positive duration", cfg.Timeout)
+		}
+	}
+	return params.ReadyCheck{
+		TCP:     cfg.TCP,
+		HTTP:    cfg.HTTP,
+		Timeout: timeout,
+	}, nil
 }
 
 func (cfg *DistInfo) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -373,6 +1028,26 @@ func (cfg *DistInfo) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			return err
 		}
 		rawDistInfoConfig.Info = rawRPM.Info
+	case params.DebDistType:
+		type typedRawConfig struct {
+			Type string
+			Info DebDist
+		}
+		var rawDeb typedRawConfig
+		if err := unmarshal(&rawDeb); err != nil {
+			return err
+		}
+		rawDistInfoConfig.Info = rawDeb.Info
+	case params.OCIDistType, params.DockerDistType:
+		type typedRawConfig struct {
+			Type string
+			Info OCIDist
+		}
+		var rawOCI typedRawConfig
+		if err := unmarshal(&rawOCI); err != nil {
+			return err
+		}
+		rawDistInfoConfig.Info = rawOCI.Info
 	}
 	*cfg = rawDistInfoConfig
 	return nil
@@ -386,14 +1061,12 @@ func (cfg *DistInfo) ToParam() (params.DistInfo, error) {
 		switch params.DistInfoType(cfg.Type) {
 		case params.SLSDistType:
 			val := SLSDist{}
-			decodeErr = mapstructure.Decode(cfg.Info, &val)
-			distInfo = &params.SLSDistInfo{
-				InitShTemplateFile:   val.InitShTemplateFile,
-				ManifestTemplateFile: val.ManifestTemplateFile,
-				ServiceArgs:          val.ServiceArgs,
-				ProductType:          val.ProductType,
-				ManifestExtensions:   val.ManifestExtensions,
-				YMLValidationExclude: val.YMLValidationExclude.Matcher(),
+			if decodeErr = mapstructure.Decode(cfg.Info, &val); decodeErr == nil {
+				slsDistInfo, err := val.ToParams()
+				if err != nil {
+					return nil, err
+				}
+				distInfo = &slsDistInfo
 			}
 		case params.BinDistType:
 			val := BinDist{}
@@ -404,13 +1077,33 @@ func (cfg *DistInfo) ToParam() (params.DistInfo, error) {
 			}
 		case params.RPMDistType:
 			val := RPMDist{}
+			if decodeErr = mapstructure.Decode(cfg.Info, &val); decodeErr == nil {
+				rpmDistInfo, err := val.ToParams()
+				if err != nil {
+					return nil, err
+				}
+				distInfo = &rpmDistInfo
+			}
+		case params.DebDistType:
+			val := DebDist{}
+			if decodeErr = mapstructure.Decode(cfg.Info, &val); decodeErr == nil {
+				debDistInfo, err := val.ToParams()
+				if err != nil {
+					return nil, err
+				}
+				distInfo = &debDistInfo
+			}
+		case params.OCIDistType, params.DockerDistType:
+			val := OCIDist{}
 			decodeErr = mapstructure.Decode(cfg.Info, &val)
-			distInfo = &params.RPMDistInfo{
-				Release:             val.Release,
-				ConfigFiles:         val.ConfigFiles,
-				BeforeInstallScript: val.BeforeInstallScript,
-				AfterInstallScript:  val.AfterInstallScript,
-				AfterRemoveScript:   val.AfterRemoveScript,
+			distInfo = &params.OCIDistInfo{
+				BaseImage:  val.BaseImage,
+				BinaryPath: val.BinaryPath,
+				Entrypoint: val.Entrypoint,
+				Cmd:        val.Cmd,
+				Env:        val.Env,
+				WorkingDir: val.WorkingDir,
+				User:       val.User,
 			}
 		default:
 			return nil, errors.Errorf("No unmarshaller found for type %s for %v", cfg.Type, *cfg)
@@ -444,7 +1137,20 @@ func (cfg *BinDist) ToParams() params.BinDistInfo {
 	}
 }
 
-func (cfg *SLSDist) ToParams() params.SLSDistInfo {
+func (cfg *SLSDist) ToParams() (params.SLSDistInfo, error) {
+	var systemd *params.Systemd
+	if cfg.Systemd != nil {
+		var err error
+		if systemd, err = cfg.Systemd.ToParam(); err != nil {
+			return params.SLSDistInfo{}, err
+		}
+		if systemd.WorkingDirectory == "" {
+			return params.SLSDistInfo{}, errors.Errorf("systemd working-directory must be specified for %s distributions", params.SLSDistType)
+		}
+		if systemd.Executable != "" {
+			return params.SLSDistInfo{}, errors.Errorf("systemd executable is not supported for %s distributions", params.SLSDistType)
+		}
+	}
 	return params.SLSDistInfo{
 		InitShTemplateFile:   cfg.InitShTemplateFile,
 		ManifestTemplateFile: cfg.ManifestTemplateFile,
@@ -452,16 +1158,205 @@ func (cfg *SLSDist) ToParams() params.SLSDistInfo {
 		ProductType:          cfg.ProductType,
 		ManifestExtensions:   cfg.ManifestExtensions,
 		YMLValidationExclude: cfg.YMLValidationExclude.Matcher(),
-	}
+		Systemd:              systemd,
+	}, nil
 }
 
-func (cfg *RPMDist) ToParams() params.RPMDistInfo {
+func (cfg *RPMDist) ToParams() (params.RPMDistInfo, error) {
+	files, err := packageFilesToParams(cfg.Files)
+	if err != nil {
+		return params.RPMDistInfo{}, err
+	}
+	if err := validateBinDir(cfg.BinDir); err != nil {
+		return params.RPMDistInfo{}, err
+	}
+	var systemd *params.Systemd
+	if cfg.Systemd != nil {
+		if systemd, err = cfg.Systemd.ToParam(); err != nil {
+			return params.RPMDistInfo{}, err
+		}
+	}
 	return params.RPMDistInfo{
 		Release:             cfg.Release,
 		ConfigFiles:         cfg.ConfigFiles,
 		BeforeInstallScript: cfg.BeforeInstallScript,
 		AfterInstallScript:  cfg.AfterInstallScript,
+		BeforeRemoveScript:  cfg.BeforeRemoveScript,
 		AfterRemoveScript:   cfg.AfterRemoveScript,
+		Requires:            cfg.Requires,
+		Provides:            cfg.Provides,
+		Conflicts:           cfg.Conflicts,
+		Files:               files,
+		BinDir:              cfg.BinDir,
+		ServiceArgs:         cfg.ServiceArgs,
+		Systemd:             systemd,
+	}, nil
+}
+
+func (cfg *DebDist) ToParams() (params.DebDistInfo, error) {
+	files, err := packageFilesToParams(cfg.Files)
+	if err != nil {
+		return params.DebDistInfo{}, err
+	}
+	if err := validateBinDir(cfg.BinDir); err != nil {
+		return params.DebDistInfo{}, err
+	}
+	return params.DebDistInfo{
+		Revision:            cfg.Revision,
+		Maintainer:          cfg.Maintainer,
+		ConfigFiles:         cfg.ConfigFiles,
+		BeforeInstallScript: cfg.BeforeInstallScript,
+		AfterInstallScript:  cfg.AfterInstallScript,
+		BeforeRemoveScript:  cfg.BeforeRemoveScript,
+		AfterRemoveScript:   cfg.AfterRemoveScript,
+		Requires:            cfg.Requires,
+		Provides:            cfg.Provides,
+		Conflicts:           cfg.Conflicts,
+		Files:               files,
+		BinDir:              cfg.BinDir,
+	}, nil
+}
+
+// validateBinDir returns an error if the provided bin-dir of an RPM or Debian package is not blank or absolute.
+func validateBinDir(binDir string) error {
+	if binDir != "" && !path.IsAbs(binDir) {
+		return errors.Errorf("invalid value for bin-dir: %q is not an absolute path", binDir)
+	}
+	return nil
+}
+
+func packageFilesToParams(files map[string]PackageFile) (map[string]params.PackageFile, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]params.PackageFile, len(files))
+	for k, v := range files {
+		file, err := v.ToParam()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid configuration for file %s", k)
+		}
+		out[k] = file
+	}
+	return out, nil
+}
+
+func (cfg *PackageFile) ToParam() (params.PackageFile, error) {
+	var mode os.FileMode
+	if cfg.Mode != "" {
+		m, err := strconv.ParseUint(cfg.Mode, 8, 32)
+		if err != nil || m > 07777 {
+			return params.PackageFile{}, errors.Errorf("invalid value for mode: %q is not an octal file mode", cfg.Mode)
+		}
+		mode = os.FileMode(m & 0777)
+		if m&04000 != 0 {
+			mode |= os.ModeSetuid
+		}
+		if m&02000 != 0 {
+			mode |= os.ModeSetgid
+		}
+		if m&01000 != 0 {
+			mode |= os.ModeSticky
+		}
+	}
+	return params.PackageFile{
+		Owner: cfg.Owner,
+		Group: cfg.Group,
+		Mode:  mode,
+	}, nil
+}
+
+// systemdAccountName matches the names of users and groups that are accepted by the useradd and groupadd commands of
+// all common distributions: a lowercase letter or underscore followed by at most 31 lowercase letters, digits,
+// underscores and hyphens.
+var systemdAccountName = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// systemdLimits are the resources of the "Limit" directives of a systemd unit.
+var systemdLimits = map[string]bool{
+	"CPU": true, "FSIZE": true, "DATA": true, "STACK": true, "CORE": true, "RSS": true, "NOFILE": true, "AS": true,
+	"NPROC": true, "MEMLOCK": true, "LOCKS": true, "SIGPENDING": true, "MSGQUEUE": true, "NICE": true, "RTPRIO": true,
+	"RTTIME": true,
+}
+
+func (cfg *Systemd) ToParam() (*params.Systemd, error) {
+	// the values are written to the unit file and the RPM scripts, which are line-based
+	for _, currValue := range []struct {
+		name  string
+		value string
+	}{
+		{"description", cfg.Description},
+		{"executable", cfg.Executable},
+		{"working-directory", cfg.WorkingDirectory},
+		{"user", cfg.User},
+		{"group", cfg.Group},
+		{"environment-file", cfg.EnvironmentFile},
+	} {
+		if strings.ContainsAny(currValue.value, "\r\n") {
+			return nil, errors.Errorf("invalid value for systemd %s: %q contains a line break", currValue.name, currValue.value)
+		}
+	}
+	for _, currName := range []struct {
+		name  string
+		value string
+	}{
+		{"user", cfg.User},
+		{"group", cfg.Group},
+	} {
+		if currName.value != "" && !systemdAccountName.MatchString(currName.value) {
+			return nil, errors.Errorf("invalid value for systemd %s: %q is not a valid user or group name", currName.name, currName.value)
+		}
+	}
+	for _, currPath := range []struct {
+		name  string
+		value string
+	}{
+		{"executable", cfg.Executable},
+		{"working-directory", cfg.WorkingDirectory},
+		{"environment-file", strings.TrimPrefix(cfg.EnvironmentFile, "-")},
+	} {
+		if currPath.value != "" && !path.IsAbs(currPath.value) {
+			return nil, errors.Errorf("invalid value for systemd %s: %q is not an absolute path", currPath.name, currPath.value)
+		}
+	}
+	switch cfg.Restart {
+	case "", "no", "on-success", "on-failure", "on-abnormal", "on-watchdog", "on-abort", "always":
+	default:
+		return nil, errors.Errorf("invalid value for systemd restart: %q is not a systemd restart policy", cfg.Restart)
+	}
+	var limits map[string]string
+	if len(cfg.Limits) > 0 {
+		limits = make(map[string]string, len(cfg.Limits))
+		for k, v := range cfg.Limits {
+			resource := strings.ToUpper(k)
+			if !systemdLimits[resource] {
+				return nil, errors.Errorf("invalid value for systemd limits: %q is not a recognized resource", k)
+			}
+			if strings.ContainsAny(v, "\r\n") {
+				return nil, errors.Errorf("invalid value for systemd limits: value of %q contains a line break", k)
+			}
+			limits[resource] = v
+		}
+	}
+	return &params.Systemd{
+		Description:      cfg.Description,
+		Executable:       cfg.Executable,
+		WorkingDirectory: cfg.WorkingDirectory,
+		User:             cfg.User,
+		Group:            cfg.Group,
+		EnvironmentFile:  cfg.EnvironmentFile,
+		Restart:          cfg.Restart,
+		Limits:           limits,
+	}, nil
+}
+
+func (cfg *OCIDist) ToParams() params.OCIDistInfo {
+	return params.OCIDistInfo{
+		BaseImage:  cfg.BaseImage,
+		BinaryPath: cfg.BinaryPath,
+		Entrypoint: cfg.Entrypoint,
+		Cmd:        cfg.Cmd,
+		Env:        cfg.Env,
+		WorkingDir: cfg.WorkingDir,
+		User:       cfg.User,
 	}
 }
 
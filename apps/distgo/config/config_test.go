@@ -15,8 +15,10 @@
 package config_test
 
 import (
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/palantir/pkg/matcher"
 	"github.com/stretchr/testify/assert"
@@ -46,6 +48,16 @@ func TestReadConfig(t *testing.T) {
 			                               echo "-X"
 			                               echo "main.year=$YEAR"
 			            version-var: main.version
+			            ldflags-vars:
+			                main.revision: "{{.VersionInfo.Revision}}"
+			            ldflags:
+			                - -s
+			            gcflags:
+			                - -N
+			            tags:
+			                - integration
+			            reproducible: true
+			            max-size: 20MB
 			            environment:
 			                foo: bar
 			                baz: 1
@@ -55,6 +67,26 @@ func TestReadConfig(t *testing.T) {
 			                  arch: "amd64"
 			                - os: "linux"
 			                  arch: "amd64"
+			                - "linux-*"
+			            os-arch-overrides:
+			                linux-amd64:
+			                    environment:
+			                        CGO_ENABLED: 1
+			                    tags:
+			                        - linux
+			                    ldflags:
+			                        - -w
+			                    args:
+			                        - -race
+			        run:
+			            environment:
+			                LOG_LEVEL: debug
+			            working-dir: var
+			            depends-on:
+			                - db
+			            ready:
+			                http: http://localhost:8080/status
+			                timeout: 1m
 			        dist:
 			            output-dir: dist
 			            input-dir: resources/input
@@ -73,6 +105,10 @@ func TestReadConfig(t *testing.T) {
 			    - ".*test"
 			  paths:
 			    - "vendor"
+			run-groups:
+			  dev:
+			    - test
+			    - db
 			`,
 			json: `{"exclude":{"names":["distgo"],"paths":["generated_src"]}}`,
 			want: func() config.Project {
@@ -88,6 +124,14 @@ echo "-X"
 echo "main.year=$YEAR"
 `,
 								VersionVar: "main.version",
+								LdflagsVars: map[string]string{
+									"main.revision": "{{.VersionInfo.Revision}}",
+								},
+								Ldflags:      []string{"-s"},
+								Gcflags:      []string{"-N"},
+								Tags:         []string{"integration"},
+								Reproducible: true,
+								MaxSize:      "20MB",
 								Environment: map[string]string{
 									"foo":  "bar",
 									"baz":  "1",
@@ -102,6 +146,31 @@ echo "main.year=$YEAR"
 										OS:   "linux",
 										Arch: "amd64",
 									},
+									{
+										OS:   "linux",
+										Arch: "*",
+									},
+								},
+								OSArchOverrides: map[string]config.OSArchBuild{
+									"linux-amd64": {
+										Environment: map[string]string{
+											"CGO_ENABLED": "1",
+										},
+										Tags:    []string{"linux"},
+										Ldflags: []string{"-w"},
+										Args:    []string{"-race"},
+									},
+								},
+							},
+							Run: config.Run{
+								Environment: map[string]string{
+									"LOG_LEVEL": "debug",
+								},
+								WorkingDir: "var",
+								DependsOn:  []string{"db"},
+								Ready: config.ReadyCheck{
+									HTTP:    "http://localhost:8080/status",
+									Timeout: "1m",
 								},
 							},
 							Dist: []config.Dist{{
@@ -125,6 +194,9 @@ echo "main.year=$YEAR"
 						Names: []string{`.*test`, `distgo`},
 						Paths: []string{`vendor`, `generated_src`},
 					},
+					RunGroups: map[string][]string{
+						"dev": {"test", "db"},
+					},
 				}
 			},
 		},
@@ -242,6 +314,469 @@ echo "main.year=$YEAR"
 	}
 }
 
+func TestInvalidOSArchs(t *testing.T) {
+	for i, currCase := range []struct {
+		yml       string
+		wantError string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    build:
+			      os-archs:
+			        - os: "linux"
+			          arch: "amd46"
+			`,
+			wantError: "invalid configuration for product test: invalid value in os-archs: unknown OS/Arch linux-amd46: not supported by the installed Go toolchain (did you mean linux-amd64?)",
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    build:
+			      os-archs:
+			        - "darwn-*"
+			`,
+			wantError: "invalid configuration for product test: invalid value in os-archs: unknown OS/Arch darwn-*: not supported by the installed Go toolchain (did you mean darwin-*?)",
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    build:
+			      os-arch-overrides:
+			        windows-amd46:
+			          tags:
+			            - windows
+			`,
+			wantError: "invalid configuration for product test: invalid key in os-arch-overrides: unknown OS/Arch windows-amd46: not supported by the installed Go toolchain (did you mean windows-amd64?)",
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		_, err = cfg.ToParams()
+		assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	for i, currCase := range []struct {
+		maxSize   string
+		want      int64
+		wantError string
+	}{
+		{maxSize: "", want: 0},
+		{maxSize: "1024", want: 1024},
+		{maxSize: "512B", want: 512},
+		{maxSize: "20MB", want: 20 * 1000 * 1000},
+		{maxSize: "15 MiB", want: 15 * 1024 * 1024},
+		{maxSize: "2GiB", want: 2 * 1024 * 1024 * 1024},
+		{maxSize: "-1", wantError: `invalid configuration for product test: invalid value for max-size: "-1" is not a positive size`},
+		{maxSize: "10TB", wantError: `invalid configuration for product test: invalid value for max-size: "10TB" is not a positive size`},
+	} {
+		cfg := config.Project{
+			Products: map[string]config.Product{
+				"test": {
+					Build: config.Build{
+						MaxSize: currCase.maxSize,
+					},
+				},
+			},
+		}
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+		} else {
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, currCase.want, got.Products["test"].Build.MaxSize, "Case %d", i)
+		}
+	}
+}
+
+func TestBuildMode(t *testing.T) {
+	for i, currCase := range []struct {
+		buildMode string
+		wantError string
+	}{
+		{buildMode: ""},
+		{buildMode: "c-shared"},
+		{buildMode: "plugin"},
+		{buildMode: "shared", wantError: `invalid configuration for product test: invalid value for build-mode: "shared" is not one of [exe pie c-shared c-archive plugin]`},
+	} {
+		cfg := config.Project{
+			Products: map[string]config.Product{
+				"test": {
+					Build: config.Build{
+						BuildMode: currCase.buildMode,
+					},
+				},
+			},
+		}
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+		} else {
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, currCase.buildMode, got.Products["test"].Build.BuildMode, "Case %d", i)
+		}
+	}
+}
+
+func TestReadyCheck(t *testing.T) {
+	for i, currCase := range []struct {
+		ready     config.ReadyCheck
+		want      params.ReadyCheck
+		wantError string
+	}{
+		{},
+		{
+			ready: config.ReadyCheck{TCP: "localhost:5432", Timeout: "90s"},
+			want:  params.ReadyCheck{TCP: "localhost:5432", Timeout: 90 * time.Second},
+		},
+		{
+			ready: config.ReadyCheck{HTTP: "http://localhost:8080/health"},
+			want:  params.ReadyCheck{HTTP: "http://localhost:8080/health"},
+		},
+		{
+			ready:     config.ReadyCheck{TCP: "localhost:5432", HTTP: "http://localhost:8080/health"},
+			wantError: "invalid configuration for product test: invalid value for ready: only one of tcp and http can be specified",
+		},
+		{
+			ready:     config.ReadyCheck{TCP: "localhost:5432", Timeout: "soon"},
+			wantError: `invalid configuration for product test: invalid value for ready timeout: "soon" is not a positive duration`,
+		},
+	} {
+		cfg := config.Project{
+			Products: map[string]config.Product{
+				"test": {
+					Run: config.Run{
+						Ready: currCase.ready,
+					},
+				},
+			},
+		}
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+		} else {
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, currCase.want, got.Products["test"].Run.Ready, "Case %d", i)
+		}
+	}
+}
+
+func TestOCIDistType(t *testing.T) {
+	for i, currCase := range []struct {
+		yml string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: oci
+			        info:
+			          base-image: base.oci.tar
+			          binary-path: /opt/test/bin/test
+			          cmd:
+			            - server
+			          env:
+			            LOG_LEVEL: info
+			          working-dir: /opt/test
+			          user: nobody
+			`,
+		},
+		{
+			// "docker" is an alias for "oci"
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: docker
+			        info:
+			          base-image: base.oci.tar
+			          binary-path: /opt/test/bin/test
+			          cmd:
+			            - server
+			          env:
+			            LOG_LEVEL: info
+			          working-dir: /opt/test
+			          user: nobody
+			`,
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		got, err := cfg.ToParams()
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, &params.OCIDistInfo{
+			BaseImage:  "base.oci.tar",
+			BinaryPath: "/opt/test/bin/test",
+			Cmd:        []string{"server"},
+			Env: map[string]string{
+				"LOG_LEVEL": "info",
+			},
+			WorkingDir: "/opt/test",
+			User:       "nobody",
+		}, got.Products["test"].Dist[0].Info, "Case %d", i)
+		assert.Equal(t, params.OCIDistType, got.Products["test"].Dist[0].Info.Type(), "Case %d", i)
+	}
+}
+
+func TestLinuxPackageDistTypes(t *testing.T) {
+	for i, currCase := range []struct {
+		yml       string
+		want      params.DistInfo
+		wantError string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			        info:
+			          release: "2"
+			          before-remove-script: systemctl stop test
+			          requires:
+			            - bash >= 4.0
+			          conflicts:
+			            - test-legacy
+			          files:
+			            /opt/test/bin/*:
+			              mode: 0750
+			            /var/lib/test:
+			              owner: test
+			              group: test
+			`,
+			want: &params.RPMDistInfo{
+				Release:            "2",
+				BeforeRemoveScript: "systemctl stop test",
+				Requires:           []string{"bash >= 4.0"},
+				Conflicts:          []string{"test-legacy"},
+				Files: map[string]params.PackageFile{
+					"/opt/test/bin/*": {Mode: 0750},
+					"/var/lib/test":   {Owner: "test", Group: "test"},
+				},
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: deb
+			        info:
+			          maintainer: Test <test@example.com>
+			          provides:
+			            - test-service
+			          files:
+			            /opt/test/bin/test:
+			              mode: "4755"
+			          bin-dir: /usr/bin
+			`,
+			want: &params.DebDistInfo{
+				Maintainer: "Test <test@example.com>",
+				Provides:   []string{"test-service"},
+				Files: map[string]params.PackageFile{
+					"/opt/test/bin/test": {Mode: os.ModeSetuid | 0755},
+				},
+				BinDir: "/usr/bin",
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			        info:
+			          bin-dir: usr/bin
+			`,
+			wantError: `invalid configuration for product test: invalid value for bin-dir: "usr/bin" is not an absolute path`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: deb
+			        info:
+			          files:
+			            /opt/test/bin/test:
+			              mode: rwx
+			`,
+			wantError: `invalid configuration for product test: invalid configuration for file /opt/test/bin/test: invalid value for mode: "rwx" is not an octal file mode`,
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, got.Products["test"].Dist[0].Info, "Case %d", i)
+	}
+}
+
+func TestArchiveFormat(t *testing.T) {
+	for i, currCase := range []struct {
+		yml       string
+		want      params.Dist
+		wantError string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: bin
+			      archive-format: zip
+			      split-by-os-arch: true
+			`,
+			want: params.Dist{
+				ArchiveFormat: params.ZipArchiveFormat,
+				SplitByOSArch: true,
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      split-by-os-arch: true
+			      archive-uid: 1000
+			      archive-gid: 100
+			`,
+			want: params.Dist{
+				SplitByOSArch: true,
+				ArchiveUID:    1000,
+				ArchiveGID:    100,
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      archive-format: rar
+			`,
+			wantError: `invalid configuration for product test: invalid value for archive-format: "rar" is not one of "tgz" or "zip"`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			      archive-format: zip
+			`,
+			wantError: "invalid configuration for product test: archive-format, split-by-os-arch, archive-uid and archive-gid are only supported for sls and bin distributions",
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      archive-gid: -1
+			`,
+			wantError: "invalid configuration for product test: invalid value for archive-uid or archive-gid: IDs cannot be negative",
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		gotDist := got.Products["test"].Dist[0]
+		assert.Equal(t, currCase.want.ArchiveFormat, gotDist.ArchiveFormat, "Case %d", i)
+		assert.Equal(t, currCase.want.SplitByOSArch, gotDist.SplitByOSArch, "Case %d", i)
+		assert.Equal(t, currCase.want.ArchiveUID, gotDist.ArchiveUID, "Case %d", i)
+		assert.Equal(t, currCase.want.ArchiveGID, gotDist.ArchiveGID, "Case %d", i)
+	}
+}
+
+func TestPathTemplates(t *testing.T) {
+	for i, currCase := range []struct {
+		yml       string
+		wantBuild params.Build
+		wantDist  params.Dist
+		wantError string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    build:
+			      output-path: "{{.OSArch.OS}}/{{.OSArch.Arch}}"
+			      artifact-name: "{{.ProductName}}-{{.ProductVersion}}"
+			    dist:
+			      output-path: "{{.ProductVersion}}"
+			      artifact-name: "{{.ProductName}}.tgz"
+			`,
+			wantBuild: params.Build{
+				OutputPath:   "{{.OSArch.OS}}/{{.OSArch.Arch}}",
+				ArtifactName: "{{.ProductName}}-{{.ProductVersion}}",
+			},
+			wantDist: params.Dist{
+				OutputPath:   "{{.ProductVersion}}",
+				ArtifactName: "{{.ProductName}}.tgz",
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    build:
+			      artifact-name: "{{.ProductName"
+			`,
+			wantError: "invalid configuration for product test: failed to parse template for artifact-name: template: artifact-name:1: unclosed action",
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      output-path: "{{end}}"
+			`,
+			wantError: "invalid configuration for product test: failed to parse template for output-path: template: output-path:1: unexpected {{end}}",
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		gotProduct := got.Products["test"]
+		assert.Equal(t, currCase.wantBuild.OutputPath, gotProduct.Build.OutputPath, "Case %d", i)
+		assert.Equal(t, currCase.wantBuild.ArtifactName, gotProduct.Build.ArtifactName, "Case %d", i)
+		assert.Equal(t, currCase.wantDist.OutputPath, gotProduct.Dist[0].OutputPath, "Case %d", i)
+		assert.Equal(t, currCase.wantDist.ArtifactName, gotProduct.Dist[0].ArtifactName, "Case %d", i)
+	}
+}
+
 func TestFilteredProducts(t *testing.T) {
 	for i, currCase := range []struct {
 		cfg  func() params.Project
@@ -285,3 +820,457 @@ func TestFilteredProducts(t *testing.T) {
 func unindent(input string) string {
 	return strings.Replace(input, "\n\t\t\t", "\n", -1)
 }
+
+func TestSigning(t *testing.T) {
+	for i, currCase := range []struct {
+		yml       string
+		want      *params.Signing
+		wantError string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    build:
+			      main-pkg: ./.
+			`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    signing:
+			      openpgp:
+			        keyring: signing/keyring.gpg
+			        key-id: 0xEB49D88F45DD2D6D
+			        passphrase-env-var: GPG_PASSPHRASE
+			      minisign:
+			        key-env-var: MINISIGN_KEY
+			`,
+			want: &params.Signing{
+				OpenPGP: &params.OpenPGPSigning{
+					Keyring:          "signing/keyring.gpg",
+					KeyID:            "0xEB49D88F45DD2D6D",
+					PassphraseEnvVar: "GPG_PASSPHRASE",
+				},
+				Minisign: &params.MinisignSigning{
+					KeyEnvVar: "MINISIGN_KEY",
+				},
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    signing: {}
+			`,
+			want: &params.Signing{},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    signing:
+			      openpgp:
+			        key-id: EB49D88F45DD2D6D
+			`,
+			wantError: "invalid configuration for product test: invalid value for signing: openpgp keyring must be specified",
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    signing:
+			      minisign:
+			        key-path: minisign.key
+			        key-env-var: MINISIGN_KEY
+			`,
+			wantError: "invalid configuration for product test: invalid value for signing: only one of minisign key-path and key-env-var can be specified",
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    signing:
+			      minisign:
+			        passphrase-env-var: MINISIGN_PASSPHRASE
+			`,
+			wantError: "invalid configuration for product test: invalid value for signing: minisign key-path or key-env-var must be specified",
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, got.Products["test"].Signing, "Case %d", i)
+	}
+}
+
+func TestSBOM(t *testing.T) {
+	for i, currCase := range []struct {
+		yml       string
+		want      *params.SBOM
+		wantError string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: sls
+			`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      sbom: {}
+			`,
+			want: &params.SBOM{},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      sbom:
+			        formats:
+			          - cyclonedx
+			        dir: deployment/sbom
+			`,
+			want: &params.SBOM{
+				Formats: []params.SBOMFormat{params.CycloneDXSBOMFormat},
+				Dir:     "deployment/sbom",
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      sbom:
+			        formats:
+			          - swid
+			`,
+			wantError: `invalid configuration for product test: invalid value for sbom formats: "swid" is not one of "spdx" or "cyclonedx"`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      sbom:
+			        dir: ../sbom
+			`,
+			wantError: `invalid configuration for product test: invalid value for sbom dir: "../sbom" is not a path within the distribution directory`,
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, got.Products["test"].Dist[0].SBOM, "Case %d", i)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	for i, currCase := range []struct {
+		yml       string
+		want      []string
+		wantError string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      input-dir: dist/input
+			      templates:
+			        - "*.yml"
+			        - deployment/manifest.yml
+			`,
+			want: []string{"*.yml", "deployment/manifest.yml"},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      templates:
+			        - "*.yml"
+			`,
+			wantError: `invalid configuration for product test: templates requires input-dir to be specified`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      input-dir: dist/input
+			      templates:
+			        - "[.yml"
+			`,
+			wantError: `invalid configuration for product test: invalid value for templates: "[.yml" is not a valid pattern`,
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, got.Products["test"].Dist[0].Templates, "Case %d", i)
+	}
+}
+
+func TestLicenses(t *testing.T) {
+	for i, currCase := range []struct {
+		yml       string
+		want      *params.Licenses
+		wantError string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      licenses: {}
+			`,
+			want: &params.Licenses{},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      licenses:
+			        deny:
+			          - gpl-3.0
+			          - AGPL-3.0
+			          - Weak-Copyleft
+			          - unknown
+			`,
+			want: &params.Licenses{
+				Deny: []string{"GPL-3.0", "AGPL-3.0", "weak-copyleft", "unknown"},
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      licenses:
+			        deny:
+			          - GPL
+			`,
+			wantError: `invalid configuration for product test: invalid value for licenses deny: "GPL" is not a recognized license identifier or category`,
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, got.Products["test"].Dist[0].Licenses, "Case %d", i)
+	}
+}
+
+func TestSystemd(t *testing.T) {
+	for i, currCase := range []struct {
+		yml       string
+		want      params.DistInfo
+		wantError string
+	}{
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			        info:
+			          service-args: server var/conf/test.yml
+			          systemd:
+			            user: test
+			            environment-file: -/etc/sysconfig/test
+			            restart: always
+			            limits:
+			              nofile: 65536
+			              NPROC: infinity
+			`,
+			want: &params.RPMDistInfo{
+				ServiceArgs: "server var/conf/test.yml",
+				Systemd: &params.Systemd{
+					User:            "test",
+					EnvironmentFile: "-/etc/sysconfig/test",
+					Restart:         "always",
+					Limits: map[string]string{
+						"NOFILE": "65536",
+						"NPROC":  "infinity",
+					},
+				},
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: sls
+			        info:
+			          systemd:
+			            working-directory: /opt/test
+			            group: test
+			`,
+			want: &params.SLSDistInfo{
+				Systemd: &params.Systemd{
+					WorkingDirectory: "/opt/test",
+					Group:            "test",
+				},
+			},
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: sls
+			        info:
+			          systemd:
+			            user: test
+			`,
+			wantError: "invalid configuration for product test: systemd working-directory must be specified for sls distributions",
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: sls
+			        info:
+			          systemd:
+			            working-directory: /opt/test
+			            executable: /usr/bin/test
+			`,
+			wantError: "invalid configuration for product test: systemd executable is not supported for sls distributions",
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			        info:
+			          systemd:
+			            executable: bin/test
+			`,
+			wantError: `invalid configuration for product test: invalid value for systemd executable: "bin/test" is not an absolute path`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			        info:
+			          systemd:
+			            restart: sometimes
+			`,
+			wantError: `invalid configuration for product test: invalid value for systemd restart: "sometimes" is not a systemd restart policy`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			        info:
+			          systemd:
+			            limits:
+			              files: 1024
+			`,
+			wantError: `invalid configuration for product test: invalid value for systemd limits: "files" is not a recognized resource`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			        info:
+			          systemd:
+			            description: "test\nExecStartPre=/bin/true"
+			`,
+			wantError: `invalid configuration for product test: invalid value for systemd description: "test\nExecStartPre=/bin/true" contains a line break`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			        info:
+			          systemd:
+			            user: "test; rm -rf /"
+			`,
+			wantError: `invalid configuration for product test: invalid value for systemd user: "test; rm -rf /" is not a valid user or group name`,
+		},
+		{
+			yml: `
+			products:
+			  test:
+			    dist:
+			      dist-type:
+			        type: rpm
+			        info:
+			          systemd:
+			            user: test
+			            group: Test
+			`,
+			wantError: `invalid configuration for product test: invalid value for systemd group: "Test" is not a valid user or group name`,
+		},
+	} {
+		cfg, err := config.LoadRawConfig(unindent(currCase.yml), "")
+		require.NoError(t, err, "Case %d", i)
+
+		got, err := cfg.ToParams()
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		info := got.Products["test"].Dist[0].Info
+		if slsDistInfo, ok := info.(*params.SLSDistInfo); ok {
+			// matchers are not comparable
+			slsDistInfo.YMLValidationExclude = nil
+		}
+		assert.Equal(t, currCase.want, info, "Case %d", i)
+	}
+}
@@ -45,7 +45,7 @@ group-id: com.palantir.cache`
 
 	cfg := configFromYML(yml)
 	fmt.Printf("%q", fmt.Sprintf("%+v", cfg))
-	// Output: "{Products:map[cache-service:{Build:{Script: MainPkg:./main/cache OutputDir: BuildArgsScript: VersionVar:main.Version Environment:map[] OSArchs:[linux-amd64]} Run:{Args:[]} Dist:[{OutputDir:cache/build/distributions InputDir:cache/dist/sls InputProducts:[] Script: DistType:{Type:sls Info:{InitShTemplateFile: ManifestTemplateFile: ServiceArgs:--config var/conf/cache.yml server ProductType: ManifestExtensions:map[cache:true] YMLValidationExclude:{Names:[] Paths:[]}}} Publish:{GroupID: Almanac:{Metadata:map[] Tags:[]}}}] DefaultPublish:{GroupID: Almanac:{Metadata:map[] Tags:[]}}}] BuildOutputDir: DistOutputDir: DistScriptInclude: GroupID:com.palantir.cache Exclude:{Names:[] Paths:[]}}"
+	// Output: "{Products:map[cache-service:{Build:{Script: MainPkg:./main/cache OutputDir: OutputPath: ArtifactName: BuildArgsScript: VersionVar:main.Version LdflagsVars:map[] Ldflags:[] Gcflags:[] Tags:[] Reproducible:false BuildMode: MaxSize: Environment:map[] OSArchs:[linux-amd64] OSArchOverrides:map[]} Run:{Args:[] Environment:map[] WorkingDir: DependsOn:[] Ready:{TCP: HTTP: Timeout:}} Dist:[{OutputDir:cache/build/distributions OutputPath: ArtifactName: InputDir:cache/dist/sls Templates:[] InputProducts:[] Script: DistType:{Type:sls Info:{InitShTemplateFile: ManifestTemplateFile: ServiceArgs:--config var/conf/cache.yml server ProductType: ManifestExtensions:map[cache:true] YMLValidationExclude:{Names:[] Paths:[]} Systemd:<nil>}} ArchiveFormat: SplitByOSArch:false ArchiveUID:0 ArchiveGID:0 SBOM:<nil> Licenses:<nil> Publish:{GroupID: Almanac:{Metadata:map[] Tags:[]}}}] DefaultPublish:{GroupID: Almanac:{Metadata:map[] Tags:[]}} Signing:<nil>}] BuildOutputDir: DistOutputDir: DistScriptInclude: GroupID:com.palantir.cache Exclude:{Names:[] Paths:[]} RunGroups:map[]}"
 }
 
 func Example_bin() {
@@ -79,7 +79,7 @@ group-id: com.palantir.godel`
 
 	cfg := configFromYML(yml)
 	fmt.Printf("%q", fmt.Sprintf("%+v", cfg))
-	// Output: "{Products:map[godel:{Build:{Script: MainPkg:./cmd/godel OutputDir: BuildArgsScript: VersionVar:main.Version Environment:map[CGO_ENABLED:0] OSArchs:[darwin-amd64 linux-amd64]} Run:{Args:[]} Dist:[{OutputDir: InputDir: InputProducts:[] Script:function setup_wrapper {\n  # logic for function (omitted for brevity)\n}\n\n# copy contents of resources directory\nmkdir -p \"$DIST_DIR/wrapper\"\nsetup_wrapper \"$DIST_DIR/wrapper\"\n DistType:{Type:bin Info:{OmitInitSh:true InitShTemplateFile:}} Publish:{GroupID: Almanac:{Metadata:map[] Tags:[]}}}] DefaultPublish:{GroupID: Almanac:{Metadata:map[] Tags:[]}}}] BuildOutputDir: DistOutputDir: DistScriptInclude: GroupID:com.palantir.godel Exclude:{Names:[] Paths:[]}}"
+	// Output: "{Products:map[godel:{Build:{Script: MainPkg:./cmd/godel OutputDir: OutputPath: ArtifactName: BuildArgsScript: VersionVar:main.Version LdflagsVars:map[] Ldflags:[] Gcflags:[] Tags:[] Reproducible:false BuildMode: MaxSize: Environment:map[CGO_ENABLED:0] OSArchs:[darwin-amd64 linux-amd64] OSArchOverrides:map[]} Run:{Args:[] Environment:map[] WorkingDir: DependsOn:[] Ready:{TCP: HTTP: Timeout:}} Dist:[{OutputDir: OutputPath: ArtifactName: InputDir: Templates:[] InputProducts:[] Script:function setup_wrapper {\n  # logic for function (omitted for brevity)\n}\n\n# copy contents of resources directory\nmkdir -p \"$DIST_DIR/wrapper\"\nsetup_wrapper \"$DIST_DIR/wrapper\"\n DistType:{Type:bin Info:{OmitInitSh:true InitShTemplateFile:}} ArchiveFormat: SplitByOSArch:false ArchiveUID:0 ArchiveGID:0 SBOM:<nil> Licenses:<nil> Publish:{GroupID: Almanac:{Metadata:map[] Tags:[]}}}] DefaultPublish:{GroupID: Almanac:{Metadata:map[] Tags:[]}} Signing:<nil>}] BuildOutputDir: DistOutputDir: DistScriptInclude: GroupID:com.palantir.godel Exclude:{Names:[] Paths:[]} RunGroups:map[]}"
 }
 
 func Example_rpm() {
@@ -110,7 +110,7 @@ group-id: com.palantir.pcloud`
 
 	cfg := configFromYML(yml)
 	fmt.Printf("%q", fmt.Sprintf("%+v", cfg))
-	// Output: "{Products:map[orchestrator:{Build:{Script: MainPkg: OutputDir: BuildArgsScript: VersionVar: Environment:map[] OSArchs:[]} Run:{Args:[]} Dist:[{OutputDir: InputDir:./rpm InputProducts:[] Script:mkdir \"$DIST_DIR\"/usr/libexec/orchestrator\ncp build/linux-amd64/orchestrator \"$DIST_DIR\"/usr/libexec/orchestrator\n DistType:{Type:rpm Info:{Release: ConfigFiles:[/usr/lib/systemd/system/orchestrator.service] BeforeInstallScript:/usr/bin/getent group orchestrator || /usr/sbin/groupadd \\\n        -g 380 orchestrator\n/usr/bin/getent passwd orchestrator || /usr/sbin/useradd -r \\\n        -d /var/lib/orchestrator -g orchestrator -u 380 -m \\\n        -s /sbin/nologin orchestrator\n AfterInstallScript:systemctl daemon-reload\n AfterRemoveScript:systemctl daemon-reload\n}} Publish:{GroupID: Almanac:{Metadata:map[] Tags:[]}}}] DefaultPublish:{GroupID: Almanac:{Metadata:map[] Tags:[]}}}] BuildOutputDir: DistOutputDir: DistScriptInclude: GroupID:com.palantir.pcloud Exclude:{Names:[] Paths:[]}}"
+	// Output: "{Products:map[orchestrator:{Build:{Script: MainPkg: OutputDir: OutputPath: ArtifactName: BuildArgsScript: VersionVar: LdflagsVars:map[] Ldflags:[] Gcflags:[] Tags:[] Reproducible:false BuildMode: MaxSize: Environment:map[] OSArchs:[] OSArchOverrides:map[]} Run:{Args:[] Environment:map[] WorkingDir: DependsOn:[] Ready:{TCP: HTTP: Timeout:}} Dist:[{OutputDir: OutputPath: ArtifactName: InputDir:./rpm Templates:[] InputProducts:[] Script:mkdir \"$DIST_DIR\"/usr/libexec/orchestrator\ncp build/linux-amd64/orchestrator \"$DIST_DIR\"/usr/libexec/orchestrator\n DistType:{Type:rpm Info:{Release: ConfigFiles:[/usr/lib/systemd/system/orchestrator.service] BeforeInstallScript:/usr/bin/getent group orchestrator || /usr/sbin/groupadd \\\n        -g 380 orchestrator\n/usr/bin/getent passwd orchestrator || /usr/sbin/useradd -r \\\n        -d /var/lib/orchestrator -g orchestrator -u 380 -m \\\n        -s /sbin/nologin orchestrator\n AfterInstallScript:systemctl daemon-reload\n BeforeRemoveScript: AfterRemoveScript:systemctl daemon-reload\n Requires:[] Provides:[] Conflicts:[] Files:map[] BinDir: ServiceArgs: Systemd:<nil>}} ArchiveFormat: SplitByOSArch:false ArchiveUID:0 ArchiveGID:0 SBOM:<nil> Licenses:<nil> Publish:{GroupID: Almanac:{Metadata:map[] Tags:[]}}}] DefaultPublish:{GroupID: Almanac:{Metadata:map[] Tags:[]}} Signing:<nil>}] BuildOutputDir: DistOutputDir: DistScriptInclude: GroupID:com.palantir.pcloud Exclude:{Names:[] Paths:[]} RunGroups:map[]}"
 }
 
 func configFromYML(yml string) config.Project {
@@ -37,6 +37,16 @@ type Build struct {
 	// OutputDir is the directory to which the executable is written.
 	OutputDir string
 
+	// OutputPath is a Go template for the path of the directory relative to OutputDir to which the outputs for an
+	// OS/Arch are written. It is rendered using a templating.PathConfig for the product and OS/Arch. If blank, the
+	// outputs are written to "{{.VersionInfo.Version}}/{{.OSArch}}".
+	OutputPath string
+
+	// ArtifactName is a Go template for the name of the outputs for an OS/Arch without the extension determined by the
+	// build mode and OS (such as ".exe" or ".so"). It is rendered using a templating.PathConfig for the product and
+	// OS/Arch. If blank, the product name is used.
+	ArtifactName string
+
 	// BuildArgsScript is the content of a script that is written to a file and run before this product is built
 	// to provide supplemental build arguments for the product. The contents of this value are written to a file
 	// with a header `#!/bin/bash` and executed. The script process inherits the environment variables of the Go
@@ -55,6 +65,42 @@ type Build struct {
 	// ldflag.
 	VersionVar string
 
+	// LdflagsVars maps the path of string variables to Go templates for their values. Each value is rendered using a
+	// templating.Config for the product (so values such as {{.VersionInfo.Revision}}, {{.VersionInfo.Branch}} and
+	// {{.BuildTime.Unix}} are available) and is provided to the "build" command as an "-X" ldflag. For example:
+	//
+	//   ldflags-vars:
+	//     main.revision: "{{.VersionInfo.Revision}}"
+	//     main.buildTime: "{{.BuildTime.Unix}}"
+	LdflagsVars map[string]string
+
+	// Ldflags contains additional flags that are provided to the linker. All ldflags for the build (including those
+	// specified by LdflagsVars, VersionVar and any "-ldflags" arguments output by BuildArgsScript) are combined into a
+	// single "-ldflags" argument.
+	Ldflags []string
+
+	// Gcflags contains flags that are provided to the compiler. They are combined with any "-gcflags" arguments
+	// output by BuildArgsScript into a single "-gcflags" argument.
+	Gcflags []string
+
+	// Tags contains the build tags used for the build. They are combined with any "-tags" arguments output by
+	// BuildArgsScript into a single "-tags" argument.
+	Tags []string
+
+	// Reproducible specifies whether the product is built in reproducible mode. In reproducible mode, file system paths
+	// are trimmed from the executable, the build ID is omitted and the {{.BuildTime}} available to LdflagsVars
+	// templates is taken from the SOURCE_DATE_EPOCH environment variable (or is the Unix epoch if the variable is not
+	// set), so that building the same source with the same toolchain produces identical output.
+	Reproducible bool
+
+	// BuildMode is the value provided to the "-buildmode" flag of the build. Must be blank or one of the BuildMode
+	// constants. If blank, the default build mode (which produces an executable) is used.
+	BuildMode string
+
+	// MaxSize is the maximum size in bytes of the executable for each OS/Arch. If an executable is larger, the build
+	// fails. If 0, the size of the executables is not limited.
+	MaxSize int64
+
 	// Environment specifies values for the environment variables that should be set for the build. For example,
 	// the following sets CGO to false:
 	//
@@ -63,6 +109,64 @@ type Build struct {
 	Environment map[string]string
 
 	// OSArchs specifies the GOOS and GOARCH pairs for which the product is built. If blank, defaults to the GOOS
-	// and GOARCH of the host system at runtime.
+	// and GOARCH of the host system at runtime. May contain patterns (see osarch.NewPattern), which are expanded by
+	// NewProductBuildSpec.
 	OSArchs []osarch.OSArch
+
+	// OSArchOverrides specifies build configuration that only applies when the product is built for a specific
+	// GOOS and GOARCH pair. The configuration for an OS/Arch is merged over the configuration of the product.
+	OSArchOverrides map[osarch.OSArch]OSArchBuild
+}
+
+const (
+	BuildModeExe      = "exe"
+	BuildModePIE      = "pie"
+	BuildModeCShared  = "c-shared"
+	BuildModeCArchive = "c-archive"
+	BuildModePlugin   = "plugin"
+)
+
+// IsExecutableBuildMode returns true if the provided build mode produces an executable.
+func IsExecutableBuildMode(buildMode string) bool {
+	return buildMode == "" || buildMode == BuildModeExe || buildMode == BuildModePIE
+}
+
+// OSArchBuild is build configuration that applies to a single OS/Arch.
+type OSArchBuild struct {
+	// Environment specifies values for environment variables that are set for the build. Values take precedence
+	// over the values for the same variables in Build.Environment.
+	Environment map[string]string
+
+	// Tags contains build tags that are used in addition to Build.Tags.
+	Tags []string
+
+	// Ldflags contains flags that are provided to the linker in addition to Build.Ldflags.
+	Ldflags []string
+
+	// Args contains additional arguments that are provided to the "build" command. They are handled in the same
+	// manner as the output of BuildArgsScript.
+	Args []string
+}
+
+// ForOSArch returns the effective build configuration for the provided OS/Arch, which is the result of merging the
+// OSArchOverrides entry for the OS/Arch (if any) over the environment, tags and ldflags of the product.
+func (b Build) ForOSArch(osArch osarch.OSArch) OSArchBuild {
+	override := b.OSArchOverrides[osArch]
+
+	var env map[string]string
+	if len(b.Environment) > 0 || len(override.Environment) > 0 {
+		env = make(map[string]string)
+		for k, v := range b.Environment {
+			env[k] = v
+		}
+		for k, v := range override.Environment {
+			env[k] = v
+		}
+	}
+	return OSArchBuild{
+		Environment: env,
+		Tags:        append(append([]string(nil), b.Tags...), override.Tags...),
+		Ldflags:     append(append([]string(nil), b.Ldflags...), override.Ldflags...),
+		Args:        append([]string(nil), override.Args...),
+	}
 }
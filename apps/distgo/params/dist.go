@@ -15,6 +15,8 @@
 package params
 
 import (
+	"os"
+
 	"github.com/palantir/pkg/matcher"
 )
 
@@ -22,11 +24,26 @@ type Dist struct {
 	// OutputDir is the directory to which the distribution is written.
 	OutputDir string
 
+	// OutputPath is a Go template for the path of the directory relative to OutputDir to which the artifacts of the
+	// distribution are written. It is rendered using a templating.PathConfig for the product, distribution and (for
+	// artifacts that contain the outputs for a single OS/Arch) OS/Arch. If blank, artifacts are written to OutputDir.
+	OutputPath string
+
+	// ArtifactName is a Go template for the file name of the artifacts of the distribution. It is rendered in the same
+	// manner as OutputPath. If blank, the name is determined by the type of the distribution (for example,
+	// "{{product}}-{{version}}.sls.tgz").
+	ArtifactName string
+
 	// InputDir is the path (from the project root) to a directory whose contents will be copied into the output
 	// distribution directory at the beginning of the "dist" command. Can be used to include static resources and
 	// other files required in a distribution.
 	InputDir string
 
+	// Templates are the glob patterns for the files in InputDir that are rendered as templates with a
+	// templating.Config when they are copied into the output distribution directory. Patterns that contain a slash
+	// match the paths of files relative to InputDir and patterns without a slash match the names of files.
+	Templates []string
+
 	// InputProducts is a slice of the names of products in the project (other than the current one) whose binaries
 	// are required for the "dist" task. The "dist" task will ensure that the outputs of "build" exist for all of
 	// the products specified in this slice (and will build the products as part of the task if necessary) and make
@@ -53,16 +70,78 @@ type Dist struct {
 	// a DistInfo of type SLSDistType.
 	Info DistInfo
 
+	// ArchiveFormat is the format of the archive created for "sls" and "bin" distributions. If blank, defaults to
+	// TGZArchiveFormat.
+	ArchiveFormat ArchiveFormat
+
+	// SplitByOSArch specifies that an archive is created for every OS/Arch of the product rather than a single archive
+	// for all of them. Only supported for "sls" and "bin" distributions. Each archive contains the content of the
+	// distribution directory other than the executables for the other OS/Archs.
+	SplitByOSArch bool
+
+	// ArchiveUID and ArchiveGID are the user and group IDs of the entries of the tgz archives created for "sls" and
+	// "bin" distributions.
+	ArchiveUID int
+	ArchiveGID int
+
+	// SBOM specifies that software bills of materials that list the third-party packages linked into the executables
+	// of the product are written for the distribution. If nil, no SBOMs are written.
+	SBOM *SBOM
+
+	// Licenses specifies that the licenses of the third-party packages linked into the executables of the product are
+	// checked and that a third-party notices file is written to the distribution directory. If nil, licenses are not
+	// checked.
+	Licenses *Licenses
+
 	// Publish is the configuration for the "publish" task.
 	Publish Publish
 }
 
+type ArchiveFormat string
+
+const (
+	TGZArchiveFormat ArchiveFormat = "tgz" // gzip-compressed tar archive
+	ZipArchiveFormat ArchiveFormat = "zip" // zip archive
+)
+
+type SBOM struct {
+	// Formats are the formats of the SBOMs that are written. If empty, SBOMs are written in all of the supported
+	// formats.
+	Formats []SBOMFormat
+
+	// Dir is the path of the directory relative to the root of the distribution directory to which the SBOMs are
+	// written so that they are included in the artifacts of the distribution. If blank, the SBOMs are written to the
+	// root of the distribution directory.
+	Dir string
+}
+
+type SBOMFormat string
+
+const (
+	SPDXSBOMFormat      SBOMFormat = "spdx"      // SPDX 2.2 JSON document
+	CycloneDXSBOMFormat SBOMFormat = "cyclonedx" // CycloneDX 1.4 JSON document
+)
+
+// SBOMFormats are the supported SBOM formats in the order in which SBOMs are written.
+var SBOMFormats = []SBOMFormat{SPDXSBOMFormat, CycloneDXSBOMFormat}
+
+type Licenses struct {
+	// Deny are the SPDX identifiers of licenses and the license categories (as defined by the license package) that
+	// third-party packages may not have.
+	Deny []string
+}
+
 type DistInfoType string
 
 const (
 	SLSDistType DistInfoType = "sls" // distribution that uses the Standard Layout Specification
 	BinDistType DistInfoType = "bin" // distribution that includes all of the binaries for a product
 	RPMDistType DistInfoType = "rpm" // RPM distribution
+	DebDistType DistInfoType = "deb" // Debian package distribution
+	OCIDistType DistInfoType = "oci" // OCI image layout archive that contains a container image for the product
+
+	// DockerDistType is an alias for OCIDistType.
+	DockerDistType DistInfoType = "docker"
 )
 
 type DistInfo interface {
@@ -109,6 +188,12 @@ type SLSDistInfo struct {
 	// "*.yaml" files in the distribution are syntactically valid. If a distribution is known to ship with YML files
 	// that are not valid YML, this parameter can be used to exclude those files from validation.
 	YMLValidationExclude matcher.Matcher
+
+	// Systemd specifies the systemd unit that is generated for the distribution at "service/bin/{{ProductName}}.service".
+	// The unit runs the executable for the linux OS/Arch of the product with ServiceArgs from the directory in which
+	// the distribution is installed, which must be specified as the WorkingDirectory of the unit. Requires the product
+	// to be built for exactly one linux OS/Arch. If nil, no unit is generated.
+	Systemd *Systemd
 }
 
 func (i *SLSDistInfo) Type() DistInfoType {
@@ -126,10 +211,126 @@ type RPMDistInfo struct {
 	BeforeInstallScript string
 	// AfterInstallScript is the content of shell script to run immediately after this RPM is installed. Optional.
 	AfterInstallScript string
+	// BeforeRemoveScript is the content of shell script to run before this RPM is removed. Optional.
+	BeforeRemoveScript string
 	// AfterRemoveScript is the content of shell script to clean up after this RPM is removed. Optional.
 	AfterRemoveScript string
+	// Requires, Provides and Conflicts are the relationships of the RPM to other packages. Each relationship is a
+	// package name optionally followed by a comparison operator and a version, such as "bash >= 4.0". Optional.
+	Requires  []string
+	Provides  []string
+	Conflicts []string
+	// Files specifies the ownership and permissions of files in the RPM. See PackageFile for details. Optional.
+	Files map[string]PackageFile
+	// BinDir is the absolute path of the directory of the RPM in which the executables of the product for the
+	// architecture of the RPM are installed, such as "/usr/bin". If blank, the RPM only contains the content of the
+	// distribution directory. Optional.
+	BinDir string
+	// ServiceArgs is the string provided as the arguments of the executable of the generated systemd unit.
+	ServiceArgs string
+	// Systemd specifies the systemd unit that is generated for the RPM at
+	// "/usr/lib/systemd/system/{{ProductName}}.service". If non-nil, the scripts of the RPM also create the user and
+	// group of the unit before the RPM is installed, enable and start the unit after it is installed (or restart it if
+	// the RPM is upgraded) and stop and disable the unit before the RPM is removed. The generated commands run before
+	// the commands of BeforeInstallScript, BeforeRemoveScript and AfterRemoveScript and after the commands of
+	// AfterInstallScript. Optional.
+	Systemd *Systemd
 }
 
 func (i *RPMDistInfo) Type() DistInfoType {
 	return RPMDistType
 }
+
+type DebDistInfo struct {
+	// Revision is the Debian revision of the package, which is appended to the version of the package. Default is
+	// "1".
+	Revision string
+	// Maintainer is the name and email address of the maintainer of the package. Optional.
+	Maintainer string
+	// ConfigFiles is a slice of absolute paths within the package that correspond to configuration files, which are
+	// not replaced on upgrade if they have been modified. Default is no files.
+	ConfigFiles []string
+	// BeforeInstallScript, AfterInstallScript, BeforeRemoveScript and AfterRemoveScript are the content of the
+	// preinst, postinst, prerm and postrm maintainer scripts of the package. Optional.
+	BeforeInstallScript string
+	AfterInstallScript  string
+	BeforeRemoveScript  string
+	AfterRemoveScript   string
+	// Requires, Provides and Conflicts are the relationships of the package to other packages (Requires is written
+	// as the "Depends" field). Each relationship is a package name optionally followed by a comparison operator and a
+	// version, such as "bash >= 4.0". Optional.
+	Requires  []string
+	Provides  []string
+	Conflicts []string
+	// Files specifies the ownership and permissions of files in the package. See PackageFile for details. Optional.
+	Files map[string]PackageFile
+	// BinDir is the absolute path of the directory of the package in which the executables of the product for the
+	// architecture of the package are installed, such as "/usr/bin". If blank, the package only contains the content
+	// of the distribution directory. Optional.
+	BinDir string
+}
+
+func (i *DebDistInfo) Type() DistInfoType {
+	return DebDistType
+}
+
+// PackageFile specifies the ownership and permissions of files in an RPM or Debian package. Package files are keyed
+// by the absolute path of a file in the package or by a pattern (as supported by path.Match) that matches the paths
+// of files. Directories in the distribution are only added to a package if they are empty or have an entry.
+type PackageFile struct {
+	// Owner is the name of the user that owns the file. Default is "root".
+	Owner string
+	// Group is the name of the group that owns the file. Default is "root".
+	Group string
+	// Mode is the permissions of the file. If 0, the permissions of the file in the distribution directory are used.
+	Mode os.FileMode
+}
+
+// Systemd specifies a systemd service unit that runs the executable of a product.
+type Systemd struct {
+	// Description is the description of the unit. Default is the name of the product.
+	Description string
+	// Executable is the absolute path of the executable that is run by the unit. Only supported by RPM distributions,
+	// for which the default is the executable of the product in the BinDir of the RPM (and which is required if BinDir
+	// is blank).
+	Executable string
+	// WorkingDirectory is the absolute path of the working directory of the service. Optional for RPM distributions.
+	WorkingDirectory string
+	// User and Group are the user and group that run the service. If User is blank, the service is run as root. If
+	// Group is blank, the service is run with the primary group of the user.
+	User  string
+	Group string
+	// EnvironmentFile is the absolute path of a file that sets environment variables for the service. A path that
+	// starts with "-" is ignored if the file does not exist. Optional.
+	EnvironmentFile string
+	// Restart is the restart policy of the service, such as "always" or "no". Default is "on-failure".
+	Restart string
+	// Limits are the resource limits of the service keyed by the name of the resource as used by setrlimit without
+	// the "RLIMIT_" prefix, such as "NOFILE". Values are written as the value of the corresponding "Limit" directive
+	// of the unit, such as "65536" or "infinity".
+	Limits map[string]string
+}
+
+type OCIDistInfo struct {
+	// BaseImage is the path (relative to the project root) to an OCI image layout archive (which may be compressed
+	// using gzip) that contains the base image for every linux OS/Arch of the product. If blank or "scratch", the
+	// images are based on an empty image.
+	BaseImage string
+	// BinaryPath is the absolute path of the product executable in the image. The executables of the input products
+	// of the distribution are added to the same directory. Default is "/usr/local/bin/{{ProductName}}".
+	BinaryPath string
+	// Entrypoint is the entrypoint of the image. Default is the executable of the product.
+	Entrypoint []string
+	// Cmd contains the default arguments provided to the entrypoint.
+	Cmd []string
+	// Env specifies values for environment variables that are set in the image.
+	Env map[string]string
+	// WorkingDir is the working directory of the image.
+	WorkingDir string
+	// User is the user (and optionally the group, using the form "user:group") that runs the entrypoint.
+	User string
+}
+
+func (i *OCIDistInfo) Type() DistInfoType {
+	return OCIDistType
+}
@@ -36,6 +36,9 @@ type Project struct {
 	GroupID string
 	// Exclude matches the paths to exclude when determining the projects to build.
 	Exclude matcher.Matcher
+	// RunGroups maps the names of run groups to the products in the group. All of the products in a group can be
+	// run together using the "run" task.
+	RunGroups map[string][]string
 }
 
 func (d Project) FilteredProducts() map[string]Product {
@@ -59,4 +62,7 @@ type Product struct {
 	// DefaultPublish specifies the publish configuration that is applied to distributions that do not specify their
 	// own publish configurations.
 	DefaultPublish Publish
+	// Signing specifies how the artifacts of the distributions of the product are signed. If nil, the artifacts are
+	// not signed.
+	Signing *Signing
 }
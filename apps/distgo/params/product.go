@@ -77,7 +77,8 @@ func NewProductBuildSpecWithDeps(spec ProductBuildSpec, allSpecs map[string]Prod
 
 // NewProductBuildSpec returns a fully initialized ProductBuildSpec that is a combination of the provided parameters.
 // If any of the required fields in the provided configuration is blank, the returned ProjectBuildSpec will have default
-// values populated in the returned object.
+// values populated in the returned object. Patterns in the OSArchs of the build configuration are expanded to the
+// targets that they match.
 func NewProductBuildSpec(projectDir, productName string, gitProductInfo git.ProjectInfo, productCfg Product, projectCfg Project) ProductBuildSpec {
 	buildSpec := ProductBuildSpec{
 		Product:        productCfg,
@@ -93,6 +94,9 @@ func NewProductBuildSpec(projectDir, productName string, gitProductInfo git.Proj
 
 	if len(buildSpec.Build.OSArchs) == 0 {
 		buildSpec.Build.OSArchs = []osarch.OSArch{osarch.Current()}
+	} else if expanded, err := osarch.Expand(buildSpec.Build.OSArchs); err == nil {
+		// patterns that cannot be expanded are retained and cause the build for them to fail
+		buildSpec.Build.OSArchs = expanded
 	}
 
 	if len(buildSpec.Dist) == 0 {
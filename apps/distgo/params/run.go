@@ -14,7 +14,45 @@
 
 package params
 
+import (
+	"time"
+)
+
 type Run struct {
 	// Args contain the arguments provided to the product when invoked using the "run" task.
 	Args []string
+
+	// Environment specifies values for environment variables that are set for the product when it is invoked using
+	// the "run" task in addition to the environment of the "run" task.
+	Environment map[string]string
+
+	// WorkingDir is the directory in which the product is invoked by the "run" task. A relative path is resolved
+	// against the project directory. If blank, the working directory of the "run" task is used.
+	WorkingDir string
+
+	// DependsOn contains the names of the products that must be started (and, if they specify a readiness check, be
+	// ready) before this product is started when multiple products are run together. Running this product also runs
+	// the products it depends on.
+	DependsOn []string
+
+	// Ready specifies how to determine that the product is ready when multiple products are run together.
+	Ready ReadyCheck
+}
+
+// ReadyCheck specifies a probe that determines whether a running product is ready. At most one of TCP and HTTP is set.
+// If neither is set, the product is considered ready as soon as it has started.
+type ReadyCheck struct {
+	// TCP is an address ("host:port") that accepts connections once the product is ready.
+	TCP string
+
+	// HTTP is a URL that returns a 2xx or 3xx response to a GET request once the product is ready.
+	HTTP string
+
+	// Timeout is the amount of time that the product has to become ready after it is started.
+	Timeout time.Duration
+}
+
+// IsSet returns true if the check specifies a probe.
+func (c ReadyCheck) IsSet() bool {
+	return c.TCP != "" || c.HTTP != ""
 }
@@ -0,0 +1,45 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package params
+
+// Signing specifies how the artifacts of the distributions of a product are signed. If signing is specified for a
+// product, the "dist" task writes a SHA256SUMS file that contains the checksums of all of the artifacts of the product
+// and creates detached signatures for the artifacts and the SHA256SUMS file using each of the keys that is specified.
+type Signing struct {
+	// OpenPGP specifies the OpenPGP key used to sign the artifacts. Optional.
+	OpenPGP *OpenPGPSigning
+	// Minisign specifies the minisign key used to sign the artifacts. Optional.
+	Minisign *MinisignSigning
+}
+
+type OpenPGPSigning struct {
+	// Keyring is the path (relative to the project directory, or absolute) to a binary or ASCII-armored keyring file
+	// that contains the secret key used to sign the artifacts.
+	Keyring string
+	// KeyID is the key ID or fingerprint of the key in the keyring that is used. If blank, the first RSA signing key in
+	// the keyring is used.
+	KeyID string
+	// PassphraseEnvVar is the environment variable that contains the passphrase of the key. Optional.
+	PassphraseEnvVar string
+}
+
+type MinisignSigning struct {
+	// KeyPath is the path (relative to the project directory, or absolute) to the minisign secret key file.
+	KeyPath string
+	// KeyEnvVar is the environment variable that contains the minisign secret key. Only used if KeyPath is blank.
+	KeyEnvVar string
+	// PassphraseEnvVar is the environment variable that contains the passphrase of the key. Optional.
+	PassphraseEnvVar string
+}
@@ -17,7 +17,9 @@ package git
 import (
 	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -135,6 +137,26 @@ func ProjectRevision(gitDir string) (string, error) {
 	return trimmedCombinedGitCmdOutput(gitDir, "rev-list", branch+"..HEAD", "--count")
 }
 
+// ProjectCommit returns the full hash of the commit that is checked out in the git repository that the provided
+// directory is in.
+func ProjectCommit(gitDir string) (string, error) {
+	return trimmedCombinedGitCmdOutput(gitDir, "rev-parse", "HEAD")
+}
+
+// ProjectCommitTime returns the committer time of the commit that is checked out in the git repository that the
+// provided directory is in.
+func ProjectCommitTime(gitDir string) (time.Time, error) {
+	output, err := trimmedCombinedGitCmdOutput(gitDir, "log", "-1", "--format=%ct")
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := strconv.ParseInt(output, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse commit time %q", output)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
 func tags(gitDir string) (string, error) {
 	return trimmedCombinedGitCmdOutput(gitDir, "tag", "-l")
 }
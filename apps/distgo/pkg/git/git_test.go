@@ -16,8 +16,11 @@ package git_test
 
 import (
 	"io/ioutil"
+	"os"
+	"os/exec"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/nmiyake/pkg/dirs"
 	"github.com/stretchr/testify/assert"
@@ -127,6 +130,23 @@ func TestProjectInfo(t *testing.T) {
 	}
 }
 
+func TestProjectCommitTime(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	gittest.InitGitDir(t, tmp)
+	cmd := exec.Command("git", "commit", "--allow-empty", "-m", "Fixed time commit")
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=2017-01-02T03:04:05Z")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	got, err := git.ProjectCommitTime(tmp)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC), got)
+}
+
 func TestIsSnapshotVersion(t *testing.T) {
 	for i, currCase := range []struct {
 		version    string
@@ -15,13 +15,20 @@
 package imports
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"go/build"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/pkg/gomod"
 )
 
 // GoFiles is a map from package paths to the names of the buildable .go source files (.go files excluding Cgo and test
@@ -45,18 +52,79 @@ func (g GoFiles) NewerThan(fi os.FileInfo) (bool, error) {
 	return false, nil
 }
 
-// AllFiles returns a map that contains all of the non-standard library Go files that are imported (and thus required to
-// build) the specified package (including the package itself). The keys in the returned map are the paths to the
-// packages and the values are a slice of the names of the .go source files in the package (excluding Cgo and test
-// files).
+// Hash returns the hex-encoded SHA-256 hash of the GoFiles. The hash is computed from the package paths, file names
+// and file contents (visited in sorted order), so it only changes when the content of the files changes and is not
+// affected by modification times.
+func (g GoFiles) Hash() (string, error) {
+	pkgs := make([]string, 0, len(g))
+	for pkg := range g {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	h := sha256.New()
+	for _, pkg := range pkgs {
+		files := make([]string, len(g[pkg]))
+		copy(files, g[pkg])
+		sort.Strings(files)
+
+		for _, goFile := range files {
+			currPath := path.Join(pkg, goFile)
+			if err := hashFile(h, currPath); err != nil {
+				return "", err
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, filePath string) (rErr error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to open file %v", filePath)
+	}
+	defer func() {
+		if err := f.Close(); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "Failed to close file %v", filePath)
+		}
+	}()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to stat file %v", filePath)
+	}
+	// write the path and size before the content so that content cannot be shifted between files without changing the hash
+	if _, err := fmt.Fprintf(w, "%s\x00%d\x00", filePath, fi.Size()); err != nil {
+		return errors.Wrapf(err, "Failed to hash file %v", filePath)
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return errors.Wrapf(err, "Failed to hash file %v", filePath)
+	}
+	return nil
+}
+
+// AllFiles returns a map that contains all of the non-standard library source files that are imported (and thus required
+// to build) the specified package (including the package itself). The keys in the returned map are the paths to the
+// packages and the values are a slice of the names of the source files in the package: the .go files (including Cgo
+// files but excluding test files) and the C, C++, header, assembly and syso files. If the package is part of a project
+// that uses Go modules, the packages are resolved using "go list".
 func AllFiles(pkgPath string) (GoFiles, error) {
-	// package name to all non-test Go files in the package
-	pkgFiles := make(map[string][]string)
+	return ContextFiles(build.Default, pkgPath)
+}
 
+// ContextFiles returns the files like AllFiles, but resolves the packages and their files for the GOOS, GOARCH, cgo
+// setting and build tags of the provided context rather than for the host.
+func ContextFiles(ctx build.Context, pkgPath string) (GoFiles, error) {
 	absPkgPath, err := filepath.Abs(pkgPath)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to convert %v to absolute path", pkgPath)
 	}
+	if gomod.Enabled(absPkgPath) {
+		return moduleFiles(ctx, absPkgPath)
+	}
+
+	// package name to all non-test source files in the package
+	pkgFiles := make(map[string][]string)
 
 	pkgsToProcess := []string{
 		absPkgPath,
@@ -70,13 +138,13 @@ func AllFiles(pkgPath string) (GoFiles, error) {
 		}
 
 		// parse current package
-		pkg, err := build.Import(".", currPkg, build.ImportComment)
+		pkg, err := ctx.Import(".", currPkg, build.ImportComment)
 		if err != nil {
 			return nil, errors.Wrapf(err, "Failed to import package %v", currPkg)
 		}
 
 		// add all files for the current package to output
-		pkgFiles[currPkg] = pkg.GoFiles
+		pkgFiles[currPkg] = sourceFiles(pkg)
 
 		// convert all non-built-in imports into packages and add to packages to process
 		for importPath := range pkg.ImportPos {
@@ -84,7 +152,7 @@ func AllFiles(pkgPath string) (GoFiles, error) {
 				// if import is a standard package, skip
 				continue
 			}
-			importPkg, err := build.Import(importPath, currPkg, build.ImportComment)
+			importPkg, err := ctx.Import(importPath, currPkg, build.ImportComment)
 			if err != nil {
 				return nil, errors.Wrapf(err, "Failed to import package %v using srcDir %v", importPath, currPkg)
 			}
@@ -93,3 +161,31 @@ func AllFiles(pkgPath string) (GoFiles, error) {
 	}
 	return GoFiles(pkgFiles), nil
 }
+
+// sourceFiles returns the names of the source files of the provided package that are used to build it.
+func sourceFiles(pkg *build.Package) []string {
+	var files []string
+	for _, currFiles := range [][]string{pkg.GoFiles, pkg.CgoFiles, pkg.CFiles, pkg.CXXFiles, pkg.HFiles, pkg.SFiles, pkg.SysoFiles} {
+		files = append(files, currFiles...)
+	}
+	return files
+}
+
+// moduleFiles returns the GoFiles for the package in the provided directory and all of the non-standard library
+// packages that it depends on as reported by "go list". Used for packages in projects that use Go modules, whose
+// dependencies are resolved from the module cache rather than from GOPATH.
+func moduleFiles(ctx build.Context, absPkgPath string) (GoFiles, error) {
+	lines, err := gomod.ListContext(ctx, absPkgPath, `{{if not .Standard}}{{.Dir}}{{range .GoFiles}}{{"\t"}}{{.}}{{end}}{{range .CgoFiles}}{{"\t"}}{{.}}{{end}}{{range .CFiles}}{{"\t"}}{{.}}{{end}}{{range .CXXFiles}}{{"\t"}}{{.}}{{end}}{{range .HFiles}}{{"\t"}}{{.}}{{end}}{{range .SFiles}}{{"\t"}}{{.}}{{end}}{{range .SysoFiles}}{{"\t"}}{{.}}{{end}}{{end}}`, "-deps", ".")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list dependencies of package %v", absPkgPath)
+	}
+	pkgFiles := make(map[string][]string)
+	for _, currLine := range lines {
+		parts := strings.Split(currLine, "\t")
+		pkgFiles[parts[0]] = parts[1:]
+	}
+	if _, ok := pkgFiles[absPkgPath]; !ok {
+		return nil, errors.Errorf("Failed to import package %v", absPkgPath)
+	}
+	return GoFiles(pkgFiles), nil
+}
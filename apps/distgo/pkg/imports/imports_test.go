@@ -16,6 +16,7 @@ package imports_test
 
 import (
 	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"testing"
@@ -215,3 +216,40 @@ func TestNewerThanFileIsNotNewer(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, newer)
 }
+
+func TestHash(t *testing.T) {
+	tmpDir, cleanup, err := dirs.TempDir(".", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	mainFile := path.Join(tmpDir, "main.go")
+	err = ioutil.WriteFile(mainFile, []byte(`package main; import "fmt"; func main() {}`), 0644)
+	require.NoError(t, err)
+
+	goFiles, err := imports.AllFiles(tmpDir)
+	require.NoError(t, err)
+	original, err := goFiles.Hash()
+	require.NoError(t, err)
+
+	// updating the modification time does not change the hash
+	later := time.Now().Add(time.Hour)
+	err = os.Chtimes(mainFile, later, later)
+	require.NoError(t, err)
+	touched, err := goFiles.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, original, touched)
+
+	// modifying the content changes the hash
+	err = ioutil.WriteFile(mainFile, []byte(`package main; func main() {}`), 0644)
+	require.NoError(t, err)
+	modified, err := goFiles.Hash()
+	require.NoError(t, err)
+	assert.NotEqual(t, original, modified)
+
+	// reverting the content restores the original hash
+	err = ioutil.WriteFile(mainFile, []byte(`package main; import "fmt"; func main() {}`), 0644)
+	require.NoError(t, err)
+	reverted, err := goFiles.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, original, reverted)
+}
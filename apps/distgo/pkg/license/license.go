@@ -0,0 +1,174 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package license finds and identifies the license files of Go packages.
+package license
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// fileNameRegexp matches the names of license files such as "LICENSE", "LICENSE.md", "LICENCE-MIT" and "COPYING".
+	fileNameRegexp = regexp.MustCompile(`(?i)^(licen[cs]e|copying|unlicense)([.\-_].*)?$`)
+	// noticeFileNameRegexp matches the names of notice files such as "NOTICE" and "NOTICE.txt".
+	noticeFileNameRegexp = regexp.MustCompile(`(?i)^notice([.\-_].*)?$`)
+)
+
+// Find returns the path of the license file of the package in the provided directory. The directory of the package is
+// searched first, followed by each of its parent directories up to and including rootDir (which is typically the
+// vendor directory or module root that contains the package). If a directory contains multiple license files, the
+// first one in lexical order is returned. Returns an empty string if no license file is found.
+func Find(dir, rootDir string) (string, error) {
+	return find(dir, rootDir, fileNameRegexp)
+}
+
+// FindNotice returns the path of the NOTICE file of the package in the provided directory (such as the NOTICE file that
+// must be distributed with software licensed under the Apache License). Directories are searched in the same manner as
+// Find. Returns an empty string if no notice file is found.
+func FindNotice(dir, rootDir string) (string, error) {
+	return find(dir, rootDir, noticeFileNameRegexp)
+}
+
+func find(dir, rootDir string, nameRegexp *regexp.Regexp) (string, error) {
+	dir, rootDir = filepath.Clean(dir), filepath.Clean(rootDir)
+	for {
+		fileInfos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to list files in directory %s", dir)
+		}
+		var names []string
+		for _, currFileInfo := range fileInfos {
+			if !currFileInfo.IsDir() && nameRegexp.MatchString(currFileInfo.Name()) {
+				names = append(names, currFileInfo.Name())
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			return filepath.Join(dir, names[0]), nil
+		}
+		parent := filepath.Dir(dir)
+		if dir == rootDir || parent == dir || !strings.HasPrefix(dir, rootDir) {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Category is the category of a license, which determines the obligations that it imposes on software that includes
+// code licensed under it.
+type Category string
+
+const (
+	// Permissive licenses only require that the license and copyright notices are retained.
+	Permissive Category = "permissive"
+	// WeakCopyleft licenses require that modifications of the licensed code itself are made available under the same
+	// license.
+	WeakCopyleft Category = "weak-copyleft"
+	// Copyleft licenses require that software that includes the licensed code is made available under the same
+	// license.
+	Copyleft Category = "copyleft"
+	// PublicDomain licenses dedicate the licensed code to the public domain.
+	PublicDomain Category = "public-domain"
+	// Unknown is the category of licenses that are not recognized.
+	Unknown Category = "unknown"
+)
+
+// Categories are the license categories.
+var Categories = []Category{Permissive, WeakCopyleft, Copyleft, PublicDomain, Unknown}
+
+// licenses are the licenses recognized by Identify. A license text is identified as the first license for which it
+// contains all of the phrases, so licenses whose texts contain the phrases of other licenses must come first.
+var licenses = []struct {
+	id       string
+	category Category
+	phrases  []string
+}{
+	{id: "AGPL-3.0", category: Copyleft, phrases: []string{"gnu affero general public license version 3"}},
+	{id: "LGPL-3.0", category: WeakCopyleft, phrases: []string{"gnu lesser general public license version 3"}},
+	{id: "LGPL-2.1", category: WeakCopyleft, phrases: []string{"gnu lesser general public license version 2.1"}},
+	{id: "GPL-3.0", category: Copyleft, phrases: []string{"gnu general public license version 3"}},
+	{id: "GPL-2.0", category: Copyleft, phrases: []string{"gnu general public license version 2"}},
+	{id: "MPL-2.0", category: WeakCopyleft, phrases: []string{"mozilla public license version 2.0"}},
+	{id: "MPL-2.0", category: WeakCopyleft, phrases: []string{"mozilla public license, version 2.0"}},
+	{id: "EPL-2.0", category: WeakCopyleft, phrases: []string{"eclipse public license - v 2.0"}},
+	{id: "EPL-1.0", category: WeakCopyleft, phrases: []string{"eclipse public license - v 1.0"}},
+	{id: "Apache-2.0", category: Permissive, phrases: []string{"apache license version 2.0"}},
+	{id: "Apache-2.0", category: Permissive, phrases: []string{"apache license, version 2.0"}},
+	{id: "BSD-3-Clause", category: Permissive, phrases: []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{id: "BSD-2-Clause", category: Permissive, phrases: []string{"redistribution and use in source and binary forms"}},
+	{id: "MIT", category: Permissive, phrases: []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{id: "ISC", category: Permissive, phrases: []string{"permission to use, copy, modify, and", "distribute this software for any purpose with or without fee is hereby granted"}},
+	{id: "Unlicense", category: PublicDomain, phrases: []string{"this is free and unencumbered software released into the public domain"}},
+	{id: "CC0-1.0", category: PublicDomain, phrases: []string{"cc0 1.0 universal"}},
+}
+
+// IDs returns the SPDX identifiers of the licenses recognized by Identify in the order in which they are matched.
+func IDs() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, currLicense := range licenses {
+		if !seen[currLicense.id] {
+			ids = append(ids, currLicense.id)
+			seen[currLicense.id] = true
+		}
+	}
+	return ids
+}
+
+// CategoryOf returns the category of the license with the provided SPDX identifier. Returns Unknown if the identifier
+// is not one of the identifiers returned by IDs.
+func CategoryOf(id string) Category {
+	for _, currLicense := range licenses {
+		if currLicense.id == id {
+			return currLicense.category
+		}
+	}
+	return Unknown
+}
+
+// Identify returns the SPDX identifier of the license with the provided text (for example, "Apache-2.0" or "MIT").
+// The text is matched against characteristic phrases of common open source licenses ignoring case and whitespace.
+// Returns an empty string if the license is not recognized.
+func Identify(text []byte) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(string(text))), " ")
+	for _, currLicense := range licenses {
+		matches := true
+		for _, currPhrase := range currLicense.phrases {
+			if !strings.Contains(normalized, currPhrase) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return currLicense.id
+		}
+	}
+	return ""
+}
+
+// IdentifyFile returns the SPDX identifier of the license in the file at the provided path as determined by Identify.
+func IdentifyFile(licensePath string) (string, error) {
+	text, err := ioutil.ReadFile(licensePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read license file %s", licensePath)
+	}
+	return Identify(text), nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package license_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/godel/apps/distgo/pkg/license"
+)
+
+func TestIdentify(t *testing.T) {
+	for i, currCase := range []struct {
+		text string
+		want string
+	}{
+		{
+			text: "                                 Apache License\n                           Version 2.0, January 2004\n                        http://www.apache.org/licenses/",
+			want: "Apache-2.0",
+		},
+		{
+			text: "Licensed under the Apache License, Version 2.0 (the \"License\");",
+			want: "Apache-2.0",
+		},
+		{
+			text: "The MIT License (MIT)\n\nPermission is hereby granted, free of charge, to any person obtaining a copy\nof this software and associated documentation files",
+			want: "MIT",
+		},
+		{
+			text: "Redistribution and use in source and binary forms, with or without\nmodification, are permitted provided that the following conditions are met:\n\n* Neither the name of the copyright holder nor the names of its\n  contributors may be used to endorse or promote products",
+			want: "BSD-3-Clause",
+		},
+		{
+			text: "Redistribution and use in source and binary forms, with or without\nmodification, are permitted provided that the following conditions are met:",
+			want: "BSD-2-Clause",
+		},
+		{
+			text: "Permission to use, copy, modify, and/or distribute this software for any\npurpose with or without fee is hereby granted, provided that the above",
+			want: "ISC",
+		},
+		{
+			text: "Mozilla Public License Version 2.0\n==================================",
+			want: "MPL-2.0",
+		},
+		{
+			text: "                    GNU GENERAL PUBLIC LICENSE\n                       Version 3, 29 June 2007\n\n  13. Use with the GNU Affero General Public License.\n\nversion 3 of the GNU Affero General Public License",
+			want: "GPL-3.0",
+		},
+		{
+			text: "                    GNU AFFERO GENERAL PUBLIC LICENSE\n                       Version 3, 19 November 2007",
+			want: "AGPL-3.0",
+		},
+		{
+			text: "                   GNU LESSER GENERAL PUBLIC LICENSE\n                       Version 3, 29 June 2007\n\n  version 3 of the GNU General Public License",
+			want: "LGPL-3.0",
+		},
+		{
+			text: "I guess Python's? If that doesn't apply then MIT. Have fun.",
+			want: "",
+		},
+	} {
+		assert.Equal(t, currCase.want, license.Identify([]byte(currCase.text)), "Case %d", i)
+	}
+}
+
+func TestFind(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for _, currFile := range []string{
+		"vendor/github.com/org/licensed/LICENSE.md",
+		"vendor/github.com/org/licensed/COPYING",
+		"vendor/github.com/org/licensed/NOTICE.txt",
+		"vendor/github.com/org/licensed/pkg/pkg.go",
+		"vendor/github.com/org/unlicensed/pkg/pkg.go",
+		"vendor/LICENSE",
+		"LICENSE",
+	} {
+		err := os.MkdirAll(path.Join(tmp, path.Dir(currFile)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, currFile), nil, 0644)
+		require.NoError(t, err)
+	}
+
+	for i, currCase := range []struct {
+		dir        string
+		rootDir    string
+		want       string
+		wantNotice string
+	}{
+		{dir: "vendor/github.com/org/licensed", rootDir: "vendor", want: "vendor/github.com/org/licensed/COPYING", wantNotice: "vendor/github.com/org/licensed/NOTICE.txt"},
+		{dir: "vendor/github.com/org/licensed/pkg", rootDir: "vendor", want: "vendor/github.com/org/licensed/COPYING", wantNotice: "vendor/github.com/org/licensed/NOTICE.txt"},
+		{dir: "vendor/github.com/org/unlicensed/pkg", rootDir: "vendor/github.com/org/unlicensed"},
+		{dir: "vendor/github.com/org/unlicensed/pkg", rootDir: "vendor", want: "vendor/LICENSE"},
+	} {
+		got, err := license.Find(path.Join(tmp, currCase.dir), path.Join(tmp, currCase.rootDir))
+		require.NoError(t, err, "Case %d", i)
+		gotNotice, err := license.FindNotice(path.Join(tmp, currCase.dir), path.Join(tmp, currCase.rootDir))
+		require.NoError(t, err, "Case %d", i)
+		for _, currPair := range [][2]string{{currCase.want, got}, {currCase.wantNotice, gotNotice}} {
+			if currPair[0] == "" {
+				assert.Equal(t, "", currPair[1], "Case %d", i)
+				continue
+			}
+			assert.Equal(t, path.Join(tmp, currPair[0]), currPair[1], "Case %d", i)
+		}
+	}
+}
+
+func TestCategoryOf(t *testing.T) {
+	for i, currCase := range []struct {
+		id   string
+		want license.Category
+	}{
+		{id: "Apache-2.0", want: license.Permissive},
+		{id: "MPL-2.0", want: license.WeakCopyleft},
+		{id: "AGPL-3.0", want: license.Copyleft},
+		{id: "CC0-1.0", want: license.PublicDomain},
+		{id: "", want: license.Unknown},
+		{id: "Proprietary", want: license.Unknown},
+	} {
+		assert.Equal(t, currCase.want, license.CategoryOf(currCase.id), "Case %d", i)
+	}
+	for _, currID := range license.IDs() {
+		assert.NotEqual(t, license.Unknown, license.CategoryOf(currID), currID)
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linuxpkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var debArchs = map[string]string{
+	"386":      "i386",
+	"amd64":    "amd64",
+	"arm":      "armhf",
+	"arm64":    "arm64",
+	"mips64le": "mips64el",
+	"ppc64le":  "ppc64el",
+	"riscv64":  "riscv64",
+	"s390x":    "s390x",
+}
+
+// DebArch returns the Debian architecture name for the provided GOARCH value.
+func DebArch(goarch string) (string, error) {
+	arch, ok := debArchs[goarch]
+	if !ok {
+		return "", errors.Errorf("architecture %s is not supported for Debian packages", goarch)
+	}
+	return arch, nil
+}
+
+var debRelationOps = map[string]string{
+	"<":  "<<",
+	"<=": "<=",
+	"=":  "=",
+	">=": ">=",
+	">":  ">>",
+}
+
+// WriteDeb writes the provided package as a Debian package to the provided writer. The package is an ar archive that
+// contains gzip-compressed control and data archives.
+func WriteDeb(w io.Writer, p Package) error {
+	arch, err := DebArch(p.Arch)
+	if err != nil {
+		return err
+	}
+	files, err := p.fileInfos()
+	if err != nil {
+		return err
+	}
+
+	data, md5sums, installedSize, err := debData(files, p.buildTime())
+	if err != nil {
+		return err
+	}
+	controlFile, err := debControlFile(p, arch, installedSize)
+	if err != nil {
+		return err
+	}
+
+	var conffiles []string
+	for _, currFile := range files {
+		if currFile.Config && currFile.mode.IsRegular() {
+			conffiles = append(conffiles, currFile.Path)
+		}
+	}
+	controlFiles := []debControlEntry{
+		{name: "control", content: controlFile, mode: 0644},
+		{name: "md5sums", content: md5sums, mode: 0644},
+	}
+	if len(conffiles) > 0 {
+		controlFiles = append(controlFiles, debControlEntry{name: "conffiles", content: strings.Join(conffiles, "\n") + "\n", mode: 0644})
+	}
+	for _, currScript := range []debControlEntry{
+		{name: "preinst", content: p.BeforeInstallScript},
+		{name: "postinst", content: p.AfterInstallScript},
+		{name: "prerm", content: p.BeforeRemoveScript},
+		{name: "postrm", content: p.AfterRemoveScript},
+	} {
+		if currScript.content != "" {
+			if !strings.HasPrefix(currScript.content, "#!") {
+				currScript.content = "#!/bin/sh\n" + currScript.content
+			}
+			currScript.mode = 0755
+			controlFiles = append(controlFiles, currScript)
+		}
+	}
+	control, err := debControl(controlFiles, p.buildTime())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "!<arch>\n"); err != nil {
+		return errors.Wrapf(err, "failed to write Debian package")
+	}
+	for _, currMember := range []struct {
+		name string
+		data []byte
+	}{
+		{"debian-binary", []byte("2.0\n")},
+		{"control.tar.gz", control},
+		{"data.tar.gz", data},
+	} {
+		if err := writeArMember(w, currMember.name, currMember.data, p.buildTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func debControlFile(p Package, arch string, installedSize int64) (string, error) {
+	buf := &bytes.Buffer{}
+	version := p.Version
+	if p.Release != "" {
+		version += "-" + p.Release
+	}
+	maintainer := p.Maintainer
+	if maintainer == "" {
+		maintainer = "unknown"
+	}
+	fmt.Fprintf(buf, "Package: %s\n", p.Name)
+	fmt.Fprintf(buf, "Version: %s\n", version)
+	fmt.Fprintf(buf, "Architecture: %s\n", arch)
+	fmt.Fprintf(buf, "Maintainer: %s\n", maintainer)
+	fmt.Fprintf(buf, "Installed-Size: %d\n", (installedSize+1023)/1024)
+	for _, currField := range []struct {
+		name      string
+		relations []Relation
+	}{
+		{"Depends", p.Requires},
+		{"Provides", p.Provides},
+		{"Conflicts", p.Conflicts},
+	} {
+		if len(currField.relations) == 0 {
+			continue
+		}
+		var values []string
+		for _, currRelation := range currField.relations {
+			if currRelation.Op == "" {
+				values = append(values, currRelation.Name)
+				continue
+			}
+			op, ok := debRelationOps[currRelation.Op]
+			if !ok {
+				return "", errors.Errorf("invalid operator %q in relation to package %s", currRelation.Op, currRelation.Name)
+			}
+			values = append(values, fmt.Sprintf("%s (%s %s)", currRelation.Name, op, currRelation.Version))
+		}
+		fmt.Fprintf(buf, "%s: %s\n", currField.name, strings.Join(values, ", "))
+	}
+	fmt.Fprintln(buf, "Section: default")
+	fmt.Fprintln(buf, "Priority: optional")
+	fmt.Fprintf(buf, "Description: %s\n", p.summary())
+	// the extended description is indented and empty lines are represented by " ."
+	for _, currLine := range strings.Split(strings.TrimSpace(p.description()), "\n") {
+		if strings.TrimSpace(currLine) == "" {
+			currLine = "."
+		}
+		fmt.Fprintf(buf, " %s\n", currLine)
+	}
+	return buf.String(), nil
+}
+
+type debControlEntry struct {
+	name    string
+	content string
+	mode    int64
+}
+
+func debControl(entries []debControlEntry, modTime time.Time) ([]byte, error) {
+	return targz(func(tw *tar.Writer) error {
+		if err := tw.WriteHeader(debTarDirHeader("./", modTime)); err != nil {
+			return errors.Wrapf(err, "failed to write control archive")
+		}
+		for _, currEntry := range entries {
+			if err := tw.WriteHeader(&tar.Header{
+				Name:     "./" + currEntry.name,
+				Mode:     currEntry.mode,
+				Size:     int64(len(currEntry.content)),
+				ModTime:  modTime,
+				Typeflag: tar.TypeReg,
+				Uname:    "root",
+				Gname:    "root",
+			}); err != nil {
+				return errors.Wrapf(err, "failed to write control archive")
+			}
+			if _, err := io.WriteString(tw, currEntry.content); err != nil {
+				return errors.Wrapf(err, "failed to write control archive")
+			}
+		}
+		return nil
+	})
+}
+
+// debData returns the data archive for the provided files, the content of the md5sums control file and the total size
+// of the files. Parent directories of the files that are not in the package are added to the archive.
+func debData(files []fileInfo, modTime time.Time) ([]byte, string, int64, error) {
+	md5sums := &bytes.Buffer{}
+	var installedSize int64
+	data, err := targz(func(tw *tar.Writer) error {
+		written := map[string]bool{"/": true}
+		if err := tw.WriteHeader(debTarDirHeader("./", modTime)); err != nil {
+			return errors.Wrapf(err, "failed to write data archive")
+		}
+		var writeParents func(p string) error
+		writeParents = func(p string) error {
+			dir := path.Dir(p)
+			if written[dir] {
+				return nil
+			}
+			if err := writeParents(dir); err != nil {
+				return err
+			}
+			written[dir] = true
+			return tw.WriteHeader(debTarDirHeader("."+dir+"/", modTime))
+		}
+
+		for _, currFile := range files {
+			if err := writeParents(currFile.Path); err != nil {
+				return errors.Wrapf(err, "failed to write data archive")
+			}
+			written[currFile.Path] = true
+
+			hdr := &tar.Header{
+				Name:    "." + currFile.Path,
+				Mode:    int64(rpmFileMode(currFile.mode) & 07777),
+				ModTime: currFile.modTime,
+				Uname:   currFile.owner(),
+				Gname:   currFile.group(),
+			}
+			switch {
+			case currFile.mode.IsDir():
+				hdr.Name += "/"
+				hdr.Typeflag = tar.TypeDir
+			case currFile.mode&os.ModeSymlink != 0:
+				hdr.Typeflag = tar.TypeSymlink
+				hdr.Linkname = currFile.linkTarget
+			default:
+				hdr.Typeflag = tar.TypeReg
+				hdr.Size = currFile.size
+				installedSize += currFile.size
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return errors.Wrapf(err, "failed to write data archive")
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			digest, err := copyFile(tw, currFile)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(md5sums, "%s  %s\n", digest, strings.TrimPrefix(currFile.Path, "/"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return data, md5sums.String(), installedSize, nil
+}
+
+// copyFile copies the content of the source of the provided regular file to the provided writer and returns its
+// hex-encoded MD5 digest.
+func copyFile(w io.Writer, f fileInfo) (string, error) {
+	src, err := os.Open(f.Src)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", f.Src)
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	h := md5.New()
+	if n, err := io.Copy(io.MultiWriter(w, h), src); err != nil {
+		return "", errors.Wrapf(err, "failed to copy %s", f.Src)
+	} else if n != f.size {
+		return "", errors.Errorf("size of %s changed while it was being written", f.Src)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func debTarDirHeader(name string, modTime time.Time) *tar.Header {
+	return &tar.Header{
+		Name:     name,
+		Mode:     0755,
+		ModTime:  modTime,
+		Typeflag: tar.TypeDir,
+		Uname:    "root",
+		Gname:    "root",
+	}
+}
+
+// targz returns the gzip-compressed tar archive written by the provided function.
+func targz(write func(tw *tar.Writer) error) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	if err := write(tw); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.Wrapf(err, "failed to close tar writer")
+	}
+	if err := gw.Close(); err != nil {
+		return nil, errors.Wrapf(err, "failed to close gzip writer")
+	}
+	return buf.Bytes(), nil
+}
+
+// writeArMember writes a member of an ar archive in the common format used by Debian packages.
+func writeArMember(w io.Writer, name string, data []byte, modTime time.Time) error {
+	header := fmt.Sprintf("%-16s%-12d%-6d%-6d%-8s%-10d`\n", name, modTime.Unix(), 0, 0, "100644", len(data))
+	if _, err := io.WriteString(w, header); err != nil {
+		return errors.Wrapf(err, "failed to write %s", name)
+	}
+	if _, err := w.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write %s", name)
+	}
+	// members are aligned to an even offset
+	if len(data)%2 != 0 {
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return errors.Wrapf(err, "failed to write %s", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package linuxpkg writes RPM and Debian packages without requiring any external tools. A package consists of the
+// files of a directory tree along with the metadata (name, version, dependencies and install scripts) of the package.
+package linuxpkg
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Package describes the content and metadata of a package.
+type Package struct {
+	// Name is the name of the package.
+	Name string
+	// Version is the version of the package.
+	Version string
+	// Release is the release (RPM) or revision (Debian) of the package.
+	Release string
+	// Arch is the architecture of the package as a GOARCH value. It is converted to the name of the architecture
+	// used by the package format.
+	Arch string
+	// Summary is a one-line description of the package. Defaults to the name of the package.
+	Summary string
+	// Description is the description of the package. Defaults to the summary.
+	Description string
+	// Maintainer is the maintainer of the package. Only used for Debian packages.
+	Maintainer string
+	// BuildTime is the time at which the package was built. Defaults to the Unix epoch.
+	BuildTime time.Time
+
+	// Files are the files in the package.
+	Files []File
+
+	// Requires, Provides and Conflicts are the relationships of the package to other packages.
+	Requires  []Relation
+	Provides  []Relation
+	Conflicts []Relation
+
+	// BeforeInstallScript, AfterInstallScript, BeforeRemoveScript and AfterRemoveScript are the content of the shell
+	// scripts that are run before and after the package is installed and removed.
+	BeforeInstallScript string
+	AfterInstallScript  string
+	BeforeRemoveScript  string
+	AfterRemoveScript   string
+}
+
+func (p Package) summary() string {
+	if p.Summary != "" {
+		return p.Summary
+	}
+	return p.Name
+}
+
+// buildTime returns the build time of the package or the Unix epoch if it is not set.
+func (p Package) buildTime() time.Time {
+	if p.BuildTime.IsZero() {
+		return time.Unix(0, 0)
+	}
+	return p.BuildTime
+}
+
+func (p Package) description() string {
+	if p.Description != "" {
+		return p.Description
+	}
+	return p.summary()
+}
+
+// File is a file, directory or symbolic link in a package.
+type File struct {
+	// Path is the absolute path of the file on the system on which the package is installed.
+	Path string
+	// Src is the path of the file on disk that provides the content of the file and, unless Mode is set, its type and
+	// permissions.
+	Src string
+	// Mode is the permissions (including the setuid, setgid and sticky bits) of the file. If 0, the permissions of Src
+	// are used.
+	Mode os.FileMode
+	// Owner and Group are the names of the user and group that own the file. Default to "root".
+	Owner string
+	Group string
+	// Config indicates that the file is a configuration file, which is not replaced when the package is upgraded if
+	// it has been modified.
+	Config bool
+}
+
+// FileAttributes specifies the ownership and permissions of files in a package.
+type FileAttributes struct {
+	Owner string
+	Group string
+	Mode  os.FileMode
+}
+
+// FilesInDir returns the files for the content of the provided directory, which is the root ("/") of the package.
+// Regular files, symbolic links and empty directories are included. Other directories are only included if
+// attributes are specified for them. The attributes for a file are the entry in attrs whose key is the path of the
+// file or a pattern (as supported by path.Match) that matches it. Files whose paths are in configFiles are marked as
+// configuration files. The returned files are sorted by path.
+func FilesInDir(dir string, attrs map[string]FileAttributes, configFiles []string) ([]File, error) {
+	configFileSet := make(map[string]bool, len(configFiles))
+	for _, currConfigFile := range configFiles {
+		configFileSet[path.Clean(currConfigFile)] = true
+	}
+	patterns := make([]string, 0, len(attrs))
+	for currPattern := range attrs {
+		patterns = append(patterns, currPattern)
+	}
+	sort.Strings(patterns)
+
+	var files []File
+	if err := filepath.Walk(dir, func(currPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, currPath)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		pkgPath := "/" + filepath.ToSlash(relPath)
+
+		fileAttrs := fileAttributes(attrs, patterns, pkgPath)
+		if info.IsDir() && fileAttrs == nil {
+			if empty, err := isEmptyDir(currPath); err != nil || !empty {
+				return err
+			}
+		}
+		files = append(files, newFile(pkgPath, currPath, fileAttrs, configFileSet[pkgPath]))
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to walk directory %s", dir)
+	}
+	return files, nil
+}
+
+// NewFile returns the file at the provided path in the package whose content is provided by src. The attributes of
+// the file and whether it is a configuration file are determined in the same manner as FilesInDir.
+func NewFile(pkgPath, src string, attrs map[string]FileAttributes, configFiles []string) File {
+	patterns := make([]string, 0, len(attrs))
+	for currPattern := range attrs {
+		patterns = append(patterns, currPattern)
+	}
+	sort.Strings(patterns)
+
+	config := false
+	for _, currConfigFile := range configFiles {
+		config = config || path.Clean(currConfigFile) == pkgPath
+	}
+	return newFile(pkgPath, src, fileAttributes(attrs, patterns, pkgPath), config)
+}
+
+func newFile(pkgPath, src string, fileAttrs *FileAttributes, config bool) File {
+	file := File{
+		Path:   pkgPath,
+		Src:    src,
+		Config: config,
+	}
+	if fileAttrs != nil {
+		file.Owner = fileAttrs.Owner
+		file.Group = fileAttrs.Group
+		file.Mode = fileAttrs.Mode
+	}
+	return file
+}
+
+// fileAttributes returns the attributes for the file at the provided path: the entry in attrs whose key is the path
+// or, if there is no such entry, the entry for the first of the provided sorted patterns that matches the path. Returns
+// nil if no attributes are specified for the file.
+func fileAttributes(attrs map[string]FileAttributes, patterns []string, pkgPath string) *FileAttributes {
+	if currAttrs, ok := attrs[pkgPath]; ok {
+		return &currAttrs
+	}
+	for _, currPattern := range patterns {
+		if ok, _ := path.Match(currPattern, pkgPath); ok {
+			currAttrs := attrs[currPattern]
+			return &currAttrs
+		}
+	}
+	return nil
+}
+
+func isEmptyDir(dir string) (bool, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to open %s", dir)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.Readdirnames(1); err != io.EOF {
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to read directory %s", dir)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// fileInfo is a file of a package along with the information about its source.
+type fileInfo struct {
+	File
+	// mode is the type and permissions of the file.
+	mode os.FileMode
+	// size is the size of the content of the file.
+	size int64
+	// modTime is the modification time of the source of the file.
+	modTime time.Time
+	// linkTarget is the target of a symbolic link.
+	linkTarget string
+}
+
+func (f fileInfo) owner() string {
+	if f.Owner != "" {
+		return f.Owner
+	}
+	return "root"
+}
+
+func (f fileInfo) group() string {
+	if f.Group != "" {
+		return f.Group
+	}
+	return "root"
+}
+
+// fileInfos returns the information for the files of the package sorted by path.
+func (p Package) fileInfos() ([]fileInfo, error) {
+	infos := make([]fileInfo, 0, len(p.Files))
+	seen := make(map[string]bool, len(p.Files))
+	for _, currFile := range p.Files {
+		currFile.Path = path.Clean("/" + currFile.Path)
+		if currFile.Path == "/" {
+			return nil, errors.Errorf("invalid path for file in package: %q", currFile.Path)
+		}
+		if seen[currFile.Path] {
+			return nil, errors.Errorf("multiple files provided for %s", currFile.Path)
+		}
+		seen[currFile.Path] = true
+
+		fi, err := os.Lstat(currFile.Src)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to stat %s", currFile.Src)
+		}
+		info := fileInfo{
+			File:    currFile,
+			mode:    fi.Mode(),
+			modTime: fi.ModTime(),
+		}
+		if currFile.Mode != 0 {
+			info.mode = fi.Mode()&os.ModeType | currFile.Mode&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)
+		}
+		switch {
+		case fi.IsDir():
+		case fi.Mode()&os.ModeSymlink != 0:
+			if info.linkTarget, err = os.Readlink(currFile.Src); err != nil {
+				return nil, errors.Wrapf(err, "failed to read link %s", currFile.Src)
+			}
+			info.size = int64(len(info.linkTarget))
+		case fi.Mode().IsRegular():
+			info.size = fi.Size()
+		default:
+			return nil, errors.Errorf("%s is not a regular file, directory or symbolic link", currFile.Src)
+		}
+		infos = append(infos, info)
+	}
+	sort.Sort(byPath(infos))
+	return infos, nil
+}
+
+type byPath []fileInfo
+
+func (a byPath) Len() int           { return len(a) }
+func (a byPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byPath) Less(i, j int) bool { return a[i].Path < a[j].Path }
+
+// Relation is a relationship to another package, such as a dependency. If Op is non-empty, the relationship only
+// applies to the versions of the other package that satisfy the comparison to Version.
+type Relation struct {
+	Name    string
+	Op      string
+	Version string
+}
+
+// relationOps are the supported comparison operators. "<<" and ">>" are the Debian spelling of "<" and ">".
+var relationOps = []string{"<=", ">=", "<<", ">>", "=", "<", ">"}
+
+// ParseRelation parses a relationship of the form "name", "name op version" (for example, "bash >= 4.0") or the
+// Debian form "name (op version)".
+func ParseRelation(relation string) (Relation, error) {
+	s := strings.TrimSpace(relation)
+	s = strings.Replace(strings.Replace(s, "(", " ", 1), ")", " ", 1)
+	for _, currOp := range relationOps {
+		if i := strings.Index(s, currOp); i >= 0 {
+			r := Relation{
+				Name:    strings.TrimSpace(s[:i]),
+				Op:      currOp,
+				Version: strings.TrimSpace(s[i+len(currOp):]),
+			}
+			switch r.Op {
+			case "<<":
+				r.Op = "<"
+			case ">>":
+				r.Op = ">"
+			}
+			if r.Name == "" || r.Version == "" || strings.ContainsAny(r.Name, " \t") || strings.ContainsAny(r.Version, " \t") {
+				return Relation{}, errors.Errorf("invalid package relation: %q", relation)
+			}
+			return r, nil
+		}
+	}
+	if s == "" || strings.ContainsAny(s, " \t") {
+		return Relation{}, errors.Errorf("invalid package relation: %q", relation)
+	}
+	return Relation{Name: s}, nil
+}
+
+// ParseRelations parses the provided relationships using ParseRelation.
+func ParseRelations(relations []string) ([]Relation, error) {
+	var parsed []Relation
+	for _, currRelation := range relations {
+		r, err := ParseRelation(currRelation)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
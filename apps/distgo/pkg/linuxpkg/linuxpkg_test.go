@@ -0,0 +1,341 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linuxpkg_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/godel/apps/distgo/pkg/linuxpkg"
+)
+
+func TestParseRelation(t *testing.T) {
+	for i, currCase := range []struct {
+		relation  string
+		want      linuxpkg.Relation
+		wantError string
+	}{
+		{relation: "bash", want: linuxpkg.Relation{Name: "bash"}},
+		{relation: "bash >= 4.0", want: linuxpkg.Relation{Name: "bash", Op: ">=", Version: "4.0"}},
+		{relation: "libc6 (<< 2.30)", want: linuxpkg.Relation{Name: "libc6", Op: "<", Version: "2.30"}},
+		{relation: "foo=1.0-1", want: linuxpkg.Relation{Name: "foo", Op: "=", Version: "1.0-1"}},
+		{relation: "", wantError: `invalid package relation: ""`},
+		{relation: "foo bar", wantError: `invalid package relation: "foo bar"`},
+		{relation: "foo >=", wantError: `invalid package relation: "foo >="`},
+	} {
+		got, err := linuxpkg.ParseRelation(currCase.relation)
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, got, "Case %d", i)
+	}
+}
+
+func TestFilesInDir(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	writePackageDir(t, tmp)
+	files, err := linuxpkg.FilesInDir(tmp, map[string]linuxpkg.FileAttributes{
+		"/opt/foo/bin/*": {Owner: "foo", Mode: 0750},
+		"/opt/foo/var":   {Owner: "foo", Group: "foo"},
+	}, []string{"/etc/foo/foo.yml"})
+	require.NoError(t, err)
+
+	var got []linuxpkg.File
+	for _, currFile := range files {
+		currFile.Src = ""
+		got = append(got, currFile)
+	}
+	assert.Equal(t, []linuxpkg.File{
+		{Path: "/etc/foo/foo.yml", Config: true},
+		{Path: "/opt/foo/bin/foo", Owner: "foo", Mode: 0750},
+		{Path: "/opt/foo/empty"},
+		{Path: "/opt/foo/link"},
+		{Path: "/opt/foo/var", Owner: "foo", Group: "foo"},
+		{Path: "/opt/foo/var/data"},
+	}, got)
+}
+
+func TestWriteRPM(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	err = linuxpkg.WriteRPM(buf, testPackage(t, tmp, "arm64"))
+	require.NoError(t, err)
+	rpm := buf.Bytes()
+
+	// lead
+	require.True(t, len(rpm) > 96)
+	assert.Equal(t, []byte{0xed, 0xab, 0xee, 0xdb}, rpm[:4])
+	assert.Equal(t, "foo-1.0.0-1", strings.TrimRight(string(rpm[10:76]), "\x00"))
+
+	// signature header is padded to a multiple of 8 bytes
+	_, sigLen := readRPMHeader(t, rpm[96:])
+	offset := 96 + sigLen
+	if offset%8 != 0 {
+		offset += 8 - offset%8
+	}
+	tags, headerLen := readRPMHeader(t, rpm[offset:])
+	assert.Equal(t, []string{"foo"}, tags[1000])
+	assert.Equal(t, []string{"1.0.0"}, tags[1001])
+	assert.Equal(t, []string{"aarch64"}, tags[1022])
+	assert.Equal(t, []string{"echo installed"}, tags[1024])
+	assert.Equal(t, []string{"bash", "rpmlib(CompressedFileNames)", "rpmlib(FileDigests)", "rpmlib(PayloadFilesHavePrefix)"}, tags[1049])
+	assert.Equal(t, []string{"4.0", "3.0.4-1", "4.6.0-1", "4.0-1"}, tags[1050])
+	assert.Equal(t, []string{"foo", "bar"}, tags[1047])
+	assert.Equal(t, []string{"baz"}, tags[1054])
+	assert.Equal(t, []string{"foo.yml", "foo", "empty", "link", "var", "data"}, tags[1117])
+	assert.Equal(t, []string{"/etc/foo/", "/opt/foo/bin/", "/opt/foo/", "/opt/foo/var/"}, tags[1118])
+	assert.Equal(t, []string{"root", "foo", "root", "root", "foo", "root"}, tags[1039])
+
+	// payload is a gzip-compressed cpio archive of the files
+	gr, err := gzip.NewReader(bytes.NewReader(rpm[offset+headerLen:]))
+	require.NoError(t, err)
+	payload, err := ioutil.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./etc/foo/foo.yml", "./opt/foo/bin/foo", "./opt/foo/empty", "./opt/foo/link", "./opt/foo/var", "./opt/foo/var/data", "TRAILER!!!"}, cpioNames(t, payload))
+
+	// verify the package using rpm if it is available
+	if _, err := exec.LookPath("rpm"); err != nil {
+		return
+	}
+	rpmPath := path.Join(tmp, "foo.rpm")
+	err = ioutil.WriteFile(rpmPath, rpm, 0644)
+	require.NoError(t, err)
+
+	output, err := exec.Command("rpm", "-K", "--nosignature", rpmPath).CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	output, err = exec.Command("rpm", "-qip", rpmPath).Output()
+	require.NoError(t, err)
+	for _, want := range []string{
+		"Name        : foo\n",
+		"Version     : 1.0.0\n",
+		"Release     : 1\n",
+		"Architecture: aarch64\n",
+	} {
+		assert.Contains(t, string(output), want)
+	}
+
+	// each line of the dump is "path size mtime digest mode owner group isconfig isdoc rdev symlink"
+	output, err = exec.Command("rpm", "-qlp", "--dump", rpmPath).Output()
+	require.NoError(t, err)
+	var paths []string
+	dump := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		paths = append(paths, fields[0])
+		dump[fields[0]] = fields
+	}
+	assert.Equal(t, []string{"/etc/foo/foo.yml", "/opt/foo/bin/foo", "/opt/foo/empty", "/opt/foo/link", "/opt/foo/var", "/opt/foo/var/data"}, paths)
+
+	configDigest := sha256.Sum256([]byte("config"))
+	require.Len(t, dump["/etc/foo/foo.yml"], 11)
+	assert.Equal(t, "6", dump["/etc/foo/foo.yml"][1])
+	assert.Equal(t, hex.EncodeToString(configDigest[:]), dump["/etc/foo/foo.yml"][3])
+	assert.Equal(t, "1", dump["/etc/foo/foo.yml"][7])
+	require.Len(t, dump["/opt/foo/bin/foo"], 11)
+	assert.Equal(t, "0100750", dump["/opt/foo/bin/foo"][4])
+	assert.Equal(t, "foo", dump["/opt/foo/bin/foo"][5])
+	assert.Equal(t, "0", dump["/opt/foo/bin/foo"][7])
+	link := dump["/opt/foo/link"]
+	assert.Equal(t, "bin/foo", link[len(link)-1])
+}
+
+func TestWriteDeb(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	debPath := path.Join(tmp, "foo.deb")
+	f, err := os.Create(debPath)
+	require.NoError(t, err)
+	err = linuxpkg.WriteDeb(f, testPackage(t, tmp, "arm"))
+	require.NoError(t, err)
+	err = f.Close()
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(debPath)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(content), "!<arch>\ndebian-binary "))
+
+	// verify the package using dpkg-deb if it is available
+	if _, err := exec.LookPath("dpkg-deb"); err != nil {
+		return
+	}
+	output, err := exec.Command("dpkg-deb", "--field", debPath).CombinedOutput()
+	require.NoError(t, err, string(output))
+	assert.Equal(t, `Package: foo
+Version: 1.0.0-1
+Architecture: armhf
+Maintainer: Foo Maintainer <foo@example.com>
+Installed-Size: 1
+Depends: bash (>= 4.0)
+Provides: bar
+Conflicts: baz (<< 2.0)
+Section: default
+Priority: optional
+Description: foo
+ Foo does things.
+ .
+ Many things.
+`, string(output))
+
+	output, err = exec.Command("dpkg-deb", "--ctrl-tarfile", debPath).Output()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./", "./control", "./md5sums", "./conffiles", "./postinst"}, tarNames(t, bytes.NewReader(output)))
+
+	output, err = exec.Command("dpkg-deb", "--fsys-tarfile", debPath).Output()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./", "./etc/", "./etc/foo/", "./etc/foo/foo.yml", "./opt/", "./opt/foo/", "./opt/foo/bin/", "./opt/foo/bin/foo", "./opt/foo/empty/", "./opt/foo/link", "./opt/foo/var/", "./opt/foo/var/data"}, tarNames(t, bytes.NewReader(output)))
+}
+
+func TestUnsupportedArch(t *testing.T) {
+	_, err := linuxpkg.RPMArch("wasm")
+	assert.EqualError(t, err, "architecture wasm is not supported for RPM packages")
+	_, err = linuxpkg.DebArch("wasm")
+	assert.EqualError(t, err, "architecture wasm is not supported for Debian packages")
+}
+
+func writePackageDir(t *testing.T, dir string) {
+	for _, currDir := range []string{"etc/foo", "opt/foo/bin", "opt/foo/empty", "opt/foo/var"} {
+		err := os.MkdirAll(path.Join(dir, currDir), 0755)
+		require.NoError(t, err)
+	}
+	for name, content := range map[string]string{
+		"etc/foo/foo.yml":  "config",
+		"opt/foo/bin/foo":  "#!/bin/sh\necho foo\n",
+		"opt/foo/var/data": "data",
+	} {
+		err := ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+	err := os.Symlink("bin/foo", path.Join(dir, "opt", "foo", "link"))
+	require.NoError(t, err)
+}
+
+func testPackage(t *testing.T, dir, arch string) linuxpkg.Package {
+	root := path.Join(dir, "root")
+	writePackageDir(t, root)
+	files, err := linuxpkg.FilesInDir(root, map[string]linuxpkg.FileAttributes{
+		"/opt/foo/bin/*": {Owner: "foo", Mode: 0750},
+		"/opt/foo/var":   {Owner: "foo", Group: "foo"},
+	}, []string{"/etc/foo/foo.yml"})
+	require.NoError(t, err)
+	return linuxpkg.Package{
+		Name:               "foo",
+		Version:            "1.0.0",
+		Release:            "1",
+		Arch:               arch,
+		Description:        "Foo does things.\n\nMany things.",
+		Maintainer:         "Foo Maintainer <foo@example.com>",
+		BuildTime:          time.Unix(1500000000, 0),
+		Files:              files,
+		Requires:           []linuxpkg.Relation{{Name: "bash", Op: ">=", Version: "4.0"}},
+		Provides:           []linuxpkg.Relation{{Name: "bar"}},
+		Conflicts:          []linuxpkg.Relation{{Name: "baz", Op: "<", Version: "2.0"}},
+		AfterInstallScript: "echo installed",
+	}
+}
+
+// readRPMHeader returns the string and string array values of the provided RPM header structure keyed by tag and the
+// length of the header structure.
+func readRPMHeader(t *testing.T, header []byte) (map[int][]string, int) {
+	require.Equal(t, []byte{0x8e, 0xad, 0xe8, 0x01}, header[:4])
+	nIndex := int(binary.BigEndian.Uint32(header[8:]))
+	storeLen := int(binary.BigEndian.Uint32(header[12:]))
+	store := header[16+16*nIndex : 16+16*nIndex+storeLen]
+
+	tags := make(map[int][]string)
+	for i := 0; i < nIndex; i++ {
+		entry := header[16+16*i:]
+		tag := int(binary.BigEndian.Uint32(entry))
+		typ := binary.BigEndian.Uint32(entry[4:])
+		offset := int(binary.BigEndian.Uint32(entry[8:]))
+		count := int(binary.BigEndian.Uint32(entry[12:]))
+		switch typ {
+		case 6, 8, 9:
+			// string, string array and i18n string
+			if typ != 8 {
+				count = 1
+			}
+			values := strings.SplitN(string(store[offset:]), "\x00", count+1)
+			tags[tag] = values[:count]
+		}
+	}
+	return tags, 16 + 16*nIndex + storeLen
+}
+
+// cpioNames returns the names of the entries of the provided cpio archive in the "newc" format.
+func cpioNames(t *testing.T, archive []byte) []string {
+	var names []string
+	for offset := 0; offset < len(archive); {
+		require.Equal(t, "070701", string(archive[offset:offset+6]))
+		field := func(i int) int {
+			v, err := strconv.ParseInt(string(archive[offset+6+8*i:offset+14+8*i]), 16, 64)
+			require.NoError(t, err)
+			return int(v)
+		}
+		fileSize, nameSize := field(6), field(11)
+		name := string(archive[offset+110 : offset+110+nameSize-1])
+		names = append(names, name)
+		if name == "TRAILER!!!" {
+			break
+		}
+		offset = pad4(pad4(offset+110+nameSize) + fileSize)
+	}
+	return names
+}
+
+func pad4(n int) int {
+	return (n + 3) &^ 3
+}
+
+func tarNames(t *testing.T, r io.Reader) []string {
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	return names
+}
@@ -0,0 +1,615 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linuxpkg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var rpmArchs = map[string]string{
+	"386":      "i686",
+	"amd64":    "x86_64",
+	"arm":      "armv7hl",
+	"arm64":    "aarch64",
+	"mips64le": "mips64el",
+	"ppc64le":  "ppc64le",
+	"riscv64":  "riscv64",
+	"s390x":    "s390x",
+}
+
+// RPMArch returns the RPM architecture name for the provided GOARCH value.
+func RPMArch(goarch string) (string, error) {
+	arch, ok := rpmArchs[goarch]
+	if !ok {
+		return "", errors.Errorf("architecture %s is not supported for RPM packages", goarch)
+	}
+	return arch, nil
+}
+
+// header tags (see rpmtag.h in the RPM source)
+const (
+	rpmTagHeaderSignatures = 62
+	rpmTagHeaderImmutable  = 63
+	rpmTagHeaderI18NTable  = 100
+
+	rpmSigTagSHA1        = 269
+	rpmSigTagSHA256      = 273
+	rpmSigTagSize        = 1000
+	rpmSigTagMD5         = 1004
+	rpmSigTagPayloadSize = 1007
+
+	rpmTagName              = 1000
+	rpmTagVersion           = 1001
+	rpmTagRelease           = 1002
+	rpmTagSummary           = 1004
+	rpmTagDescription       = 1005
+	rpmTagBuildTime         = 1006
+	rpmTagSize              = 1009
+	rpmTagGroup             = 1016
+	rpmTagOS                = 1021
+	rpmTagArch              = 1022
+	rpmTagPreIn             = 1023
+	rpmTagPostIn            = 1024
+	rpmTagPreUn             = 1025
+	rpmTagPostUn            = 1026
+	rpmTagFileSizes         = 1028
+	rpmTagFileModes         = 1030
+	rpmTagFileRDevs         = 1033
+	rpmTagFileMTimes        = 1034
+	rpmTagFileDigests       = 1035
+	rpmTagFileLinkTos       = 1036
+	rpmTagFileFlags         = 1037
+	rpmTagFileUserName      = 1039
+	rpmTagFileGroupName     = 1040
+	rpmTagProvideName       = 1047
+	rpmTagRequireFlags      = 1048
+	rpmTagRequireName       = 1049
+	rpmTagRequireVersion    = 1050
+	rpmTagConflictFlags     = 1053
+	rpmTagConflictName      = 1054
+	rpmTagConflictVersion   = 1055
+	rpmTagPreInProg         = 1085
+	rpmTagPostInProg        = 1086
+	rpmTagPreUnProg         = 1087
+	rpmTagPostUnProg        = 1088
+	rpmTagFileDevices       = 1095
+	rpmTagFileInodes        = 1096
+	rpmTagFileLangs         = 1097
+	rpmTagProvideFlags      = 1112
+	rpmTagProvideVersion    = 1113
+	rpmTagDirIndexes        = 1116
+	rpmTagBaseNames         = 1117
+	rpmTagDirNames          = 1118
+	rpmTagPayloadFormat     = 1124
+	rpmTagPayloadCompressor = 1125
+	rpmTagPayloadFlags      = 1126
+	rpmTagFileDigestAlgo    = 5011
+	rpmTagPayloadDigest     = 5092
+	rpmTagPayloadDigestAlgo = 5093
+)
+
+// header data types
+const (
+	rpmTypeInt16       = 3
+	rpmTypeInt32       = 4
+	rpmTypeString      = 6
+	rpmTypeBin         = 7
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// dependency flags
+const (
+	rpmSenseLess    = 1 << 1
+	rpmSenseGreater = 1 << 2
+	rpmSenseEqual   = 1 << 3
+	rpmSenseRPMLib  = 1 << 24
+)
+
+// file flags
+const (
+	rpmFileConfig    = 1 << 0
+	rpmFileNoReplace = 1 << 4
+)
+
+// digest algorithm identifier for SHA-256 (PGPHASHALGO_SHA256)
+const rpmDigestAlgoSHA256 = 8
+
+var rpmSenseOps = map[string]int32{
+	"":   0,
+	"<":  rpmSenseLess,
+	"<=": rpmSenseLess | rpmSenseEqual,
+	"=":  rpmSenseEqual,
+	">=": rpmSenseGreater | rpmSenseEqual,
+	">":  rpmSenseGreater,
+}
+
+// WriteRPM writes the provided package as an RPM package to the provided writer. The payload is a gzip-compressed cpio
+// archive and the file digests are SHA-256.
+func WriteRPM(w io.Writer, p Package) error {
+	arch, err := RPMArch(p.Arch)
+	if err != nil {
+		return err
+	}
+	files, err := p.fileInfos()
+	if err != nil {
+		return err
+	}
+
+	payload, payloadSize, digests, err := rpmPayload(files)
+	if err != nil {
+		return err
+	}
+	payloadDigest := sha256.Sum256(payload)
+
+	h, err := rpmMainHeader(p, arch, files, digests, hex.EncodeToString(payloadDigest[:]))
+	if err != nil {
+		return err
+	}
+	hb := h.bytes(rpmTagHeaderImmutable)
+
+	md5Digest := md5.New()
+	_, _ = md5Digest.Write(hb)
+	_, _ = md5Digest.Write(payload)
+	sha1Digest := sha1.Sum(hb)
+	sha256Digest := sha256.Sum256(hb)
+
+	sig := &rpmHeader{}
+	sig.addString(rpmSigTagSHA1, hex.EncodeToString(sha1Digest[:]))
+	sig.addString(rpmSigTagSHA256, hex.EncodeToString(sha256Digest[:]))
+	sig.addInt32(rpmSigTagSize, int32(len(hb)+len(payload)))
+	sig.addBin(rpmSigTagMD5, md5Digest.Sum(nil))
+	sig.addInt32(rpmSigTagPayloadSize, int32(payloadSize))
+	sb := sig.bytes(rpmTagHeaderSignatures)
+	// the signature header is padded to a multiple of 8 bytes
+	if rem := len(sb) % 8; rem != 0 {
+		sb = append(sb, make([]byte, 8-rem)...)
+	}
+
+	for _, currBytes := range [][]byte{
+		rpmLead(fmt.Sprintf("%s-%s-%s", p.Name, p.Version, p.Release), arch),
+		sb,
+		hb,
+		payload,
+	} {
+		if _, err := w.Write(currBytes); err != nil {
+			return errors.Wrapf(err, "failed to write RPM")
+		}
+	}
+	return nil
+}
+
+// rpmLead returns the 96-byte lead of an RPM file.
+func rpmLead(nvr, arch string) []byte {
+	lead := make([]byte, 96)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	// type (binary) is 0
+	if arch == "x86_64" || arch == "i686" {
+		binary.BigEndian.PutUint16(lead[8:], 1)
+	}
+	// name is NUL-terminated and limited to 66 bytes
+	if len(nvr) > 65 {
+		nvr = nvr[:65]
+	}
+	copy(lead[10:76], nvr)
+	// OS (linux)
+	binary.BigEndian.PutUint16(lead[76:], 1)
+	// signature type (header-style signature)
+	binary.BigEndian.PutUint16(lead[78:], 5)
+	return lead
+}
+
+func rpmMainHeader(p Package, arch string, files []fileInfo, digests []string, payloadDigest string) (*rpmHeader, error) {
+	h := &rpmHeader{}
+	h.addStrings(rpmTagHeaderI18NTable, []string{"C"})
+	h.addString(rpmTagName, p.Name)
+	h.addString(rpmTagVersion, p.Version)
+	h.addString(rpmTagRelease, p.Release)
+	h.addI18NString(rpmTagSummary, p.summary())
+	h.addI18NString(rpmTagDescription, p.description())
+	h.addInt32(rpmTagBuildTime, int32(p.buildTime().Unix()))
+	h.addI18NString(rpmTagGroup, "Unspecified")
+	h.addString(rpmTagOS, "linux")
+	h.addString(rpmTagArch, arch)
+
+	for _, currScript := range []struct {
+		script  string
+		tag     int
+		progTag int
+	}{
+		{p.BeforeInstallScript, rpmTagPreIn, rpmTagPreInProg},
+		{p.AfterInstallScript, rpmTagPostIn, rpmTagPostInProg},
+		{p.BeforeRemoveScript, rpmTagPreUn, rpmTagPreUnProg},
+		{p.AfterRemoveScript, rpmTagPostUn, rpmTagPostUnProg},
+	} {
+		if currScript.script != "" {
+			h.addString(currScript.tag, currScript.script)
+			h.addString(currScript.progTag, "/bin/sh")
+		}
+	}
+
+	var totalSize int64
+	if len(files) > 0 {
+		var (
+			sizes, mtimes, flags, devices, inodes, dirIndexes []int32
+			modes, rdevs                                      []int16
+			linkTos, users, groups, langs, baseNames, dirs    []string
+		)
+		dirIndex := make(map[string]int32)
+		for i, currFile := range files {
+			size := currFile.size
+			if currFile.mode.IsDir() {
+				size = 4096
+			} else if currFile.mode.IsRegular() {
+				totalSize += currFile.size
+			}
+			sizes = append(sizes, int32(size))
+			modes = append(modes, int16(rpmFileMode(currFile.mode)))
+			rdevs = append(rdevs, 0)
+			mtimes = append(mtimes, int32(currFile.modTime.Unix()))
+			linkTos = append(linkTos, currFile.linkTarget)
+			var fileFlags int32
+			if currFile.Config {
+				fileFlags = rpmFileConfig | rpmFileNoReplace
+			}
+			flags = append(flags, fileFlags)
+			users = append(users, currFile.owner())
+			groups = append(groups, currFile.group())
+			devices = append(devices, 1)
+			inodes = append(inodes, int32(i+1))
+			langs = append(langs, "")
+
+			dir := path.Dir(currFile.Path)
+			if dir != "/" {
+				dir += "/"
+			}
+			index, ok := dirIndex[dir]
+			if !ok {
+				index = int32(len(dirs))
+				dirIndex[dir] = index
+				dirs = append(dirs, dir)
+			}
+			dirIndexes = append(dirIndexes, index)
+			baseNames = append(baseNames, path.Base(currFile.Path))
+		}
+		h.addInt32(rpmTagFileSizes, sizes...)
+		h.addInt16(rpmTagFileModes, modes...)
+		h.addInt16(rpmTagFileRDevs, rdevs...)
+		h.addInt32(rpmTagFileMTimes, mtimes...)
+		h.addStrings(rpmTagFileDigests, digests)
+		h.addStrings(rpmTagFileLinkTos, linkTos)
+		h.addInt32(rpmTagFileFlags, flags...)
+		h.addStrings(rpmTagFileUserName, users)
+		h.addStrings(rpmTagFileGroupName, groups)
+		h.addInt32(rpmTagFileDevices, devices...)
+		h.addInt32(rpmTagFileInodes, inodes...)
+		h.addStrings(rpmTagFileLangs, langs)
+		h.addInt32(rpmTagDirIndexes, dirIndexes...)
+		h.addStrings(rpmTagBaseNames, baseNames)
+		h.addStrings(rpmTagDirNames, dirs)
+		h.addInt32(rpmTagFileDigestAlgo, rpmDigestAlgoSHA256)
+	}
+	h.addInt32(rpmTagSize, int32(totalSize))
+
+	requires := append([]Relation(nil), p.Requires...)
+	requireFlags, err := rpmSenseFlags(requires)
+	if err != nil {
+		return nil, err
+	}
+	for _, currFeature := range []Relation{
+		{Name: "rpmlib(CompressedFileNames)", Op: "<=", Version: "3.0.4-1"},
+		{Name: "rpmlib(FileDigests)", Op: "<=", Version: "4.6.0-1"},
+		{Name: "rpmlib(PayloadFilesHavePrefix)", Op: "<=", Version: "4.0-1"},
+	} {
+		requires = append(requires, currFeature)
+		requireFlags = append(requireFlags, rpmSenseLess|rpmSenseEqual|rpmSenseRPMLib)
+	}
+	h.addRelations(rpmTagRequireName, rpmTagRequireFlags, rpmTagRequireVersion, requires, requireFlags)
+
+	// every package provides itself
+	provides := append([]Relation{{Name: p.Name, Op: "=", Version: p.Version + "-" + p.Release}}, p.Provides...)
+	provideFlags, err := rpmSenseFlags(provides)
+	if err != nil {
+		return nil, err
+	}
+	h.addRelations(rpmTagProvideName, rpmTagProvideFlags, rpmTagProvideVersion, provides, provideFlags)
+
+	if len(p.Conflicts) > 0 {
+		conflictFlags, err := rpmSenseFlags(p.Conflicts)
+		if err != nil {
+			return nil, err
+		}
+		h.addRelations(rpmTagConflictName, rpmTagConflictFlags, rpmTagConflictVersion, p.Conflicts, conflictFlags)
+	}
+
+	h.addString(rpmTagPayloadFormat, "cpio")
+	h.addString(rpmTagPayloadCompressor, "gzip")
+	h.addString(rpmTagPayloadFlags, "9")
+	h.addStrings(rpmTagPayloadDigest, []string{payloadDigest})
+	h.addInt32(rpmTagPayloadDigestAlgo, rpmDigestAlgoSHA256)
+	return h, nil
+}
+
+func rpmSenseFlag(r Relation) (int32, error) {
+	flag, ok := rpmSenseOps[r.Op]
+	if !ok {
+		return 0, errors.Errorf("invalid operator %q in relation to package %s", r.Op, r.Name)
+	}
+	return flag, nil
+}
+
+func rpmSenseFlags(relations []Relation) ([]int32, error) {
+	flags := make([]int32, len(relations))
+	for i, currRelation := range relations {
+		flag, err := rpmSenseFlag(currRelation)
+		if err != nil {
+			return nil, err
+		}
+		flags[i] = flag
+	}
+	return flags, nil
+}
+
+// rpmFileMode returns the mode of the provided file as a Unix st_mode value.
+func rpmFileMode(mode os.FileMode) uint16 {
+	m := uint16(mode.Perm())
+	switch {
+	case mode.IsDir():
+		m |= 0040000
+	case mode&os.ModeSymlink != 0:
+		m |= 0120000
+	default:
+		m |= 0100000
+	}
+	if mode&os.ModeSetuid != 0 {
+		m |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= 01000
+	}
+	return m
+}
+
+// rpmPayload returns the gzip-compressed cpio archive of the provided files, the uncompressed size of the archive and
+// the hex-encoded SHA-256 digests of the files (empty for files that are not regular files).
+func rpmPayload(files []fileInfo) ([]byte, int64, []string, error) {
+	buf := &bytes.Buffer{}
+	gw, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	if err != nil {
+		return nil, 0, nil, errors.Wrapf(err, "failed to create gzip writer")
+	}
+	cw := &countingWriter{w: gw}
+	digests := make([]string, len(files))
+	for i, currFile := range files {
+		if digests[i], err = writeCPIOEntry(cw, currFile, i+1); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+	if err := writeCPIOTrailer(cw); err != nil {
+		return nil, 0, nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, 0, nil, errors.Wrapf(err, "failed to close gzip writer")
+	}
+	return buf.Bytes(), cw.n, digests, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// writeCPIOEntry writes the provided file to the provided writer as an entry in a cpio archive in the "newc" format and
+// returns the hex-encoded SHA-256 digest of the content of the file if it is a regular file.
+func writeCPIOEntry(w *countingWriter, f fileInfo, ino int) (string, error) {
+	nlink := 1
+	if f.mode.IsDir() {
+		nlink = 2
+	}
+	size := f.size
+	if f.mode.IsDir() {
+		size = 0
+	}
+	if err := writeCPIOHeader(w, "."+f.Path, ino, int(rpmFileMode(f.mode)), nlink, f.modTime.Unix(), size); err != nil {
+		return "", err
+	}
+
+	var digest string
+	switch {
+	case f.mode&os.ModeSymlink != 0:
+		if _, err := io.WriteString(w, f.linkTarget); err != nil {
+			return "", errors.Wrapf(err, "failed to write cpio entry for %s", f.Path)
+		}
+	case f.mode.IsRegular():
+		src, err := os.Open(f.Src)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to open %s", f.Src)
+		}
+		defer func() {
+			_ = src.Close()
+		}()
+		h := sha256.New()
+		if n, err := io.Copy(io.MultiWriter(w, h), src); err != nil {
+			return "", errors.Wrapf(err, "failed to write cpio entry for %s", f.Path)
+		} else if n != f.size {
+			return "", errors.Errorf("size of %s changed while it was being written", f.Src)
+		}
+		digest = hex.EncodeToString(h.Sum(nil))
+	}
+	return digest, writeCPIOPadding(w)
+}
+
+func writeCPIOTrailer(w *countingWriter) error {
+	if err := writeCPIOHeader(w, "TRAILER!!!", 0, 0, 1, 0, 0); err != nil {
+		return err
+	}
+	return writeCPIOPadding(w)
+}
+
+func writeCPIOHeader(w *countingWriter, name string, ino, mode, nlink int, mtime, size int64) error {
+	// magic, ino, mode, uid, gid, nlink, mtime, filesize, devmajor, devminor, rdevmajor, rdevminor, namesize, check
+	header := fmt.Sprintf("070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x", ino, mode, 0, 0, nlink, mtime, size, 0, 0, 0, 0, len(name)+1, 0)
+	if _, err := io.WriteString(w, header+name+"\x00"); err != nil {
+		return errors.Wrapf(err, "failed to write cpio header for %s", name)
+	}
+	return writeCPIOPadding(w)
+}
+
+// writeCPIOPadding pads the output to a multiple of 4 bytes.
+func writeCPIOPadding(w *countingWriter) error {
+	if rem := w.n % 4; rem != 0 {
+		if _, err := w.Write(make([]byte, 4-rem)); err != nil {
+			return errors.Wrapf(err, "failed to write cpio padding")
+		}
+	}
+	return nil
+}
+
+// rpmHeader is an RPM header structure, which is used for both the signature and the main header of an RPM.
+type rpmHeader struct {
+	entries []rpmHeaderEntry
+}
+
+type rpmHeaderEntry struct {
+	tag   int
+	typ   int
+	count int
+	data  []byte
+}
+
+func (h *rpmHeader) add(tag, typ, count int, data []byte) {
+	h.entries = append(h.entries, rpmHeaderEntry{tag: tag, typ: typ, count: count, data: data})
+}
+
+func (h *rpmHeader) addString(tag int, s string) {
+	h.add(tag, rpmTypeString, 1, []byte(s+"\x00"))
+}
+
+func (h *rpmHeader) addI18NString(tag int, s string) {
+	h.add(tag, rpmTypeI18NString, 1, []byte(s+"\x00"))
+}
+
+func (h *rpmHeader) addStrings(tag int, ss []string) {
+	buf := &bytes.Buffer{}
+	for _, s := range ss {
+		buf.WriteString(s)
+		buf.WriteByte(0)
+	}
+	h.add(tag, rpmTypeStringArray, len(ss), buf.Bytes())
+}
+
+func (h *rpmHeader) addInt32(tag int, vs ...int32) {
+	data := make([]byte, 4*len(vs))
+	for i, v := range vs {
+		binary.BigEndian.PutUint32(data[4*i:], uint32(v))
+	}
+	h.add(tag, rpmTypeInt32, len(vs), data)
+}
+
+func (h *rpmHeader) addInt16(tag int, vs ...int16) {
+	data := make([]byte, 2*len(vs))
+	for i, v := range vs {
+		binary.BigEndian.PutUint16(data[2*i:], uint16(v))
+	}
+	h.add(tag, rpmTypeInt16, len(vs), data)
+}
+
+func (h *rpmHeader) addBin(tag int, data []byte) {
+	h.add(tag, rpmTypeBin, len(data), data)
+}
+
+func (h *rpmHeader) addRelations(nameTag, flagsTag, versionTag int, relations []Relation, flags []int32) {
+	names := make([]string, len(relations))
+	versions := make([]string, len(relations))
+	for i, currRelation := range relations {
+		names[i] = currRelation.Name
+		versions[i] = currRelation.Version
+	}
+	h.addStrings(nameTag, names)
+	h.addInt32(flagsTag, flags...)
+	h.addStrings(versionTag, versions)
+}
+
+type byTag []rpmHeaderEntry
+
+func (a byTag) Len() int           { return len(a) }
+func (a byTag) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byTag) Less(i, j int) bool { return a[i].tag < a[j].tag }
+
+// bytes returns the serialized form of the header. The header is an immutable region identified by the provided tag
+// whose trailer is stored at the end of the data of the header.
+func (h *rpmHeader) bytes(regionTag int) []byte {
+	entries := append([]rpmHeaderEntry(nil), h.entries...)
+	sort.Stable(byTag(entries))
+
+	index := &bytes.Buffer{}
+	store := &bytes.Buffer{}
+	writeInt32s := func(w io.Writer, vs ...int) {
+		for _, v := range vs {
+			_ = binary.Write(w, binary.BigEndian, int32(v))
+		}
+	}
+
+	// the region entry is the first entry of the index and refers to the trailer, which is stored after the data of all
+	// of the other entries and is an index entry whose offset is the negated size of the index of the region
+	nEntries := len(entries) + 1
+	var entriesIndex bytes.Buffer
+	for _, currEntry := range entries {
+		alignment := 1
+		switch currEntry.typ {
+		case rpmTypeInt16:
+			alignment = 2
+		case rpmTypeInt32:
+			alignment = 4
+		}
+		if rem := store.Len() % alignment; rem != 0 {
+			store.Write(make([]byte, alignment-rem))
+		}
+		writeInt32s(&entriesIndex, currEntry.tag, currEntry.typ, store.Len(), currEntry.count)
+		store.Write(currEntry.data)
+	}
+	writeInt32s(index, regionTag, rpmTypeBin, store.Len(), 16)
+	index.Write(entriesIndex.Bytes())
+	writeInt32s(store, regionTag, rpmTypeBin, -16*nEntries, 16)
+
+	out := &bytes.Buffer{}
+	out.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+	writeInt32s(out, nEntries, store.Len())
+	out.Write(index.Bytes())
+	out.Write(store.Bytes())
+	return out.Bytes()
+}
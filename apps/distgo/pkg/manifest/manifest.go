@@ -0,0 +1,164 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FileName is the name of the manifest file written for each version of a product.
+const FileName = "build-manifest.json"
+
+// BuildArtifactType is the type of the artifacts in a manifest that are executables created by the "build" task. The
+// type of artifacts created by the "dist" task is the type of the distribution.
+const BuildArtifactType = "build"
+
+const (
+	// ChecksumsArtifactType is the type of the SHA256SUMS files written by the "dist" task for products that are signed.
+	ChecksumsArtifactType = "sha256sums"
+	// SignatureArtifactType is the type of the detached signatures created by the "dist" task for products that are
+	// signed.
+	SignatureArtifactType = "signature"
+	// SBOMArtifactType is the type of the software bills of materials written by the "dist" task for distributions
+	// that specify them.
+	SBOMArtifactType = "sbom"
+)
+
+// Manifest records the artifacts that have been produced for a version of a product.
+type Manifest struct {
+	Product   string     `json:"product"`
+	Version   string     `json:"version"`
+	Artifacts []Artifact `json:"artifacts"`
+}
+
+// Artifact records a single file produced by the "build" or "dist" task.
+type Artifact struct {
+	// Path is the path to the artifact relative to the project directory.
+	Path string `json:"path"`
+	// Type is BuildArtifactType for executables, the distribution type for distribution artifacts,
+	// ChecksumsArtifactType or SignatureArtifactType for the checksum files and signatures of signed products and
+	// SBOMArtifactType for software bills of materials.
+	Type string `json:"type"`
+	// OSArchs are the OS/Archs of the executables contained in the artifact.
+	OSArchs   []string `json:"os-archs"`
+	SHA256    string   `json:"sha256"`
+	Size      int64    `json:"size"`
+	GoVersion string   `json:"go-version,omitempty"`
+	// Ldflags is the value of the "-ldflags" argument provided to the build. Only set for executables.
+	Ldflags string `json:"ldflags,omitempty"`
+	// GitRevision is the commit of the project from which the artifact was produced.
+	GitRevision string `json:"git-revision,omitempty"`
+	// Fingerprint identifies the inputs from which a distribution artifact was created. It is used to determine
+	// whether the distribution must be created again. Only set for distribution artifacts.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// SetFileInfo sets the SHA256 and Size of the artifact to the SHA-256 hash and size of the file at the provided path.
+func (a *Artifact) SetFileInfo(filePath string) (rErr error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer func() {
+		if err := f.Close(); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to close %s", filePath)
+		}
+	}()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compute digest of %s", filePath)
+	}
+	a.SHA256 = hex.EncodeToString(h.Sum(nil))
+	a.Size = size
+	return nil
+}
+
+// Read reads the manifest at the provided path. Returns an empty manifest if the file does not exist.
+func Read(manifestPath string) (Manifest, error) {
+	bytes, err := ioutil.ReadFile(manifestPath)
+	if os.IsNotExist(err) {
+		return Manifest{}, nil
+	} else if err != nil {
+		return Manifest{}, errors.Wrapf(err, "failed to read manifest %s", manifestPath)
+	}
+	var m Manifest
+	if err := json.Unmarshal(bytes, &m); err != nil {
+		return Manifest{}, errors.Wrapf(err, "failed to parse manifest %s", manifestPath)
+	}
+	return m, nil
+}
+
+// updateLock serializes updates to manifests so that concurrent builds within the process do not lose entries.
+var updateLock sync.Mutex
+
+// Update adds the provided artifacts to the manifest at the provided path, creating the manifest if it does not exist.
+// An existing entry with the same path as a provided artifact is replaced. The entries of the manifest are sorted by
+// path.
+func Update(manifestPath, product, version string, artifacts ...Artifact) error {
+	updateLock.Lock()
+	defer updateLock.Unlock()
+
+	m, err := Read(manifestPath)
+	if err != nil {
+		return err
+	}
+	m.Product = product
+	m.Version = version
+
+	artifactsByPath := make(map[string]Artifact)
+	for _, curr := range append(m.Artifacts, artifacts...) {
+		artifactsByPath[curr.Path] = curr
+	}
+	m.Artifacts = make([]Artifact, 0, len(artifactsByPath))
+	for _, curr := range artifactsByPath {
+		m.Artifacts = append(m.Artifacts, curr)
+	}
+	sort.Sort(byPath(m.Artifacts))
+
+	bytes, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal manifest")
+	}
+	if err := os.MkdirAll(path.Dir(manifestPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directories for %s", manifestPath)
+	}
+	// write to a temporary file and rename so that readers never observe a partially written manifest
+	tmpPath := manifestPath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, append(bytes, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write manifest %s", manifestPath)
+	}
+	if err := os.Rename(tmpPath, manifestPath); err != nil {
+		return errors.Wrapf(err, "failed to write manifest %s", manifestPath)
+	}
+	return nil
+}
+
+type byPath []Artifact
+
+func (a byPath) Len() int           { return len(a) }
+func (a byPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byPath) Less(i, j int) bool { return a[i].Path < a[j].Path }
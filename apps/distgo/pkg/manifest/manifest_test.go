@@ -0,0 +1,79 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest_test
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/godel/apps/distgo/pkg/manifest"
+)
+
+func TestUpdate(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	manifestPath := path.Join(tmp, "foo", manifest.FileName)
+
+	// reading a manifest that does not exist returns an empty manifest
+	got, err := manifest.Read(manifestPath)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Manifest{}, got)
+
+	err = manifest.Update(manifestPath, "foo", "1.0.0",
+		manifest.Artifact{Path: "dist/foo-1.0.0.tgz", Type: "bin", SHA256: "a"},
+		manifest.Artifact{Path: "build/1.0.0/linux-amd64/foo", Type: manifest.BuildArtifactType, SHA256: "b"},
+	)
+	require.NoError(t, err)
+
+	// entry with an existing path replaces the existing entry
+	err = manifest.Update(manifestPath, "foo", "1.0.0",
+		manifest.Artifact{Path: "dist/foo-1.0.0.tgz", Type: "bin", SHA256: "c"},
+	)
+	require.NoError(t, err)
+
+	got, err = manifest.Read(manifestPath)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Manifest{
+		Product: "foo",
+		Version: "1.0.0",
+		Artifacts: []manifest.Artifact{
+			{Path: "build/1.0.0/linux-amd64/foo", Type: manifest.BuildArtifactType, SHA256: "b"},
+			{Path: "dist/foo-1.0.0.tgz", Type: "bin", SHA256: "c"},
+		},
+	}, got)
+}
+
+func TestSetFileInfo(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	filePath := path.Join(tmp, "file")
+	err = ioutil.WriteFile(filePath, []byte("foo\n"), 0644)
+	require.NoError(t, err)
+
+	var artifact manifest.Artifact
+	err = artifact.SetFileInfo(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c", artifact.SHA256)
+	assert.Equal(t, int64(4), artifact.Size)
+}
@@ -0,0 +1,182 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Layer is a filesystem layer of an image.
+type Layer struct {
+	// Files are the files and directories in the layer. Directories that contain the files are created if they are
+	// not specified.
+	Files []File
+	// CreatedBy describes how the layer was created. It is recorded in the history of the image.
+	CreatedBy string
+}
+
+// File is a file or directory in a layer.
+type File struct {
+	// Path is the absolute path of the file in the image.
+	Path string
+	// Src is the path of the file or directory on disk that provides the content and permissions of the file. Only the
+	// directory itself (and not its content) is added for a directory. Symbolic links are added as links.
+	Src string
+}
+
+// DirLayer returns a layer that contains the content of the provided directory (but not the directory itself) at the
+// provided path in the image.
+func DirLayer(dir, imagePath, createdBy string) (Layer, error) {
+	layer := Layer{
+		CreatedBy: createdBy,
+	}
+	if err := filepath.Walk(dir, func(currPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, currPath)
+		if err != nil {
+			return err
+		}
+		if relPath != "." {
+			layer.Files = append(layer.Files, File{
+				Path: path.Join(imagePath, filepath.ToSlash(relPath)),
+				Src:  currPath,
+			})
+		}
+		return nil
+	}); err != nil {
+		return Layer{}, errors.Wrapf(err, "failed to walk directory %s", dir)
+	}
+	return layer, nil
+}
+
+// layerModTime is the modification time of all of the entries in a layer so that layers are reproducible.
+var layerModTime = time.Unix(0, 0)
+
+// tarGz returns the layer as a gzip-compressed tar archive and the digest of the uncompressed archive.
+func (l Layer) tarGz() ([]byte, string, error) {
+	files := make(map[string]string, len(l.Files))
+	var names []string
+	for _, currFile := range l.Files {
+		name := strings.TrimPrefix(path.Clean("/"+currFile.Path), "/")
+		if name == "" {
+			return nil, "", errors.Errorf("invalid path for file in layer: %q", currFile.Path)
+		}
+		if _, ok := files[name]; !ok {
+			names = append(names, name)
+		}
+		files[name] = currFile.Src
+	}
+	// sorting ensures that directories are written before their content
+	sort.Strings(names)
+
+	compressed := &bytes.Buffer{}
+	gw := gzip.NewWriter(compressed)
+	uncompressedHash := sha256.New()
+	tw := tar.NewWriter(io.MultiWriter(gw, uncompressedHash))
+
+	written := make(map[string]bool)
+	for _, currName := range names {
+		// create parent directories that are not specified explicitly
+		var parents []string
+		for dir := path.Dir(currName); dir != "." && !written[dir]; dir = path.Dir(dir) {
+			parents = append([]string{dir}, parents...)
+		}
+		for _, currDir := range parents {
+			if err := tw.WriteHeader(&tar.Header{
+				Name:     currDir + "/",
+				Typeflag: tar.TypeDir,
+				Mode:     0755,
+				ModTime:  layerModTime,
+			}); err != nil {
+				return nil, "", errors.Wrapf(err, "failed to write directory %s", currDir)
+			}
+			written[currDir] = true
+		}
+		if err := writeFile(tw, currName, files[currName]); err != nil {
+			return nil, "", err
+		}
+		written[currName] = true
+	}
+	if err := tw.Close(); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to close tar writer")
+	}
+	if err := gw.Close(); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to close gzip writer")
+	}
+	return compressed.Bytes(), "sha256:" + hex.EncodeToString(uncompressedHash.Sum(nil)), nil
+}
+
+// writeFile writes the entry for the file at the provided path on disk to the provided tar writer with the provided
+// name.
+func writeFile(tw *tar.Writer, name, src string) error {
+	fi, err := os.Lstat(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", src)
+	}
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    int64(fi.Mode().Perm()),
+		ModTime: layerModTime,
+	}
+	switch {
+	case fi.IsDir():
+		hdr.Name += "/"
+		hdr.Typeflag = tar.TypeDir
+	case fi.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read link %s", src)
+		}
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = target
+	case fi.Mode().IsRegular():
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = fi.Size()
+	default:
+		return errors.Errorf("%s is not a regular file, directory or symbolic link", src)
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return errors.Wrapf(err, "failed to write header for %s", name)
+	}
+	if hdr.Typeflag != tar.TypeReg {
+		return nil
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := io.Copy(tw, f); err != nil {
+		return errors.Wrapf(err, "failed to write %s", name)
+	}
+	return nil
+}
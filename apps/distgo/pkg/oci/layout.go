@@ -0,0 +1,157 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Layout is an image layout that has been read into memory.
+type Layout struct {
+	index Index
+	blobs blobs
+}
+
+// ReadLayout reads the image layout archive at the provided path. The archive may be compressed using gzip.
+func ReadLayout(archivePath string) (*Layout, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", archivePath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", archivePath)
+		}
+		r = gz
+	}
+
+	l := &Layout{
+		blobs: make(blobs),
+	}
+	var indexContent []byte
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", archivePath)
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if name != indexFileName && !strings.HasPrefix(name, blobsDir+"/") {
+			continue
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s in %s", name, archivePath)
+		}
+		if name == indexFileName {
+			indexContent = content
+		} else if parts := strings.Split(name, "/"); len(parts) == 3 {
+			// blobs are stored at "blobs/{{algorithm}}/{{hex}}"
+			l.blobs[parts[1]+":"+parts[2]] = content
+		}
+	}
+	if indexContent == nil {
+		return nil, errors.Errorf("%s is not an OCI image layout: it does not contain %s", archivePath, indexFileName)
+	}
+	if err := json.Unmarshal(indexContent, &l.index); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal %s in %s", indexFileName, archivePath)
+	}
+	return l, nil
+}
+
+// image returns the manifest and configuration of the image for the provided platform in the layout.
+func (l *Layout) image(platform Platform) (Manifest, ImageConfig, error) {
+	desc, ok, err := l.find(l.index.Manifests, platform)
+	if err != nil {
+		return Manifest{}, ImageConfig{}, err
+	}
+	if !ok {
+		return Manifest{}, ImageConfig{}, errors.Errorf("image layout does not contain an image for %s", platform)
+	}
+	var manifest Manifest
+	if err := l.blobs.getJSON(desc, &manifest); err != nil {
+		return Manifest{}, ImageConfig{}, err
+	}
+	var config ImageConfig
+	if err := l.blobs.getJSON(manifest.Config, &config); err != nil {
+		return Manifest{}, ImageConfig{}, err
+	}
+	return manifest, config, nil
+}
+
+// find returns the descriptor of the first manifest for the provided platform in the provided descriptors, descending
+// into image indexes. Manifests whose descriptors do not specify a platform are matched using the platform in their
+// configuration.
+func (l *Layout) find(descs []Descriptor, platform Platform) (Descriptor, bool, error) {
+	for _, currDesc := range descs {
+		switch currDesc.MediaType {
+		case MediaTypeImageIndex, mediaTypeDockerManifestList:
+			var index Index
+			if err := l.blobs.getJSON(currDesc, &index); err != nil {
+				return Descriptor{}, false, err
+			}
+			if desc, ok, err := l.find(index.Manifests, platform); err != nil || ok {
+				return desc, ok, err
+			}
+		case MediaTypeImageManifest, mediaTypeDockerManifest:
+			if currDesc.Platform != nil {
+				if currDesc.Platform.matches(platform) {
+					return currDesc, true, nil
+				}
+				continue
+			}
+			var manifest Manifest
+			if err := l.blobs.getJSON(currDesc, &manifest); err != nil {
+				return Descriptor{}, false, err
+			}
+			var config ImageConfig
+			if err := l.blobs.getJSON(manifest.Config, &config); err != nil {
+				return Descriptor{}, false, err
+			}
+			if (Platform{Architecture: config.Architecture, OS: config.OS, Variant: config.Variant}).matches(platform) {
+				return currDesc, true, nil
+			}
+		}
+	}
+	return Descriptor{}, false, nil
+}
+
+// matches returns true if the platform satisfies the provided platform. The variant is only compared if the provided
+// platform specifies one.
+func (p Platform) matches(want Platform) bool {
+	return p.OS == want.OS && p.Architecture == want.Architecture && (want.Variant == "" || p.Variant == want.Variant)
+}
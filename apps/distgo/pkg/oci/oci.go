@@ -0,0 +1,168 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oci writes container images in the OCI image layout format
+// (https://github.com/opencontainers/image-spec/blob/master/image-layout.md) as tar archives without requiring a
+// container runtime or daemon. Images can be based on the image for the same platform in an existing image layout
+// archive or on an empty ("scratch") image.
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MediaTypeImageIndex     = "application/vnd.oci.image.index.v1+json"
+	MediaTypeImageManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeImageConfig    = "application/vnd.oci.image.config.v1+json"
+	MediaTypeImageLayerGzip = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+	// media types used by Docker for the equivalent documents, which are accepted in base images
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// AnnotationRefName is the annotation that specifies the name of a reference in the index of an image layout.
+	AnnotationRefName = "org.opencontainers.image.ref.name"
+
+	layoutFileName = "oci-layout"
+	indexFileName  = "index.json"
+	blobsDir       = "blobs"
+	layoutVersion  = "1.0.0"
+)
+
+// Descriptor describes the content of a blob.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Platform is the platform on which an image runs. The values of OS and Architecture are the same as the values of
+// GOOS and GOARCH.
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p Platform) String() string {
+	if p.Variant != "" {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Architecture
+}
+
+// Index is an image index, which references the manifests of an image for different platforms. The "index.json" file
+// of an image layout is also an Index.
+type Index struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an image manifest, which references the configuration and the layers of an image for a single platform.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// ImageConfig is the configuration of an image.
+type ImageConfig struct {
+	Created      string          `json:"created,omitempty"`
+	Author       string          `json:"author,omitempty"`
+	Architecture string          `json:"architecture"`
+	OS           string          `json:"os"`
+	Variant      string          `json:"variant,omitempty"`
+	Config       ContainerConfig `json:"config"`
+	RootFS       RootFS          `json:"rootfs"`
+	History      []History       `json:"history,omitempty"`
+}
+
+// ContainerConfig contains the parameters used when running a container from an image.
+type ContainerConfig struct {
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Volumes      map[string]struct{} `json:"Volumes,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	StopSignal   string              `json:"StopSignal,omitempty"`
+}
+
+// RootFS contains the digests of the uncompressed content of the layers of an image.
+type RootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+// History describes the creation of a layer of an image.
+type History struct {
+	Created    string `json:"created,omitempty"`
+	CreatedBy  string `json:"created_by,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+	EmptyLayer bool   `json:"empty_layer,omitempty"`
+}
+
+// digest returns the digest of the provided content in the form used in descriptors ("sha256:{{hex}}").
+func digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// blobs stores the content of blobs by digest.
+type blobs map[string][]byte
+
+// add stores the provided content and returns a descriptor with the provided media type for it.
+func (b blobs) add(mediaType string, content []byte) Descriptor {
+	d := digest(content)
+	b[d] = content
+	return Descriptor{
+		MediaType: mediaType,
+		Digest:    d,
+		Size:      int64(len(content)),
+	}
+}
+
+// addJSON stores the JSON encoding of the provided value and returns a descriptor with the provided media type for it.
+func (b blobs) addJSON(mediaType string, v interface{}) (Descriptor, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return Descriptor{}, errors.Wrapf(err, "failed to marshal %s", mediaType)
+	}
+	return b.add(mediaType, content), nil
+}
+
+// getJSON unmarshals the content of the blob for the provided descriptor into the provided value.
+func (b blobs) getJSON(desc Descriptor, v interface{}) error {
+	content, ok := b[desc.Digest]
+	if !ok {
+		return errors.Errorf("blob %s does not exist", desc.Digest)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal blob %s", desc.Digest)
+	}
+	return nil
+}
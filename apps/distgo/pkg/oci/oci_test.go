@@ -0,0 +1,261 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/godel/apps/distgo/pkg/oci"
+)
+
+func TestWriteScratch(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	binary := path.Join(tmp, "foo")
+	err = ioutil.WriteFile(binary, []byte("binary"), 0755)
+	require.NoError(t, err)
+
+	for i, currCase := range []struct {
+		platforms []oci.Platform
+	}{
+		{platforms: []oci.Platform{{OS: "linux", Architecture: "amd64"}}},
+		{platforms: []oci.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"}}},
+	} {
+		var images []oci.Image
+		for _, currPlatform := range currCase.platforms {
+			images = append(images, oci.Image{
+				Platform: currPlatform,
+				Layers: []oci.Layer{{
+					Files: []oci.File{{Path: "/usr/local/bin/foo", Src: binary}},
+				}},
+				Config: oci.ContainerConfig{
+					Entrypoint: []string{"/usr/local/bin/foo"},
+					Env:        []string{"FOO=bar"},
+					Labels:     map[string]string{"label": "value"},
+				},
+			})
+		}
+		buf := &bytes.Buffer{}
+		err := oci.Write(buf, images, nil, "1.0.0")
+		require.NoError(t, err, "Case %d", i)
+
+		// output is reproducible
+		buf2 := &bytes.Buffer{}
+		err = oci.Write(buf2, images, nil, "1.0.0")
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, buf.Bytes(), buf2.Bytes(), "Case %d", i)
+
+		files := readTar(t, buf.Bytes())
+		assert.Equal(t, `{"imageLayoutVersion":"1.0.0"}`, string(files["oci-layout"]), "Case %d", i)
+
+		var index oci.Index
+		unmarshal(t, files["index.json"], &index)
+		require.Equal(t, 1, len(index.Manifests), "Case %d", i)
+		assert.Equal(t, "1.0.0", index.Manifests[0].Annotations[oci.AnnotationRefName], "Case %d", i)
+
+		manifestDescs := index.Manifests
+		if len(currCase.platforms) > 1 {
+			assert.Equal(t, oci.MediaTypeImageIndex, index.Manifests[0].MediaType, "Case %d", i)
+			var imageIndex oci.Index
+			unmarshal(t, blob(t, files, index.Manifests[0]), &imageIndex)
+			manifestDescs = imageIndex.Manifests
+		}
+		require.Equal(t, len(currCase.platforms), len(manifestDescs), "Case %d", i)
+
+		for j, currDesc := range manifestDescs {
+			assert.Equal(t, oci.MediaTypeImageManifest, currDesc.MediaType, "Case %d", i)
+			assert.Equal(t, currCase.platforms[j], *currDesc.Platform, "Case %d", i)
+
+			var manifest oci.Manifest
+			unmarshal(t, blob(t, files, currDesc), &manifest)
+			var config oci.ImageConfig
+			unmarshal(t, blob(t, files, manifest.Config), &config)
+			assert.Equal(t, currCase.platforms[j].Architecture, config.Architecture, "Case %d", i)
+			assert.Equal(t, "linux", config.OS, "Case %d", i)
+			assert.Equal(t, []string{"/usr/local/bin/foo"}, config.Config.Entrypoint, "Case %d", i)
+			assert.Equal(t, []string{"FOO=bar"}, config.Config.Env, "Case %d", i)
+			assert.Equal(t, map[string]string{"label": "value"}, config.Config.Labels, "Case %d", i)
+
+			require.Equal(t, 1, len(manifest.Layers), "Case %d", i)
+			assert.Equal(t, oci.MediaTypeImageLayerGzip, manifest.Layers[0].MediaType, "Case %d", i)
+			assert.Equal(t, 1, len(config.RootFS.DiffIDs), "Case %d", i)
+			layerFiles := readTarGz(t, blob(t, files, manifest.Layers[0]))
+			assert.Equal(t, "binary", string(layerFiles["usr/local/bin/foo"]), "Case %d", i)
+		}
+	}
+}
+
+func TestWriteWithBase(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	err = os.MkdirAll(path.Join(tmp, "dist", "etc"), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path.Join(tmp, "dist", "etc", "config.yml"), []byte("config"), 0644)
+	require.NoError(t, err)
+	binary := path.Join(tmp, "foo")
+	err = ioutil.WriteFile(binary, []byte("binary"), 0755)
+	require.NoError(t, err)
+
+	// write base image for two platforms
+	baseLayer, err := oci.DirLayer(path.Join(tmp, "dist"), "/", "base")
+	require.NoError(t, err)
+	var baseImages []oci.Image
+	for _, currArch := range []string{"amd64", "arm64"} {
+		baseImages = append(baseImages, oci.Image{
+			Platform: oci.Platform{OS: "linux", Architecture: currArch},
+			Layers:   []oci.Layer{baseLayer},
+			Config: oci.ContainerConfig{
+				Env:    []string{"PATH=/bin", "BASE=" + currArch},
+				Cmd:    []string{"sh"},
+				Labels: map[string]string{"base": "true"},
+			},
+		})
+	}
+	baseBuf := &bytes.Buffer{}
+	err = oci.Write(baseBuf, baseImages, nil, "base")
+	require.NoError(t, err)
+
+	// gzip-compressed base layouts are supported
+	basePath := path.Join(tmp, "base.oci.tar.gz")
+	gzBuf := &bytes.Buffer{}
+	gw := gzip.NewWriter(gzBuf)
+	_, err = gw.Write(baseBuf.Bytes())
+	require.NoError(t, err)
+	err = gw.Close()
+	require.NoError(t, err)
+	err = ioutil.WriteFile(basePath, gzBuf.Bytes(), 0644)
+	require.NoError(t, err)
+
+	base, err := oci.ReadLayout(basePath)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	err = oci.Write(buf, []oci.Image{{
+		Platform: oci.Platform{OS: "linux", Architecture: "arm64"},
+		Layers: []oci.Layer{{
+			Files: []oci.File{{Path: "/usr/local/bin/foo", Src: binary}},
+		}},
+		Config: oci.ContainerConfig{
+			Entrypoint: []string{"/usr/local/bin/foo"},
+			Env:        []string{"PATH=/usr/local/bin:/bin", "FOO=bar"},
+			Labels:     map[string]string{"product": "foo"},
+		},
+	}}, base, "")
+	require.NoError(t, err)
+
+	files := readTar(t, buf.Bytes())
+	var index oci.Index
+	unmarshal(t, files["index.json"], &index)
+	require.Equal(t, 1, len(index.Manifests))
+	assert.Nil(t, index.Manifests[0].Annotations)
+
+	var manifest oci.Manifest
+	unmarshal(t, blob(t, files, index.Manifests[0]), &manifest)
+	var config oci.ImageConfig
+	unmarshal(t, blob(t, files, manifest.Config), &config)
+
+	assert.Equal(t, "arm64", config.Architecture)
+	assert.Equal(t, []string{"PATH=/usr/local/bin:/bin", "BASE=arm64", "FOO=bar"}, config.Config.Env)
+	assert.Equal(t, []string{"/usr/local/bin/foo"}, config.Config.Entrypoint)
+	// entrypoint clears the command of the base image
+	assert.Nil(t, config.Config.Cmd)
+	assert.Equal(t, map[string]string{"base": "true", "product": "foo"}, config.Config.Labels)
+
+	// base layer is followed by the new layer
+	require.Equal(t, 2, len(manifest.Layers))
+	assert.Equal(t, 2, len(config.RootFS.DiffIDs))
+	baseLayerFiles := readTarGz(t, blob(t, files, manifest.Layers[0]))
+	assert.Equal(t, "config", string(baseLayerFiles["etc/config.yml"]))
+	layerFiles := readTarGz(t, blob(t, files, manifest.Layers[1]))
+	assert.Equal(t, "binary", string(layerFiles["usr/local/bin/foo"]))
+
+	// base does not contain an image for the platform
+	err = oci.Write(ioutil.Discard, []oci.Image{{
+		Platform: oci.Platform{OS: "linux", Architecture: "386"},
+	}}, base, "")
+	assert.EqualError(t, err, "failed to create image for linux/386: failed to determine base image: image layout does not contain an image for linux/386")
+}
+
+func TestReadLayoutInvalid(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	err = tw.Close()
+	require.NoError(t, err)
+	archivePath := path.Join(tmp, "empty.tar")
+	err = ioutil.WriteFile(archivePath, buf.Bytes(), 0644)
+	require.NoError(t, err)
+
+	_, err = oci.ReadLayout(archivePath)
+	assert.EqualError(t, err, archivePath+" is not an OCI image layout: it does not contain index.json")
+}
+
+func readTar(t *testing.T, content []byte) map[string][]byte {
+	return readTarReader(t, bytes.NewReader(content))
+}
+
+func readTarGz(t *testing.T, content []byte) map[string][]byte {
+	gr, err := gzip.NewReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	return readTarReader(t, gr)
+}
+
+func readTarReader(t *testing.T, r io.Reader) map[string][]byte {
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = content
+	}
+	return files
+}
+
+func blob(t *testing.T, files map[string][]byte, desc oci.Descriptor) []byte {
+	content, ok := files["blobs/"+strings.Replace(desc.Digest, ":", "/", 1)]
+	require.True(t, ok, "blob %s does not exist", desc.Digest)
+	assert.Equal(t, desc.Size, int64(len(content)))
+	return content
+}
+
+func unmarshal(t *testing.T, content []byte, v interface{}) {
+	err := json.Unmarshal(content, v)
+	require.NoError(t, err)
+}
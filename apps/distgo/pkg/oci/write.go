@@ -0,0 +1,272 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Image specifies the image for a single platform.
+type Image struct {
+	// Platform is the platform of the image.
+	Platform Platform
+	// Layers are the layers that are added on top of the layers of the base image.
+	Layers []Layer
+	// Config is the container configuration of the image. It is merged with the configuration of the base image:
+	// entries in Env replace the entries for the same variables in the base image, Labels, ExposedPorts and Volumes
+	// are added to the values of the base image and the other fields replace the values of the base image if they are
+	// non-empty. As is the case for a Dockerfile, specifying Entrypoint clears the Cmd of the base image.
+	Config ContainerConfig
+}
+
+// Write writes an image layout archive that contains the provided images to the provided writer. If base is non-nil,
+// each image is based on the image for its platform in base; otherwise, images are based on an empty image. If a
+// single image is provided, the index of the layout references its manifest. Otherwise, the index references an image
+// index that references the manifest of every image. The reference in the index of the layout is annotated with the
+// provided reference name if it is non-empty. The same input always produces the same archive.
+func Write(w io.Writer, images []Image, base *Layout, refName string) error {
+	if len(images) == 0 {
+		return errors.Errorf("at least one image must be provided")
+	}
+
+	out := make(blobs)
+	seen := make(map[string]bool)
+	var manifests []Descriptor
+	for _, currImage := range images {
+		platform := currImage.Platform
+		if seen[platform.String()] {
+			return errors.Errorf("multiple images provided for %s", platform)
+		}
+		seen[platform.String()] = true
+
+		desc, err := writeImage(out, currImage, base)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create image for %s", platform)
+		}
+		desc.Platform = &platform
+		manifests = append(manifests, desc)
+	}
+
+	ref := manifests[0]
+	if len(manifests) > 1 {
+		var err error
+		if ref, err = out.addJSON(MediaTypeImageIndex, Index{
+			SchemaVersion: 2,
+			MediaType:     MediaTypeImageIndex,
+			Manifests:     manifests,
+		}); err != nil {
+			return err
+		}
+	}
+	if refName != "" {
+		ref.Annotations = map[string]string{
+			AnnotationRefName: refName,
+		}
+	}
+	return writeArchive(w, Index{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeImageIndex,
+		Manifests:     []Descriptor{ref},
+	}, out)
+}
+
+// writeImage stores the blobs of the provided image in out and returns the descriptor of its manifest.
+func writeImage(out blobs, image Image, base *Layout) (Descriptor, error) {
+	manifest := Manifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeImageManifest,
+	}
+	var config ImageConfig
+	if base != nil {
+		baseManifest, baseConfig, err := base.image(image.Platform)
+		if err != nil {
+			return Descriptor{}, errors.Wrapf(err, "failed to determine base image")
+		}
+		for _, currLayer := range baseManifest.Layers {
+			content, ok := base.blobs[currLayer.Digest]
+			if !ok {
+				return Descriptor{}, errors.Errorf("base image does not contain layer %s", currLayer.Digest)
+			}
+			out[currLayer.Digest] = content
+		}
+		manifest.Layers = append(manifest.Layers, baseManifest.Layers...)
+		config = baseConfig
+	}
+	config.Architecture = image.Platform.Architecture
+	config.OS = image.Platform.OS
+	config.Variant = image.Platform.Variant
+	config.RootFS.Type = "layers"
+
+	for _, currLayer := range image.Layers {
+		content, diffID, err := currLayer.tarGz()
+		if err != nil {
+			return Descriptor{}, err
+		}
+		manifest.Layers = append(manifest.Layers, out.add(MediaTypeImageLayerGzip, content))
+		config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, diffID)
+		config.History = append(config.History, History{
+			CreatedBy: currLayer.CreatedBy,
+		})
+	}
+	config.Config = mergeConfig(config.Config, image.Config)
+
+	configDesc, err := out.addJSON(MediaTypeImageConfig, config)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	manifest.Config = configDesc
+	return out.addJSON(MediaTypeImageManifest, manifest)
+}
+
+// mergeConfig returns the result of applying the provided configuration to the configuration of a base image as
+// described by Image.Config.
+func mergeConfig(base, cfg ContainerConfig) ContainerConfig {
+	for _, currEnv := range cfg.Env {
+		key := strings.SplitN(currEnv, "=", 2)[0]
+		replaced := false
+		for i, currBaseEnv := range base.Env {
+			if strings.SplitN(currBaseEnv, "=", 2)[0] == key {
+				base.Env[i] = currEnv
+				replaced = true
+			}
+		}
+		if !replaced {
+			base.Env = append(base.Env, currEnv)
+		}
+	}
+	if len(cfg.Entrypoint) > 0 {
+		base.Entrypoint = cfg.Entrypoint
+		base.Cmd = nil
+	}
+	if len(cfg.Cmd) > 0 {
+		base.Cmd = cfg.Cmd
+	}
+	if cfg.User != "" {
+		base.User = cfg.User
+	}
+	if cfg.WorkingDir != "" {
+		base.WorkingDir = cfg.WorkingDir
+	}
+	if cfg.StopSignal != "" {
+		base.StopSignal = cfg.StopSignal
+	}
+	base.Labels = mergeStringMaps(base.Labels, cfg.Labels)
+	base.ExposedPorts = mergeSetMaps(base.ExposedPorts, cfg.ExposedPorts)
+	base.Volumes = mergeSetMaps(base.Volumes, cfg.Volumes)
+	return base
+}
+
+func mergeStringMaps(base, m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(m))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range m {
+		merged[k] = v
+	}
+	return merged
+}
+
+func mergeSetMaps(base, m map[string]struct{}) map[string]struct{} {
+	if len(m) == 0 {
+		return base
+	}
+	merged := make(map[string]struct{}, len(base)+len(m))
+	for k := range base {
+		merged[k] = struct{}{}
+	}
+	for k := range m {
+		merged[k] = struct{}{}
+	}
+	return merged
+}
+
+// writeArchive writes an image layout archive with the provided index and blobs to the provided writer.
+func writeArchive(w io.Writer, index Index, content blobs) error {
+	tw := tar.NewWriter(w)
+	writeEntry := func(name string, content []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			ModTime:  layerModTime,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to write header for %s", name)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return errors.Wrapf(err, "failed to write %s", name)
+		}
+		return nil
+	}
+
+	layoutContent, err := json.Marshal(map[string]string{"imageLayoutVersion": layoutVersion})
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", layoutFileName)
+	}
+	if err := writeEntry(layoutFileName, layoutContent); err != nil {
+		return err
+	}
+	indexContent, err := json.Marshal(index)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", indexFileName)
+	}
+	if err := writeEntry(indexFileName, indexContent); err != nil {
+		return err
+	}
+
+	digests := make([]string, 0, len(content))
+	for currDigest := range content {
+		digests = append(digests, currDigest)
+	}
+	sort.Strings(digests)
+	writtenDirs := make(map[string]bool)
+	for _, currDigest := range digests {
+		parts := strings.SplitN(currDigest, ":", 2)
+		if len(parts) != 2 {
+			return errors.Errorf("invalid digest: %s", currDigest)
+		}
+		for _, currDir := range []string{blobsDir + "/", blobsDir + "/" + parts[0] + "/"} {
+			if writtenDirs[currDir] {
+				continue
+			}
+			if err := tw.WriteHeader(&tar.Header{
+				Name:     currDir,
+				Typeflag: tar.TypeDir,
+				Mode:     0755,
+				ModTime:  layerModTime,
+			}); err != nil {
+				return errors.Wrapf(err, "failed to write header for %s", currDir)
+			}
+			writtenDirs[currDir] = true
+		}
+		if err := writeEntry(blobsDir+"/"+parts[0]+"/"+parts[1], content[currDigest]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close tar writer")
+	}
+	return nil
+}
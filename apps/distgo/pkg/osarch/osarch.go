@@ -25,11 +25,39 @@ type OSArch struct {
 	Arch string
 }
 
-// String returns a string representation of the form "GOOS-GOARCH".
+// String returns a string representation of the form "GOOS-GOARCH". The string representation of the FirstClass
+// pattern is FirstClass.
 func (o OSArch) String() string {
+	if o.isFirstClass() {
+		return FirstClass
+	}
 	return fmt.Sprintf("%v-%v", o.OS, o.Arch)
 }
 
+// UnmarshalYAML unmarshals an OSArch from either a mapping with "os" and "arch" keys or a string that is accepted by
+// NewPattern.
+func (o *OSArch) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err == nil {
+		osArch, err := NewPattern(str)
+		if err != nil {
+			return err
+		}
+		*o = osArch
+		return nil
+	}
+
+	var fields struct {
+		OS   string `yaml:"os"`
+		Arch string `yaml:"arch"`
+	}
+	if err := unmarshal(&fields); err != nil {
+		return err
+	}
+	*o = OSArch{OS: fields.OS, Arch: fields.Arch}
+	return nil
+}
+
 // Current returns and OSArch that reflects the GOOS/GOARCH value for the current runtime.
 func Current() OSArch {
 	return OSArch{
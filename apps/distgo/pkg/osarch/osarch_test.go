@@ -54,3 +54,101 @@ func TestOSArchCurrent(t *testing.T) {
 	want := osarch.OSArch{OS: runtime.GOOS, Arch: runtime.GOARCH}
 	assert.Equal(t, want, osarch.Current())
 }
+
+func TestNewPattern(t *testing.T) {
+	for i, currCase := range []struct {
+		input     string
+		want      osarch.OSArch
+		wantError bool
+	}{
+		{input: "darwin-amd64", want: osarch.OSArch{OS: "darwin", Arch: "amd64"}},
+		{input: "linux-*", want: osarch.OSArch{OS: "linux", Arch: "*"}},
+		{input: "*-arm64", want: osarch.OSArch{OS: "*", Arch: "arm64"}},
+		{input: "first-class", want: osarch.OSArch{OS: "first-class"}},
+		{input: "linux-a*", wantError: true},
+		{input: "*", wantError: true},
+		{input: "", wantError: true},
+	} {
+		got, err := osarch.NewPattern(currCase.input)
+		if currCase.wantError {
+			assert.EqualError(t, err, "not a valid OSArch value: "+currCase.input, "Case %d", i)
+		} else {
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, currCase.want, got, "Case %d", i)
+			assert.Equal(t, currCase.input, got.String(), "Case %d", i)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	linuxAMD64 := osarch.OSArch{OS: "linux", Arch: "amd64"}
+
+	got, err := osarch.Expand([]osarch.OSArch{{OS: "linux", Arch: "*"}, linuxAMD64})
+	require.NoError(t, err)
+	assert.Contains(t, got, linuxAMD64)
+	assert.Contains(t, got, osarch.OSArch{OS: "linux", Arch: "arm64"})
+	for _, curr := range got {
+		assert.Equal(t, "linux", curr.OS)
+	}
+	assert.Equal(t, 1, countOf(got, linuxAMD64))
+
+	got, err = osarch.Expand([]osarch.OSArch{{OS: "*", Arch: "arm64"}})
+	require.NoError(t, err)
+	assert.Contains(t, got, osarch.OSArch{OS: "darwin", Arch: "arm64"})
+	for _, curr := range got {
+		assert.Equal(t, "arm64", curr.Arch)
+	}
+
+	got, err = osarch.Expand([]osarch.OSArch{{OS: "first-class"}})
+	require.NoError(t, err)
+	assert.Contains(t, got, linuxAMD64)
+	assert.NotContains(t, got, osarch.OSArch{OS: "plan9", Arch: "386"})
+
+	_, err = osarch.Expand([]osarch.OSArch{{OS: "lnux", Arch: "*"}})
+	assert.EqualError(t, err, "unknown OS/Arch lnux-*: not supported by the installed Go toolchain (did you mean linux-*?)")
+
+	// values that are not patterns are not validated
+	got, err = osarch.Expand([]osarch.OSArch{{OS: "foo", Arch: "bar"}})
+	require.NoError(t, err)
+	assert.Equal(t, []osarch.OSArch{{OS: "foo", Arch: "bar"}}, got)
+}
+
+func TestValidate(t *testing.T) {
+	for i, currCase := range []struct {
+		input     osarch.OSArch
+		wantError string
+	}{
+		{input: osarch.OSArch{OS: "linux", Arch: "amd64"}},
+		{input: osarch.OSArch{OS: "linux", Arch: "*"}},
+		{input: osarch.OSArch{OS: "first-class"}},
+		{
+			input:     osarch.OSArch{OS: "linux", Arch: "amd46"},
+			wantError: "unknown OS/Arch linux-amd46: not supported by the installed Go toolchain (did you mean linux-amd64?)",
+		},
+		{
+			input:     osarch.OSArch{OS: "lnux", Arch: "*"},
+			wantError: "unknown OS/Arch lnux-*: not supported by the installed Go toolchain (did you mean linux-*?)",
+		},
+		{
+			input:     osarch.OSArch{OS: "*", Arch: "amd46"},
+			wantError: "unknown OS/Arch *-amd46: not supported by the installed Go toolchain (did you mean *-amd64?)",
+		},
+	} {
+		err := osarch.Validate(currCase.input)
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+		} else {
+			assert.NoError(t, err, "Case %d", i)
+		}
+	}
+}
+
+func countOf(osArchs []osarch.OSArch, want osarch.OSArch) int {
+	count := 0
+	for _, curr := range osArchs {
+		if curr == want {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,230 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package osarch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Wildcard is the value of the OS or Arch of a pattern that matches any OS or Arch.
+	Wildcard = "*"
+	// FirstClass is the name of the pattern that matches the targets that the Go toolchain reports as first-class
+	// ports. Toolchains older than Go 1.12 do not report first-class ports, so the pattern cannot be used with them.
+	FirstClass = "first-class"
+)
+
+// Target is a GOOS/GOARCH pair supported by the Go toolchain.
+type Target struct {
+	OSArch
+	FirstClass   bool
+	CgoSupported bool
+}
+
+var (
+	targetsOnce sync.Once
+	targets     []Target
+	targetsErr  error
+)
+
+// Targets returns the targets supported by the installed Go toolchain as reported by "go tool dist list". The command
+// is only run once per process.
+func Targets() ([]Target, error) {
+	targetsOnce.Do(func() {
+		output, err := exec.Command("go", "tool", "dist", "list", "-json").Output()
+		if err != nil {
+			targetsErr = fmt.Errorf("failed to determine supported OS/Arch targets using \"go tool dist list\": %v", err)
+			return
+		}
+		var distList []struct {
+			GOOS         string
+			GOARCH       string
+			CgoSupported bool
+			FirstClass   bool
+		}
+		if err := json.Unmarshal(output, &distList); err != nil {
+			targetsErr = fmt.Errorf("failed to parse output of \"go tool dist list\": %v", err)
+			return
+		}
+		for _, curr := range distList {
+			targets = append(targets, Target{
+				OSArch:       OSArch{OS: curr.GOOS, Arch: curr.GOARCH},
+				FirstClass:   curr.FirstClass,
+				CgoSupported: curr.CgoSupported,
+			})
+		}
+	})
+	return targets, targetsErr
+}
+
+// NewPattern returns an OSArch for the provided input, which may be a pattern. In addition to the values accepted by
+// New, the OS or the Arch may be Wildcard (for example, "linux-*" or "*-arm64") and the input may be FirstClass.
+func NewPattern(input string) (OSArch, error) {
+	if input == FirstClass {
+		return OSArch{OS: FirstClass}, nil
+	}
+	if parts := strings.Split(input, "-"); len(parts) == 2 && isPatternPart(parts[0]) && isPatternPart(parts[1]) {
+		return OSArch{OS: parts[0], Arch: parts[1]}, nil
+	}
+	return OSArch{}, fmt.Errorf("not a valid OSArch value: %s", input)
+}
+
+func isPatternPart(input string) bool {
+	return input == Wildcard || isAlphaNumericOnly(input)
+}
+
+// IsPattern returns true if the OSArch is a pattern that may match multiple targets.
+func (o OSArch) IsPattern() bool {
+	return o.isFirstClass() || o.OS == Wildcard || o.Arch == Wildcard
+}
+
+func (o OSArch) isFirstClass() bool {
+	return o.OS == FirstClass && o.Arch == ""
+}
+
+// matches returns true if the provided target matches the OSArch.
+func (o OSArch) matches(target Target) bool {
+	if o.isFirstClass() {
+		return target.FirstClass
+	}
+	return (o.OS == Wildcard || o.OS == target.OS) && (o.Arch == Wildcard || o.Arch == target.Arch)
+}
+
+// Expand returns the OSArchs that result from replacing every pattern in the provided slice with the targets that it
+// matches, in the order reported by the toolchain. Values that are not patterns are returned unchanged and duplicate
+// values are removed. Returns an error if a pattern does not match any target. The toolchain is only queried if the
+// input contains a pattern.
+func Expand(osArchs []OSArch) ([]OSArch, error) {
+	var expanded []OSArch
+	seen := make(map[OSArch]struct{})
+	add := func(osArch OSArch) {
+		if _, ok := seen[osArch]; !ok {
+			seen[osArch] = struct{}{}
+			expanded = append(expanded, osArch)
+		}
+	}
+	for _, curr := range osArchs {
+		if !curr.IsPattern() {
+			add(curr)
+			continue
+		}
+		allTargets, err := Targets()
+		if err != nil {
+			return nil, err
+		}
+		matched := false
+		for _, currTarget := range allTargets {
+			if curr.matches(currTarget) {
+				add(currTarget.OSArch)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, Validate(curr)
+		}
+	}
+	return expanded, nil
+}
+
+// Validate returns an error if the provided OSArch is not a target supported by the installed Go toolchain or is a
+// pattern that does not match any such target. The error suggests the valid value that is closest to the provided one.
+func Validate(osArch OSArch) error {
+	allTargets, err := Targets()
+	if err != nil {
+		return err
+	}
+	if osArch.isFirstClass() {
+		for _, currTarget := range allTargets {
+			if currTarget.FirstClass {
+				return nil
+			}
+		}
+		// toolchains older than Go 1.12 do not report first-class ports
+		return errors.New("the installed Go toolchain does not report first-class ports, so the first-class OS/Arch pattern cannot be used")
+	}
+
+	candidates := make(map[string]struct{})
+	for _, currTarget := range allTargets {
+		if osArch.matches(currTarget) {
+			return nil
+		}
+		switch {
+		case osArch.OS == Wildcard:
+			candidates[Wildcard+"-"+currTarget.Arch] = struct{}{}
+		case osArch.Arch == Wildcard:
+			candidates[currTarget.OS+"-"+Wildcard] = struct{}{}
+		default:
+			candidates[currTarget.String()] = struct{}{}
+		}
+	}
+
+	msg := fmt.Sprintf("unknown OS/Arch %s: not supported by the installed Go toolchain", osArch.String())
+	if suggestion := closest(osArch.String(), candidates); suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %s?)", suggestion)
+	}
+	return errors.New(msg)
+}
+
+// closest returns the candidate with the smallest edit distance from the provided value. Ties are broken by choosing
+// the candidate that sorts first.
+func closest(value string, candidates map[string]struct{}) string {
+	sorted := make([]string, 0, len(candidates))
+	for k := range candidates {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	best, bestDist := "", -1
+	for _, curr := range sorted {
+		if dist := editDistance(value, curr); bestDist == -1 || dist < bestDist {
+			best, bestDist = curr, dist
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between the provided strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,265 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// toolName is the name of the tool recorded as the creator of SBOMs.
+const toolName = "distgo"
+
+// WriteSPDX writes the provided document to w as an SPDX 2.2 JSON document. The product is described by the document
+// and contains a package for each of the third-party packages of the document. Fields whose values are not known are
+// set to "NOASSERTION".
+func WriteSPDX(w io.Writer, doc Document) error {
+	const productID = "SPDXRef-Product"
+	spdxDoc := spdxDocument{
+		SPDXVersion:       "SPDX-2.2",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              fmt.Sprintf("%s-%s", doc.Name, doc.Version),
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s-%s", doc.Name, doc.Version, documentUUID(doc)),
+		CreationInfo: spdxCreationInfo{
+			Created:  doc.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + toolName},
+		},
+		DocumentDescribes: []string{productID},
+		Packages: []spdxPackage{{
+			SPDXID:           productID,
+			Name:             doc.Name,
+			VersionInfo:      doc.Version,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: productID,
+		}},
+	}
+	for i, currPkg := range doc.Packages {
+		pkgID := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		pkgLicense := spdxNoAssertion
+		if currPkg.License != "" {
+			pkgLicense = currPkg.License
+		}
+		spdxDoc.Packages = append(spdxDoc.Packages, spdxPackage{
+			SPDXID:           pkgID,
+			Name:             currPkg.ImportPath,
+			VersionInfo:      currPkg.Version,
+			PackageFileName:  currPkg.VendorPath,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: pkgLicense,
+			LicenseDeclared:  pkgLicense,
+			CopyrightText:    spdxNoAssertion,
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  currPkg.purl(),
+			}},
+		})
+		spdxDoc.Relationships = append(spdxDoc.Relationships, spdxRelationship{
+			SPDXElementID:      productID,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: pkgID,
+		})
+	}
+	return writeJSON(w, spdxDoc)
+}
+
+const spdxNoAssertion = "NOASSERTION"
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	DocumentDescribes []string           `json:"documentDescribes"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	PackageFileName  string            `json:"packageFileName,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// WriteCycloneDX writes the provided document to w as a CycloneDX 1.4 JSON document. The product is the component
+// described by the metadata of the document and each of the third-party packages of the document is a library
+// component on which it depends. The vendor path of a package is recorded as the "distgo:vendor-path" property of its
+// component.
+func WriteCycloneDX(w io.Writer, doc Document) error {
+	productRef := fmt.Sprintf("%s@%s", doc.Name, doc.Version)
+	bom := cycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + documentUUID(doc),
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: doc.Created.UTC().Format(time.RFC3339),
+			Tools:     []cycloneDXTool{{Name: toolName}},
+			Component: cycloneDXComponent{
+				Type:    "application",
+				BOMRef:  productRef,
+				Name:    doc.Name,
+				Version: doc.Version,
+			},
+		},
+		Components: []cycloneDXComponent{},
+	}
+	dependency := cycloneDXDependency{
+		Ref:       productRef,
+		DependsOn: []string{},
+	}
+	for _, currPkg := range doc.Packages {
+		component := cycloneDXComponent{
+			Type:    "library",
+			BOMRef:  currPkg.purl(),
+			Name:    currPkg.ImportPath,
+			Version: currPkg.Version,
+			PURL:    currPkg.purl(),
+		}
+		if currPkg.License != "" {
+			component.Licenses = []cycloneDXLicenseChoice{{License: cycloneDXLicense{ID: currPkg.License}}}
+		}
+		if currPkg.VendorPath != "" {
+			component.Properties = []cycloneDXProperty{{Name: "distgo:vendor-path", Value: currPkg.VendorPath}}
+		}
+		bom.Components = append(bom.Components, component)
+		dependency.DependsOn = append(dependency.DependsOn, component.BOMRef)
+	}
+	bom.Dependencies = []cycloneDXDependency{dependency}
+	return writeJSON(w, bom)
+}
+
+type cycloneDXBOM struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     cycloneDXMetadata     `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components"`
+	Dependencies []cycloneDXDependency `json:"dependencies"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []cycloneDXTool    `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTool struct {
+	Name string `json:"name"`
+}
+
+type cycloneDXComponent struct {
+	Type       string                   `json:"type"`
+	BOMRef     string                   `json:"bom-ref"`
+	Name       string                   `json:"name"`
+	Version    string                   `json:"version,omitempty"`
+	PURL       string                   `json:"purl,omitempty"`
+	Licenses   []cycloneDXLicenseChoice `json:"licenses,omitempty"`
+	Properties []cycloneDXProperty      `json:"properties,omitempty"`
+}
+
+type cycloneDXLicenseChoice struct {
+	License cycloneDXLicense `json:"license"`
+}
+
+type cycloneDXLicense struct {
+	ID string `json:"id"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// purl returns the package URL of the package (for example, "pkg:golang/github.com/pkg/errors@v0.8.0").
+func (p Package) purl() string {
+	if p.Version == "" {
+		return "pkg:golang/" + p.ImportPath
+	}
+	return fmt.Sprintf("pkg:golang/%s@%s", p.ImportPath, p.Version)
+}
+
+// documentUUID returns a name-based (version 5) UUID derived from the content of the provided document so that
+// identical documents have the same identifier.
+func documentUUID(doc Document) string {
+	// URL namespace defined by RFC 4122
+	namespace := []byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+	h := sha1.New()
+	_, _ = h.Write(namespace)
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%d", doc.Name, doc.Version, doc.Created.Unix())
+	for _, currPkg := range doc.Packages {
+		_, _ = fmt.Fprintf(h, "\x00%s\x00%s\x00%s\x00%s", currPkg.ImportPath, currPkg.VendorPath, currPkg.Version, currPkg.License)
+	}
+	sum := h.Sum(nil)
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	bytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal SBOM as JSON")
+	}
+	if _, err := w.Write(append(bytes, '\n')); err != nil {
+		return errors.Wrapf(err, "failed to write SBOM")
+	}
+	return nil
+}
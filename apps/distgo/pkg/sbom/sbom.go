@@ -0,0 +1,216 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sbom creates software bills of materials (SBOMs) that list the third-party Go packages that are linked into
+// the executables of a product. SBOMs can be written as SPDX JSON or CycloneDX JSON documents.
+package sbom
+
+import (
+	"go/build"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/pkg/imports"
+	"github.com/palantir/godel/apps/distgo/pkg/license"
+	"github.com/palantir/godel/pkg/gomod"
+)
+
+// Document is an SBOM for a product.
+type Document struct {
+	// Name is the name of the product.
+	Name string
+	// Version is the version of the product.
+	Version string
+	// Created is the creation time recorded in the document.
+	Created time.Time
+	// Packages are the third-party packages linked into the product sorted by import path.
+	Packages []Package
+}
+
+// Package is a third-party package that is linked into a product.
+type Package struct {
+	// ImportPath is the import path of the package without the path of the vendor directory that contains it (for
+	// example, "github.com/pkg/errors").
+	ImportPath string
+	// VendorPath is the path of the directory of the package relative to the project directory (for example,
+	// "vendor/github.com/pkg/errors"). Blank if the package is not in the project directory.
+	VendorPath string
+	// Version is the version of the package as recorded in the vendor metadata of the project (or the version of its
+	// module if the project uses Go modules). Blank if the version is not known.
+	Version string
+	// License is the SPDX identifier of the license of the package. Blank if the license is not known.
+	License string
+	// LicensePath is the path of the license file of the package. Blank if no license file was found.
+	LicensePath string
+	// NoticePath is the path of the NOTICE file of the package. Blank if no NOTICE file was found.
+	NoticePath string
+}
+
+// MainPkg is a main package of a product.
+type MainPkg struct {
+	// Dir is the directory of the package.
+	Dir string
+	// Contexts are the build contexts (one for each OS/Arch for which the product is built) for which the package and
+	// its dependencies are resolved.
+	Contexts []build.Context
+}
+
+// Packages returns the third-party packages that are imported (directly or transitively) by the provided main packages
+// of the project in projectDir for any of their build contexts, sorted by import path. Packages in projectDir that are
+// not in a vendor directory are part of the project and are omitted.
+func Packages(projectDir string, mainPkgs []MainPkg) ([]Package, error) {
+	pkgs := make(map[string]Package)
+	for _, currMainPkg := range mainPkgs {
+		for _, currCtx := range currMainPkg.Contexts {
+			var currPkgs []Package
+			var err error
+			if gomod.Enabled(currMainPkg.Dir) {
+				currPkgs, err = modulePackages(currCtx, projectDir, currMainPkg.Dir)
+			} else {
+				currPkgs, err = gopathPackages(currCtx, projectDir, currMainPkg.Dir)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to resolve packages for %s/%s", currCtx.GOOS, currCtx.GOARCH)
+			}
+			for _, currPkg := range currPkgs {
+				pkgs[currPkg.ImportPath] = currPkg
+			}
+		}
+	}
+
+	sorted := make([]Package, 0, len(pkgs))
+	for _, currPkg := range pkgs {
+		sorted = append(sorted, currPkg)
+	}
+	sort.Sort(byImportPath(sorted))
+	return sorted, nil
+}
+
+type byImportPath []Package
+
+func (a byImportPath) Len() int           { return len(a) }
+func (a byImportPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byImportPath) Less(i, j int) bool { return a[i].ImportPath < a[j].ImportPath }
+
+// gopathPackages returns the third-party packages required to build the main package in the provided directory of a
+// project that does not use Go modules for the provided context. The versions of vendored packages are read from the
+// vendor metadata in the vendor directory that contains them.
+func gopathPackages(ctx build.Context, projectDir, mainPkgDir string) ([]Package, error) {
+	goFiles, err := imports.ContextFiles(ctx, mainPkgDir)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]vendorVersions)
+
+	var pkgs []Package
+	for currDir := range goFiles {
+		var importPath, vendorPath, rootDir string
+		if relPath, err := filepath.Rel(projectDir, currDir); err == nil && !strings.HasPrefix(relPath, "..") {
+			relPath = filepath.ToSlash(relPath)
+			idx := strings.LastIndex("/"+relPath+"/", "/vendor/")
+			if idx == -1 {
+				// package is part of the project
+				continue
+			}
+			importPath = relPath[idx+len("vendor/"):]
+			vendorPath = relPath
+			rootDir = filepath.Join(projectDir, filepath.FromSlash(relPath[:idx]), "vendor")
+		} else {
+			pkg, err := ctx.ImportDir(currDir, build.FindOnly)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to determine import path of package in %s", currDir)
+			}
+			importPath = pkg.ImportPath
+			if idx := strings.LastIndex(importPath, "/vendor/"); idx != -1 {
+				importPath = importPath[idx+len("/vendor/"):]
+			}
+			rootDir = strings.TrimSuffix(currDir, filepath.FromSlash("/"+importPath))
+		}
+
+		pkg := Package{
+			ImportPath: importPath,
+			VendorPath: vendorPath,
+		}
+		if vendorPath != "" {
+			if _, ok := versions[rootDir]; !ok {
+				if versions[rootDir], err = readVendorVersions(rootDir); err != nil {
+					return nil, err
+				}
+			}
+			pkg.Version = versions[rootDir].version(importPath)
+		}
+		if err := setLicense(&pkg, currDir, rootDir); err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// modulePackages returns the third-party packages required to build the main package in the provided directory of a
+// project that uses Go modules for the provided context. The version of a package is the version of the module that
+// contains it.
+func modulePackages(ctx build.Context, projectDir, mainPkgDir string) ([]Package, error) {
+	// nested conditions are used rather than "and" because "and" does not short-circuit in older versions of Go
+	lines, err := gomod.ListContext(ctx, mainPkgDir, `{{if not .Standard}}{{if .Module}}{{if not .Module.Main}}{{.ImportPath}}{{"\t"}}{{.Dir}}{{"\t"}}{{.Module.Path}}{{"\t"}}{{.Module.Version}}{{end}}{{end}}{{end}}`, "-deps", ".")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list dependencies of package %s", mainPkgDir)
+	}
+	var pkgs []Package
+	for _, currLine := range lines {
+		parts := strings.Split(currLine, "\t")
+		if len(parts) != 4 {
+			return nil, errors.Errorf("unexpected output from go list: %q", currLine)
+		}
+		importPath, dir, modulePath, moduleVersion := parts[0], parts[1], parts[2], parts[3]
+		pkg := Package{
+			ImportPath: importPath,
+			Version:    moduleVersion,
+		}
+		if relPath, err := filepath.Rel(projectDir, dir); err == nil && !strings.HasPrefix(relPath, "..") {
+			pkg.VendorPath = filepath.ToSlash(relPath)
+		}
+		moduleDir := strings.TrimSuffix(dir, filepath.FromSlash(strings.TrimPrefix(importPath, modulePath)))
+		if err := setLicense(&pkg, dir, moduleDir); err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// setLicense sets the license of the provided package by finding and identifying the license file of the package in the
+// provided directory and sets its NOTICE file. Parent directories are searched up to rootDir.
+func setLicense(pkg *Package, dir, rootDir string) error {
+	noticePath, err := license.FindNotice(dir, rootDir)
+	if err != nil {
+		return err
+	}
+	pkg.NoticePath = noticePath
+	licensePath, err := license.Find(dir, rootDir)
+	if err != nil || licensePath == "" {
+		return err
+	}
+	id, err := license.IdentifyFile(licensePath)
+	if err != nil {
+		return err
+	}
+	pkg.License = id
+	pkg.LicensePath = licensePath
+	return nil
+}
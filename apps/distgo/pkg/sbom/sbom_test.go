@@ -0,0 +1,330 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/build"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/nmiyake/pkg/gofiles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/godel/apps/distgo/pkg/sbom"
+)
+
+const (
+	mitLicense    = "Permission is hereby granted, free of charge, to any person obtaining a copy of this software"
+	apacheLicense = "Apache License\nVersion 2.0, January 2004"
+)
+
+func TestPackages(t *testing.T) {
+	// project must be in the GOPATH so that vendored packages are resolved
+	tmpDir, cleanup, err := dirs.TempDir(".", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for i, currCase := range []struct {
+		metadata     map[string]string
+		wantVersions []string
+	}{
+		{
+			wantVersions: []string{"", ""},
+		},
+		{
+			metadata: map[string]string{
+				"vendor/vendor.json": `{"package": [
+					{"path": "github.com/org/bar/baz", "revision": "0123456789abcdef"},
+					{"path": "github.com/org/foo", "revision": "fedcba9876543210", "version": "v1", "versionExact": "v1.2.0"}
+				]}`,
+			},
+			wantVersions: []string{"0123456789abcdef", "v1.2.0"},
+		},
+		{
+			metadata: map[string]string{
+				"vendor/modules.txt": "# github.com/org/bar v0.1.0 => github.com/fork/bar v0.1.1\ngithub.com/org/bar/baz\n# github.com/org/foo v1.2.0\n## explicit\ngithub.com/org/foo\n",
+			},
+			wantVersions: []string{"v0.1.1", "v1.2.0"},
+		},
+		{
+			metadata: map[string]string{
+				"Gopkg.lock": "[[projects]]\n  name = \"github.com/org/bar\"\n  packages = [\"baz\"]\n  revision = \"0123456789abcdef\"\n\n[[projects]]\n  name = \"github.com/org/foo\"\n  packages = [\".\"]\n  revision = \"fedcba9876543210\"\n  version = \"v1.2.0\"\n\n[solve-meta]\n  analyzer-name = \"dep\"\n",
+			},
+			wantVersions: []string{"0123456789abcdef", "v1.2.0"},
+		},
+	} {
+		currProjectDir, err := ioutil.TempDir(tmpDir, "")
+		require.NoError(t, err, "Case %d", i)
+		currProjectDir, err = filepath.Abs(currProjectDir)
+		require.NoError(t, err, "Case %d", i)
+
+		specs := []gofiles.GoFileSpec{
+			{
+				RelPath: "main.go",
+				Src:     `package main; import _ "github.com/org/foo"; import _ "{{index . "internal/internal.go"}}"; func main() {}`,
+			},
+			{
+				RelPath: "internal/internal.go",
+				Src:     `package internal; import _ "github.com/org/bar/baz"`,
+			},
+			{
+				RelPath: "vendor/github.com/org/foo/foo.go",
+				Src:     `package foo`,
+			},
+			{
+				RelPath: "vendor/github.com/org/bar/baz/baz.go",
+				Src:     `package baz`,
+			},
+			{
+				RelPath: "vendor/github.com/org/unused/unused.go",
+				Src:     `package unused`,
+			},
+		}
+		_, err = gofiles.Write(currProjectDir, specs)
+		require.NoError(t, err, "Case %d", i)
+
+		files := map[string]string{
+			"vendor/github.com/org/foo/LICENSE": mitLicense,
+			"vendor/github.com/org/bar/LICENSE": apacheLicense,
+			"vendor/github.com/org/bar/NOTICE":  "bar\nCopyright 2016 Org",
+		}
+		for k, v := range currCase.metadata {
+			files[k] = v
+		}
+		for k, v := range files {
+			err := ioutil.WriteFile(path.Join(currProjectDir, k), []byte(v), 0644)
+			require.NoError(t, err, "Case %d", i)
+		}
+
+		got, err := sbom.Packages(currProjectDir, []sbom.MainPkg{{Dir: currProjectDir, Contexts: []build.Context{build.Default}}})
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, []sbom.Package{
+			{
+				ImportPath:  "github.com/org/bar/baz",
+				VendorPath:  "vendor/github.com/org/bar/baz",
+				Version:     currCase.wantVersions[0],
+				License:     "Apache-2.0",
+				LicensePath: path.Join(currProjectDir, "vendor/github.com/org/bar/LICENSE"),
+				NoticePath:  path.Join(currProjectDir, "vendor/github.com/org/bar/NOTICE"),
+			},
+			{
+				ImportPath:  "github.com/org/foo",
+				VendorPath:  "vendor/github.com/org/foo",
+				Version:     currCase.wantVersions[1],
+				License:     "MIT",
+				LicensePath: path.Join(currProjectDir, "vendor/github.com/org/foo/LICENSE"),
+			},
+		}, got, "Case %d", i)
+	}
+}
+
+func TestPackagesForContexts(t *testing.T) {
+	// project must be in the GOPATH so that vendored packages are resolved
+	tmpDir, cleanup, err := dirs.TempDir(".", "")
+	defer cleanup()
+	require.NoError(t, err)
+	projectDir, err := filepath.Abs(tmpDir)
+	require.NoError(t, err)
+
+	_, err = gofiles.Write(projectDir, []gofiles.GoFileSpec{
+		{
+			RelPath: "main.go",
+			Src:     `package main; func main() {}`,
+		},
+		{
+			RelPath: "main_linux.go",
+			Src:     `package main; import _ "github.com/org/linux"`,
+		},
+		{
+			RelPath: "main_windows.go",
+			Src:     `package main; import _ "github.com/org/windows"`,
+		},
+		{
+			RelPath: "vendor/github.com/org/linux/linux.go",
+			Src:     `package linux`,
+		},
+		{
+			RelPath: "vendor/github.com/org/windows/windows.go",
+			Src:     `package windows`,
+		},
+	})
+	require.NoError(t, err)
+
+	linuxCtx := build.Default
+	linuxCtx.GOOS, linuxCtx.GOARCH = "linux", "amd64"
+	windowsCtx := build.Default
+	windowsCtx.GOOS, windowsCtx.GOARCH = "windows", "amd64"
+
+	for i, currCase := range []struct {
+		contexts []build.Context
+		want     []string
+	}{
+		{
+			contexts: []build.Context{linuxCtx},
+			want:     []string{"github.com/org/linux"},
+		},
+		{
+			contexts: []build.Context{windowsCtx},
+			want:     []string{"github.com/org/windows"},
+		},
+		{
+			contexts: []build.Context{linuxCtx, windowsCtx},
+			want:     []string{"github.com/org/linux", "github.com/org/windows"},
+		},
+	} {
+		pkgs, err := sbom.Packages(projectDir, []sbom.MainPkg{{Dir: projectDir, Contexts: currCase.contexts}})
+		require.NoError(t, err, "Case %d", i)
+		var got []string
+		for _, currPkg := range pkgs {
+			got = append(got, currPkg.ImportPath)
+		}
+		assert.Equal(t, currCase.want, got, "Case %d", i)
+	}
+}
+
+func TestWriteSPDX(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := sbom.WriteSPDX(buf, testDocument())
+	require.NoError(t, err)
+
+	var got struct {
+		SPDXVersion  string `json:"spdxVersion"`
+		Name         string `json:"name"`
+		CreationInfo struct {
+			Created string `json:"created"`
+		} `json:"creationInfo"`
+		Packages []struct {
+			SPDXID           string `json:"SPDXID"`
+			Name             string `json:"name"`
+			VersionInfo      string `json:"versionInfo"`
+			PackageFileName  string `json:"packageFileName"`
+			LicenseConcluded string `json:"licenseConcluded"`
+			ExternalRefs     []struct {
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+		Relationships []struct {
+			SPDXElementID      string `json:"spdxElementId"`
+			RelationshipType   string `json:"relationshipType"`
+			RelatedSPDXElement string `json:"relatedSpdxElement"`
+		} `json:"relationships"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &got)
+	require.NoError(t, err)
+
+	assert.Equal(t, "SPDX-2.2", got.SPDXVersion)
+	assert.Equal(t, "foo-1.0.0", got.Name)
+	assert.Equal(t, "2017-01-02T03:04:05Z", got.CreationInfo.Created)
+	require.Len(t, got.Packages, 3)
+	assert.Equal(t, "foo", got.Packages[0].Name)
+	assert.Equal(t, "github.com/org/bar", got.Packages[1].Name)
+	assert.Equal(t, "v1.2.0", got.Packages[1].VersionInfo)
+	assert.Equal(t, "vendor/github.com/org/bar", got.Packages[1].PackageFileName)
+	assert.Equal(t, "MIT", got.Packages[1].LicenseConcluded)
+	assert.Equal(t, "pkg:golang/github.com/org/bar@v1.2.0", got.Packages[1].ExternalRefs[0].ReferenceLocator)
+	assert.Equal(t, "github.com/org/baz", got.Packages[2].Name)
+	assert.Equal(t, "NOASSERTION", got.Packages[2].LicenseConcluded)
+	require.Len(t, got.Relationships, 3)
+	assert.Equal(t, "DESCRIBES", got.Relationships[0].RelationshipType)
+	assert.Equal(t, got.Packages[0].SPDXID, got.Relationships[0].RelatedSPDXElement)
+	assert.Equal(t, "CONTAINS", got.Relationships[2].RelationshipType)
+	assert.Equal(t, got.Packages[2].SPDXID, got.Relationships[2].RelatedSPDXElement)
+
+	// output is deterministic
+	buf2 := &bytes.Buffer{}
+	err = sbom.WriteSPDX(buf2, testDocument())
+	require.NoError(t, err)
+	assert.Equal(t, buf.String(), buf2.String())
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := sbom.WriteCycloneDX(buf, testDocument())
+	require.NoError(t, err)
+
+	var got struct {
+		BOMFormat    string `json:"bomFormat"`
+		SerialNumber string `json:"serialNumber"`
+		Metadata     struct {
+			Timestamp string `json:"timestamp"`
+			Component struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"component"`
+		} `json:"metadata"`
+		Components []struct {
+			Name     string `json:"name"`
+			Version  string `json:"version"`
+			PURL     string `json:"purl"`
+			Licenses []struct {
+				License struct {
+					ID string `json:"id"`
+				} `json:"license"`
+			} `json:"licenses"`
+			Properties []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"properties"`
+		} `json:"components"`
+		Dependencies []struct {
+			DependsOn []string `json:"dependsOn"`
+		} `json:"dependencies"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &got)
+	require.NoError(t, err)
+
+	assert.Equal(t, "CycloneDX", got.BOMFormat)
+	assert.Regexp(t, "^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", got.SerialNumber)
+	assert.Equal(t, "2017-01-02T03:04:05Z", got.Metadata.Timestamp)
+	assert.Equal(t, "foo", got.Metadata.Component.Name)
+	assert.Equal(t, "1.0.0", got.Metadata.Component.Version)
+	require.Len(t, got.Components, 2)
+	assert.Equal(t, "github.com/org/bar", got.Components[0].Name)
+	assert.Equal(t, "pkg:golang/github.com/org/bar@v1.2.0", got.Components[0].PURL)
+	require.Len(t, got.Components[0].Licenses, 1)
+	assert.Equal(t, "MIT", got.Components[0].Licenses[0].License.ID)
+	require.Len(t, got.Components[0].Properties, 1)
+	assert.Equal(t, "vendor/github.com/org/bar", got.Components[0].Properties[0].Value)
+	assert.Equal(t, "pkg:golang/github.com/org/baz", got.Components[1].PURL)
+	assert.Empty(t, got.Components[1].Licenses)
+	require.Len(t, got.Dependencies, 1)
+	assert.Equal(t, []string{"pkg:golang/github.com/org/bar@v1.2.0", "pkg:golang/github.com/org/baz"}, got.Dependencies[0].DependsOn)
+}
+
+func testDocument() sbom.Document {
+	return sbom.Document{
+		Name:    "foo",
+		Version: "1.0.0",
+		Created: time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+		Packages: []sbom.Package{
+			{
+				ImportPath: "github.com/org/bar",
+				VendorPath: "vendor/github.com/org/bar",
+				Version:    "v1.2.0",
+				License:    "MIT",
+			},
+			{
+				ImportPath: "github.com/org/baz",
+			},
+		},
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// vendorVersions is a map from import paths (of packages or of the roots of the repositories or modules that contain
+// them) to versions.
+type vendorVersions map[string]string
+
+// version returns the version of the package with the provided import path. The version is that of the longest import
+// path in the map that is the import path of the package or one of its parents. Returns an empty string if there is no
+// such import path.
+func (v vendorVersions) version(importPath string) string {
+	for currPath := importPath; currPath != "." && currPath != "/"; currPath = path.Dir(currPath) {
+		if version, ok := v[currPath]; ok {
+			return version
+		}
+	}
+	return ""
+}
+
+// readVendorVersions returns the versions of the packages in the provided vendor directory as recorded in the metadata
+// of the vendoring tool used to create it. The following files are read if they exist: "vendor.json" in the vendor
+// directory (govendor), "modules.txt" in the vendor directory (Go modules) and "Gopkg.lock" in the parent directory of
+// the vendor directory (dep). If multiple files specify a version for an import path, the file that appears first in
+// this list takes precedence.
+func readVendorVersions(vendorDir string) (vendorVersions, error) {
+	versions := make(vendorVersions)
+	// files are parsed in reverse order of precedence so that versions from files with higher precedence overwrite
+	// those from files with lower precedence
+	for _, currFile := range []struct {
+		path  string
+		parse func(content []byte, versions vendorVersions) error
+	}{
+		{path: filepath.Join(filepath.Dir(vendorDir), "Gopkg.lock"), parse: parseGopkgLock},
+		{path: filepath.Join(vendorDir, "modules.txt"), parse: parseModulesTxt},
+		{path: filepath.Join(vendorDir, "vendor.json"), parse: parseVendorJSON},
+	} {
+		content, err := ioutil.ReadFile(currFile.path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", currFile.path)
+		}
+		if err := currFile.parse(content, versions); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", currFile.path)
+		}
+	}
+	return versions, nil
+}
+
+// parseVendorJSON adds the versions in the provided govendor "vendor.json" file to versions. The version of a package
+// is its exact version (such as a tag) if it is recorded and its revision otherwise.
+func parseVendorJSON(content []byte, versions vendorVersions) error {
+	var vendorJSON struct {
+		Package []struct {
+			Path         string `json:"path"`
+			Revision     string `json:"revision"`
+			VersionExact string `json:"versionExact"`
+		} `json:"package"`
+	}
+	if err := json.Unmarshal(content, &vendorJSON); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal JSON")
+	}
+	for _, currPkg := range vendorJSON.Package {
+		version := currPkg.VersionExact
+		if version == "" {
+			version = currPkg.Revision
+		}
+		if version != "" {
+			versions[currPkg.Path] = version
+		}
+	}
+	return nil
+}
+
+// parseModulesTxt adds the versions of the modules in the provided "vendor/modules.txt" file to versions. If a module
+// is replaced, the version of its replacement is used.
+func parseModulesTxt(content []byte, versions vendorVersions) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		// module lines have the form "# path version" or "# path [version] => replacement [version]"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != "#" {
+			continue
+		}
+		version := fields[2]
+		if fields[len(fields)-2] != "=>" && len(fields) > 3 {
+			version = fields[len(fields)-1]
+		} else if version == "=>" {
+			// replaced by a directory, which does not have a version
+			continue
+		}
+		versions[fields[1]] = version
+	}
+	return scanner.Err()
+}
+
+// parseGopkgLock adds the versions of the projects in the provided dep "Gopkg.lock" file to versions. The version of a
+// project is its version (such as a tag) if it is recorded and its revision otherwise.
+func parseGopkgLock(content []byte, versions vendorVersions) error {
+	var name, revision, version string
+	addProject := func() {
+		if name == "" {
+			return
+		}
+		if version == "" {
+			version = revision
+		}
+		if version != "" {
+			versions[name] = version
+		}
+		name, revision, version = "", "", ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			addProject()
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		if key != "name" && key != "revision" && key != "version" {
+			continue
+		}
+		value, err := strconv.Unquote(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for %s: %s", key, parts[1])
+		}
+		switch key {
+		case "name":
+			name = value
+		case "revision":
+			revision = value
+		case "version":
+			version = value
+		}
+	}
+	addProject()
+	return scanner.Err()
+}
@@ -0,0 +1,164 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/scrypt"
+)
+
+// MinisignExtension is the extension of the signatures created by minisign signers.
+const MinisignExtension = ".minisig"
+
+const (
+	// minisignSecretKeySize is the size of a decoded minisign secret key: the signature, KDF and checksum algorithms
+	// (2 bytes each), the KDF salt (32 bytes), the KDF operations and memory limits (8 bytes each) and the key ID
+	// (8 bytes), Ed25519 private key (64 bytes) and checksum (32 bytes).
+	minisignSecretKeySize    = 2 + 2 + 2 + 32 + 8 + 8 + 8 + ed25519.PrivateKeySize + 32
+	minisignUntrustedComment = "untrusted comment:"
+)
+
+type minisignSigner struct {
+	keyID      []byte
+	privateKey ed25519.PrivateKey
+}
+
+// NewMinisignSigner returns a Signer that creates minisign signatures using the provided minisign secret key, which is
+// either the content of a secret key file created by "minisign -G" or the base64-encoded key on the second line of such
+// a file. If the key is encrypted, it is decrypted using the provided passphrase. The signatures sign the entire
+// content of the file rather than its BLAKE2b hash, which "minisign -V" verifies by default.
+func NewMinisignSigner(key []byte, passphrase []byte) (Signer, error) {
+	var encoded string
+	for _, currLine := range strings.Split(string(key), "\n") {
+		if currLine = strings.TrimSpace(currLine); currLine != "" && !strings.HasPrefix(currLine, minisignUntrustedComment) {
+			encoded = currLine
+			break
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) != minisignSecretKeySize {
+		return nil, errors.Errorf("invalid minisign secret key")
+	}
+
+	sigAlgo, kdfAlgo := string(decoded[0:2]), string(decoded[2:4])
+	salt := decoded[6:38]
+	opsLimit := binary.LittleEndian.Uint64(decoded[38:46])
+	memLimit := binary.LittleEndian.Uint64(decoded[46:54])
+	keyNum := decoded[54:]
+	if sigAlgo != "Ed" {
+		return nil, errors.Errorf("unsupported minisign signature algorithm %q", sigAlgo)
+	}
+	switch kdfAlgo {
+	case "\x00\x00":
+	case "Sc":
+		if passphrase == nil {
+			return nil, errors.Errorf("minisign secret key is encrypted, but no passphrase was provided")
+		}
+		n, r, p := minisignScryptParams(opsLimit, memLimit)
+		stream, err := scrypt.Key(passphrase, salt, n, r, p, len(keyNum))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to derive key from passphrase")
+		}
+		for i := range keyNum {
+			keyNum[i] ^= stream[i]
+		}
+	default:
+		return nil, errors.Errorf("unsupported minisign key derivation algorithm %q", kdfAlgo)
+	}
+
+	signer := &minisignSigner{
+		keyID:      keyNum[:8],
+		privateKey: ed25519.PrivateKey(keyNum[8 : 8+ed25519.PrivateKeySize]),
+	}
+	// the checksum of the key uses BLAKE2b, so the key is verified by checking that its public key matches its seed
+	if !bytes.Equal(newEd25519Key(signer.privateKey[:32]), signer.privateKey) {
+		if kdfAlgo == "Sc" {
+			return nil, errors.Errorf("incorrect passphrase for minisign secret key")
+		}
+		return nil, errors.Errorf("invalid minisign secret key")
+	}
+	return signer, nil
+}
+
+func (s *minisignSigner) Extension() string {
+	return MinisignExtension
+}
+
+func (s *minisignSigner) Sign(w io.Writer, r io.Reader, name string) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", name)
+	}
+	sig := ed25519.Sign(s.privateKey, content)
+	trustedComment := fmt.Sprintf("timestamp:%d\tfile:%s", time.Now().Unix(), name)
+	// the global signature signs the signature and the trusted comment
+	globalSig := ed25519.Sign(s.privateKey, append(append([]byte{}, sig...), trustedComment...))
+
+	sigBlob := append(append([]byte("Ed"), s.keyID...), sig...)
+	if _, err := fmt.Fprintf(w, "%s signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		minisignUntrustedComment,
+		base64.StdEncoding.EncodeToString(sigBlob),
+		trustedComment,
+		base64.StdEncoding.EncodeToString(globalSig),
+	); err != nil {
+		return errors.Wrapf(err, "failed to write signature")
+	}
+	return nil
+}
+
+// minisignScryptParams returns the scrypt parameters N, r and p that minisign (using libsodium) derives from the
+// operations and memory limits stored in a secret key.
+func minisignScryptParams(opsLimit, memLimit uint64) (int, int, int) {
+	const r = 8
+	if opsLimit < 32768 {
+		opsLimit = 32768
+	}
+	maxN := memLimit / (r * 128)
+	if opsLimit < memLimit/32 {
+		maxN = opsLimit / (r * 4)
+	}
+	nLog2 := uint(1)
+	for ; nLog2 < 63; nLog2++ {
+		if uint64(1)<<nLog2 > maxN/2 {
+			break
+		}
+	}
+	p := 1
+	if opsLimit >= memLimit/32 {
+		maxRP := (opsLimit / 4) / (uint64(1) << nLog2)
+		if maxRP > 0x3fffffff {
+			maxRP = 0x3fffffff
+		}
+		p = int(maxRP) / r
+	}
+	return 1 << nLog2, r, p
+}
+
+// newEd25519Key returns the Ed25519 private key for the provided 32-byte seed.
+func newEd25519Key(seed []byte) ed25519.PrivateKey {
+	// the private key is derived from the first 32 bytes read from the reader, so the error is always nil
+	_, privateKey, _ := ed25519.GenerateKey(bytes.NewReader(seed))
+	return privateKey
+}
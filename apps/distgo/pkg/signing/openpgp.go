@@ -0,0 +1,139 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// OpenPGPExtension is the extension of the ASCII-armored detached signatures created by OpenPGP signers.
+const OpenPGPExtension = ".asc"
+
+type openPGPSigner struct {
+	privateKey *packet.PrivateKey
+}
+
+// NewOpenPGPSigner returns a Signer that creates ASCII-armored OpenPGP signatures using a secret key from the provided
+// keyring, which is the content of a binary or ASCII-armored file that contains secret keys (for example, the output
+// of "gpg --export-secret-keys"). The key with the provided key ID or fingerprint (which may also be a suffix of the
+// fingerprint such as a short key ID) is used. If keyID is empty, the first RSA signing key whose secret is available
+// is used. If the key is protected by a passphrase, it is decrypted using the provided passphrase.
+func NewOpenPGPSigner(keyring []byte, keyID string, passphrase []byte) (Signer, error) {
+	var entities openpgp.EntityList
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(keyring), []byte("-----BEGIN ")) {
+		entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(keyring))
+	} else {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(keyring))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read keyring")
+	}
+
+	wantID := strings.ToUpper(keyID)
+	if strings.HasPrefix(wantID, "0X") {
+		wantID = wantID[2:]
+	}
+	for _, privateKey := range signingKeys(entities) {
+		if wantID != "" && !strings.HasSuffix(fmt.Sprintf("%X", privateKey.Fingerprint), wantID) {
+			continue
+		}
+		if privateKey.PubKeyAlgo != packet.PubKeyAlgoRSA && privateKey.PubKeyAlgo != packet.PubKeyAlgoRSASignOnly {
+			if wantID != "" {
+				return nil, errors.Errorf("secret key %s uses unsupported public key algorithm %d: only RSA keys are supported", privateKey.KeyIdString(), privateKey.PubKeyAlgo)
+			}
+			continue
+		}
+		if privateKey.Encrypted {
+			if passphrase == nil {
+				return nil, errors.Errorf("failed to read secret key %s: key is protected by a passphrase, but no passphrase was provided", privateKey.KeyIdString())
+			}
+			if err := privateKey.Decrypt(passphrase); err != nil {
+				return nil, errors.Errorf("failed to read secret key %s: incorrect passphrase", privateKey.KeyIdString())
+			}
+		}
+		return &openPGPSigner{
+			privateKey: privateKey,
+		}, nil
+	}
+	if wantID != "" {
+		return nil, errors.Errorf("keyring does not contain a secret key with ID %s", keyID)
+	}
+	return nil, errors.Errorf("keyring does not contain an RSA secret key")
+}
+
+// signingKeys returns the secret keys in the provided entities that can be used to sign: the primary key of each entity
+// followed by its subkeys that are flagged for signing. Keys whose secret material is not present are omitted.
+func signingKeys(entities openpgp.EntityList) []*packet.PrivateKey {
+	var keys []*packet.PrivateKey
+	for _, entity := range entities {
+		if entity.PrivateKey != nil {
+			keys = append(keys, entity.PrivateKey)
+		}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil && subkey.Sig.FlagsValid && subkey.Sig.FlagSign {
+				keys = append(keys, subkey.PrivateKey)
+			}
+		}
+	}
+	return keys
+}
+
+func (s *openPGPSigner) Extension() string {
+	return OpenPGPExtension
+}
+
+func (s *openPGPSigner) Sign(w io.Writer, r io.Reader, name string) error {
+	sig := &packet.Signature{
+		SigType:      packet.SigTypeBinary,
+		PubKeyAlgo:   s.privateKey.PubKeyAlgo,
+		Hash:         crypto.SHA256,
+		CreationTime: time.Now(),
+		IssuerKeyId:  &s.privateKey.KeyId,
+	}
+	h := sig.Hash.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return errors.Wrapf(err, "failed to read %s", name)
+	}
+	if err := sig.Sign(h, s.privateKey, nil); err != nil {
+		return errors.Wrapf(err, "failed to sign %s", name)
+	}
+
+	armored, err := armor.Encode(w, openpgp.SignatureType, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write signature")
+	}
+	if err := sig.Serialize(armored); err != nil {
+		return errors.Wrapf(err, "failed to write signature")
+	}
+	if err := armored.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write signature")
+	}
+	// the armor encoder does not terminate the last line
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return errors.Wrapf(err, "failed to write signature")
+	}
+	return nil
+}
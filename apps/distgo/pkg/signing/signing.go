@@ -0,0 +1,110 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signing creates checksum files and detached signatures for files. Signatures are created using OpenPGP keys
+// read from a keyring file or using minisign keys.
+package signing
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+)
+
+// Signer creates detached signatures.
+type Signer interface {
+	// Extension returns the extension (including the leading ".") that is appended to the name of a file to form the
+	// name of its signature.
+	Extension() string
+	// Sign writes a detached signature for the content read from r to w. name is the name of the signed file.
+	Sign(w io.Writer, r io.Reader, name string) error
+}
+
+// SignFile writes a detached signature for the file at the provided path to the path formed by appending the extension
+// of the signer to the path and returns the path of the signature.
+func SignFile(signer Signer, filePath string) (rPath string, rErr error) {
+	in, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	sigPath := filePath + signer.Extension()
+	out, err := os.Create(sigPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create %s", sigPath)
+	}
+	defer func() {
+		if err := out.Close(); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to close %s", sigPath)
+		}
+	}()
+
+	if err := signer.Sign(out, in, path.Base(filePath)); err != nil {
+		return "", errors.Wrapf(err, "failed to sign %s", filePath)
+	}
+	return sigPath, nil
+}
+
+// WriteSHA256Sums writes the checksum file returned by SHA256Sums for the provided files to the provided path.
+func WriteSHA256Sums(dst string, filePaths []string) error {
+	sums, err := SHA256Sums(filePaths)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(dst, sums, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", dst)
+	}
+	return nil
+}
+
+// SHA256Sums returns a checksum file in the format used by the "sha256sum" tool. The file contains a line with the
+// SHA-256 checksum and base name of each of the provided files in the order in which they are provided, so it can be
+// verified using "sha256sum -c" in a directory that contains all of the files.
+func SHA256Sums(filePaths []string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for _, currPath := range filePaths {
+		sum, err := sha256Sum(currPath)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(buf, "%s  %s\n", sum, path.Base(currPath))
+	}
+	return buf.Bytes(), nil
+}
+
+func sha256Sum(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", filePath)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "failed to compute checksum of %s", filePath)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
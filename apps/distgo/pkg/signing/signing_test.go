@@ -0,0 +1,255 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/palantir/godel/apps/distgo/pkg/signing"
+)
+
+func TestWriteSHA256Sums(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	var want string
+	var filePaths []string
+	for _, currName := range []string{"foo-1.0.0.tgz", "foo-1.0.0.rpm"} {
+		currPath := path.Join(tmp, "dist", currName)
+		err = os.MkdirAll(path.Dir(currPath), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(currPath, []byte(currName), 0644)
+		require.NoError(t, err)
+		filePaths = append(filePaths, currPath)
+
+		sum := sha256.Sum256([]byte(currName))
+		want += hex.EncodeToString(sum[:]) + "  " + currName + "\n"
+	}
+
+	dst := path.Join(tmp, "foo-1.0.0-SHA256SUMS")
+	err = signing.WriteSHA256Sums(dst, filePaths)
+	require.NoError(t, err)
+	got, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+
+	// verify the file using sha256sum if it is available
+	if _, err := exec.LookPath("sha256sum"); err != nil {
+		return
+	}
+	cmd := exec.Command("sha256sum", "-c", dst)
+	cmd.Dir = path.Join(tmp, "dist")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestOpenPGPSigner(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	content := []byte("artifact content")
+	filePath := path.Join(tmp, "foo-1.0.0.tgz")
+	err = ioutil.WriteFile(filePath, content, 0644)
+	require.NoError(t, err)
+
+	for i, currCase := range []struct {
+		keyring    string
+		keyID      string
+		passphrase []byte
+		wantError  string
+	}{
+		// binary keyring with an unprotected RSA key
+		{keyring: "rsa.gpg"},
+		{keyring: "rsa.gpg", keyID: "1EF017A1A8FCAD3C826494CEA3B86EB31E713997"},
+		{keyring: "rsa.gpg", keyID: "0xa3b86eb31e713997"},
+		// ASCII-armored keyring with an RSA key protected by the passphrase "passphrase"
+		{keyring: "protected.asc", passphrase: []byte("passphrase")},
+		{keyring: "protected.asc", keyID: "156DB036", passphrase: []byte("passphrase")},
+		{
+			keyring:   "rsa.gpg",
+			keyID:     "2BA8E30F156DB036",
+			wantError: "keyring does not contain a secret key with ID 2BA8E30F156DB036",
+		},
+		{
+			keyring:   "protected.asc",
+			wantError: "failed to read secret key 2BA8E30F156DB036: key is protected by a passphrase, but no passphrase was provided",
+		},
+		{
+			keyring:    "protected.asc",
+			passphrase: []byte("wrong"),
+			wantError:  "failed to read secret key 2BA8E30F156DB036: incorrect passphrase",
+		},
+	} {
+		keyring, err := ioutil.ReadFile(path.Join("testdata", currCase.keyring))
+		require.NoError(t, err, "Case %d", i)
+
+		signer, err := signing.NewOpenPGPSigner(keyring, currCase.keyID, currCase.passphrase)
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, signing.OpenPGPExtension, signer.Extension(), "Case %d", i)
+
+		sigPath, err := signing.SignFile(signer, filePath)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, filePath+".asc", sigPath, "Case %d", i)
+		sig, err := ioutil.ReadFile(sigPath)
+		require.NoError(t, err, "Case %d", i)
+		assert.True(t, strings.HasPrefix(string(sig), "-----BEGIN PGP SIGNATURE-----\n\n"), "Case %d: %s", i, string(sig))
+		assert.True(t, strings.HasSuffix(string(sig), "\n-----END PGP SIGNATURE-----\n"), "Case %d: %s", i, string(sig))
+
+		verifyWithGPG(t, tmp, keyring, filePath, sigPath, fmt.Sprintf("Case %d", i))
+	}
+}
+
+// verifyWithGPG verifies the provided signature using gpg if it is available.
+func verifyWithGPG(t *testing.T, tmp string, keyring []byte, filePath, sigPath, msg string) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		return
+	}
+	gpgHome, err := ioutil.TempDir(tmp, "gnupg")
+	require.NoError(t, err, msg)
+	gpg := func(args ...string) *exec.Cmd {
+		cmd := exec.Command("gpg", append([]string{"--homedir", gpgHome, "--batch"}, args...)...)
+		cmd.Stdin = bytes.NewReader(keyring)
+		return cmd
+	}
+	output, err := gpg("--import").CombinedOutput()
+	require.NoError(t, err, "%s: %s", msg, string(output))
+	output, err = gpg("--verify", sigPath, filePath).CombinedOutput()
+	require.NoError(t, err, "%s: %s", msg, string(output))
+	assert.Contains(t, string(output), "Good signature", msg)
+}
+
+func TestMinisignSigner(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	content := []byte("artifact content")
+	filePath := path.Join(tmp, "foo-1.0.0.tgz")
+	err = ioutil.WriteFile(filePath, content, 0644)
+	require.NoError(t, err)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	for i, currCase := range []struct {
+		key        string
+		passphrase []byte
+		wantError  string
+	}{
+		{key: minisignSecretKey(t, keyID, privateKey, nil)},
+		// key without the comment line
+		{key: strings.Split(minisignSecretKey(t, keyID, privateKey, nil), "\n")[1]},
+		{key: minisignSecretKey(t, keyID, privateKey, []byte("passphrase")), passphrase: []byte("passphrase")},
+		{
+			key:       minisignSecretKey(t, keyID, privateKey, []byte("passphrase")),
+			wantError: "minisign secret key is encrypted, but no passphrase was provided",
+		},
+		{
+			key:        minisignSecretKey(t, keyID, privateKey, []byte("passphrase")),
+			passphrase: []byte("wrong"),
+			wantError:  "incorrect passphrase for minisign secret key",
+		},
+		{
+			key:       "untrusted comment: minisign secret key\nnot-a-key\n",
+			wantError: "invalid minisign secret key",
+		},
+	} {
+		signer, err := signing.NewMinisignSigner([]byte(currCase.key), currCase.passphrase)
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, signing.MinisignExtension, signer.Extension(), "Case %d", i)
+
+		sigPath, err := signing.SignFile(signer, filePath)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, filePath+".minisig", sigPath, "Case %d", i)
+		sig, err := ioutil.ReadFile(sigPath)
+		require.NoError(t, err, "Case %d", i)
+
+		lines := strings.Split(string(sig), "\n")
+		require.Len(t, lines, 5, "Case %d", i)
+		assert.Equal(t, "untrusted comment: signature from minisign secret key", lines[0], "Case %d", i)
+		sigBlob, err := base64.StdEncoding.DecodeString(lines[1])
+		require.NoError(t, err, "Case %d", i)
+		require.Len(t, sigBlob, 2+8+ed25519.SignatureSize, "Case %d", i)
+		assert.Equal(t, "Ed", string(sigBlob[:2]), "Case %d", i)
+		assert.Equal(t, keyID, sigBlob[2:10], "Case %d", i)
+		assert.True(t, ed25519.Verify(publicKey, content, sigBlob[10:]), "Case %d", i)
+
+		require.True(t, strings.HasPrefix(lines[2], "trusted comment: timestamp:"), "Case %d", i)
+		assert.True(t, strings.HasSuffix(lines[2], "\tfile:foo-1.0.0.tgz"), "Case %d", i)
+		globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+		require.NoError(t, err, "Case %d", i)
+		trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+		assert.True(t, ed25519.Verify(publicKey, append(sigBlob[10:], trustedComment...), globalSig), "Case %d", i)
+	}
+}
+
+// minisignSecretKey returns the content of a minisign secret key file for the provided key. If passphrase is non-nil,
+// the key is encrypted using the passphrase with scrypt parameters that are cheap to compute.
+func minisignSecretKey(t *testing.T, keyID []byte, privateKey ed25519.PrivateKey, passphrase []byte) string {
+	const opsLimit, memLimit = 32768, 16777216
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+
+	keyNum := append(append(append([]byte{}, keyID...), privateKey...), make([]byte, 32)...)
+	kdfAlgo := "\x00\x00"
+	if passphrase != nil {
+		kdfAlgo = "Sc"
+		// N, r and p are the parameters derived by minisign from the operations and memory limits
+		stream, err := scrypt.Key(passphrase, salt, 1024, 8, 1, len(keyNum))
+		require.NoError(t, err)
+		for i := range keyNum {
+			keyNum[i] ^= stream[i]
+		}
+	}
+
+	key := []byte("Ed" + kdfAlgo + "B2")
+	key = append(key, salt...)
+	limits := make([]byte, 16)
+	binary.LittleEndian.PutUint64(limits[:8], opsLimit)
+	binary.LittleEndian.PutUint64(limits[8:], memLimit)
+	key = append(key, limits...)
+	key = append(key, keyNum...)
+	return "untrusted comment: minisign encrypted secret key\n" + base64.StdEncoding.EncodeToString(key) + "\n"
+}
@@ -15,6 +15,8 @@
 package templating
 
 import (
+	"time"
+
 	"github.com/palantir/godel/apps/distgo/params"
 	"github.com/palantir/godel/apps/distgo/pkg/git"
 )
@@ -39,4 +41,8 @@ type Config struct {
 	// {{.Publish.Metadata}} is a map of string to string
 	// {{.Publish.Tags}} is a slice of strings
 	Publish params.Publish
+
+	// {{.BuildTime}} is a time.Time that is the same for all templates rendered by a single invocation. For example,
+	// {{.BuildTime.Unix}} or {{.BuildTime.Format "2006-01-02T15:04:05Z07:00"}}
+	BuildTime time.Time
 }
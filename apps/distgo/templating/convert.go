@@ -15,6 +15,14 @@
 package templating
 
 import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
 	"github.com/palantir/godel/apps/distgo/params"
 )
 
@@ -25,5 +33,69 @@ func ConvertSpec(buildSpec params.ProductBuildSpec, distCfg params.Dist) Config
 		VersionInfo:    buildSpec.VersionInfo,
 		Dist:           distCfg.Info,
 		Publish:        distCfg.Publish,
+		BuildTime:      BuildTime(),
+	}
+}
+
+// ConvertBuildSpec returns the Config for templates that are rendered as part of building the provided spec. Because
+// a build is not specific to a distribution, Dist is nil and Publish is the default publish configuration of the
+// product. If the spec is built in reproducible mode, BuildTime is ReproducibleBuildTime.
+func ConvertBuildSpec(buildSpec params.ProductBuildSpec) Config {
+	buildTime := BuildTime()
+	if buildSpec.Build.Reproducible {
+		buildTime = ReproducibleBuildTime()
+	}
+	return Config{
+		ProductName:    buildSpec.ProductName,
+		ProductVersion: buildSpec.ProductVersion,
+		VersionInfo:    buildSpec.VersionInfo,
+		Publish:        buildSpec.DefaultPublish,
+		BuildTime:      buildTime,
+	}
+}
+
+var (
+	buildTimeOnce sync.Once
+	buildTime     time.Time
+)
+
+// BuildTime returns the time used as the build time for templates. If the SOURCE_DATE_EPOCH environment variable is set
+// to a valid Unix timestamp, the time it specifies is used. Otherwise, the value is the current time. The value is
+// determined the first time the function is called and the same value is returned for the remainder of the process.
+func BuildTime() time.Time {
+	buildTimeOnce.Do(func() {
+		if epoch, ok, err := SourceDateEpoch(); ok && err == nil {
+			buildTime = epoch
+			return
+		}
+		buildTime = time.Now().UTC()
+	})
+	return buildTime
+}
+
+// ReproducibleBuildTime returns the build time used for templates rendered by reproducible builds. The value is the
+// time specified by the SOURCE_DATE_EPOCH environment variable if it is set to a valid Unix timestamp and the Unix
+// epoch otherwise, so it never depends on the time at which the build is run.
+func ReproducibleBuildTime() time.Time {
+	if epoch, ok, err := SourceDateEpoch(); ok && err == nil {
+		return epoch
+	}
+	return time.Unix(0, 0).UTC()
+}
+
+// SourceDateEpochEnvVar is the environment variable that specifies the Unix timestamp used as the build time.
+const SourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
+
+// SourceDateEpoch returns the time specified by the SOURCE_DATE_EPOCH environment variable. Returns false if the
+// variable is not set and an error if its value is not a non-negative integer.
+func SourceDateEpoch() (time.Time, bool, error) {
+	value, ok := os.LookupEnv(SourceDateEpochEnvVar)
+	if !ok || strings.TrimSpace(value) == "" {
+		return time.Time{}, false, nil
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, true, errors.Errorf("invalid value for %s: %q must be a non-negative integer", SourceDateEpochEnvVar, value)
 	}
+	return time.Unix(seconds, 0).UTC(), true, nil
 }
@@ -0,0 +1,71 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package templating
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/apps/distgo/pkg/osarch"
+)
+
+// PathConfig is the data used to render the templates that specify the paths of build outputs and distribution
+// artifacts. It provides all of the values of Config in addition to the OS/Arch of the output.
+type PathConfig struct {
+	Config
+
+	// {{.OSArch}} is the OS/Arch of the output of the form "GOOS-GOARCH" (for example, "linux-amd64")
+	// {{.OSArch.OS}} and {{.OSArch.Arch}} are strings
+	// Empty for distribution artifacts that contain the outputs for multiple OS/Archs.
+	OSArch OSArch
+}
+
+// OSArch is an osarch.OSArch whose string representation is empty if it is the zero value.
+type OSArch osarch.OSArch
+
+func (o OSArch) String() string {
+	if o == (OSArch{}) {
+		return ""
+	}
+	return osarch.OSArch(o).String()
+}
+
+// ParsePathTemplate parses the provided path template. The name is used to identify the template in errors.
+func ParsePathTemplate(name, tmpl string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse template for %s", name)
+	}
+	return t, nil
+}
+
+// RenderPath renders the provided path template using the provided data. The name is used to identify the template in
+// errors. Returns an error if the template renders to an empty string.
+func RenderPath(name, tmpl string, data PathConfig) (string, error) {
+	t, err := ParsePathTemplate(name, tmpl)
+	if err != nil {
+		return "", err
+	}
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, data); err != nil {
+		return "", errors.Wrapf(err, "failed to execute template for %s", name)
+	}
+	if buf.Len() == 0 {
+		return "", errors.Errorf("template for %s rendered to an empty path: %q", name, tmpl)
+	}
+	return buf.String(), nil
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/palantir/godel/apps/gunit/generated_src"
 	"github.com/palantir/godel/apps/gunit/params"
+	"github.com/palantir/godel/pkg/gomod"
 )
 
 var Library = amalgomated.NewCmdLibrary(amalgomatedtesters.Instance())
@@ -125,8 +126,13 @@ func AllTagsMatcher(cfg params.GUnit) matcher.Matcher {
 
 // PkgPaths returns a slice that contains the relative package paths for the packages "pkgPaths" relative to the
 // project directory "wd" excluding any of the paths that match the provided "exclude" Matcher. If "pkgPaths" is an
-// empty slice, then all of the packages in "wd" (except those that match the "exclude" matcher) are returned.
+// empty slice, then all of the packages in "wd" (except those that match the "exclude" matcher) are returned. If the
+// project uses Go modules, the packages are resolved using "go list".
 func PkgPaths(pkgPaths []string, wd string, exclude matcher.Matcher) ([]string, error) {
+	if gomod.Enabled(wd) {
+		return modulePkgPaths(pkgPaths, wd, exclude)
+	}
+
 	var pkgs pkgpath.Packages
 	var err error
 	if len(pkgPaths) == 0 {
@@ -154,3 +160,22 @@ func PkgPaths(pkgPaths []string, wd string, exclude matcher.Matcher) ([]string,
 	}
 	return resultPkgPaths, nil
 }
+
+// modulePkgPaths returns the result of PkgPaths for a project that uses Go modules.
+func modulePkgPaths(pkgPaths []string, wd string, exclude matcher.Matcher) ([]string, error) {
+	var patterns []string
+	for _, currPkg := range pkgPaths {
+		if exclude == nil || !exclude.Match(currPkg) {
+			patterns = append(patterns, currPkg)
+		}
+	}
+	if len(pkgPaths) > 0 && len(patterns) == 0 {
+		// all of the provided packages are excluded
+		return nil, nil
+	}
+	resultPkgPaths, err := gomod.Packages(wd, patterns, exclude)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list packages in %s", wd)
+	}
+	return resultPkgPaths, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/palantir/pkg/matcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/godel/apps/gunit/cmd"
+	"github.com/palantir/godel/pkg/gomod"
+)
+
+func TestPkgPathsModule(t *testing.T) {
+	// the module is created in a temporary directory that is outside of GOPATH
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for file, content := range map[string]string{
+		"go.mod":           "module github.com/org/mod\n",
+		"foo/foo.go":       "package foo",
+		"foo/bar/bar.go":   "package bar",
+		"generated/gen.go": "package generated",
+	} {
+		err := os.MkdirAll(path.Join(tmp, path.Dir(file)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, file), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	orig, isSet := os.LookupEnv(gomod.EnvVar)
+	defer func() {
+		if isSet {
+			_ = os.Setenv(gomod.EnvVar, orig)
+		} else {
+			_ = os.Unsetenv(gomod.EnvVar)
+		}
+	}()
+	err = os.Setenv(gomod.EnvVar, "on")
+	require.NoError(t, err)
+
+	for i, currCase := range []struct {
+		pkgPaths []string
+		exclude  matcher.Matcher
+		want     []string
+	}{
+		{pkgPaths: nil, exclude: matcher.Name("generated"), want: []string{"./foo", "./foo/bar"}},
+		{pkgPaths: nil, exclude: nil, want: []string{"./foo", "./foo/bar", "./generated"}},
+		{pkgPaths: []string{"./foo/...", "./generated"}, exclude: matcher.Path("generated"), want: []string{"./foo", "./foo/bar"}},
+		{pkgPaths: []string{"./foo/bar"}, exclude: nil, want: []string{"./foo/bar"}},
+		{pkgPaths: []string{"./generated"}, exclude: matcher.Path("generated"), want: nil},
+	} {
+		got, err := cmd.PkgPaths(currCase.pkgPaths, tmp, currCase.exclude)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, got, "Case %d", i)
+	}
+}
@@ -27,11 +27,17 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/termie/go-shutil"
+
+	"github.com/palantir/godel/pkg/gomod"
 )
 
+// VerifyProject verifies that the Go environment is set up properly and that the project in the provided directory is
+// located at the path in GOPATH that matches its git remote, moving or copying it there if info is false. Projects
+// that use Go modules do not need to be located in GOPATH, so their location is not verified.
 func VerifyProject(wd string, info bool) error {
+	moduleMode := gomod.Enabled(wd)
 	gopath := os.Getenv("GOPATH")
-	if gopath == "" {
+	if gopath == "" && !moduleMode {
 		return fmt.Errorf("GOPATH environment variable must be set")
 	}
 
@@ -68,6 +74,16 @@ func VerifyProject(wd string, info bool) error {
 		}
 	}
 
+	if moduleMode {
+		moduleRoot := gomod.ModuleRoot(wd)
+		modulePath, err := gomod.ModulePath(moduleRoot)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Project uses Go modules (module %s in %s): its location does not need to be in GOPATH\n", modulePath, moduleRoot)
+		return nil
+	}
+
 	srcPath, err := gitRepoRootPath(wd)
 	if err != nil {
 		return fmt.Errorf("Directory %q must be in a git project", wd)
@@ -152,6 +168,11 @@ func VerifyGoEnv(wd string) {
 		}
 	}
 
+	if gomod.Enabled(wd) {
+		// projects that use Go modules do not require GOPATH
+		return
+	}
+
 	gopath := os.Getenv("GOPATH")
 	if gopath == "" {
 		title := "GOPATH environment variable is empty"
@@ -0,0 +1,73 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkpath_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/godel/cmd/checkpath"
+	"github.com/palantir/godel/pkg/gomod"
+)
+
+func TestVerifyProjectModule(t *testing.T) {
+	for _, name := range []string{"GOPATH", gomod.EnvVar} {
+		orig, isSet := os.LookupEnv(name)
+		defer func(name string) {
+			if isSet {
+				_ = os.Setenv(name, orig)
+			} else {
+				_ = os.Unsetenv(name)
+			}
+		}(name)
+	}
+	err := os.Unsetenv("GOPATH")
+	require.NoError(t, err)
+	err = os.Setenv(gomod.EnvVar, "on")
+	require.NoError(t, err)
+
+	for i, currCase := range []struct {
+		goMod     string
+		wantError string
+	}{
+		// a module does not need to be in GOPATH or in a git project
+		{goMod: "module github.com/org/mod\n"},
+		{wantError: "GOPATH environment variable must be set"},
+	} {
+		// the project is created in a temporary directory that is outside of GOPATH
+		tmp, cleanup, err := dirs.TempDir("", "")
+		defer cleanup()
+		require.NoError(t, err, "Case %d", i)
+		err = ioutil.WriteFile(path.Join(tmp, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+		require.NoError(t, err, "Case %d", i)
+		if currCase.goMod != "" {
+			err = ioutil.WriteFile(path.Join(tmp, "go.mod"), []byte(currCase.goMod), 0644)
+			require.NoError(t, err, "Case %d", i)
+		}
+
+		err = checkpath.VerifyProject(tmp, true)
+		if currCase.wantError != "" {
+			assert.EqualError(t, err, currCase.wantError, "Case %d", i)
+			continue
+		}
+		assert.NoError(t, err, "Case %d", i)
+	}
+}
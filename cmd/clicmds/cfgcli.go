@@ -161,6 +161,13 @@ var (
 			subcommandPath: []string{"publish"},
 			pathToCfg:      []string{"dist.yml"},
 		},
+		{
+			name:           "sbom",
+			app:            distgoCreator,
+			decorator:      distgoDecorator,
+			subcommandPath: []string{"sbom"},
+			pathToCfg:      []string{"dist.yml"},
+		},
 	}
 )
 
@@ -18,9 +18,21 @@ import (
 	"github.com/palantir/pkg/matcher"
 	"github.com/palantir/pkg/pkgpath"
 	"github.com/pkg/errors"
+
+	"github.com/palantir/godel/pkg/gomod"
 )
 
+// List returns the relative paths (with a "./" prefix) of the packages in the project in the provided directory that do
+// not match the provided exclude matcher. If the project uses Go modules, the packages are resolved using "go list".
 func List(exclude matcher.Matcher, wd string) ([]string, error) {
+	if gomod.Enabled(wd) {
+		pkgPaths, err := gomod.Packages(wd, nil, exclude)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to list packages")
+		}
+		return pkgPaths, nil
+	}
+
 	pkgs, err := pkgpath.PackagesInDir(wd, exclude)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to list packages")
@@ -0,0 +1,75 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packages_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/palantir/pkg/matcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/godel/cmd/packages"
+	"github.com/palantir/godel/pkg/gomod"
+)
+
+func TestListModule(t *testing.T) {
+	// the module is created in a temporary directory that is outside of GOPATH
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	for file, content := range map[string]string{
+		"go.mod":           "module github.com/org/mod\n",
+		"main.go":          "package main\n\nfunc main() {}\n",
+		"foo/foo.go":       "package foo",
+		"foo/bar/bar.go":   "package bar",
+		"vendor/dep/d.go":  "package dep",
+		"nested/go.mod":    "module github.com/org/nested\n",
+		"nested/nested.go": "package nested",
+	} {
+		err := os.MkdirAll(path.Join(tmp, path.Dir(file)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(tmp, file), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	orig, isSet := os.LookupEnv(gomod.EnvVar)
+	defer func() {
+		if isSet {
+			_ = os.Setenv(gomod.EnvVar, orig)
+		} else {
+			_ = os.Unsetenv(gomod.EnvVar)
+		}
+	}()
+	err = os.Setenv(gomod.EnvVar, "on")
+	require.NoError(t, err)
+
+	for i, currCase := range []struct {
+		exclude matcher.Matcher
+		want    []string
+	}{
+		{exclude: nil, want: []string{"./.", "./foo", "./foo/bar"}},
+		{exclude: matcher.Path("foo/bar"), want: []string{"./.", "./foo"}},
+	} {
+		got, err := packages.List(currCase.exclude, tmp)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, got, "Case %d", i)
+	}
+}
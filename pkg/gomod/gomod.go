@@ -0,0 +1,190 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gomod provides functions for working with projects that use Go modules. A project is in module mode if the
+// project directory or one of its ancestors contains a go.mod file and modules have not been disabled by setting the
+// GO111MODULE environment variable to "off". Projects in module mode do not need to be located in GOPATH, and their
+// packages are resolved using "go list" rather than by walking the GOPATH.
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"go/build"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/pkg/matcher"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FileName is the name of the file that defines a module.
+	FileName = "go.mod"
+	// EnvVar is the environment variable that controls whether the Go toolchain uses module mode.
+	EnvVar = "GO111MODULE"
+)
+
+// ModuleRoot returns the directory that contains the go.mod file that applies to the provided directory, which is the
+// closest go.mod file in the directory or one of its ancestors. Returns an empty string if no such file exists.
+func ModuleRoot(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for currDir := absDir; ; currDir = filepath.Dir(currDir) {
+		if fi, err := os.Stat(filepath.Join(currDir, FileName)); err == nil && !fi.IsDir() {
+			return currDir
+		}
+		if parent := filepath.Dir(currDir); parent == currDir {
+			return ""
+		}
+	}
+}
+
+// Enabled returns true if the project in the provided directory is in module mode: a go.mod file applies to the
+// directory and the GO111MODULE environment variable is not "off".
+func Enabled(dir string) bool {
+	return os.Getenv(EnvVar) != "off" && ModuleRoot(dir) != ""
+}
+
+// Env returns the environment variables (in "KEY=VALUE" form) that should be set for Go commands that operate on the
+// project in the provided directory. Module mode is enabled explicitly for projects in module mode so that toolchains
+// that only enable it automatically outside of GOPATH treat the project as a module. Returns nil for other projects.
+func Env(dir string) []string {
+	if !Enabled(dir) {
+		return nil
+	}
+	return []string{EnvVar + "=on"}
+}
+
+// ModulePath returns the module path declared by the go.mod file in the provided module root directory.
+func ModulePath(moduleRoot string) (string, error) {
+	modFile := path.Join(moduleRoot, FileName)
+	f, err := os.Open(modFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", modFile)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", modFile)
+	}
+	return "", errors.Errorf("%s does not declare a module path", modFile)
+}
+
+// Packages returns the relative paths (with a "./" prefix, for example "./foo/bar") of the packages in the project in
+// the provided directory that match the provided package patterns as reported by "go list", excluding any packages
+// whose relative path matches the provided exclude matcher. If no patterns are provided, all of the packages in the
+// module ("./...") are returned. Packages outside of the directory are omitted. The returned paths are sorted.
+func Packages(dir string, patterns []string, exclude matcher.Matcher) ([]string, error) {
+	return packages(dir, "{{.Dir}}", patterns, exclude)
+}
+
+// MainPackages returns the relative paths (as returned by Packages) of all of the "main" packages in the module in the
+// provided directory, excluding any packages whose relative path matches the provided exclude matcher.
+func MainPackages(dir string, exclude matcher.Matcher) ([]string, error) {
+	return packages(dir, `{{if eq .Name "main"}}{{.Dir}}{{end}}`, nil, exclude)
+}
+
+// packages returns the relative paths of the package directories printed by "go list" using the provided format.
+func packages(dir, format string, patterns []string, exclude matcher.Matcher) ([]string, error) {
+	dirs, err := List(dir, format, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert %s to absolute path", dir)
+	}
+
+	var pkgPaths []string
+	for _, currDir := range dirs {
+		relPath, err := filepath.Rel(absDir, currDir)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if exclude != nil && exclude.Match(relPath) {
+			continue
+		}
+		pkgPaths = append(pkgPaths, "./"+relPath)
+	}
+	sort.Strings(pkgPaths)
+	return pkgPaths, nil
+}
+
+// List runs "go list" with the provided format in the provided directory with the provided arguments (flags such as
+// "-deps" followed by package patterns) and returns the non-empty lines of its output. If no arguments are provided,
+// "./..." is used. Errors in individual packages do not cause List to fail.
+func List(dir, format string, args ...string) ([]string, error) {
+	return list(dir, nil, format, args)
+}
+
+// ListContext runs "go list" like List, but resolves packages for the GOOS, GOARCH, cgo setting and build tags of the
+// provided context rather than for the host.
+func ListContext(ctx build.Context, dir, format string, args ...string) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	cgoEnabled := "0"
+	if ctx.CgoEnabled {
+		cgoEnabled = "1"
+	}
+	env := []string{"GOOS=" + ctx.GOOS, "GOARCH=" + ctx.GOARCH, "CGO_ENABLED=" + cgoEnabled}
+	if len(ctx.BuildTags) > 0 {
+		args = append([]string{"-tags", strings.Join(ctx.BuildTags, " ")}, args...)
+	}
+	return list(dir, env, format, args)
+}
+
+func list(dir string, env []string, format string, args []string) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert %s to absolute path", dir)
+	}
+	cmd := exec.Command("go", append([]string{"list", "-e", "-f", format}, args...)...)
+	cmd.Dir = absDir
+	// set PWD so that the reported directories are based on the provided directory even if it contains symlinks
+	cmd.Env = append(append(append(os.Environ(), Env(absDir)...), env...), "PWD="+absDir)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "go list failed in %s: %s", dir, strings.TrimSpace(stderr.String()))
+	}
+
+	var lines []string
+	for _, currLine := range strings.Split(string(output), "\n") {
+		if currLine = strings.TrimSpace(currLine); currLine != "" {
+			lines = append(lines, currLine)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nmiyake/pkg/dirs"
+	"github.com/palantir/pkg/matcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/godel/pkg/gomod"
+)
+
+func TestModuleRoot(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	writeFiles(t, tmp, map[string]string{
+		"mod/go.mod":               "module github.com/org/mod\n",
+		"mod/foo/bar/bar.go":       "package bar",
+		"mod/nested/go.mod":        `module "github.com/org/nested"` + "\n",
+		"mod/nested/baz/baz.go":    "package baz",
+		"nomod/foo/foo.go":         "package foo",
+		"mod/nested/baz/README.md": "",
+	})
+
+	for i, currCase := range []struct {
+		dir            string
+		wantRoot       string
+		wantModulePath string
+	}{
+		{dir: "mod", wantRoot: "mod", wantModulePath: "github.com/org/mod"},
+		{dir: "mod/foo/bar", wantRoot: "mod", wantModulePath: "github.com/org/mod"},
+		{dir: "mod/nested/baz", wantRoot: "mod/nested", wantModulePath: "github.com/org/nested"},
+		{dir: "nomod/foo"},
+	} {
+		dir := path.Join(tmp, currCase.dir)
+		root := gomod.ModuleRoot(dir)
+		if currCase.wantRoot == "" {
+			assert.Equal(t, "", root, "Case %d", i)
+			continue
+		}
+		assert.Equal(t, path.Join(tmp, currCase.wantRoot), root, "Case %d", i)
+
+		modulePath, err := gomod.ModulePath(root)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.wantModulePath, modulePath, "Case %d", i)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	writeFiles(t, tmp, map[string]string{
+		"mod/go.mod": "module github.com/org/mod\n",
+		"nomod/a.go": "package a",
+	})
+
+	defer restoreEnv(gomod.EnvVar)()
+
+	for i, currCase := range []struct {
+		envValue string
+		dir      string
+		want     bool
+	}{
+		{envValue: "", dir: "mod", want: true},
+		{envValue: "on", dir: "mod", want: true},
+		{envValue: "off", dir: "mod", want: false},
+		{envValue: "", dir: "nomod", want: false},
+	} {
+		err := os.Setenv(gomod.EnvVar, currCase.envValue)
+		require.NoError(t, err, "Case %d", i)
+
+		assert.Equal(t, currCase.want, gomod.Enabled(path.Join(tmp, currCase.dir)), "Case %d", i)
+		if currCase.want {
+			assert.Equal(t, []string{"GO111MODULE=on"}, gomod.Env(path.Join(tmp, currCase.dir)), "Case %d", i)
+		} else {
+			assert.Nil(t, gomod.Env(path.Join(tmp, currCase.dir)), "Case %d", i)
+		}
+	}
+}
+
+func TestPackages(t *testing.T) {
+	tmp, cleanup, err := dirs.TempDir("", "")
+	defer cleanup()
+	require.NoError(t, err)
+
+	writeFiles(t, tmp, map[string]string{
+		"go.mod":                 "module github.com/org/mod\n",
+		"main.go":                "package main\n\nfunc main() {}\n",
+		"foo/foo.go":             "package foo",
+		"foo/bar/bar.go":         "package bar",
+		"cmd/tool/main.go":       "package main\n\nfunc main() {}\n",
+		"generated/gen.go":       "package generated",
+		"nested/go.mod":          "module github.com/org/nested\n",
+		"nested/nested.go":       "package nested",
+		"testdata/testdata.go":   "package testdata",
+		"foo/bar/README.md":      "",
+		"empty/not-a-package.md": "",
+	})
+
+	defer restoreEnv(gomod.EnvVar)()
+	err = os.Setenv(gomod.EnvVar, "on")
+	require.NoError(t, err)
+
+	got, err := gomod.Packages(tmp, nil, matcher.Path("generated"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./.", "./cmd/tool", "./foo", "./foo/bar"}, got)
+
+	got, err = gomod.Packages(tmp, []string{"./foo/..."}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./foo", "./foo/bar"}, got)
+
+	got, err = gomod.MainPackages(tmp, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./.", "./cmd/tool"}, got)
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for file, content := range files {
+		err := os.MkdirAll(path.Join(root, path.Dir(file)), 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(path.Join(root, file), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+}
+
+// restoreEnv returns a function that restores the provided environment variable to its current value.
+func restoreEnv(name string) func() {
+	orig, isSet := os.LookupEnv(name)
+	return func() {
+		if isSet {
+			_ = os.Setenv(name, orig)
+		} else {
+			_ = os.Unsetenv(name)
+		}
+	}
+}